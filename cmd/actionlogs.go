@@ -0,0 +1,528 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements --fetch-action-logs for the AWS Bedrock Intelligent Agents CLI.
+The trace of an invocation says when the agent called an action group, but what the
+Lambda function did is in its CloudWatch Logs. For every action group invocation in the
+trace, the Lambda function is looked up in the action group definition, and the log
+events of the invocation are found by time window and request ID and shown with the trace.
+
+There is no CloudWatch Logs module among the dependencies, so FilterLogEvents is called
+as a signed JSON request.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagent"
+	agenttypes "github.com/aws/aws-sdk-go-v2/service/bedrockagent/types"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/types"
+)
+
+const (
+	// actionLogSlack widens the time window around an action group invocation
+	actionLogSlack = 2 * time.Second
+
+	// CloudWatch Logs receives the log events some seconds after the function wrote them,
+	// so the search is repeated while invocations are missing or incomplete
+	actionLogAttempts = 5
+	actionLogRetry    = 2 * time.Second
+
+	// actionLogMaxEvents bounds the events read from one log group
+	actionLogMaxEvents = 5000
+)
+
+// actionInvocation is an action group invocation found in the trace
+type actionInvocation struct {
+	TraceID      string
+	ActionGroup  string
+	Operation    string
+	AgentID      string
+	AgentVersion string
+	RequestID    string // Client request ID of the observation; may be the Lambda request ID
+	Start        time.Time
+	End          time.Time
+}
+
+// actionLog is the Lambda log of an action group invocation
+type actionLog struct {
+	TraceID     string          `json:"traceId"`
+	ActionGroup string          `json:"actionGroup"`
+	Operation   string          `json:"operation,omitempty"`
+	Function    string          `json:"function,omitempty"`
+	RequestID   string          `json:"requestId,omitempty"`
+	StartedAt   time.Time       `json:"startedAt"`
+	DurationMs  int64           `json:"durationMs"`
+	Lines       []actionLogLine `json:"lines"`
+	Note        string          `json:"note,omitempty"` // Why there are no lines
+}
+
+// actionLogLine is a log event of a Lambda invocation
+type actionLogLine struct {
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+// lambdaInvocation is the log events of one Lambda request, from START to REPORT
+type lambdaInvocation struct {
+	RequestID string
+	Start     time.Time
+	Lines     []actionLogLine
+	Complete  bool // The REPORT line was received
+}
+
+// actionInvocations pairs the action group invocation inputs of the trace with their observations
+func actionInvocations(traces []types.TracePart) []actionInvocation {
+	var invocations []actionInvocation
+	byTraceID := map[string]int{}
+	for _, part := range traces {
+		orchestration, ok := part.Trace.(*types.TraceMemberOrchestrationTrace)
+		if !ok || part.EventTime == nil {
+			continue
+		}
+		switch v := orchestration.Value.(type) {
+		case *types.OrchestrationTraceMemberInvocationInput:
+			input := v.Value.ActionGroupInvocationInput
+			if input == nil || input.ExecutionType == types.ExecutionTypeReturnControl {
+				continue
+			}
+			operation := aws.ToString(input.Function)
+			if operation == "" {
+				operation = strings.TrimSpace(strings.ToUpper(aws.ToString(input.Verb)) + " " + aws.ToString(input.ApiPath))
+			}
+			byTraceID[aws.ToString(v.Value.TraceId)] = len(invocations)
+			invocations = append(invocations, actionInvocation{
+				TraceID:      aws.ToString(v.Value.TraceId),
+				ActionGroup:  aws.ToString(input.ActionGroupName),
+				Operation:    operation,
+				AgentID:      aws.ToString(part.AgentId),
+				AgentVersion: aws.ToString(part.AgentVersion),
+				Start:        *part.EventTime,
+				End:          *part.EventTime,
+			})
+		case *types.OrchestrationTraceMemberObservation:
+			i, ok := byTraceID[aws.ToString(v.Value.TraceId)]
+			if !ok || v.Value.ActionGroupInvocationOutput == nil {
+				continue
+			}
+			invocations[i].End = *part.EventTime
+			if metadata := v.Value.ActionGroupInvocationOutput.Metadata; metadata != nil {
+				invocations[i].RequestID = aws.ToString(metadata.ClientRequestId)
+			}
+		}
+	}
+	return invocations
+}
+
+// fetchActionLogs returns the Lambda logs of the action group invocations in the trace.
+// Problems are reported as warnings; the invocations concerned get a note instead of lines.
+func fetchActionLogs(ctx context.Context, opts AgentOptions, traces []types.TracePart) []actionLog {
+	invocations := actionInvocations(traces)
+	logs := make([]actionLog, len(invocations))
+	if len(invocations) == 0 {
+		return logs
+	}
+
+	helper := NewAWSHelper(opts)
+	cfg, err := helper.LoadConfig(ctx)
+	var client *bedrockagent.Client
+	if err == nil {
+		client = bedrockagent.NewFromConfig(cfg)
+	} else {
+		opts.Warnings.Warn(WarningActionLogs, "error loading AWS config", err)
+	}
+
+	// The invocations of one function are found with one search of its log group
+	functions := map[string]map[string]string{} // Lambda ARNs by action group, per agent version
+	byFunction := map[string][]int{}
+	for i, inv := range invocations {
+		logs[i] = actionLog{
+			TraceID:     inv.TraceID,
+			ActionGroup: inv.ActionGroup,
+			Operation:   inv.Operation,
+			StartedAt:   inv.Start,
+			DurationMs:  inv.End.Sub(inv.Start).Milliseconds(),
+			Lines:       []actionLogLine{},
+		}
+		if err != nil {
+			logs[i].Note = "AWS configuration not available"
+			continue
+		}
+
+		agentID, version := inv.AgentID, inv.AgentVersion
+		if agentID == "" {
+			agentID = opts.AgentID
+		}
+		key := agentID + "/" + version
+		if _, ok := functions[key]; !ok {
+			lambdas, lookupErr := actionGroupLambdas(ctx, client, agentID, version)
+			if lookupErr != nil {
+				opts.Warnings.Warn(WarningActionLogs, "error looking up action groups", lookupErr)
+			}
+			functions[key] = lambdas
+		}
+		arn := functions[key][inv.ActionGroup]
+		if arn == "" {
+			logs[i].Note = "action group has no Lambda function"
+			continue
+		}
+		logs[i].Function = arn
+		byFunction[arn] = append(byFunction[arn], i)
+	}
+
+	for arn, indexes := range byFunction {
+		if err := fetchFunctionLogs(ctx, opts, cfg, arn, invocations, indexes, logs); err != nil {
+			opts.Warnings.Warn(WarningActionLogs, "error fetching logs of "+arn, err)
+			for _, i := range indexes {
+				logs[i].Note = "logs not available"
+			}
+		}
+	}
+	return logs
+}
+
+// fetchFunctionLogs searches the log group of a function for the given invocations and sets their lines
+func fetchFunctionLogs(ctx context.Context, opts AgentOptions, cfg aws.Config, arn string, invocations []actionInvocation, indexes []int, logs []actionLog) error {
+	group, region, err := lambdaLogGroup(arn)
+	if err != nil {
+		return err
+	}
+	start, end := invocations[indexes[0]].Start, invocations[indexes[0]].End
+	for _, i := range indexes {
+		start = minTime(start, invocations[i].Start)
+		end = maxTime(end, invocations[i].End)
+	}
+	start, end = start.Add(-actionLogSlack), end.Add(actionLogSlack)
+
+	logsClient := newCloudWatchLogsClient(cfg, region)
+	for attempt := 1; ; attempt++ {
+		events, err := logsClient.filterLogEvents(ctx, group, start, end)
+		if err != nil {
+			return err
+		}
+
+		complete := true
+		used := map[string]bool{}
+		candidates := lambdaInvocations(events)
+		for _, i := range indexes {
+			match := matchLambdaInvocation(invocations[i], candidates, used)
+			if match == nil {
+				complete = false
+				logs[i].Note = fmt.Sprintf("no invocation in %s between %s and %s", group, start.Local().Format("15:04:05"), end.Local().Format("15:04:05"))
+				continue
+			}
+			used[match.RequestID] = true
+			complete = complete && match.Complete
+			logs[i].RequestID = match.RequestID
+			logs[i].Lines = match.Lines
+			logs[i].Note = ""
+		}
+		if complete || attempt == actionLogAttempts {
+			return nil
+		}
+		logVerbose(opts, "Waiting for the logs of %s to arrive (attempt %d)", group, attempt)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(actionLogRetry):
+		}
+	}
+}
+
+// actionGroupLambdas returns the Lambda function ARNs of the action groups of an agent version by name
+func actionGroupLambdas(ctx context.Context, client *bedrockagent.Client, agentID, version string) (map[string]string, error) {
+	if version == "" {
+		version = draftAgentVersion
+	}
+	lambdas := map[string]string{}
+	paginator := bedrockagent.NewListAgentActionGroupsPaginator(client, &bedrockagent.ListAgentActionGroupsInput{
+		AgentId:      aws.String(agentID),
+		AgentVersion: aws.String(version),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return lambdas, HandleAWSError(fmt.Errorf("failed to list action groups: %w", err))
+		}
+		for _, summary := range page.ActionGroupSummaries {
+			out, err := client.GetAgentActionGroup(ctx, &bedrockagent.GetAgentActionGroupInput{
+				AgentId:       aws.String(agentID),
+				AgentVersion:  aws.String(version),
+				ActionGroupId: summary.ActionGroupId,
+			})
+			if err != nil {
+				return lambdas, HandleAWSError(fmt.Errorf("failed to get action group '%s': %w", aws.ToString(summary.ActionGroupName), err))
+			}
+			if executor, ok := out.AgentActionGroup.ActionGroupExecutor.(*agenttypes.ActionGroupExecutorMemberLambda); ok {
+				lambdas[aws.ToString(summary.ActionGroupName)] = executor.Value
+			}
+		}
+	}
+	return lambdas, nil
+}
+
+// lambdaLogGroup returns the log group and region of a Lambda function ARN, which may be qualified
+func lambdaLogGroup(arn string) (group, region string, err error) {
+	parts := strings.Split(arn, ":")
+	if len(parts) < 7 || parts[0] != "arn" || parts[2] != "lambda" || parts[5] != "function" {
+		return "", "", fmt.Errorf("'%s' is not a Lambda function ARN", arn)
+	}
+	return "/aws/lambda/" + parts[6], parts[3], nil
+}
+
+// lambdaRequestLine matches the START and REPORT lines of the Lambda text log format
+var lambdaRequestLine = regexp.MustCompile(`^(START|REPORT) RequestId: ([0-9a-fA-F-]+)`)
+
+// lambdaRequestEvent returns whether a log event starts or ends a Lambda request, in the text
+// or the JSON log format, and the request ID
+func lambdaRequestEvent(message string) (kind, requestID string) {
+	if m := lambdaRequestLine.FindStringSubmatch(message); m != nil {
+		return m[1], m[2]
+	}
+	if !strings.HasPrefix(message, "{") {
+		return "", ""
+	}
+	var record struct {
+		Type   string `json:"type"`
+		Record struct {
+			RequestID string `json:"requestId"`
+		} `json:"record"`
+	}
+	if json.Unmarshal([]byte(message), &record) != nil {
+		return "", ""
+	}
+	switch record.Type {
+	case "platform.start":
+		return "START", record.Record.RequestID
+	case "platform.report":
+		return "REPORT", record.Record.RequestID
+	}
+	return "", ""
+}
+
+// lambdaInvocations groups log events into Lambda requests. An execution environment handles one
+// request at a time and writes to its own log stream, so the events of a stream between START and
+// REPORT belong to that request.
+func lambdaInvocations(events []cloudWatchLogEvent) []*lambdaInvocation {
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Timestamp < events[j].Timestamp })
+
+	var invocations []*lambdaInvocation
+	current := map[string]*lambdaInvocation{}
+	for _, event := range events {
+		line := actionLogLine{Time: time.UnixMilli(event.Timestamp), Message: strings.TrimRight(event.Message, "\n")}
+		kind, requestID := lambdaRequestEvent(line.Message)
+		if kind == "START" {
+			inv := &lambdaInvocation{RequestID: requestID, Start: line.Time}
+			invocations = append(invocations, inv)
+			current[event.LogStreamName] = inv
+		}
+		inv := current[event.LogStreamName]
+		if inv == nil {
+			continue // Init lines, or the request started before the window
+		}
+		inv.Lines = append(inv.Lines, line)
+		if kind == "REPORT" {
+			inv.Complete = true
+			delete(current, event.LogStreamName)
+		}
+	}
+	return invocations
+}
+
+// matchLambdaInvocation returns the Lambda request of an action group invocation: the one with
+// its request ID, otherwise the unused request that started closest to the invocation
+func matchLambdaInvocation(inv actionInvocation, candidates []*lambdaInvocation, used map[string]bool) *lambdaInvocation {
+	var best *lambdaInvocation
+	for _, candidate := range candidates {
+		if inv.RequestID != "" && strings.EqualFold(candidate.RequestID, inv.RequestID) {
+			return candidate
+		}
+		if used[candidate.RequestID] || candidate.Start.Before(inv.Start.Add(-actionLogSlack)) || candidate.Start.After(inv.End.Add(actionLogSlack)) {
+			continue
+		}
+		if best == nil || absDuration(candidate.Start.Sub(inv.Start)) < absDuration(best.Start.Sub(inv.Start)) {
+			best = candidate
+		}
+	}
+	return best
+}
+
+// writeActionLogs writes the Lambda logs of the action group invocations
+func writeActionLogs(w io.Writer, logs []actionLog, color colorizer) {
+	if len(logs) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%s\n", color.style(ansiBold, "Action group logs:"))
+	for _, entry := range logs {
+		title := entry.ActionGroup
+		if entry.Operation != "" {
+			title += " " + entry.Operation
+		}
+		fmt.Fprintf(w, "%s %s, %s (trace %s)\n", color.style(ansiBold, "▸"), title,
+			formatDuration(time.Duration(entry.DurationMs)*time.Millisecond), entry.TraceID)
+		if entry.Function != "" {
+			name := entry.Function[strings.LastIndex(entry.Function, ":")+1:]
+			if group, _, err := lambdaLogGroup(entry.Function); err == nil {
+				name = strings.TrimPrefix(group, "/aws/lambda/")
+			}
+			if entry.RequestID != "" {
+				fmt.Fprintf(w, "  Lambda %s, RequestId %s\n", name, entry.RequestID)
+			} else {
+				fmt.Fprintf(w, "  Lambda %s\n", name)
+			}
+		}
+		if entry.Note != "" {
+			fmt.Fprintf(w, "  %s\n", color.Banner(entry.Note))
+		}
+		for _, line := range entry.Lines {
+			message := strings.ReplaceAll(line.Message, "\n", "\n                ")
+			fmt.Fprintf(w, "  %s  %s\n", color.style(ansiDim, line.Time.Local().Format("15:04:05.000")), message)
+		}
+	}
+}
+
+// cloudWatchLogEvent is a log event returned by FilterLogEvents
+type cloudWatchLogEvent struct {
+	LogStreamName string `json:"logStreamName"`
+	Timestamp     int64  `json:"timestamp"`
+	Message       string `json:"message"`
+}
+
+// cloudWatchLogsClient calls the CloudWatch Logs JSON API of a region
+type cloudWatchLogsClient struct {
+	cfg      aws.Config
+	region   string
+	endpoint string
+}
+
+// newCloudWatchLogsClient returns a client for the region. The endpoint can be changed with
+// AWS_ENDPOINT_URL_CLOUDWATCH_LOGS or AWS_ENDPOINT_URL, like for the SDK clients.
+func newCloudWatchLogsClient(cfg aws.Config, region string) *cloudWatchLogsClient {
+	if region == "" {
+		region = cfg.Region
+	}
+	endpoint := fmt.Sprintf("https://logs.%s.amazonaws.com/", region)
+	for _, name := range []string{"AWS_ENDPOINT_URL_CLOUDWATCH_LOGS", "AWS_ENDPOINT_URL"} {
+		if value := os.Getenv(name); value != "" {
+			endpoint = value
+			break
+		}
+	}
+	return &cloudWatchLogsClient{cfg: cfg, region: region, endpoint: endpoint}
+}
+
+// filterLogEvents returns the events of a log group in a time window, following the pages
+func (c *cloudWatchLogsClient) filterLogEvents(ctx context.Context, group string, start, end time.Time) ([]cloudWatchLogEvent, error) {
+	var events []cloudWatchLogEvent
+	request := map[string]interface{}{
+		"logGroupName": group,
+		"startTime":    start.UnixMilli(),
+		"endTime":      end.UnixMilli(),
+	}
+	for len(events) < actionLogMaxEvents {
+		var page struct {
+			Events    []cloudWatchLogEvent `json:"events"`
+			NextToken string               `json:"nextToken"`
+		}
+		if err := c.call(ctx, "FilterLogEvents", request, &page); err != nil {
+			return nil, err
+		}
+		events = append(events, page.Events...)
+		if page.NextToken == "" {
+			break
+		}
+		request["nextToken"] = page.NextToken
+	}
+	return events, nil
+}
+
+// call sends a signed request for an operation and decodes the response into out
+func (c *cloudWatchLogsClient) call(ctx context.Context, operation string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "Logs_20140328."+operation)
+
+	credentials, err := c.cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+	hash := sha256.Sum256(body)
+	if err := v4.NewSigner().SignHTTP(ctx, credentials, req, hex.EncodeToString(hash[:]), "logs", c.region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	httpClient := http.DefaultClient
+	if c.cfg.HTTPClient != nil {
+		if client, ok := c.cfg.HTTPClient.(*http.Client); ok {
+			httpClient = client
+		}
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", operation, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", operation, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(data, &apiErr)
+		code := apiErr.Type[strings.LastIndex(apiErr.Type, "#")+1:]
+		if code == "" {
+			code = resp.Status
+		}
+		return fmt.Errorf("%s failed: %s: %s", operation, code, apiErr.Message)
+	}
+	return json.Unmarshal(data, out)
+}
+
+// minTime returns the earlier of two times
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+// maxTime returns the later of two times
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// absDuration returns the absolute value of a duration
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,219 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the 'agent' command group for AWS Bedrock Intelligent Agents CLI.
+'agent prepare' and 'agent update-alias' wrap the control-plane PrepareAgent and
+UpdateAgentAlias APIs without prompting, and with --wait poll until the agent or alias
+is PREPARED, so a CI pipeline can deploy and then smoke-test with 'invoke' in one tool.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagent/types"
+	"github.com/spf13/cobra"
+)
+
+// UpdateAliasOptions contains all options for pointing an alias at an agent version
+type UpdateAliasOptions struct {
+	ConfigFile   string
+	AgentID      string
+	AliasID      string // Alias ID or alias name
+	Version      string
+	Region       string
+	Wait         bool
+	PollInterval time.Duration
+	Timeout      time.Duration
+	Verbose      bool
+}
+
+var (
+	agentPrepareOpts PrepareOptions
+	agentPrepareWait bool
+	updateAliasOpts  UpdateAliasOptions
+)
+
+// agentCmd groups the control-plane commands used in deployment workflows
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Prepare agents and update aliases in deployment workflows",
+	Long: `Prepare agents and update aliases in deployment workflows.
+
+The subcommands never prompt for confirmation and, with --wait, only return once
+the agent or alias is PREPARED, so the next step of a pipeline can invoke it.
+
+Examples:
+  # Prepare the draft, point the staging alias at version 7, and smoke-test it
+  aws-bia agent prepare --agent-id abc123 --wait
+  aws-bia agent update-alias --agent-id abc123 --alias-id staging --version 7 --wait
+  aws-bia invoke --agent-id abc123 --agent-alias-id staging --input "ping" --quiet
+`,
+}
+
+// agentPrepareCmd represents the agent prepare command
+var agentPrepareCmd = &cobra.Command{
+	Use:   "prepare",
+	Short: "Prepare the draft version of an agent",
+	Long: `Prepare the draft version of an agent with the PrepareAgent API.
+
+Without --wait the command returns as soon as preparation has started. With --wait
+it polls the agent status until it becomes PREPARED, and fails when it becomes FAILED
+or --timeout passes.
+
+Examples:
+  # Start preparation
+  aws-bia agent prepare --agent-id abc123
+
+  # Wait until the draft can be invoked through TSTALIASID
+  aws-bia agent prepare --agent-id abc123 --wait --timeout 5m
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		opts := agentPrepareOpts
+		opts.NoWait = !agentPrepareWait
+		if err := runPrepareCommand(ctx, opts); err != nil {
+			logError("Error preparing agent", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// agentUpdateAliasCmd represents the agent update-alias command
+var agentUpdateAliasCmd = &cobra.Command{
+	Use:   "update-alias",
+	Short: "Point an agent alias at an agent version",
+	Long: `Point an agent alias at an agent version with the UpdateAgentAlias API.
+
+The alias can be given by ID or by name. Unlike 'promote' there is no confirmation
+prompt. With --wait the command polls the alias status until it becomes PREPARED.
+
+Examples:
+  # Point the staging alias at version 7
+  aws-bia agent update-alias --agent-id abc123 --alias-id staging --version 7
+
+  # Wait until invocations through the alias use the new version
+  aws-bia agent update-alias --agent-id abc123 --alias-id ABCDEFGHIJ --version 7 --wait
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		if err := runUpdateAliasCommand(ctx, updateAliasOpts); err != nil {
+			logError("Error updating agent alias", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(agentCmd)
+	agentCmd.AddCommand(agentPrepareCmd)
+	agentCmd.AddCommand(agentUpdateAliasCmd)
+
+	agentPrepareCmd.Flags().StringVar(&agentPrepareOpts.ConfigFile, "config", "", "Path to configuration file (yaml)")
+	agentPrepareCmd.Flags().StringVar(&agentPrepareOpts.AgentID, "agent-id", "", "The ID of the agent to prepare (can be set in config file)")
+	agentPrepareCmd.Flags().StringVar(&agentPrepareOpts.Region, "region", "", "AWS region to use (defaults to AWS_REGION environment variable)")
+	agentPrepareCmd.Flags().BoolVar(&agentPrepareWait, "wait", false, "Wait until the agent is PREPARED")
+	agentPrepareCmd.Flags().DurationVar(&agentPrepareOpts.PollInterval, "poll-interval", DefaultPollInterval, "Interval between status checks")
+	agentPrepareCmd.Flags().DurationVar(&agentPrepareOpts.Timeout, "timeout", DefaultPrepareTimeout, "Maximum time to wait for preparation")
+	agentPrepareCmd.Flags().BoolVar(&agentPrepareOpts.Verbose, "verbose", false, "Enable verbose output")
+	registerAgentCompletions(agentPrepareCmd)
+
+	agentUpdateAliasCmd.Flags().StringVar(&updateAliasOpts.ConfigFile, "config", "", "Path to configuration file (yaml)")
+	agentUpdateAliasCmd.Flags().StringVar(&updateAliasOpts.AgentID, "agent-id", "", "The ID of the agent (can be set in config file)")
+	agentUpdateAliasCmd.Flags().StringVar(&updateAliasOpts.AliasID, "alias-id", "", "The ID or name of the alias to update (can be set in config file as agent_alias_id)")
+	agentUpdateAliasCmd.Flags().StringVar(&updateAliasOpts.Version, "version", "", "The agent version the alias should point to")
+	agentUpdateAliasCmd.Flags().StringVar(&updateAliasOpts.Region, "region", "", "AWS region to use (defaults to AWS_REGION environment variable)")
+	agentUpdateAliasCmd.Flags().BoolVar(&updateAliasOpts.Wait, "wait", false, "Wait until the alias is PREPARED")
+	agentUpdateAliasCmd.Flags().DurationVar(&updateAliasOpts.PollInterval, "poll-interval", DefaultPollInterval, "Interval between status checks")
+	agentUpdateAliasCmd.Flags().DurationVar(&updateAliasOpts.Timeout, "timeout", DefaultPrepareTimeout, "Maximum time to wait for the alias")
+	agentUpdateAliasCmd.Flags().BoolVar(&updateAliasOpts.Verbose, "verbose", false, "Enable verbose output")
+	registerAgentCompletions(agentUpdateAliasCmd)
+}
+
+// runUpdateAliasCommand updates the alias routing configuration and optionally waits for the alias
+func runUpdateAliasCommand(ctx context.Context, opts UpdateAliasOptions) error {
+	InitLogger(opts.Verbose)
+	defer SyncLogger()
+
+	agentOpts := AgentOptions{
+		AgentID:      opts.AgentID,
+		AgentAliasID: opts.AliasID,
+		Region:       opts.Region,
+		Verbose:      opts.Verbose,
+		Timeout:      DefaultTimeout,
+	}
+	if err := loadConfig(opts.ConfigFile, "agent", &agentOpts); err != nil {
+		return err
+	}
+
+	if agentOpts.AgentID == "" {
+		return fmt.Errorf("agent ID is required")
+	}
+	if agentOpts.AgentAliasID == "" {
+		return fmt.Errorf("alias ID is required")
+	}
+	if opts.Version == "" {
+		return fmt.Errorf("version is required")
+	}
+	if opts.Wait && opts.PollInterval <= 0 {
+		return fmt.Errorf("poll interval must be a positive duration")
+	}
+	if opts.Wait && opts.Timeout <= 0 {
+		return fmt.Errorf("timeout must be a positive duration")
+	}
+
+	client, err := NewAWSHelper(agentOpts).CreateAgentClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS client: %w", err)
+	}
+
+	alias, err := ResolveAgentAlias(ctx, client, agentOpts.AgentID, agentOpts.AgentAliasID)
+	if err != nil {
+		return err
+	}
+	aliasID := aws.ToString(alias.AgentAliasId)
+	aliasName := aws.ToString(alias.AgentAliasName)
+
+	if current := aliasRoutedVersion(alias); current == opts.Version {
+		fmt.Printf("Alias '%s' (%s) already points to version %s (status: %s)\n",
+			aliasName, aliasID, opts.Version, alias.AgentAliasStatus)
+		if !opts.Wait || alias.AgentAliasStatus == types.AgentAliasStatusPrepared {
+			return nil
+		}
+	} else {
+		updated, err := updateAliasVersion(ctx, client, alias, opts.Version)
+		if err != nil {
+			return err
+		}
+		status := ""
+		if updated != nil {
+			status = string(updated.AgentAliasStatus)
+		}
+		fmt.Printf("Alias '%s' (%s) updated from version %s to %s (status: %s)\n",
+			aliasName, aliasID, valueOrNone(current), opts.Version, status)
+		if !opts.Wait {
+			return nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	alias, err = WaitForAliasPrepared(ctx, client, agentOpts.AgentID, aliasID, opts.PollInterval, statusProgress[types.AgentAliasStatus]())
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Alias '%s' is %s on version %s\n", aliasName, alias.AgentAliasStatus, aliasRoutedVersion(alias))
+	return nil
+}
@@ -0,0 +1,161 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements resolving agent and alias names to IDs for the AWS Bedrock
+Intelligent Agents CLI. Resolved IDs are kept in a small local cache, agents.json in the
+cache directory (~/.cache/aws-bia by default), so repeated invocations skip the
+control-plane lookups.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagent"
+)
+
+// agentCacheTTL is how long a resolved name is trusted before it is looked up again
+const agentCacheTTL = 24 * time.Hour
+
+// agentCache maps agent and alias names to their IDs, keyed by region
+type agentCache struct {
+	Agents  map[string]agentCacheEntry `json:"agents"`  // "<region>/<agent name>"
+	Aliases map[string]agentCacheEntry `json:"aliases"` // "<region>/<agent ID>/<alias name>"
+}
+
+// agentCacheEntry is a single resolved ID
+type agentCacheEntry struct {
+	ID         string    `json:"id"`
+	ResolvedAt time.Time `json:"resolvedAt"`
+}
+
+// agentCachePath returns the location of the name cache
+func agentCachePath() (string, error) {
+	return cachePath("agents.json")
+}
+
+// loadAgentCache reads the name cache; a missing or unreadable cache is treated as empty
+func loadAgentCache() *agentCache {
+	cache := &agentCache{
+		Agents:  map[string]agentCacheEntry{},
+		Aliases: map[string]agentCacheEntry{},
+	}
+
+	path, err := agentCachePath()
+	if err != nil {
+		return cache
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(data, cache); err != nil {
+		LogWarn("Ignoring unreadable agent cache %s: %v", path, err)
+		return &agentCache{Agents: map[string]agentCacheEntry{}, Aliases: map[string]agentCacheEntry{}}
+	}
+	if cache.Agents == nil {
+		cache.Agents = map[string]agentCacheEntry{}
+	}
+	if cache.Aliases == nil {
+		cache.Aliases = map[string]agentCacheEntry{}
+	}
+	return cache
+}
+
+// save writes the name cache, creating its directory
+func (c *agentCache) save() error {
+	path, err := agentCachePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal agent cache: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write agent cache '%s': %w", path, err)
+	}
+	return nil
+}
+
+// lookupAgentCache returns a cached ID that has not expired
+func lookupAgentCache(entries map[string]agentCacheEntry, key string) (string, bool) {
+	entry, ok := entries[key]
+	if !ok || entry.ID == "" || time.Since(entry.ResolvedAt) > agentCacheTTL {
+		return "", false
+	}
+	return entry.ID, true
+}
+
+// resolveAgentNames fills AgentID and AgentAliasID from --agent-name and --alias-name.
+// Names take precedence over IDs loaded from the configuration file.
+func resolveAgentNames(ctx context.Context, opts *AgentOptions) error {
+	if opts.AgentName == "" && opts.AliasName == "" {
+		return nil
+	}
+
+	awsHelper := NewAWSHelper(*opts)
+	cfg, err := awsHelper.LoadConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	// The control-plane client is only created when the cache cannot answer
+	var client *bedrockagent.Client
+	agentClient := func() *bedrockagent.Client {
+		if client == nil {
+			client = bedrockagent.NewFromConfig(cfg)
+		}
+		return client
+	}
+
+	cache := loadAgentCache()
+	updated := false
+
+	if opts.AgentName != "" {
+		key := cfg.Region + "/" + opts.AgentName
+		id, ok := lookupAgentCache(cache.Agents, key)
+		if !ok {
+			if id, err = findAgentIDByName(ctx, agentClient(), opts.AgentName); err != nil {
+				return err
+			}
+			cache.Agents[key] = agentCacheEntry{ID: id, ResolvedAt: time.Now().UTC()}
+			updated = true
+		}
+		opts.AgentID = id
+		logVerbose(*opts, "Resolved agent name '%s' to ID %s", opts.AgentName, id)
+	}
+
+	if opts.AliasName != "" {
+		if opts.AgentID == "" {
+			return fmt.Errorf("--alias-name requires --agent-id or --agent-name")
+		}
+		key := cfg.Region + "/" + opts.AgentID + "/" + opts.AliasName
+		id, ok := lookupAgentCache(cache.Aliases, key)
+		if !ok {
+			if id, err = findAgentAliasID(ctx, agentClient(), opts.AgentID, opts.AliasName); err != nil {
+				return err
+			}
+			cache.Aliases[key] = agentCacheEntry{ID: id, ResolvedAt: time.Now().UTC()}
+			updated = true
+		}
+		opts.AgentAliasID = id
+		logVerbose(*opts, "Resolved alias name '%s' to ID %s", opts.AliasName, id)
+	}
+
+	if updated {
+		if err := cache.save(); err != nil {
+			opts.Warnings.Warn(WarningConfig, "could not update agent cache", err)
+		}
+	}
+	return nil
+}
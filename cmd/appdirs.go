@@ -0,0 +1,159 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the on-disk locations of the AWS Bedrock Intelligent Agents CLI.
+Configuration, caches, and data follow the XDG base directories, $XDG_CONFIG_HOME,
+$XDG_CACHE_HOME, and $XDG_DATA_HOME (~/.config, ~/.cache, and ~/.local/share by default),
+and %APPDATA% and %LOCALAPPDATA% on Windows; sockets go to $XDG_RUNTIME_DIR. Files that
+an earlier version created under ~/.aws-bia are still used as long as the XDG location
+does not exist, so upgrading loses no sessions, caches, or prompts; 'aws-bia paths'
+shows which location is in use.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// appDirName names the directory of the CLI within each base directory
+const appDirName = "aws-bia"
+
+// homeDir returns the home directory of the user
+func homeDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine home directory: %w", err)
+	}
+	return home, nil
+}
+
+// legacyAppDir returns ~/.aws-bia, where earlier versions kept all their files
+func legacyAppDir() (string, error) {
+	home, err := homeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".aws-bia"), nil
+}
+
+// xdgBaseDir returns the base directory named by env, or home joined with fallback when
+// the variable is unset; relative values are invalid by the XDG specification and ignored
+func xdgBaseDir(env string, fallback ...string) string {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return dir
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(append([]string{home}, fallback...)...)
+	}
+	return ""
+}
+
+// userConfigDir returns the base directory for configuration: %APPDATA% on Windows, and
+// $XDG_CONFIG_HOME or ~/.config elsewhere
+func userConfigDir() string {
+	if runtime.GOOS == "windows" {
+		return os.Getenv("APPDATA")
+	}
+	return xdgBaseDir("XDG_CONFIG_HOME", ".config")
+}
+
+// userCacheDir returns the base directory for caches: %LOCALAPPDATA% on Windows, and
+// $XDG_CACHE_HOME or ~/.cache elsewhere
+func userCacheDir() string {
+	if runtime.GOOS == "windows" {
+		return os.Getenv("LOCALAPPDATA")
+	}
+	return xdgBaseDir("XDG_CACHE_HOME", ".cache")
+}
+
+// userDataDir returns the base directory for the data files of the user: %APPDATA% on
+// Windows, and $XDG_DATA_HOME or ~/.local/share elsewhere
+func userDataDir() string {
+	if runtime.GOOS == "windows" {
+		return os.Getenv("APPDATA")
+	}
+	return xdgBaseDir("XDG_DATA_HOME", ".local", "share")
+}
+
+// userRuntimeDir returns the base directory for sockets: $XDG_RUNTIME_DIR, which the login
+// session provides, or the state directory $XDG_STATE_HOME (~/.local/state) without one, and
+// %LOCALAPPDATA% on Windows
+func userRuntimeDir() string {
+	if runtime.GOOS == "windows" {
+		return os.Getenv("LOCALAPPDATA")
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); filepath.IsAbs(dir) {
+		return dir
+	}
+	return xdgBaseDir("XDG_STATE_HOME", ".local", "state")
+}
+
+// systemDataDirs returns the directories for data files shared by all users: %ProgramData%
+// on Windows, and $XDG_DATA_DIRS or /usr/local/share and /usr/share elsewhere
+func systemDataDirs() []string {
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("ProgramData"); dir != "" {
+			return []string{dir}
+		}
+		return nil
+	}
+
+	var dirs []string
+	for _, dir := range filepath.SplitList(os.Getenv("XDG_DATA_DIRS")) {
+		if filepath.IsAbs(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	if len(dirs) == 0 {
+		dirs = []string{"/usr/local/share", "/usr/share"}
+	}
+	return dirs
+}
+
+// appPath returns the location of an item of the CLI in a base directory. The location
+// under ~/.aws-bia given by legacy is returned instead when only it exists.
+func appPath(base, name, legacy string) (string, error) {
+	if base == "" {
+		return "", fmt.Errorf("failed to determine the directory for %s", name)
+	}
+	path := filepath.Join(base, appDirName, name)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	if dir, err := legacyAppDir(); err == nil {
+		old := filepath.Join(dir, legacy)
+		if _, err := os.Stat(old); err == nil {
+			return old, nil
+		}
+	}
+	return path, nil
+}
+
+// configHomePath returns the location of an item in the configuration directory
+func configHomePath(name string) (string, error) {
+	return appPath(userConfigDir(), name, name)
+}
+
+// cachePath returns the location of an item in the cache directory; in ~/.aws-bia caches
+// were kept in a cache subdirectory
+func cachePath(name string) (string, error) {
+	return appPath(userCacheDir(), name, filepath.Join("cache", name))
+}
+
+// dataPath returns the location of an item in the data directory
+func dataPath(name string) (string, error) {
+	return appPath(userDataDir(), name, name)
+}
+
+// runtimePath returns the location of an item in the runtime directory; earlier versions kept
+// nothing there, so there is no location under ~/.aws-bia to fall back to
+func runtimePath(name string) (string, error) {
+	dir := userRuntimeDir()
+	if dir == "" {
+		return "", fmt.Errorf("failed to determine the directory for %s", name)
+	}
+	return filepath.Join(dir, appDirName, name), nil
+}
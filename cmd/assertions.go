@@ -0,0 +1,78 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements answer assertions for the 'invoke' command. With --fail-if-empty an
+answer without any text fails the command, every --expect-regex pattern must match
+the answer, and with --expect-similar the answer must resemble a reference text by at
+least --similarity-threshold. A failed assertion is reported on stderr and the command
+exits with ExitCodeAssertionFailed, so CI checks need no grep around the CLI. The turns
+of 'run' scripts use the same similarity assertion.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// ExitCodeAssertionFailed is the exit status of invoke when the answer fails an assertion.
+// It is distinct from the exit status 1 of failed invocations.
+const ExitCodeAssertionFailed = 5
+
+// ErrAssertionFailed reports an answer that is empty, does not match an --expect-regex pattern,
+// or is not similar enough to the --expect-similar text
+var ErrAssertionFailed = errors.New("the answer failed an assertion")
+
+// checkAssertions reports every assertion the answer fails
+func checkAssertions(opts AgentOptions, expect []*regexp.Regexp, answer string) error {
+	var failures []string
+	if opts.FailIfEmpty && strings.TrimSpace(answer) == "" {
+		failures = append(failures, "the answer is empty")
+	}
+	for _, pattern := range expect {
+		if !pattern.MatchString(answer) {
+			failures = append(failures, fmt.Sprintf("the answer does not match /%s/", pattern))
+		}
+	}
+	if opts.ExpectSimilar != "" {
+		score := ResponseSimilarity(answer, opts.ExpectSimilar)
+		logVerbose(opts, "The answer is %.2f similar to the expected text", score)
+		if failure := similarityFailure(score, opts.SimilarityThreshold); failure != "" {
+			failures = append(failures, failure)
+		}
+	}
+	if len(failures) == 0 {
+		logVerbose(opts, "The answer passed all assertions")
+		return nil
+	}
+
+	reportAssertionFailures(opts.Color, failures)
+	return ErrAssertionFailed
+}
+
+// similarityFailure describes a similarity score below the threshold, or returns "" when it passes
+func similarityFailure(score, threshold float64) string {
+	if score >= threshold {
+		return ""
+	}
+	return fmt.Sprintf("the answer is %.2f similar to the expected text, below the threshold of %.2f", score, threshold)
+}
+
+// validateSimilarityThreshold checks that a threshold is a similarity score
+func validateSimilarityThreshold(threshold float64) error {
+	if threshold < 0 || threshold > 1 {
+		return fmt.Errorf("similarity threshold must be between 0 and 1, got %g", threshold)
+	}
+	return nil
+}
+
+// reportAssertionFailures writes every failed assertion to stderr
+func reportAssertionFailures(colorMode string, failures []string) {
+	color := colorizer{enabled: useColor(colorMode, os.Stderr)}
+	for _, failure := range failures {
+		fmt.Fprintf(os.Stderr, "%s %s\n", color.Error("Assertion failed:"), failure)
+	}
+}
@@ -10,19 +10,73 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"strings"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagent"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/types"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/google/uuid"
 )
 
+// BedrockAgentRuntimeAPI is the part of the Bedrock Agent runtime API used to invoke agents.
+// *bedrockagentruntime.Client implements it; tests and embedding programs can inject their own.
+type BedrockAgentRuntimeAPI interface {
+	InvokeAgent(ctx context.Context, params *bedrockagentruntime.InvokeAgentInput,
+		optFns ...func(*bedrockagentruntime.Options)) (*bedrockagentruntime.InvokeAgentOutput, error)
+	Options() bedrockagentruntime.Options
+}
+
+// RuntimeClients holds the runtime clients of a run, one per region. They are created on first
+// use and shared by every invocation, so chat turns, script turns, and server requests reuse
+// the loaded configuration, credentials, and HTTP connections.
+type RuntimeClients struct {
+	mu       sync.Mutex
+	client   BedrockAgentRuntimeAPI // Injected client that serves every region
+	byRegion map[string]BedrockAgentRuntimeAPI
+}
+
+// NewRuntimeClients returns a holder that serves client, or creates clients on first use when client is nil
+func NewRuntimeClients(client BedrockAgentRuntimeAPI) *RuntimeClients {
+	return &RuntimeClients{client: client, byRegion: make(map[string]BedrockAgentRuntimeAPI)}
+}
+
+// get returns the shared client of the helper's region, creating it the first time
+func (c *RuntimeClients) get(ctx context.Context, a *AWSHelper) (BedrockAgentRuntimeAPI, error) {
+	if c.client != nil {
+		return c.client, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if client, ok := c.byRegion[a.Options.Region]; ok {
+		return client, nil
+	}
+	client, err := a.newRuntimeClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.byRegion[a.Options.Region] = client
+	return client, nil
+}
+
 // AWSHelper provides AWS-specific functionality
 type AWSHelper struct {
-	Options    AgentOptions
-	FileHelper *FileHelper
+	Options     AgentOptions
+	FileHelper  *FileHelper
+	Recorder    *InvocationRecorder                         // Captures the raw response when recording
+	Dumper      *StreamDumper                               // Captures the timed event-stream frames with --dump-stream
+	Replay      *Recording                                  // Serves a recorded response instead of calling AWS
+	Cache       *ResponseCache                              // Serves and stores responses with --cache
+	Diagnostics *DiagBundle                                 // Captures the raw response for --diag-bundle
+	InlineAgent *bedrockagentruntime.InvokeInlineAgentInput // Agent definition sent with --prompt-override-file
+	RetryBudget *RetryBudget                                // Limits the retries shared with other clients of the run
+	Progress    *progressIndicator                          // Reports the invocation phase; nil when disabled
 }
 
 // NewAWSHelper creates a new AWSHelper
@@ -41,27 +95,152 @@ func (a *AWSHelper) LoadConfig(ctx context.Context) (aws.Config, error) {
 		configOptions = append(configOptions, config.WithRegion(a.Options.Region))
 	}
 
+	if a.Options.UseFIPS {
+		configOptions = append(configOptions, config.WithUseFIPSEndpoint(aws.FIPSEndpointStateEnabled))
+	}
+	if a.Options.UseDualStack {
+		configOptions = append(configOptions, config.WithUseDualStackEndpoint(aws.DualStackEndpointStateEnabled))
+	}
+
+	// Custom headers, request hooks, and registered middleware apply to every client
+	if fns := apiOptions(a.Options); len(fns) > 0 {
+		configOptions = append(configOptions, config.WithAPIOptions(fns))
+	}
+
 	return config.LoadDefaultConfig(ctx, configOptions...)
 }
 
-// CreateClient creates a Bedrock Agent runtime client
-func (a *AWSHelper) CreateClient(ctx context.Context) (*bedrockagentruntime.Client, error) {
+// CreateClient returns the Bedrock Agent runtime client for an invocation: the shared client
+// of Options.Clients when set, otherwise a new one
+func (a *AWSHelper) CreateClient(ctx context.Context) (BedrockAgentRuntimeAPI, error) {
+	// Replayed invocations never touch AWS, so no configuration or credentials are needed
+	if a.Replay != nil {
+		return newReplayClient(a.Replay), nil
+	}
+	if a.Options.Clients != nil {
+		return a.Options.Clients.get(ctx, a)
+	}
+	return a.newRuntimeClient(ctx)
+}
+
+// newRuntimeClient loads the AWS configuration and creates a runtime client.
+// Capturing responses and the retry budget are per invocation, see invocationOptions.
+func (a *AWSHelper) newRuntimeClient(ctx context.Context) (*bedrockagentruntime.Client, error) {
+	cfg, err := a.LoadConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	var optFns []func(*bedrockagentruntime.Options)
+
+	// The endpoint URL only replaces the runtime endpoint, agent lookups still go to the regional control plane
+	if a.Options.EndpointURL != "" {
+		if err := validateEndpointURL(a.Options.EndpointURL); err != nil {
+			return nil, err
+		}
+		optFns = append(optFns, func(o *bedrockagentruntime.Options) {
+			o.BaseEndpoint = aws.String(a.Options.EndpointURL)
+		})
+	}
+	return bedrockagentruntime.NewFromConfig(cfg, optFns...), nil
+}
+
+// invocationOptions returns the per-call options of an invocation: the HTTP client is wrapped
+// to capture the response, and retries draw from the retry budget. Applying them per call
+// keeps a shared client free of the recorders of other invocations.
+func (a *AWSHelper) invocationOptions() []func(*bedrockagentruntime.Options) {
+	var optFns []func(*bedrockagentruntime.Options)
+	if a.RetryBudget != nil {
+		optFns = append(optFns, func(o *bedrockagentruntime.Options) {
+			o.Retryer = a.RetryBudget.wrap(o.Retryer)
+		})
+	}
+	if a.Cache != nil {
+		optFns = append(optFns, func(o *bedrockagentruntime.Options) {
+			o.HTTPClient = a.Cache.recorder.wrapHTTPClient(o.HTTPClient)
+		})
+	}
+	if a.Diagnostics != nil {
+		optFns = append(optFns, func(o *bedrockagentruntime.Options) {
+			o.HTTPClient = a.Diagnostics.recorder.wrapHTTPClient(o.HTTPClient)
+		})
+	}
+	if a.Recorder != nil {
+		optFns = append(optFns, func(o *bedrockagentruntime.Options) {
+			o.HTTPClient = a.Recorder.wrapHTTPClient(o.HTTPClient)
+		})
+	}
+	if a.Dumper != nil {
+		optFns = append(optFns, func(o *bedrockagentruntime.Options) {
+			o.HTTPClient = a.Dumper.wrapHTTPClient(o.HTTPClient)
+		})
+	}
+	return optFns
+}
+
+// CreateAgentClient creates a Bedrock Agent control-plane client
+func (a *AWSHelper) CreateAgentClient(ctx context.Context) (*bedrockagent.Client, error) {
+	cfg, err := a.LoadConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	return bedrockagent.NewFromConfig(cfg), nil
+}
+
+// CreateBedrockRuntimeClient creates a Bedrock runtime client for the ApplyGuardrail and Converse APIs
+func (a *AWSHelper) CreateBedrockRuntimeClient(ctx context.Context) (*bedrockruntime.Client, error) {
 	cfg, err := a.LoadConfig(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
 
-	return bedrockagentruntime.NewFromConfig(cfg), nil
+	return bedrockruntime.NewFromConfig(cfg), nil
+}
+
+// validateEndpointURL checks that an endpoint URL is an absolute http or https URL
+func validateEndpointURL(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid endpoint URL '%s': must be an http:// or https:// URL", endpoint)
+	}
+	return nil
 }
 
 // PrepareInvokeInput creates the InvokeAgentInput struct from the options
-func (a *AWSHelper) PrepareInvokeInput() (*bedrockagentruntime.InvokeAgentInput, error) {
+func (a *AWSHelper) PrepareInvokeInput(ctx context.Context) (*bedrockagentruntime.InvokeAgentInput, error) {
 	input := &bedrockagentruntime.InvokeAgentInput{
 		AgentId:      aws.String(a.Options.AgentID),
 		AgentAliasId: aws.String(a.Options.AgentAliasID),
 		InputText:    aws.String(a.Options.InputText),
 	}
 
+	// Start from the session state of --session-state-file, the flags below add to it
+	if doc := a.Options.SessionStateDocument; doc != nil {
+		state, err := doc.sessionState()
+		if err != nil {
+			return nil, err
+		}
+		input.SessionState = state
+	}
+
+	// Request trace events when enabled
+	if a.Options.EnableTrace {
+		input.EnableTrace = aws.Bool(true)
+	}
+
+	// Override the latency profile of the agent's model
+	if a.Options.Latency != "" {
+		input.BedrockModelConfigurations = &types.BedrockModelConfigurations{
+			PerformanceConfig: &types.PerformanceConfiguration{Latency: types.PerformanceConfigLatency(a.Options.Latency)},
+		}
+	}
+
+	// Continue a long-term memory thread across sessions
+	if a.Options.MemoryID != "" {
+		input.MemoryId = aws.String(a.Options.MemoryID)
+	}
+
 	// Add session ID if provided, otherwise generate a random UUID
 	if a.Options.SessionID != "" {
 		input.SessionId = aws.String(a.Options.SessionID)
@@ -81,21 +260,103 @@ func (a *AWSHelper) PrepareInvokeInput() (*bedrockagentruntime.InvokeAgentInput,
 			input.SessionState = &types.SessionState{}
 		}
 
+		// S3 upload entries are fetched with the same AWS configuration
+		if a.hasS3Uploads() && a.FileHelper.S3Client == nil {
+			cfg, err := a.LoadConfig(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load AWS config: %w", err)
+			}
+			a.FileHelper.S3Client = s3.NewFromConfig(cfg)
+		}
+
 		// Process files
-		inputFiles, err := a.FileHelper.PrepareInputFiles()
+		inputFiles, err := a.FileHelper.PrepareInputFiles(ctx)
 		if err != nil {
 			return nil, err
 		}
 
 		// Add the files to the session state
-		input.SessionState.Files = inputFiles
+		input.SessionState.Files = append(input.SessionState.Files, inputFiles...)
+	}
+
+	// Override the knowledge bases and retrieval settings for this invocation
+	kbConfigurations, err := buildKnowledgeBaseConfigurations(a.Options)
+	if err != nil {
+		return nil, err
+	}
+	if len(kbConfigurations) > 0 {
+		if input.SessionState == nil {
+			input.SessionState = &types.SessionState{}
+		}
+		input.SessionState.KnowledgeBaseConfigurations = append(input.SessionState.KnowledgeBaseConfigurations, kbConfigurations...)
 	}
 
-	j, _ := json.Marshal(input)
-	logVerbose(a.Options, "Prepared InvokeAgentInput: %s", string(j))
+	if verboseLogging(a.Options) {
+		logVerbose(a.Options, "Prepared InvokeAgentInput: %s", describeInvokeInput(input))
+	}
 	return input, nil
 }
 
+// loggedInvokeInput is the redacted form of an InvokeAgentInput written to the verbose log
+type loggedInvokeInput struct {
+	*bedrockagentruntime.InvokeAgentInput
+	UploadFiles []loggedInputFile `json:",omitempty"`
+}
+
+// loggedInputFile describes an upload file without its content
+type loggedInputFile struct {
+	Name       string
+	MediaType  string `json:",omitempty"`
+	Size       int    `json:",omitempty"`
+	SourceType string
+	S3URI      string `json:",omitempty"`
+	UseCase    string
+}
+
+// describeInvokeInput renders the input as JSON for the verbose log. Upload files are reduced to
+// their name, size, and media type, and the input text follows the --log-redact policy.
+func describeInvokeInput(input *bedrockagentruntime.InvokeAgentInput) string {
+	copied := *input
+	if input.InputText != nil {
+		copied.InputText = aws.String(redactLogText(*input.InputText))
+	}
+	logged := loggedInvokeInput{InvokeAgentInput: &copied}
+
+	if input.SessionState != nil && len(input.SessionState.Files) > 0 {
+		state := *input.SessionState
+		state.Files = nil
+		copied.SessionState = &state
+
+		for _, file := range input.SessionState.Files {
+			described := loggedInputFile{Name: aws.ToString(file.Name), UseCase: string(file.UseCase)}
+			if file.Source != nil {
+				described.SourceType = string(file.Source.SourceType)
+				if content := file.Source.ByteContent; content != nil {
+					described.MediaType = aws.ToString(content.MediaType)
+					described.Size = len(content.Data)
+				}
+				if location := file.Source.S3Location; location != nil {
+					described.S3URI = aws.ToString(location.Uri)
+				}
+			}
+			logged.UploadFiles = append(logged.UploadFiles, described)
+		}
+	}
+
+	j, _ := json.Marshal(logged)
+	return string(j)
+}
+
+// hasS3Uploads reports whether any upload file entry refers to an S3 object
+func (a *AWSHelper) hasS3Uploads() bool {
+	for _, filePath := range a.Options.UploadFiles {
+		if isS3URI(filePath) {
+			return true
+		}
+	}
+	return false
+}
+
 // HandleAWSError provides more detailed error information based on the AWS error type
 func HandleAWSError(err error) error {
 	if err == nil {
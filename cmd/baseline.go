@@ -0,0 +1,59 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements baseline comparison for the 'invoke' command. With --compare-with
+the answer is compared to a stored response, either the JSON document of an earlier
+'--format json' run or a plain text file. A unified diff is printed on stderr and the
+command exits with ExitCodeResponseDiffers when they differ, for regression tests in CI.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ExitCodeResponseDiffers is the exit status of invoke when the answer differs from the baseline.
+// It is distinct from the exit status 1 of failed invocations.
+const ExitCodeResponseDiffers = 3
+
+// ErrResponseDiffers reports an answer that does not match the --compare-with baseline
+var ErrResponseDiffers = errors.New("response differs from the baseline")
+
+// loadBaseline reads the answer stored in a baseline file
+func loadBaseline(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read baseline '%s': %w", path, err)
+	}
+
+	// A JSON response document carries the answer in its content field
+	var document struct {
+		Content *string `json:"content"`
+	}
+	if json.Unmarshal(data, &document) == nil && document.Content != nil {
+		return *document.Content, nil
+	}
+	return string(data), nil
+}
+
+// compareWithBaseline prints the difference between the baseline and the new answer
+func compareWithBaseline(opts AgentOptions, baseline, answer string) error {
+	// A trailing newline in a text baseline is not part of the answer
+	baseline = strings.TrimRight(baseline, " \t\r\n")
+	answer = strings.TrimRight(answer, " \t\r\n")
+
+	if baseline == answer {
+		if !opts.Quiet {
+			fmt.Fprintf(os.Stderr, "Response matches baseline %s\n", opts.CompareWith)
+		}
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "\nResponse differs from baseline %s:\n", opts.CompareWith)
+	fmt.Fprint(os.Stderr, unifiedDiff(opts.CompareWith, "response", baseline, answer))
+	return ErrResponseDiffers
+}
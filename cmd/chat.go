@@ -0,0 +1,424 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the 'chat' command for AWS Bedrock Intelligent Agents CLI.
+It provides an interactive REPL that keeps a single agent session across turns
+and prints a compact status line with latency, token usage, and estimated cost
+after every answer.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// ChatOptions contains all options for an interactive chat session
+type ChatOptions struct {
+	ConfigFile       string
+	AgentID          string
+	AgentAliasID     string
+	SessionID        string
+	MemoryID         string
+	Region           string
+	EndpointURL      string
+	UseFIPS          bool
+	UseDualStack     bool
+	Timeout          time.Duration // Maximum duration of a turn; 0 means no limit
+	ConnectTimeout   time.Duration
+	IdleTimeout      time.Duration
+	MaxDurationSet   bool
+	InputTokenPrice  float64
+	OutputTokenPrice float64
+	Budget           float64
+	MaxTokensTotal   int64
+	MaxCost          float64
+	RPS              float64
+	Burst            int
+	NoStatus         bool
+	OutputFile       string
+	AppendOutput     bool
+	TimestampOutput  bool
+	OutputMaxSize    string
+	Verbose          bool
+}
+
+var chatOpts ChatOptions
+
+// chatCmd represents the chat command
+var chatCmd = &cobra.Command{
+	Use:   "chat",
+	Short: "Start an interactive chat session with an agent",
+	Long: `Start an interactive chat session with an agent.
+
+Every turn is sent in the same session, so the agent keeps the conversation
+context. After each answer a status line shows the latency, the tokens used,
+and, when token prices are configured, the estimated cost of the turn and the
+whole session.
+
+Commands:
+  /new      Start a new session
+  /status   Show the session totals
+  /editor   Write the next input in $EDITOR
+  /help     Show the available commands
+  /exit     Leave the chat (Ctrl-D also works)
+
+Inputs can span several lines: end a line with \ to continue on the next one, or
+put the text between lines of triple quotes ("""). Text pasted into the terminal
+is sent as one input, also when it has several lines.
+
+Examples:
+  # Chat with an agent
+  aws-bia chat --agent-id abc123 --agent-alias-id def456
+
+  # Show estimated cost and warn above a session budget of $0.50
+  aws-bia chat --agent-id abc123 --agent-alias-id def456 \
+    --input-token-price 0.003 --output-token-price 0.015 --budget 0.50
+
+  # Keep a log of every turn, rotated at 1MB
+  aws-bia chat --agent-id abc123 --agent-alias-id def456 --output-file chat.log --append --output-file-max-size 1MB
+
+  # End the session once 50,000 tokens or an estimated $2 have been used
+  aws-bia chat --agent-id abc123 --agent-alias-id def456 \
+    --max-tokens-total 50000 --input-token-price 0.003 --output-token-price 0.015 --max-cost 2
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		chatOpts.MaxDurationSet = cmd.Flags().Changed("max-duration") || cmd.Flags().Changed("timeout")
+
+		// Ctrl-C interrupts the answer of a turn, at the prompt it ends the chat
+		if err := runChatCommand(context.Background(), chatOpts); err != nil {
+			logError("Error in chat session", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(chatCmd)
+
+	chatCmd.Flags().StringVar(&chatOpts.ConfigFile, "config", "", "Path to configuration file (yaml)")
+	chatCmd.Flags().StringVar(&chatOpts.AgentID, "agent-id", "", "The ID of the agent to chat with (can be set in config file)")
+	chatCmd.Flags().StringVar(&chatOpts.AgentAliasID, "agent-alias-id", "", "The ID of the agent alias (can be set in config file)")
+	chatCmd.Flags().StringVar(&chatOpts.SessionID, "session-id", "", "Resume an existing session (if not provided, a random ID will be generated)")
+	chatCmd.Flags().StringVar(&chatOpts.MemoryID, "memory-id", "", "Agent memory ID to continue a long-term memory thread (can be set in config file)")
+	chatCmd.Flags().StringVar(&chatOpts.Region, "region", "", "AWS region to use (defaults to AWS_REGION environment variable)")
+	chatCmd.Flags().StringVar(&chatOpts.EndpointURL, "endpoint-url", "", "Send agent runtime requests to this URL instead of the regional endpoint (e.g. a VPC endpoint or local mock)")
+	chatCmd.Flags().BoolVar(&chatOpts.UseFIPS, "use-fips", false, "Use FIPS endpoints for AWS requests")
+	chatCmd.Flags().BoolVar(&chatOpts.UseDualStack, "use-dualstack", false, "Use dual-stack (IPv4 and IPv6) endpoints for AWS requests")
+	chatCmd.Flags().DurationVar(&chatOpts.ConnectTimeout, "connect-timeout", DefaultConnectTimeout, "Maximum time until the agent starts answering, 0 for no limit")
+	chatCmd.Flags().DurationVar(&chatOpts.IdleTimeout, "idle-timeout", DefaultIdleTimeout, "Maximum time between two stream events, 0 for no limit")
+	chatCmd.Flags().DurationVar(&chatOpts.Timeout, "max-duration", DefaultMaxDuration, "Maximum duration of each turn, 0 for no limit (streamed answers have no limit unless it is given)")
+	chatCmd.Flags().DurationVar(&chatOpts.Timeout, "timeout", DefaultMaxDuration, "Maximum duration of each turn")
+	_ = chatCmd.Flags().MarkDeprecated("timeout", "use --max-duration, --connect-timeout, or --idle-timeout instead")
+	chatCmd.Flags().Float64Var(&chatOpts.RPS, "rps", 0, "Send at most this many requests per second (0 for no limit, can be set in config file)")
+	chatCmd.Flags().IntVar(&chatOpts.Burst, "burst", DefaultBurst, "Requests that may be sent at once before --rps applies")
+	chatCmd.Flags().Float64Var(&chatOpts.InputTokenPrice, "input-token-price", 0, "USD per 1,000 input tokens for cost estimates (can be set in config file)")
+	chatCmd.Flags().Float64Var(&chatOpts.OutputTokenPrice, "output-token-price", 0, "USD per 1,000 output tokens for cost estimates (can be set in config file)")
+	chatCmd.Flags().Float64Var(&chatOpts.Budget, "budget", 0, "Warn when the estimated session cost exceeds this amount in USD (can be set in config file)")
+	chatCmd.Flags().Int64Var(&chatOpts.MaxTokensTotal, "max-tokens-total", 0, "End the session once this many tokens have been used (0 for no limit, can be set in config file)")
+	chatCmd.Flags().Float64Var(&chatOpts.MaxCost, "max-cost", 0, "End the session once the estimated cost reaches this amount in USD (needs token prices, can be set in config file)")
+	chatCmd.Flags().BoolVar(&chatOpts.NoStatus, "no-status", false, "Do not print the status line after each answer")
+	chatCmd.Flags().StringVar(&chatOpts.OutputFile, "output-file", "", "Also write the input and answer of every turn to this file")
+	chatCmd.Flags().BoolVar(&chatOpts.AppendOutput, "append", false, "Append to the --output-file instead of replacing it")
+	chatCmd.Flags().BoolVar(&chatOpts.TimestampOutput, "output-file-timestamped", false, "Insert the start time of the chat into the --output-file name")
+	chatCmd.Flags().StringVar(&chatOpts.OutputMaxSize, "output-file-max-size", "", "Rotate the --output-file to <name>.1 once it reaches this size, e.g. 10MB (keeps 5 rotated files)")
+	chatCmd.Flags().BoolVar(&chatOpts.Verbose, "verbose", false, "Enable verbose output")
+
+	registerAgentCompletions(chatCmd)
+}
+
+// chatSession tracks the state of an interactive chat across turns
+type chatSession struct {
+	opts         AgentOptions
+	pricing      TokenPricing
+	budget       float64
+	showStatus   bool
+	turns        int
+	totalUsage   TokenUsage
+	totalCost    float64
+	budgetWarned bool
+	logOpts      OutputFileOptions // For the --output-file log of the turns
+}
+
+// runChatCommand runs the read-eval-print loop until the user exits
+func runChatCommand(ctx context.Context, opts ChatOptions) error {
+	InitLogger(opts.Verbose)
+	defer SyncLogger()
+
+	v, err := LoadConfigForCommand(opts.ConfigFile, "chat", opts.Verbose)
+	if err != nil {
+		return err
+	}
+
+	agentOpts := AgentOptions{
+		AgentID:         opts.AgentID,
+		AgentAliasID:    opts.AgentAliasID,
+		SessionID:       opts.SessionID,
+		MemoryID:        opts.MemoryID,
+		Region:          opts.Region,
+		EndpointURL:     opts.EndpointURL,
+		UseFIPS:         opts.UseFIPS,
+		UseDualStack:    opts.UseDualStack,
+		Timeout:         opts.Timeout,
+		ConnectTimeout:  opts.ConnectTimeout,
+		IdleTimeout:     opts.IdleTimeout,
+		MaxDurationSet:  opts.MaxDurationSet,
+		OutputFormat:    OutputFormatText,
+		FileUseCase:     FileUseCaseCodeInterpreter,
+		EnableStreaming: true,
+		EnableTrace:     true, // Trace events carry the token usage
+		Verbose:         opts.Verbose,
+		Clients:         NewRuntimeClients(nil), // Every turn reuses the client of the first
+		RPS:             opts.RPS,
+		Burst:           opts.Burst,
+		MaxTokensTotal:  opts.MaxTokensTotal,
+		MaxCost:         opts.MaxCost,
+		OutputFile:      opts.OutputFile,
+		AppendOutput:    opts.AppendOutput,
+		TimestampOutput: opts.TimestampOutput,
+		OutputMaxSize:   opts.OutputMaxSize,
+	}
+	applyAgentConfig(v, &agentOpts)
+	applyTimeoutConfig(v, &agentOpts)
+	if err := setupRateLimiter(v, &agentOpts); err != nil {
+		return err
+	}
+	pricing := TokenPricing{
+		InputPer1K:  configFloat(v, "input_token_price", opts.InputTokenPrice),
+		OutputPer1K: configFloat(v, "output_token_price", opts.OutputTokenPrice),
+	}
+	if err := setupUsageBudget(v, &agentOpts, pricing); err != nil {
+		return err
+	}
+	agentOpts.Color = v.GetString("color")
+
+	if agentOpts.AgentID == "" {
+		return fmt.Errorf("agent ID is required")
+	}
+	if agentOpts.AgentAliasID == "" {
+		return fmt.Errorf("agent alias ID is required")
+	}
+	if err := validateTimeouts(agentOpts); err != nil {
+		return err
+	}
+	if agentOpts.SessionID == "" {
+		agentOpts.SessionID = uuid.New().String()
+	}
+	logOpts, err := outputFileOptions(agentOpts)
+	if err != nil {
+		return err
+	}
+	if agentOpts.TimestampOutput {
+		agentOpts.OutputFile = timestampedOutputPath(agentOpts.OutputFile, time.Now())
+	}
+
+	session := &chatSession{
+		opts:       agentOpts,
+		pricing:    pricing,
+		budget:     configFloat(v, "session_budget", opts.Budget),
+		showStatus: !opts.NoStatus,
+		logOpts:    logOpts,
+	}
+
+	fmt.Fprintf(os.Stderr, "Chatting with agent %s (alias %s)\n", agentOpts.AgentID, agentOpts.AgentAliasID)
+	fmt.Fprintf(os.Stderr, "Session ID: %s\n", agentOpts.SessionID)
+	fmt.Fprintln(os.Stderr, "Type /help for commands, /exit to quit.")
+
+	reader := newChatInput(stdinReader)
+	for {
+		input, err := reader.Read()
+		if err == io.EOF {
+			fmt.Fprintln(os.Stderr)
+			session.printTotals()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if input == "" {
+			continue
+		}
+
+		// Only a single line is a command, pasted text may well start with a path
+		if strings.HasPrefix(input, "/") && !strings.Contains(input, "\n") {
+			if strings.Fields(input)[0] != "/editor" {
+				if session.handleCommand(input) {
+					session.printTotals()
+					return nil
+				}
+				continue
+			}
+			if input, err = composeInEditor(""); err != nil {
+				logError("Error composing input", err)
+				continue
+			}
+			if input == "" {
+				fmt.Fprintln(os.Stderr, "Empty input, nothing was sent")
+				continue
+			}
+		}
+
+		if err := session.runTurn(ctx, input); errors.Is(err, ErrInterrupted) {
+			fmt.Fprintln(os.Stderr, "[Interrupted, the answer is incomplete]")
+		} else if err != nil {
+			logError("Error invoking agent", err)
+		}
+
+		// The session ends as soon as the budget is used up, rather than on the next input
+		if err := agentOpts.UsageBudget.Check(); err != nil {
+			fmt.Fprintln(os.Stderr, agentOpts.UsageBudget.Summary())
+			return err
+		}
+	}
+}
+
+// handleCommand runs a slash command and returns true when the chat should end
+func (s *chatSession) handleCommand(input string) bool {
+	switch strings.Fields(input)[0] {
+	case "/exit", "/quit":
+		return true
+	case "/new":
+		s.opts.SessionID = uuid.New().String()
+		fmt.Fprintf(os.Stderr, "Started new session: %s\n", s.opts.SessionID)
+	case "/status":
+		s.printTotals()
+	case "/help":
+		fmt.Fprintln(os.Stderr, "/new      Start a new session")
+		fmt.Fprintln(os.Stderr, "/status   Show the session totals")
+		fmt.Fprintln(os.Stderr, "/editor   Write the next input in $EDITOR")
+		fmt.Fprintln(os.Stderr, "/help     Show this help")
+		fmt.Fprintln(os.Stderr, "/exit     Leave the chat")
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %s (type /help for commands)\n", input)
+	}
+	return false
+}
+
+// runTurn sends one input to the agent, streams the answer, and prints the status line
+func (s *chatSession) runTurn(ctx context.Context, input string) error {
+	turnOpts := s.opts
+	turnOpts.InputText = input
+
+	// Only while the turn runs does Ctrl-C cancel it instead of ending the process
+	ctx, interrupt := context.WithCancel(ctx)
+	defer interrupt()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	defer signal.Stop(signals)
+	go func() {
+		select {
+		case <-signals:
+			interrupt()
+		case <-ctx.Done():
+		}
+	}()
+
+	// The connect and idle timeouts are applied while invoking and streaming
+	ctx, cancel := withMaxDuration(ctx, turnOpts.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	output, err := invokeAgent(ctx, NewAWSHelper(turnOpts))
+	if err != nil {
+		if isInterruption(ctx, err) {
+			return ErrInterrupted
+		}
+		return timeoutCause(ctx, err)
+	}
+
+	stream := output.GetStream()
+	if stream == nil {
+		return fmt.Errorf("no response stream available")
+	}
+	defer stream.Close()
+
+	processor := NewStreamProcessor(turnOpts, os.Stdout, true)
+	processor.Context = ctx
+	result, err := processor.ProcessStream(stream)
+	turnOpts.UsageBudget.Record(result.Usage) // Tokens of a broken stream were still used
+	fmt.Fprintln(os.Stdout)
+	if err != nil && !result.Interrupted {
+		return timeoutCause(ctx, err)
+	}
+
+	s.recordTurn(time.Since(start), result.Usage)
+	recordSessionTurn(turnOpts, output, result)
+	s.logTurn(input, result.Text)
+	return err
+}
+
+// logTurn writes the input and answer of a turn to the --output-file. The file is opened
+// for every turn, so it can be rotated between turns once it reaches the maximum size.
+func (s *chatSession) logTurn(input, answer string) {
+	if s.opts.OutputFile == "" {
+		return
+	}
+	w, closer, err := PrepareOutput(s.opts.OutputFile, s.logOpts)
+	if err != nil {
+		LogWarn("Could not write the chat log: %v", err)
+		return
+	}
+	defer closer()
+
+	fmt.Fprintf(w, "> %s\n\n%s\n\n", input, strings.TrimSpace(answer))
+	s.logOpts.Append = true // Later turns add to the file
+}
+
+// recordTurn updates the session totals and prints the per-turn status line
+func (s *chatSession) recordTurn(latency time.Duration, usage TokenUsage) {
+	s.turns++
+	s.totalUsage.Add(usage)
+
+	cost := s.pricing.EstimateCost(usage)
+	s.totalCost += cost
+
+	if s.showStatus {
+		parts := []string{formatDuration(latency)}
+		if usage.IsZero() {
+			parts = append(parts, "tokens n/a")
+		} else {
+			parts = append(parts, fmt.Sprintf("%s in / %s out tokens", formatCount(usage.InputTokens), formatCount(usage.OutputTokens)))
+		}
+		if s.pricing.IsSet() {
+			parts = append(parts, formatCost(cost), "session "+formatCost(s.totalCost))
+		}
+		fmt.Fprintf(os.Stderr, "[%s]\n", strings.Join(parts, " | "))
+	}
+
+	if s.budget > 0 && s.pricing.IsSet() && s.totalCost > s.budget && !s.budgetWarned {
+		s.budgetWarned = true
+		fmt.Fprintf(os.Stderr, "Warning: estimated session cost %s exceeds the budget of %s\n",
+			formatCost(s.totalCost), formatCost(s.budget))
+	}
+}
+
+// printTotals prints the cumulative usage of the session
+func (s *chatSession) printTotals() {
+	if s.turns == 0 {
+		return
+	}
+	summary := fmt.Sprintf("Session totals: %d turn(s), %s in / %s out tokens",
+		s.turns, formatCount(s.totalUsage.InputTokens), formatCount(s.totalUsage.OutputTokens))
+	if s.pricing.IsSet() {
+		summary += ", estimated cost " + formatCost(s.totalCost)
+	}
+	fmt.Fprintln(os.Stderr, summary)
+}
+
+// configFloat returns the flag value when set, otherwise the value from the configuration
+func configFloat(v *viper.Viper, key string, flagValue float64) float64 {
+	if flagValue > 0 {
+		return flagValue
+	}
+	return v.GetFloat64(key)
+}
@@ -0,0 +1,168 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the input of the 'chat' command for the AWS Bedrock Intelligent
+Agents CLI. Besides single lines it reads multi-line inputs: lines ending with a
+backslash continue on the next line, and text between lines of triple quotes (""") is
+sent as one input. In a terminal, lines that arrive at once are taken as pasted text
+and sent together, so a pasted code block is not submitted line by line.
+*/
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// chatQuotes opens and closes a multi-line input
+const chatQuotes = `"""`
+
+// chatPasteDelay is the longest gap between lines that are taken as one paste; typing a
+// whole line and pressing enter takes much longer
+const chatPasteDelay = 50 * time.Millisecond
+
+// chatLine is the result of reading one line
+type chatLine struct {
+	text string
+	err  error
+}
+
+// chatInput reads the inputs of a chat from the shared stdin reader
+type chatInput struct {
+	reader      *bufio.Reader
+	detectPaste bool
+	lines       chan chatLine
+	pending     bool // A read is in flight and its line goes to the next caller
+}
+
+// newChatInput returns the input of a chat; pasted text is only detected in a terminal
+func newChatInput(reader *bufio.Reader) *chatInput {
+	return &chatInput{
+		reader:      reader,
+		detectPaste: isTerminal(os.Stdin),
+		lines:       make(chan chatLine, 1),
+	}
+}
+
+// startRead reads the next line in the background unless a read is already in flight
+func (c *chatInput) startRead() {
+	if c.pending {
+		return
+	}
+	c.pending = true
+	go func() {
+		text, err := c.reader.ReadString('\n')
+		c.lines <- chatLine{text: text, err: err}
+	}()
+}
+
+// readLine returns the next line, waiting as long as it takes
+func (c *chatInput) readLine() (string, error) {
+	c.startRead()
+	line := <-c.lines
+	c.pending = false
+	return line.text, line.err
+}
+
+// readLineWithin returns the next line if it arrives within d; otherwise the read stays
+// in flight for the next input and ok is false
+func (c *chatInput) readLineWithin(d time.Duration) (text string, ok bool, err error) {
+	c.startRead()
+	select {
+	case line := <-c.lines:
+		c.pending = false
+		return line.text, true, line.err
+	case <-time.After(d):
+		return "", false, nil
+	}
+}
+
+// Read prompts for the next input and returns it without surrounding space; io.EOF
+// is only returned once the input has ended and nothing was read
+func (c *chatInput) Read() (string, error) {
+	fmt.Fprint(os.Stderr, "\n> ")
+	line, err := c.readLine()
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	if err == io.EOF && strings.TrimSpace(line) == "" {
+		return "", io.EOF
+	}
+
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case strings.HasPrefix(trimmed, chatQuotes):
+		return c.readQuoted(strings.TrimPrefix(trimmed, chatQuotes), err)
+	case strings.HasSuffix(trimmed, `\`):
+		return c.readContinued(trimmed, err)
+	case c.detectPaste && err == nil:
+		return c.readPasted(line)
+	}
+	return trimmed, nil
+}
+
+// readQuoted reads the lines up to the closing triple quotes
+func (c *chatInput) readQuoted(first string, err error) (string, error) {
+	var lines []string
+	line := first
+	for {
+		if i := strings.Index(line, chatQuotes); i >= 0 {
+			lines = append(lines, line[:i])
+			return strings.TrimSpace(strings.Join(lines, "\n")), nil
+		}
+		lines = append(lines, line)
+		if err != nil {
+			// The input ended before the closing quotes, what was written is still sent
+			return strings.TrimSpace(strings.Join(lines, "\n")), nil
+		}
+
+		fmt.Fprint(os.Stderr, "... ")
+		line, err = c.readLine()
+		if err != nil && err != io.EOF {
+			return "", fmt.Errorf("failed to read input: %w", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+	}
+}
+
+// readContinued reads lines as long as they end with a backslash
+func (c *chatInput) readContinued(first string, err error) (string, error) {
+	var lines []string
+	line := first
+	for strings.HasSuffix(line, `\`) && err == nil {
+		lines = append(lines, strings.TrimSuffix(line, `\`))
+
+		fmt.Fprint(os.Stderr, "... ")
+		line, err = c.readLine()
+		if err != nil && err != io.EOF {
+			return "", fmt.Errorf("failed to read input: %w", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+	}
+	lines = append(lines, strings.TrimSuffix(line, `\`))
+	return strings.TrimSpace(strings.Join(lines, "\n")), nil
+}
+
+// readPasted adds the lines that follow the first one without delay
+func (c *chatInput) readPasted(first string) (string, error) {
+	var input strings.Builder
+	input.WriteString(first)
+	for {
+		line, ok, err := c.readLineWithin(chatPasteDelay)
+		if !ok {
+			break
+		}
+		input.WriteString(line)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read input: %w", err)
+		}
+	}
+	return strings.TrimSpace(input.String()), nil
+}
@@ -0,0 +1,145 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements checkpoints for the 'run' command of the AWS Bedrock Intelligent Agents CLI.
+With --checkpoint the status of every turn of a conversation script is written to a JSON file
+as the run progresses, so a run that was interrupted or stopped at a failing turn can be picked
+up with --resume: the completed turns are skipped and the run continues in the same session.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Status of a turn in a checkpoint
+const (
+	TurnPending   = "pending"
+	TurnCompleted = "completed"
+	TurnFailed    = "failed"
+)
+
+// checkpointVersion is the version of the checkpoint document written by this build
+const checkpointVersion = 1
+
+// runCheckpoint is the progress of a conversation script, written after every turn
+type runCheckpoint struct {
+	Version      int              `json:"version"`
+	Script       string           `json:"script"`
+	AgentID      string           `json:"agentId"`
+	AgentAliasID string           `json:"agentAliasId"`
+	SessionID    string           `json:"sessionId"`
+	Turns        []checkpointTurn `json:"turns"`
+	UpdatedAt    string           `json:"updatedAt"`
+
+	path string
+}
+
+// checkpointTurn is the status of one turn of the script
+type checkpointTurn struct {
+	Turn        int             `json:"turn"`
+	Name        string          `json:"name,omitempty"`
+	Input       string          `json:"input"`
+	Status      string          `json:"status"`
+	Error       string          `json:"error,omitempty"`
+	Response    json.RawMessage `json:"response,omitempty"` // Kept with --format json for the final document
+	CompletedAt string          `json:"completedAt,omitempty"`
+}
+
+// newRunCheckpoint creates a checkpoint with every turn of the script pending
+func newRunCheckpoint(path, scriptFile string, script *ConversationScript) *runCheckpoint {
+	checkpoint := &runCheckpoint{Version: checkpointVersion, Script: scriptFile, path: path}
+	for i, turn := range script.Turns {
+		checkpoint.Turns = append(checkpoint.Turns, checkpointTurn{
+			Turn:   i + 1,
+			Name:   turn.Name,
+			Input:  turn.Input,
+			Status: TurnPending,
+		})
+	}
+	return checkpoint
+}
+
+// loadRunCheckpoint reads a checkpoint and checks that it was written for the same script
+func loadRunCheckpoint(path string, script *ConversationScript) (*runCheckpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	var checkpoint runCheckpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint '%s': %w", path, err)
+	}
+	if checkpoint.Version != checkpointVersion {
+		return nil, fmt.Errorf("checkpoint '%s' has unsupported version %d", path, checkpoint.Version)
+	}
+	if checkpoint.SessionID == "" {
+		return nil, fmt.Errorf("checkpoint '%s' has no session ID", path)
+	}
+
+	// Resuming a changed script would skip turns that were never sent
+	if len(checkpoint.Turns) != len(script.Turns) {
+		return nil, fmt.Errorf("checkpoint '%s' has %d turn(s) but the script has %d", path, len(checkpoint.Turns), len(script.Turns))
+	}
+	for i, turn := range script.Turns {
+		if checkpoint.Turns[i].Input != turn.Input {
+			return nil, fmt.Errorf("turn %d of the script changed since checkpoint '%s' was written", i+1, path)
+		}
+	}
+
+	checkpoint.path = path
+	return &checkpoint, nil
+}
+
+// Resume returns the index of the first turn that has not completed, or len(Turns) when all did.
+// The turns of a script share a session, so everything after a failed turn is sent again too.
+func (c *runCheckpoint) Resume() int {
+	for i, turn := range c.Turns {
+		if turn.Status != TurnCompleted {
+			return i
+		}
+	}
+	return len(c.Turns)
+}
+
+// Completed records the answer of a turn; the response is only kept for JSON documents
+func (c *runCheckpoint) Completed(i int, response json.RawMessage) error {
+	if c == nil {
+		return nil
+	}
+	c.Turns[i].Status = TurnCompleted
+	c.Turns[i].Error = ""
+	c.Turns[i].Response = response
+	c.Turns[i].CompletedAt = time.Now().UTC().Format(time.RFC3339)
+	return c.Save()
+}
+
+// Failed records the error of a turn
+func (c *runCheckpoint) Failed(i int, err error) error {
+	if c == nil {
+		return nil
+	}
+	c.Turns[i].Status = TurnFailed
+	c.Turns[i].Error = err.Error()
+	return c.Save()
+}
+
+// Save writes the checkpoint; the file is replaced atomically so an interrupted write cannot corrupt it
+func (c *runCheckpoint) Save() error {
+	if c == nil {
+		return nil
+	}
+	c.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+	if err := writeFileAtomic(c.path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write checkpoint '%s': %w", c.path, err)
+	}
+	return nil
+}
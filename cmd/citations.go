@@ -0,0 +1,89 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the citation styles of text output selected with --citations.
+Citations can follow the answer as a numbered block, be marked inline at the end of
+the cited text with the same numbers, be printed as a JSON array, or be left out.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/types"
+)
+
+// Citation styles accepted by --citations
+const (
+	CitationsFootnotes = "footnotes" // Numbered block after the answer
+	CitationsInline    = "inline"    // [n] markers in the answer and the numbered block
+	CitationsJSON      = "json"      // JSON array after the answer
+	CitationsNone      = "none"      // No citations
+)
+
+// validateCitationStyle checks the value of --citations
+func validateCitationStyle(style string) error {
+	switch style {
+	case "", CitationsFootnotes, CitationsInline, CitationsJSON, CitationsNone:
+		return nil
+	default:
+		return fmt.Errorf("citations must be one of: %s, %s, %s, %s, got '%s'",
+			CitationsFootnotes, CitationsInline, CitationsJSON, CitationsNone, style)
+	}
+}
+
+// citationMarker is a [n] marker placed at a character offset of the answer
+type citationMarker struct {
+	offset int
+	number int
+}
+
+// insertCitationMarkers adds the markers of the citations received with a chunk to its text.
+// written is the number of characters of the answer written before the chunk and first the
+// number of the first citation. Markers whose offset lies in text that was already written
+// are placed at the start of the chunk.
+func insertCitationMarkers(chunk string, written, first int, citations []types.Citation) string {
+	runes := []rune(chunk)
+
+	var markers []citationMarker
+	for i, citation := range citations {
+		_, end, ok := citationSpan(citation)
+		if !ok {
+			end = written + len(runes) // Without a span the citation covers the chunk
+		}
+		offset := min(max(end-written, 0), len(runes))
+		markers = append(markers, citationMarker{offset: offset, number: first + i})
+	}
+	if len(markers) == 0 {
+		return chunk
+	}
+	sort.SliceStable(markers, func(i, j int) bool { return markers[i].offset < markers[j].offset })
+
+	var b strings.Builder
+	last := 0
+	for _, marker := range markers {
+		b.WriteString(string(runes[last:marker.offset]))
+		fmt.Fprintf(&b, "[%d]", marker.number)
+		last = marker.offset
+	}
+	b.WriteString(string(runes[last:]))
+	return b.String()
+}
+
+// writeCitationsJSONOutput writes the citations of a text response as an indented JSON array
+func (rf *ResponseFormatter) writeCitationsJSONOutput(citations []types.Citation) {
+	formatted := rf.formatCitationsForJSON(citations)
+	if len(formatted) == 0 {
+		return
+	}
+
+	data, err := json.MarshalIndent(formatted, "", "  ")
+	if err != nil {
+		rf.Options.Warnings.Warn(WarningCitation, "failed to encode citations", err)
+		return
+	}
+	fmt.Fprintf(rf.Writer, "\n%s\n", data)
+}
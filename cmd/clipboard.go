@@ -0,0 +1,136 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements --copy and --paste-input for the AWS Bedrock Intelligent Agents CLI.
+The system clipboard is reached through the tools of the platform: pbcopy and pbpaste on
+macOS, PowerShell on Windows, and wl-copy/wl-paste, xclip, or xsel elsewhere, whichever is
+installed.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// clipboardTool is a pair of commands that write and read the clipboard
+type clipboardTool struct {
+	copy  []string
+	paste []string
+}
+
+// clipboardTools returns the clipboard tools of the platform in order of preference
+func clipboardTools() []clipboardTool {
+	switch runtime.GOOS {
+	case "darwin":
+		return []clipboardTool{{copy: []string{"pbcopy"}, paste: []string{"pbpaste"}}}
+	case "windows":
+		return []clipboardTool{{
+			copy:  []string{"powershell", "-NoProfile", "-Command", "[Console]::InputEncoding = [Text.Encoding]::UTF8; Set-Clipboard -Value ([Console]::In.ReadToEnd())"},
+			paste: []string{"powershell", "-NoProfile", "-Command", "[Console]::OutputEncoding = [Text.Encoding]::UTF8; Get-Clipboard -Raw"},
+		}}
+	}
+
+	var tools []clipboardTool
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		tools = append(tools, clipboardTool{copy: []string{"wl-copy"}, paste: []string{"wl-paste", "--no-newline"}})
+	}
+	return append(tools,
+		clipboardTool{copy: []string{"xclip", "-selection", "clipboard"}, paste: []string{"xclip", "-selection", "clipboard", "-o"}},
+		clipboardTool{copy: []string{"xsel", "--clipboard", "--input"}, paste: []string{"xsel", "--clipboard", "--output"}},
+	)
+}
+
+// findClipboardTool returns the first installed clipboard tool
+func findClipboardTool() (clipboardTool, error) {
+	var names []string
+	for _, tool := range clipboardTools() {
+		if _, err := exec.LookPath(tool.copy[0]); err == nil {
+			return tool, nil
+		}
+		names = append(names, tool.copy[0])
+	}
+	return clipboardTool{}, fmt.Errorf("no clipboard tool found, install one of: %s", strings.Join(names, ", "))
+}
+
+// runClipboardCommand runs a clipboard tool, including its error output in the error
+func runClipboardCommand(args []string, stdin string) (string, error) {
+	cmd := exec.Command(args[0], args[1:]...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("%s failed: %w: %s", args[0], err, message)
+		}
+		return "", fmt.Errorf("%s failed: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+// copyToClipboard places text on the system clipboard
+func copyToClipboard(text string) error {
+	tool, err := findClipboardTool()
+	if err != nil {
+		return err
+	}
+	_, err = runClipboardCommand(tool.copy, text)
+	return err
+}
+
+// pasteFromClipboard returns the text on the system clipboard
+func pasteFromClipboard() (string, error) {
+	tool, err := findClipboardTool()
+	if err != nil {
+		return "", err
+	}
+	text, err := runClipboardCommand(tool.paste, "")
+	if err != nil {
+		return "", err
+	}
+	// PowerShell ends its output with a line break the clipboard does not contain
+	if runtime.GOOS == "windows" {
+		text = strings.TrimSuffix(strings.TrimSuffix(text, "\n"), "\r")
+	}
+	return text, nil
+}
+
+// pasteInput sets the input text to the contents of the clipboard
+func pasteInput(opts *AgentOptions) error {
+	text, err := pasteFromClipboard()
+	if err != nil {
+		return fmt.Errorf("failed to read the clipboard: %w", err)
+	}
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("the clipboard contains no text to use as input")
+	}
+	opts.InputText = text
+	logVerbose(*opts, "Read %d characters of input from the clipboard", len([]rune(text)))
+	return nil
+}
+
+// copyAnswer places the answer on the clipboard; a failure does not fail the invocation
+func copyAnswer(opts AgentOptions, result StreamResult) {
+	// What was shown is copied, which is the translation with --translate-replace
+	if opts.TranslateReplace && result.Translation != "" {
+		result.Text = result.Translation
+	}
+	if strings.TrimSpace(result.Text) == "" {
+		opts.Warnings.Warn(WarningClipboard, "the answer is empty, nothing was copied to the clipboard", nil)
+		return
+	}
+	if err := copyToClipboard(strings.TrimSpace(result.Text)); err != nil {
+		opts.Warnings.Warn(WarningClipboard, "failed to copy the answer to the clipboard", err)
+		return
+	}
+	if !opts.Quiet {
+		fmt.Fprintln(os.Stderr, "Copied the answer to the clipboard")
+	}
+}
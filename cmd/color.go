@@ -0,0 +1,205 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements colored terminal output for the AWS Bedrock Intelligent Agents CLI.
+Agent answers are styled as markdown with lightweight syntax highlighting for fenced
+code blocks, and citations, file notices, and return-control banners get distinct
+colors. Color is only used when writing to a terminal unless --color always is given.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Color mode options
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+// ANSI escape sequences used for styling
+const (
+	ansiReset   = "\033[0m"
+	ansiBold    = "\033[1m"
+	ansiDim     = "\033[2m"
+	ansiRed     = "\033[31m"
+	ansiGreen   = "\033[32m"
+	ansiYellow  = "\033[33m"
+	ansiBlue    = "\033[34m"
+	ansiMagenta = "\033[35m"
+	ansiCyan    = "\033[36m"
+)
+
+var (
+	inlineCodePattern = regexp.MustCompile("`[^`]+`")
+	boldPattern       = regexp.MustCompile(`\*\*[^*]+\*\*`)
+	codeTokenPattern  = regexp.MustCompile(`"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'|\b[0-9][0-9.]*\b|\b[A-Za-z_][A-Za-z0-9_]*\b`)
+)
+
+// codeKeywords are highlighted in fenced code blocks regardless of the language
+var codeKeywords = map[string]bool{
+	"func": true, "return": true, "if": true, "else": true, "for": true, "while": true,
+	"switch": true, "case": true, "break": true, "continue": true, "def": true, "class": true,
+	"import": true, "from": true, "package": true, "const": true, "let": true, "var": true,
+	"type": true, "struct": true, "interface": true, "try": true, "catch": true, "except": true,
+	"finally": true, "raise": true, "throw": true, "new": true, "async": true, "await": true,
+	"resource": true, "module": true, "variable": true, "output": true, "select": true,
+	"true": true, "false": true, "nil": true, "null": true, "None": true, "True": true, "False": true,
+}
+
+// validateColorMode validates the --color flag value
+func validateColorMode(mode string) error {
+	switch mode {
+	case "", ColorAuto, ColorAlways, ColorNever:
+		return nil
+	default:
+		return fmt.Errorf("color must be one of: %s, %s, %s, got '%s'", ColorAuto, ColorAlways, ColorNever, mode)
+	}
+}
+
+// useColor decides whether output written to w should be colored
+func useColor(mode string, w io.Writer) bool {
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+
+	// Auto: only color terminals, and honor NO_COLOR and dumb terminals
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return isTerminal(w)
+}
+
+// isTerminal reports whether w is a character device such as a TTY
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	stat, err := f.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
+// colorizer applies ANSI styles when enabled and returns text unchanged otherwise
+type colorizer struct {
+	enabled bool
+}
+
+// style wraps s in the given ANSI code
+func (c colorizer) style(code, s string) string {
+	if !c.enabled || s == "" {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+// Citation styles citation text
+func (c colorizer) Citation(s string) string { return c.style(ansiBlue, s) }
+
+// Notice styles file notices
+func (c colorizer) Notice(s string) string { return c.style(ansiGreen, s) }
+
+// Banner styles return-control banners
+func (c colorizer) Banner(s string) string { return c.style(ansiBold+ansiYellow, s) }
+
+// Error styles error messages
+func (c colorizer) Error(s string) string { return c.style(ansiRed, s) }
+
+// markdownWriter styles agent text as markdown, one complete line at a time
+type markdownWriter struct {
+	out    io.Writer
+	color  colorizer
+	buf    bytes.Buffer
+	inCode bool // Inside a fenced code block
+}
+
+// newMarkdownWriter creates a writer that styles markdown lines written to out
+func newMarkdownWriter(out io.Writer, color colorizer) *markdownWriter {
+	return &markdownWriter{out: out, color: color}
+}
+
+// Write buffers p and writes every complete line with styling applied
+func (m *markdownWriter) Write(p []byte) (int, error) {
+	m.buf.Write(p)
+	for {
+		line, err := m.buf.ReadString('\n')
+		if err != nil {
+			// Keep the incomplete line for the next write
+			m.buf.Reset()
+			m.buf.WriteString(line)
+			return len(p), nil
+		}
+		if _, err := io.WriteString(m.out, m.styleLine(strings.TrimSuffix(line, "\n"))+"\n"); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Flush writes any buffered incomplete line
+func (m *markdownWriter) Flush() error {
+	if m.buf.Len() == 0 {
+		return nil
+	}
+	line := m.buf.String()
+	m.buf.Reset()
+	_, err := io.WriteString(m.out, m.styleLine(line))
+	return err
+}
+
+// styleLine applies markdown or code styling to a single line
+func (m *markdownWriter) styleLine(line string) string {
+	trimmed := strings.TrimSpace(line)
+
+	if strings.HasPrefix(trimmed, "```") {
+		m.inCode = !m.inCode
+		return m.color.style(ansiDim, line)
+	}
+	if m.inCode {
+		return m.highlightCode(line)
+	}
+
+	switch {
+	case strings.HasPrefix(trimmed, "#"):
+		return m.color.style(ansiBold+ansiMagenta, line)
+	case strings.HasPrefix(trimmed, ">"):
+		return m.color.style(ansiDim, line)
+	}
+
+	line = boldPattern.ReplaceAllStringFunc(line, func(s string) string {
+		return m.color.style(ansiBold, s)
+	})
+	return inlineCodePattern.ReplaceAllStringFunc(line, func(s string) string {
+		return m.color.style(ansiCyan, s)
+	})
+}
+
+// highlightCode colors comments, strings, numbers, and keywords in a code line
+func (m *markdownWriter) highlightCode(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "--") {
+		return m.color.style(ansiDim, line)
+	}
+
+	return codeTokenPattern.ReplaceAllStringFunc(line, func(token string) string {
+		switch {
+		case token[0] == '"' || token[0] == '\'':
+			return m.color.style(ansiGreen, token)
+		case token[0] >= '0' && token[0] <= '9':
+			return m.color.style(ansiMagenta, token)
+		case codeKeywords[token]:
+			return m.color.style(ansiCyan, token)
+		default:
+			return token
+		}
+	})
+}
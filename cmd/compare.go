@@ -0,0 +1,623 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the 'agent compare' command for AWS Bedrock Intelligent Agents CLI.
+It sends every input of a JSONL file to two aliases of an agent, typically the released
+version and a candidate, and reports the answers side by side with their differences,
+latencies, and token usage, as text, a self-contained HTML page, or JSON, to support
+the decision whether to promote the candidate.
+*/
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"os/signal"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Output formats of the comparison report besides text and json
+const OutputFormatHTML = "html"
+
+// aliasIDPattern matches alias IDs; other alias values are looked up as alias names
+var aliasIDPattern = regexp.MustCompile(`^[0-9A-Z]{10}$`)
+
+// CompareOptions contains all options for comparing two aliases of an agent
+type CompareOptions struct {
+	ConfigFile   string
+	AgentID      string
+	AliasA       string
+	AliasB       string
+	InputsFile   string
+	Region       string
+	EndpointURL  string
+	Timeout      time.Duration
+	TimeoutSet   bool
+	OutputFormat string
+	OutputFile   string
+	Verbose      bool
+}
+
+// compareInput is one line of the inputs file
+type compareInput struct {
+	ID    string `json:"id"`
+	Input string `json:"input"`
+}
+
+// compareCase is the answers of both aliases to one input
+type compareCase struct {
+	compareInput
+	Results    []multiResult // The answers of alias A and alias B
+	Similarity float64
+	Diff       string
+}
+
+// compareSide sums up the answers of one alias
+type compareSide struct {
+	Target        multiTarget
+	Failed        int
+	MedianLatency time.Duration
+	MeanLatency   time.Duration
+	Usage         TokenUsage
+}
+
+// compareReport is the outcome of a comparison
+type compareReport struct {
+	AgentID        string
+	Cases          []compareCase
+	Sides          []compareSide
+	Identical      int
+	MeanSimilarity float64
+	GeneratedAt    time.Time
+}
+
+var compareOpts CompareOptions
+
+// agentCompareCmd represents the agent compare command
+var agentCompareCmd = &cobra.Command{
+	Use:   "compare",
+	Short: "Compare the answers of two aliases of an agent to the same inputs",
+	Long: `Send every input of a JSONL file to two aliases of an agent and compare the answers.
+
+Each line of the inputs file is a JSON object with the input and an optional id:
+
+  {"id": "refund", "input": "How do I get a refund?"}
+  {"input": "What are your opening hours?"}
+
+Every input is sent to both aliases at the same time, each in a new session. The
+report shows the answers with a diff, the similarity of their words, the latency,
+and the token usage of both aliases and their difference, followed by a summary:
+how many answers are identical, the median and mean latency, the total tokens,
+and the failed invocations. --format html writes a self-contained page with the
+answers side by side, --format json a document for further processing.
+
+Aliases are given by ID or by name. The command fails when any invocation failed,
+after writing the report.
+
+Examples:
+  # Compare the staging alias with prod
+  aws-bia agent compare --agent-id abc123 --alias-a prod --alias-b staging --inputs eval.jsonl
+
+  # Write an HTML report for the release review
+  aws-bia agent compare --agent-id abc123 --alias-a prod --alias-b staging --inputs eval.jsonl \
+    --format html --output-file compare.html
+
+  # Fail a pipeline when fewer than 90% of the answers are identical
+  aws-bia agent compare --agent-id abc123 --alias-a prod --alias-b staging --inputs eval.jsonl \
+    --format json | jq -e '.summary.identical / .summary.cases >= 0.9'
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		compareOpts.TimeoutSet = cmd.Flags().Changed("timeout")
+		if err := runCompareCommand(ctx, compareOpts); err != nil {
+			logError("Error comparing aliases", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	agentCmd.AddCommand(agentCompareCmd)
+
+	agentCompareCmd.Flags().StringVar(&compareOpts.ConfigFile, "config", "", "Path to configuration file (yaml)")
+	agentCompareCmd.Flags().StringVar(&compareOpts.AgentID, "agent-id", "", "The ID of the agent (can be set in config file)")
+	agentCompareCmd.Flags().StringVar(&compareOpts.AliasA, "alias-a", "", "ID or name of the first alias, usually the released one")
+	agentCompareCmd.Flags().StringVar(&compareOpts.AliasB, "alias-b", "", "ID or name of the second alias, usually the candidate")
+	agentCompareCmd.Flags().StringVar(&compareOpts.InputsFile, "inputs", "", "JSONL file with one {\"input\": ...} object per line")
+	agentCompareCmd.Flags().StringVar(&compareOpts.Region, "region", "", "AWS region to use (defaults to AWS_REGION environment variable)")
+	agentCompareCmd.Flags().StringVar(&compareOpts.EndpointURL, "endpoint-url", "", "Send agent runtime requests to this URL instead of the regional endpoint (e.g. a VPC endpoint or local mock)")
+	agentCompareCmd.Flags().DurationVar(&compareOpts.Timeout, "timeout", DefaultTimeout, "Timeout for each invocation")
+	agentCompareCmd.Flags().StringVar(&compareOpts.OutputFormat, "format", OutputFormatText, "Output format: text, html, or json")
+	agentCompareCmd.Flags().StringVar(&compareOpts.OutputFile, "output-file", "", "Write the report to this file instead of stdout")
+	agentCompareCmd.Flags().BoolVar(&compareOpts.Verbose, "verbose", false, "Enable verbose output")
+	_ = agentCompareCmd.MarkFlagRequired("alias-a")
+	_ = agentCompareCmd.MarkFlagRequired("alias-b")
+	_ = agentCompareCmd.MarkFlagRequired("inputs")
+
+	registerAgentCompletions(agentCompareCmd)
+}
+
+// runCompareCommand sends the inputs to both aliases and writes the report
+func runCompareCommand(ctx context.Context, opts CompareOptions) error {
+	InitLogger(opts.Verbose)
+	defer SyncLogger()
+
+	v, err := LoadConfigForCommand(opts.ConfigFile, "agent", opts.Verbose)
+	if err != nil {
+		return err
+	}
+
+	baseOpts := AgentOptions{
+		AgentID:        opts.AgentID,
+		Region:         opts.Region,
+		EndpointURL:    opts.EndpointURL,
+		Timeout:        opts.Timeout,
+		MaxDurationSet: opts.TimeoutSet,
+		OutputFormat:   OutputFormatJSON, // Collect the answers without writing them
+		EnableTrace:    true,             // Trace events carry the token usage
+		Verbose:        opts.Verbose,
+		Clients:        NewRuntimeClients(nil),
+	}
+	applyAgentConfig(v, &baseOpts)
+	applyTimeoutSetting(v, &baseOpts)
+
+	if baseOpts.AgentID == "" {
+		return fmt.Errorf("agent ID is required")
+	}
+	if baseOpts.Timeout <= 0 {
+		return fmt.Errorf("timeout must be a positive duration")
+	}
+	switch opts.OutputFormat {
+	case OutputFormatText, OutputFormatHTML, OutputFormatJSON:
+	default:
+		return fmt.Errorf("output format must be one of: %s, %s, %s, got '%s'",
+			OutputFormatText, OutputFormatHTML, OutputFormatJSON, opts.OutputFormat)
+	}
+
+	inputs, err := loadCompareInputs(opts.InputsFile)
+	if err != nil {
+		return err
+	}
+	targets := make([]multiTarget, 0, 2)
+	for _, alias := range []string{opts.AliasA, opts.AliasB} {
+		target, err := resolveCompareTarget(ctx, baseOpts, alias)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, target)
+	}
+
+	// Each input is sent to both aliases together, so both see the same load
+	budget := NewRetryBudget(DefaultRetryBudget, DefaultRetryBudgetTime)
+	report := &compareReport{AgentID: baseOpts.AgentID, GeneratedAt: time.Now()}
+	for i, input := range inputs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s\n", i+1, len(inputs), excerpt(input.Input, 60))
+		caseOpts := baseOpts
+		caseOpts.InputText = input.Input
+		report.Cases = append(report.Cases, newCompareCase(input, invokeTargets(ctx, caseOpts, targets, budget)))
+	}
+	report.summarize(targets)
+
+	writer, closer, err := PrepareOutput(opts.OutputFile, OutputFileOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to prepare output: %w", err)
+	}
+	if closer != nil {
+		defer closer()
+	}
+	switch opts.OutputFormat {
+	case OutputFormatJSON:
+		err = writeCompareJSON(writer, report)
+	case OutputFormatHTML:
+		err = writeCompareHTML(writer, report)
+	default:
+		err = writeCompareText(writer, report, colorizer{enabled: useColor(v.GetString("color"), writer)})
+	}
+	if err != nil {
+		return err
+	}
+	if opts.OutputFile != "" {
+		fmt.Fprintf(os.Stderr, "Wrote comparison report to %s\n", opts.OutputFile)
+	}
+
+	if failed := report.Sides[0].Failed + report.Sides[1].Failed; failed > 0 {
+		return fmt.Errorf("%d of %d invocations failed", failed, 2*len(report.Cases))
+	}
+	return nil
+}
+
+// loadCompareInputs reads the inputs file; blank lines are skipped and a missing id is the line number
+func loadCompareInputs(path string) ([]compareInput, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open inputs file: %w", err)
+	}
+	defer file.Close()
+
+	var inputs []compareInput
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var input compareInput
+		if err := json.Unmarshal([]byte(text), &input); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid JSON: %w", path, line, err)
+		}
+		if strings.TrimSpace(input.Input) == "" {
+			return nil, fmt.Errorf("%s:%d: \"input\" is missing or empty", path, line)
+		}
+		if input.ID == "" {
+			input.ID = fmt.Sprintf("line %d", line)
+		}
+		inputs = append(inputs, input)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read inputs file: %w", err)
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("inputs file %s contains no inputs", path)
+	}
+	return inputs, nil
+}
+
+// resolveCompareTarget returns the target of an alias given by ID or by name
+func resolveCompareTarget(ctx context.Context, opts AgentOptions, alias string) (multiTarget, error) {
+	target := multiTarget{Name: alias, AgentID: opts.AgentID, AgentAliasID: alias}
+	if aliasIDPattern.MatchString(alias) {
+		return target, nil
+	}
+	opts.AliasName = alias
+	if err := resolveAgentNames(ctx, &opts); err != nil {
+		return target, err
+	}
+	target.AgentAliasID = opts.AgentAliasID
+	return target, nil
+}
+
+// newCompareCase compares the answers of both aliases to an input
+func newCompareCase(input compareInput, results []multiResult) compareCase {
+	c := compareCase{compareInput: input, Results: results}
+	a, b := results[0], results[1]
+	if a.Err == nil && b.Err == nil {
+		c.Similarity = ResponseSimilarity(a.Text, b.Text)
+		if c.Identical() {
+			c.Similarity = 1
+		} else {
+			c.Diff = unifiedDiff(a.Target.Name, b.Target.Name, strings.TrimSpace(a.Text), strings.TrimSpace(b.Text))
+		}
+	}
+	return c
+}
+
+// Identical reports whether both aliases gave the same answer, apart from surrounding space
+func (c compareCase) Identical() bool {
+	a, b := c.Results[0], c.Results[1]
+	return a.Err == nil && b.Err == nil && strings.TrimSpace(a.Text) == strings.TrimSpace(b.Text)
+}
+
+// summarize computes the totals of both aliases and the similarity of their answers
+func (r *compareReport) summarize(targets []multiTarget) {
+	r.Sides = make([]compareSide, len(targets))
+	compared := 0
+	for side, target := range targets {
+		r.Sides[side].Target = target
+		var latencies []time.Duration
+		var total time.Duration
+		for _, c := range r.Cases {
+			result := c.Results[side]
+			if result.Err != nil {
+				r.Sides[side].Failed++
+				continue
+			}
+			latencies = append(latencies, result.Latency)
+			total += result.Latency
+			r.Sides[side].Usage.Add(result.Usage)
+		}
+		if len(latencies) > 0 {
+			sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+			r.Sides[side].MedianLatency = latencies[len(latencies)/2]
+			r.Sides[side].MeanLatency = total / time.Duration(len(latencies))
+		}
+	}
+
+	var similarity float64
+	for _, c := range r.Cases {
+		if c.Results[0].Err != nil || c.Results[1].Err != nil {
+			continue
+		}
+		compared++
+		similarity += c.Similarity
+		if c.Identical() {
+			r.Identical++
+		}
+	}
+	if compared > 0 {
+		r.MeanSimilarity = similarity / float64(compared)
+	}
+}
+
+// signedDuration formats the difference of two latencies with its sign
+func signedDuration(d time.Duration) string {
+	d = d.Round(time.Millisecond)
+	if d < 0 {
+		return "-" + formatDuration(-d)
+	}
+	return "+" + formatDuration(d)
+}
+
+// signedCount formats the difference of two token counts with its sign
+func signedCount(n int64) string {
+	if n < 0 {
+		return "-" + formatCount(-n)
+	}
+	return "+" + formatCount(n)
+}
+
+// compareResultLine describes the latency and token usage of one answer
+func compareResultLine(result multiResult) string {
+	if result.Err != nil {
+		return "failed: " + result.Err.Error()
+	}
+	return multiStatusLine(result)
+}
+
+// writeCompareText writes the report as text
+func writeCompareText(w io.Writer, r *compareReport, color colorizer) error {
+	a, b := r.Sides[0].Target, r.Sides[1].Target
+	width := max(len(a.Name), len(b.Name))
+	fmt.Fprintf(w, "Comparing %s (%s) and %s (%s) of agent %s on %d input(s)\n",
+		a.Name, a.AgentAliasID, b.Name, b.AgentAliasID, r.AgentID, len(r.Cases))
+
+	for i, c := range r.Cases {
+		fmt.Fprintf(w, "\n%s %s\n", color.style(ansiBold, fmt.Sprintf("[%d] %s:", i+1, c.ID)), excerpt(c.Input, 100))
+		resultA, resultB := c.Results[0], c.Results[1]
+		fmt.Fprintf(w, "  %-*s  %s\n", width, a.Name, compareResultLine(resultA))
+		line := compareResultLine(resultB)
+		if resultA.Err == nil && resultB.Err == nil {
+			line += fmt.Sprintf(" (%s", signedDuration(resultB.Latency-resultA.Latency))
+			if !resultA.Usage.IsZero() || !resultB.Usage.IsZero() {
+				line += fmt.Sprintf(", %s tokens", signedCount(resultB.Usage.InputTokens+resultB.Usage.OutputTokens-resultA.Usage.InputTokens-resultA.Usage.OutputTokens))
+			}
+			line += ")"
+		}
+		fmt.Fprintf(w, "  %-*s  %s\n", width, b.Name, line)
+
+		switch {
+		case resultA.Err != nil || resultB.Err != nil:
+		case c.Identical():
+			fmt.Fprintf(w, "  %s\n", color.Notice("identical answers"))
+		default:
+			fmt.Fprintf(w, "  similarity %.0f%%\n", c.Similarity*100)
+			for _, diffLine := range strings.Split(strings.TrimRight(c.Diff, "\n"), "\n") {
+				switch {
+				case strings.HasPrefix(diffLine, "+++"), strings.HasPrefix(diffLine, "---"):
+					diffLine = color.style(ansiBold, diffLine)
+				case strings.HasPrefix(diffLine, "+"):
+					diffLine = color.style(ansiGreen, diffLine)
+				case strings.HasPrefix(diffLine, "-"):
+					diffLine = color.Error(diffLine)
+				case strings.HasPrefix(diffLine, "@@"):
+					diffLine = color.style(ansiCyan, diffLine)
+				}
+				fmt.Fprintf(w, "    %s\n", diffLine)
+			}
+		}
+	}
+
+	sideA, sideB := r.Sides[0], r.Sides[1]
+	columnWidth := max(width, 10)
+	row := func(label, valueA, valueB, delta string) {
+		fmt.Fprintln(w, strings.TrimRight(fmt.Sprintf("  %-16s %-*s %-*s %s", label, columnWidth, valueA, columnWidth, valueB, delta), " "))
+	}
+	fmt.Fprintf(w, "\n%s\n", color.style(ansiBold, "Summary:"))
+	row("", a.Name, b.Name, "delta")
+	row("Median latency", formatDuration(sideA.MedianLatency), formatDuration(sideB.MedianLatency), signedDuration(sideB.MedianLatency-sideA.MedianLatency))
+	row("Mean latency", formatDuration(sideA.MeanLatency), formatDuration(sideB.MeanLatency), signedDuration(sideB.MeanLatency-sideA.MeanLatency))
+	row("Input tokens", formatCount(sideA.Usage.InputTokens), formatCount(sideB.Usage.InputTokens), signedCount(sideB.Usage.InputTokens-sideA.Usage.InputTokens))
+	row("Output tokens", formatCount(sideA.Usage.OutputTokens), formatCount(sideB.Usage.OutputTokens), signedCount(sideB.Usage.OutputTokens-sideA.Usage.OutputTokens))
+	row("Failed", fmt.Sprint(sideA.Failed), fmt.Sprint(sideB.Failed), "")
+	_, err := fmt.Fprintf(w, "  Identical answers: %d of %d, mean similarity %.0f%%\n", r.Identical, len(r.Cases), r.MeanSimilarity*100)
+	return err
+}
+
+// compareResultJSON returns one answer for the JSON report
+func compareResultJSON(result multiResult) map[string]interface{} {
+	item := map[string]interface{}{
+		"content":   result.Text,
+		"latencyMs": result.Latency.Milliseconds(),
+	}
+	if result.SessionID != "" {
+		item["sessionId"] = result.SessionID
+	}
+	if !result.Usage.IsZero() {
+		item["usage"] = map[string]interface{}{
+			"inputTokens":  result.Usage.InputTokens,
+			"outputTokens": result.Usage.OutputTokens,
+		}
+	}
+	if result.Err != nil {
+		item["error"] = jsonErrorObject(result.Err)
+	}
+	return item
+}
+
+// writeCompareJSON writes the report as a JSON document
+func writeCompareJSON(w io.Writer, r *compareReport) error {
+	cases := make([]map[string]interface{}, 0, len(r.Cases))
+	for _, c := range r.Cases {
+		resultA, resultB := c.Results[0], c.Results[1]
+		item := map[string]interface{}{
+			"id":        c.ID,
+			"input":     c.Input,
+			"a":         compareResultJSON(resultA),
+			"b":         compareResultJSON(resultB),
+			"identical": c.Identical(),
+		}
+		if resultA.Err == nil && resultB.Err == nil {
+			item["similarity"] = c.Similarity
+			item["latencyDeltaMs"] = (resultB.Latency - resultA.Latency).Milliseconds()
+			item["tokenDelta"] = map[string]int64{
+				"inputTokens":  resultB.Usage.InputTokens - resultA.Usage.InputTokens,
+				"outputTokens": resultB.Usage.OutputTokens - resultA.Usage.OutputTokens,
+			}
+			if c.Diff != "" {
+				item["diff"] = c.Diff
+			}
+		}
+		cases = append(cases, item)
+	}
+
+	sides := make([]map[string]interface{}, 0, len(r.Sides))
+	for _, side := range r.Sides {
+		sides = append(sides, map[string]interface{}{
+			"alias":           side.Target.Name,
+			"agentAliasId":    side.Target.AgentAliasID,
+			"failed":          side.Failed,
+			"medianLatencyMs": side.MedianLatency.Milliseconds(),
+			"meanLatencyMs":   side.MeanLatency.Milliseconds(),
+			"inputTokens":     side.Usage.InputTokens,
+			"outputTokens":    side.Usage.OutputTokens,
+		})
+	}
+
+	jsonData, err := json.MarshalIndent(map[string]interface{}{
+		"agentId": r.AgentID,
+		"a":       sides[0],
+		"b":       sides[1],
+		"cases":   cases,
+		"summary": map[string]interface{}{
+			"cases":          len(r.Cases),
+			"identical":      r.Identical,
+			"meanSimilarity": r.MeanSimilarity,
+		},
+		"timestamp": r.GeneratedAt.Format(time.RFC3339),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal comparison to JSON: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(jsonData))
+	return err
+}
+
+// htmlCompareTemplate is the self-contained page written by writeCompareHTML
+const htmlCompareTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{(side 0).Target.Name}} vs {{(side 1).Target.Name}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; max-width: 1400px; margin: 2em auto; padding: 0 1em; color: #1f2328; }
+header { border-bottom: 1px solid #d0d7de; margin-bottom: 1.5em; }
+.meta { color: #656d76; font-size: 0.9em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #d0d7de; padding: 0.5em 0.75em; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; }
+.case { margin-bottom: 2em; }
+.answer { white-space: pre-wrap; width: 50%; }
+.identical { color: #1a7f37; }
+.failed { color: #cf222e; }
+pre { background: #f6f8fa; padding: 0.75em 1em; border-radius: 6px; overflow-x: auto; }
+.add { color: #1a7f37; }
+.del { color: #cf222e; }
+.hunk { color: #0969da; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{(side 0).Target.Name}} vs {{(side 1).Target.Name}}</h1>
+<p class="meta">Agent <code>{{.AgentID}}</code> &middot; aliases <code>{{(side 0).Target.AgentAliasID}}</code> and <code>{{(side 1).Target.AgentAliasID}}</code> &middot; {{len .Cases}} input(s) &middot; {{.GeneratedAt.Local.Format "Mon, 02 Jan 2006 15:04 MST"}}</p>
+</header>
+<h2>Summary</h2>
+<p>{{.Identical}} of {{len .Cases}} answers identical, mean similarity {{percent .MeanSimilarity}}</p>
+<table>
+<tr><th></th><th>{{(side 0).Target.Name}}</th><th>{{(side 1).Target.Name}}</th><th>Delta</th></tr>
+<tr><td>Median latency</td><td>{{duration (side 0).MedianLatency}}</td><td>{{duration (side 1).MedianLatency}}</td><td>{{durationDelta (side 0).MedianLatency (side 1).MedianLatency}}</td></tr>
+<tr><td>Mean latency</td><td>{{duration (side 0).MeanLatency}}</td><td>{{duration (side 1).MeanLatency}}</td><td>{{durationDelta (side 0).MeanLatency (side 1).MeanLatency}}</td></tr>
+<tr><td>Input tokens</td><td>{{count (side 0).Usage.InputTokens}}</td><td>{{count (side 1).Usage.InputTokens}}</td><td>{{countDelta (side 0).Usage.InputTokens (side 1).Usage.InputTokens}}</td></tr>
+<tr><td>Output tokens</td><td>{{count (side 0).Usage.OutputTokens}}</td><td>{{count (side 1).Usage.OutputTokens}}</td><td>{{countDelta (side 0).Usage.OutputTokens (side 1).Usage.OutputTokens}}</td></tr>
+<tr><td>Failed</td><td>{{(side 0).Failed}}</td><td>{{(side 1).Failed}}</td><td></td></tr>
+</table>
+{{range $i, $case := .Cases}}{{$a := index $case.Results 0}}{{$b := index $case.Results 1}}
+<section class="case">
+<h2>{{inc $i}}. {{$case.ID}}</h2>
+<p>{{$case.Input}}</p>
+<table>
+<tr><th>{{$a.Target.Name}} <span class="meta">{{result $a}}</span></th><th>{{$b.Target.Name}} <span class="meta">{{result $b}}</span></th></tr>
+<tr><td class="answer">{{if $a.Err}}<span class="failed">{{$a.Err}}</span>{{else}}{{trim $a.Text}}{{end}}</td><td class="answer">{{if $b.Err}}<span class="failed">{{$b.Err}}</span>{{else}}{{trim $b.Text}}{{end}}</td></tr>
+</table>
+{{- if $case.Diff}}
+<p class="meta">Similarity {{percent $case.Similarity}}</p>
+<pre>{{range diffLines $case.Diff}}<span class="{{.Class}}">{{.Text}}</span>
+{{end}}</pre>
+{{- else if $case.Identical}}
+<p class="identical">Identical answers</p>
+{{- end}}
+</section>
+{{- end}}
+</body>
+</html>
+`
+
+// compareDiffLine is a line of a diff with its CSS class
+type compareDiffLine struct {
+	Class string
+	Text  string
+}
+
+// writeCompareHTML writes the report as a single HTML page
+func writeCompareHTML(w io.Writer, r *compareReport) error {
+	tmpl, err := template.New("compare").Funcs(template.FuncMap{
+		"side":          func(i int) compareSide { return r.Sides[i] },
+		"inc":           func(i int) int { return i + 1 },
+		"trim":          strings.TrimSpace,
+		"percent":       func(f float64) string { return fmt.Sprintf("%.0f%%", f*100) },
+		"duration":      formatDuration,
+		"durationDelta": func(a, b time.Duration) string { return signedDuration(b - a) },
+		"count":         formatCount,
+		"countDelta":    func(a, b int64) string { return signedCount(b - a) },
+		"result":        compareResultLine,
+		"diffLines": func(diff string) []compareDiffLine {
+			var lines []compareDiffLine
+			for _, line := range strings.Split(strings.TrimRight(diff, "\n"), "\n") {
+				class := ""
+				switch {
+				case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+				case strings.HasPrefix(line, "+"):
+					class = "add"
+				case strings.HasPrefix(line, "-"):
+					class = "del"
+				case strings.HasPrefix(line, "@@"):
+					class = "hunk"
+				}
+				lines = append(lines, compareDiffLine{Class: class, Text: line})
+			}
+			return lines
+		},
+	}).Parse(htmlCompareTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to parse report template: %w", err)
+	}
+
+	if err := tmpl.Execute(w, r); err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+	return nil
+}
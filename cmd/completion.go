@@ -0,0 +1,171 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the 'completion' command for the AWS Bedrock Intelligent Agents CLI.
+Besides the static completion scripts for bash, zsh, fish, and PowerShell, it provides
+dynamic completion for --agent-id, --agent-alias-id, and --prompt that looks up real
+agents and aliases through the control-plane API and prompts in the prompt directories.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagent"
+	"github.com/spf13/cobra"
+)
+
+// completionTimeout bounds control-plane lookups so a slow network does not hang the shell
+const completionTimeout = 5 * time.Second
+
+// completionCmd represents the completion command
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell]",
+	Short: "Generate shell completion scripts",
+	Long: `Generate a shell completion script for aws-bia.
+
+Completion of --agent-id and --agent-alias-id lists the agents and aliases in
+the configured account and region, and --prompt lists the prompts found in the
+prompt directories. The agent ID, alias ID, and region are taken from the
+command line or the config file, like for the command being completed.
+
+Examples:
+  # Bash (current shell)
+  source <(aws-bia completion bash)
+
+  # Bash (permanently, Linux)
+  aws-bia completion bash > /etc/bash_completion.d/aws-bia
+
+  # Zsh
+  aws-bia completion zsh > "${fpath[1]}/_aws-bia"
+
+  # Fish
+  aws-bia completion fish > ~/.config/fish/completions/aws-bia.fish
+
+  # PowerShell
+  aws-bia completion powershell | Out-String | Invoke-Expression`,
+	DisableFlagsInUseLine: true,
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch args[0] {
+		case "bash":
+			return rootCmd.GenBashCompletionV2(os.Stdout, true)
+		case "zsh":
+			return rootCmd.GenZshCompletion(os.Stdout)
+		case "fish":
+			return rootCmd.GenFishCompletion(os.Stdout, true)
+		case "powershell":
+			return rootCmd.GenPowerShellCompletionWithDesc(os.Stdout)
+		}
+		return fmt.Errorf("unsupported shell: %s", args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+}
+
+// registerAgentCompletions adds dynamic completion to the agent, alias, and prompt flags of a command
+func registerAgentCompletions(cmd *cobra.Command) {
+	completions := map[string]cobra.CompletionFunc{
+		"agent-id":       completeAgentIDs,
+		"agent-alias-id": completeAgentAliasIDs,
+		"prompt":         completePromptNames,
+	}
+	for name, fn := range completions {
+		if cmd.Flags().Lookup(name) != nil {
+			if err := cmd.RegisterFlagCompletionFunc(name, fn); err != nil {
+				logError("Warning: Error registering completion", err)
+			}
+		}
+	}
+}
+
+// completionOptions collects the agent, alias, and region from the flags typed so far and the config file
+func completionOptions(cmd *cobra.Command) AgentOptions {
+	var options AgentOptions
+	for flag, value := range map[string]*string{
+		"config":         &options.ConfigFile,
+		"agent-id":       &options.AgentID,
+		"agent-alias-id": &options.AgentAliasID,
+		"region":         &options.Region,
+	} {
+		if cmd.Flags().Lookup(flag) != nil {
+			*value, _ = cmd.Flags().GetString(flag)
+		}
+	}
+
+	// Config errors must not print anything while the shell is completing
+	if v, err := LoadConfigForCommand(options.ConfigFile, cmd.Name(), false); err == nil {
+		applyAgentConfig(v, &options)
+	}
+	return options
+}
+
+// completeAgentIDs offers the IDs of the agents in the account, described by their names
+func completeAgentIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	options := completionOptions(cmd)
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+
+	client, err := NewAWSHelper(options).CreateAgentClient(ctx)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []string
+	paginator := bedrockagent.NewListAgentsPaginator(client, &bedrockagent.ListAgentsInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			break
+		}
+		for _, summary := range page.AgentSummaries {
+			completions = append(completions, cobra.CompletionWithDesc(
+				aws.ToString(summary.AgentId), aws.ToString(summary.AgentName)))
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeAgentAliasIDs offers the alias IDs of the selected agent, described by their names
+func completeAgentAliasIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	options := completionOptions(cmd)
+	if options.AgentID == "" {
+		return cobra.AppendActiveHelp(nil, "Set --agent-id first to complete its aliases"), cobra.ShellCompDirectiveNoFileComp
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+
+	client, err := NewAWSHelper(options).CreateAgentClient(ctx)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []string
+	paginator := bedrockagent.NewListAgentAliasesPaginator(client, &bedrockagent.ListAgentAliasesInput{
+		AgentId: aws.String(options.AgentID),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			break
+		}
+		for _, summary := range page.AgentAliasSummaries {
+			completions = append(completions, cobra.CompletionWithDesc(
+				aws.ToString(summary.AgentAliasId), aws.ToString(summary.AgentAliasName)))
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completePromptNames offers the prompt templates found in the prompt directories
+func completePromptNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return NewPromptManager(completionOptions(cmd).PromptDirs).GetAvailablePrompts(), cobra.ShellCompDirectiveNoFileComp
+}
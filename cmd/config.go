@@ -0,0 +1,611 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the 'config' command group for AWS Bedrock Intelligent Agents CLI.
+It provides subcommands to scaffold, inspect, modify, and validate the YAML
+configuration file so users get feedback instead of hand-writing YAML blindly.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// configKey describes a setting that can appear in the configuration file
+type configKey struct {
+	Name        string
+	Description string
+	Validate    func(value string) error
+	Nested      bool // Holds a mapping whose entries are set as "<name>.<entry>"
+	List        bool // Holds a YAML list; 'config set' takes the items separated by commas
+}
+
+// configKeys lists every setting recognized in the configuration file
+var configKeys = []configKey{
+	{Name: "agent_id", Description: "Default agent ID"},
+	{Name: "agent_alias_id", Description: "Default agent alias ID"},
+	{Name: "memory_id", Description: "Agent memory ID that continues a long-term memory thread across sessions"},
+	{Name: "model_id", Description: "Default model ID or inference profile for model invoke"},
+	{Name: "guardrail_id", Description: "ID or ARN of the guardrail for guardrail test"},
+	{Name: "guardrail_version", Description: "Guardrail version applied by guardrail test (default: DRAFT)"},
+	{Name: "region", Description: "AWS region"},
+	{Name: "endpoint_url", Description: "Agent runtime endpoint URL used instead of the regional endpoint", Validate: validateEndpointURL},
+	{Name: "use_fips", Description: "Use FIPS endpoints for AWS requests (true or false)", Validate: validateBoolValue},
+	{Name: "use_dualstack", Description: "Use dual-stack endpoints for AWS requests (true or false)", Validate: validateBoolValue},
+	{Name: "timeout", Description: "Request timeout (e.g. 30s, 1m); the maximum duration for invoke without --stream", Validate: validateDurationValue},
+	{Name: "connect_timeout", Description: "Time until the agent starts responding, for invoke and serve (0 for no limit)", Validate: validateTimeLimitValue},
+	{Name: "idle_timeout", Description: "Time allowed between stream events, for invoke and serve (0 for no limit)", Validate: validateTimeLimitValue},
+	{Name: "max_duration", Description: "Maximum duration of an invocation, for invoke, chat, and serve; also limits streamed answers (0 for no limit)", Validate: validateTimeLimitValue},
+	{Name: "latency", Description: "Model latency profile (standard or optimized)", Validate: validateLatency},
+	{Name: "stream", Description: "Stream responses by default (true or false)", Validate: validateBoolValue},
+	{Name: "format", Description: "Default output format (text, json, or template)", Validate: validateOutputFormatValue},
+	{Name: "color", Description: "Color mode for text output (auto, always, or never)", Validate: validateColorMode},
+	{Name: "citations", Description: "Citation style of text output (footnotes, inline, json, or none)", Validate: validateCitationStyle},
+	{Name: "on_conflict", Description: "When a saved file already exists (rename, overwrite, skip, or error)", Validate: validateConflictPolicy},
+	{Name: "cache", Description: "Serve identical invocations from the local response cache (true or false)", Validate: validateBoolValue},
+	{Name: "cache_ttl", Description: "How long a cached response is served (e.g. 30m, 2h)", Validate: validateDurationValue},
+	{Name: "max_concurrent", Description: "Maximum concurrent invocations per agent alias, for serve", Validate: validatePositiveIntValue},
+	{Name: "max_queue", Description: "Requests waiting for a slot per agent alias before serve answers 429", Validate: validateNonNegativeIntValue},
+	{Name: "queue_timeout", Description: "Maximum time a serve request waits for a slot (e.g. 30s)", Validate: validateDurationValue},
+	{Name: "otel_endpoint", Description: "OTLP/HTTP collector URL for invoke traces and metrics"},
+	{Name: "rps", Description: "Requests per second sent by invoke, chat, run, and invoke-multi (0 for no limit)", Validate: validateNonNegativeNumberValue},
+	{Name: "burst", Description: "Requests that may be sent at once before the rps limit applies", Validate: validatePositiveIntValue},
+	{Name: "retry_budget", Description: "Total number of retries shared by all targets of invoke-multi", Validate: validateNonNegativeIntValue},
+	{Name: "retry_budget_time", Description: "Total retry backoff time shared by all targets of invoke-multi (e.g. 30s)", Validate: validateTimeLimitValue},
+	{Name: "input_token_price", Description: "USD per 1,000 input tokens for cost estimates", Validate: validateNonNegativeNumberValue},
+	{Name: "output_token_price", Description: "USD per 1,000 output tokens for cost estimates", Validate: validateNonNegativeNumberValue},
+	{Name: "session_budget", Description: "Chat session budget in USD", Validate: validateNonNegativeNumberValue},
+	{Name: "max_tokens_total", Description: "Tokens chat and run may use before they stop sending requests", Validate: validatePositiveIntValue},
+	{Name: "max_cost", Description: "Estimated cost in USD chat and run may reach before they stop sending requests", Validate: validateNonNegativeNumberValue},
+	{Name: "template_env", Description: "Environment variables prompt and output templates may read with env", List: true},
+	{Name: "prompt_dirs", Description: "Directories searched for --prompt templates before the default locations", List: true},
+	{Name: "prompts_source", Description: "Git repository or s3://bucket/prefix that 'prompts sync' pulls templates from"},
+	{Name: "prompts_ref", Description: "Git tag, branch, or commit, or S3 version folder pinned by 'prompts sync'"},
+	{Name: "prompts_path", Description: "Directory of the templates within the prompts_source Git repository"},
+	{Name: "regions", Description: "Agent-id:alias-id replicated in each region, for invoke --regions failover", Nested: true},
+	{Name: "targets", Description: "Named agent-id:alias-id targets for invoke-multi", Nested: true},
+	{Name: "smoke_test", Description: "Probe, latency_budget, expect, and reject patterns for agent smoke-test", Nested: true},
+	{Name: "request_headers", Description: "Headers added to every AWS request; $VAR references are expanded", Nested: true},
+	{Name: "request_hooks", Description: "Commands that receive every AWS request as JSON and may return headers to set", List: true},
+	{Name: "post_hook", Description: "Command that receives the final JSON response of invoke on stdin"},
+	{Name: "post_hook_replace", Description: "Show the post hook's stdout instead of the response (true or false)", Validate: validateBoolValue},
+	{Name: "notify_webhook", Description: "URL that receives a JSON summary when invoke finishes or fails", Validate: validateEndpointURL},
+	{Name: "translate_to", Description: "Language invoke translates answers into, e.g. ja or German"},
+	{Name: "translate_model", Description: "Model ID that translates answers with the Converse API"},
+	{Name: "translate_agent", Description: "Agent that translates answers, as agent-id:alias-id"},
+	{Name: "translate_replace", Description: "Show the translation instead of the answer (true or false)", Validate: validateBoolValue},
+	{Name: "tools_file", Description: "YAML file of local tools that run the calls of return-control responses"},
+	{Name: "tools_allowlist", Description: "Programs and http(s):// URL prefixes local tools may use", List: true},
+	{Name: "tools_audit_log", Description: "JSON lines file every local tool call is appended to"},
+	{Name: "on_saved_file", Description: "Commands run after saving generated files, by extension ({} is the path)", Nested: true},
+}
+
+var configInitForce bool
+
+// configCmd represents the config command group
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the aws-bia configuration file",
+	Long: `Manage the aws-bia configuration file.
+
+Examples:
+  # Create ~/.config/aws-bia/aws-bia.yaml interactively
+  aws-bia config init
+
+  # Show the resolved configuration and where it was loaded from
+  aws-bia config view
+
+  # Change a single setting
+  aws-bia config set region us-west-2
+
+  # Check the configuration file for unknown keys and invalid values
+  aws-bia config validate
+`,
+}
+
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create a configuration file interactively",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runConfigInit(cfgFile, configInitForce); err != nil {
+			logError("Error creating config file", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var configViewCmd = &cobra.Command{
+	Use:   "view",
+	Short: "Print the resolved configuration and its source file",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runConfigView(cfgFile); err != nil {
+			logError("Error reading config file", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a value in the configuration file",
+	Long: `Set a value in the configuration file.
+
+The value is written to the file given by --config, the configuration file
+that would currently be used, or ~/.config/aws-bia/aws-bia.yaml if none
+exists. Comments in an existing file are not preserved.
+
+Lists such as tools_allowlist take all of their items at once, separated by
+commas. Mappings such as on_saved_file are set one entry at a time as
+<key>.<entry>.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runConfigSet(cfgFile, args[0], args[1]); err != nil {
+			logError("Error updating config file", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the configuration file for unknown keys and invalid values",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runConfigValidate(cfgFile); err != nil {
+			logError("Invalid config file", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configInitCmd, configViewCmd, configSetCmd, configValidateCmd)
+
+	configInitCmd.Flags().BoolVar(&configInitForce, "force", false, "Overwrite an existing configuration file")
+}
+
+// runConfigInit asks for the common settings and writes a new configuration file
+func runConfigInit(configPath string, force bool) error {
+	path, err := configWritePath(configPath, false)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path); err == nil && !force {
+		ok, err := confirmAction(fmt.Sprintf("%s already exists. Overwrite?", path))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Aborted")
+			return nil
+		}
+	}
+
+	v := viper.New()
+	v.SetConfigType("yaml")
+
+	for _, key := range configKeys {
+		if key.Nested {
+			continue // Mappings are edited with 'config set <name>.<entry>'
+		}
+		prompt := key.Description
+		if key.List {
+			prompt += ", separated by commas"
+		}
+		for {
+			value, err := readLine(fmt.Sprintf("%s (%s): ", key.Name, prompt))
+			if err != nil {
+				return err
+			}
+			if value == "" {
+				break
+			}
+			if key.Validate != nil {
+				if err := key.Validate(value); err != nil {
+					fmt.Fprintf(os.Stderr, "  %v\n", err)
+					continue
+				}
+			}
+			if key.List {
+				v.Set(key.Name, splitConfigList(value))
+			} else {
+				v.Set(key.Name, value)
+			}
+			break
+		}
+	}
+
+	if err := writeConfigFile(v, path); err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote %s\n", path)
+	return nil
+}
+
+// runConfigView prints every known setting as resolved from the configuration file
+func runConfigView(configPath string) error {
+	v, err := LoadConfigForCommand(configPath, "", false)
+	if err != nil {
+		return err
+	}
+
+	source := v.ConfigFileUsed()
+	if source == "" {
+		source = "(none found)"
+	}
+	fmt.Printf("Config file: %s\n\n", source)
+
+	width := 0
+	for _, key := range configKeys {
+		if len(key.Name) > width {
+			width = len(key.Name)
+		}
+	}
+
+	for _, key := range configKeys {
+		value := "(not set)"
+		if v.IsSet(key.Name) {
+			value = fmt.Sprintf("%v", v.Get(key.Name))
+		}
+		fmt.Printf("%-*s  %s\n", width+1, key.Name+":", value)
+	}
+
+	// Settings that only apply to one command
+	for _, command := range commandSections {
+		section := v.GetStringMap(command)
+		if len(section) == 0 {
+			continue
+		}
+		names := make([]string, 0, len(section))
+		for name := range section {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		fmt.Printf("\n%s:\n", command)
+		for _, name := range names {
+			fmt.Printf("  %-*s  %v\n", width+1, name+":", section[name])
+		}
+	}
+
+	return nil
+}
+
+// runConfigSet updates a single key in the configuration file
+func runConfigSet(configPath, name, value string) error {
+	key, ok := lookupConfigKey(name)
+	if !ok {
+		if list, ok := listKeyOfEntry(name); ok {
+			return fmt.Errorf("'%s' is a list, set all of its items with 'config set %s <item>,<item>'", list, list)
+		}
+		return unknownConfigKeyError(name)
+	}
+	if key.Validate != nil {
+		if err := key.Validate(value); err != nil {
+			return err
+		}
+	}
+
+	path, err := configWritePath(configPath, true)
+	if err != nil {
+		return err
+	}
+
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if _, err := os.Stat(path); err == nil {
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			return fmt.Errorf("failed to read config file: %w", err)
+		}
+	}
+
+	if _, setting := splitCommandSection(name); key.Nested && setting == key.Name {
+		return fmt.Errorf("'%s' is a mapping, set an entry with '%s.<entry>'", key.Name, key.Name)
+	}
+
+	if key.List {
+		v.Set(name, splitConfigList(value))
+	} else {
+		v.Set(name, value)
+	}
+	if err := writeConfigFile(v, path); err != nil {
+		return err
+	}
+
+	fmt.Printf("Set %s in %s\n", name, path)
+	return nil
+}
+
+// runConfigValidate reports unknown keys and invalid values in the configuration file
+func runConfigValidate(configPath string) error {
+	v, err := LoadConfigForCommand(configPath, "", false)
+	if err != nil {
+		return err
+	}
+	if v.ConfigFileUsed() == "" {
+		return fmt.Errorf("no configuration file found")
+	}
+
+	problems := validateConfigValues(v)
+	if len(problems) == 0 {
+		fmt.Printf("%s is valid\n", v.ConfigFileUsed())
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "%s has %d problem(s):\n", v.ConfigFileUsed(), len(problems))
+	for _, problem := range problems {
+		fmt.Fprintf(os.Stderr, "  - %s\n", problem)
+	}
+	return fmt.Errorf("found %d problem(s) in config file", len(problems))
+}
+
+// validateConfigValues checks every key in the configuration against the known settings
+func validateConfigValues(v *viper.Viper) []string {
+	var problems []string
+
+	keys := v.AllKeys()
+	sort.Strings(keys)
+	reported := make(map[string]bool)
+	for _, name := range keys {
+		key, ok := lookupConfigKey(name)
+		if !ok {
+			// The entries of a list written as a mapping would be read as an empty list
+			if list, ok := listKeyOfEntry(name); ok {
+				if !reported[list] {
+					reported[list] = true
+					problems = append(problems, fmt.Sprintf("%s: must be a list, not a mapping", list))
+				}
+				continue
+			}
+			problems = append(problems, unknownConfigKeyError(name).Error())
+			continue
+		}
+		if key.List {
+			if _, err := configStringList(v, name); err != nil {
+				problems = append(problems, err.Error())
+			}
+			continue
+		}
+		if key.Validate != nil {
+			if err := key.Validate(v.GetString(name)); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+			}
+		}
+	}
+
+	return problems
+}
+
+// lookupConfigKey returns the known setting with the given name, which may be
+// prefixed with a command section such as "invoke."
+func lookupConfigKey(name string) (configKey, bool) {
+	_, name = splitCommandSection(name)
+	for _, key := range configKeys {
+		if key.Name == name || (key.Nested && strings.HasPrefix(name, key.Name+".")) {
+			return key, true
+		}
+	}
+	return configKey{}, false
+}
+
+// listKeyOfEntry returns the list setting, with its command section, that a key such as
+// "tools_allowlist.0" would be an entry of if the list were written as a mapping
+func listKeyOfEntry(name string) (string, bool) {
+	section, setting := splitCommandSection(name)
+	for _, key := range configKeys {
+		if key.List && strings.HasPrefix(setting, key.Name+".") {
+			if section != "" {
+				return section + "." + key.Name, true
+			}
+			return key.Name, true
+		}
+	}
+	return "", false
+}
+
+// splitConfigList splits a comma-separated 'config set' value into the items of a list setting
+func splitConfigList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// configStringList reads a list setting. A single string is a list of one item. Anything
+// else, such as a mapping, is an error rather than an empty list, so a safeguard like
+// tools_allowlist cannot be turned off by writing it in the wrong shape.
+func configStringList(v *viper.Viper, name string) ([]string, error) {
+	switch value := v.Get(name).(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{value}, nil
+	case []string:
+		return value, nil
+	case []interface{}:
+		items := make([]string, 0, len(value))
+		for i, item := range value {
+			switch item.(type) {
+			case string, bool, int, int64, float64:
+				items = append(items, fmt.Sprint(item))
+			default:
+				return nil, fmt.Errorf("%s: item %d must be a string", name, i+1)
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("%s: must be a list, not a mapping", name)
+	}
+}
+
+// unknownConfigKeyError builds an error for an unknown key, suggesting the closest known key
+func unknownConfigKeyError(name string) error {
+	section, setting := splitCommandSection(name)
+	best, bestDistance := "", -1
+	for _, key := range configKeys {
+		d := editDistance(normalizeConfigKey(setting), normalizeConfigKey(key.Name))
+		if bestDistance == -1 || d < bestDistance {
+			best, bestDistance = key.Name, d
+		}
+	}
+
+	if bestDistance >= 0 && bestDistance <= 2 {
+		if section != "" {
+			best = section + "." + best
+		}
+		return fmt.Errorf("unknown key '%s' (did you mean '%s'?)", name, best)
+	}
+	return fmt.Errorf("unknown key '%s'", name)
+}
+
+// normalizeConfigKey strips separators so that e.g. "agentid" and "agent-id" match "agent_id"
+func normalizeConfigKey(name string) string {
+	return strings.NewReplacer("_", "", "-", "", ".", "").Replace(strings.ToLower(name))
+}
+
+// editDistance returns the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+// validateDurationValue checks that a value parses as a positive duration
+func validateDurationValue(value string) error {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid duration '%s' (use formats like 30s, 1m, 2h30m)", value)
+	}
+	if d <= 0 {
+		return fmt.Errorf("duration must be positive, got '%s'", value)
+	}
+	return nil
+}
+
+// validateTimeLimitValue checks that a value parses as a duration, where 0 disables the limit
+func validateTimeLimitValue(value string) error {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid duration '%s' (use formats like 30s, 1m, 2h30m)", value)
+	}
+	if d < 0 {
+		return fmt.Errorf("duration must not be negative, got '%s'", value)
+	}
+	return nil
+}
+
+// validateBoolValue checks that a value parses as a boolean
+func validateBoolValue(value string) error {
+	if _, err := strconv.ParseBool(value); err != nil {
+		return fmt.Errorf("expected true or false, got '%s'", value)
+	}
+	return nil
+}
+
+// validateOutputFormatValue checks that a value names an output format of the invoke command
+func validateOutputFormatValue(value string) error {
+	switch value {
+	case OutputFormatText, OutputFormatJSON, OutputFormatTemplate:
+		return nil
+	default:
+		return fmt.Errorf("format must be one of: %s, %s, %s, got '%s'",
+			OutputFormatText, OutputFormatJSON, OutputFormatTemplate, value)
+	}
+}
+
+// validateNonNegativeNumberValue checks that a value parses as a non-negative number
+func validateNonNegativeNumberValue(value string) error {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n < 0 {
+		return fmt.Errorf("expected a non-negative number, got '%s'", value)
+	}
+	return nil
+}
+
+// validatePositiveIntValue checks that a value is a whole number of at least 1
+func validatePositiveIntValue(value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return fmt.Errorf("expected a whole number of at least 1, got '%s'", value)
+	}
+	return nil
+}
+
+// validateNonNegativeIntValue checks that a value is a whole number of at least 0
+func validateNonNegativeIntValue(value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return fmt.Errorf("expected a whole number of at least 0, got '%s'", value)
+	}
+	return nil
+}
+
+// configWritePath determines which configuration file a write should go to
+func configWritePath(configPath string, preferExisting bool) (string, error) {
+	if configPath != "" {
+		return filepath.Abs(configPath)
+	}
+
+	if preferExisting {
+		if v, err := LoadConfigForCommand("", "", false); err == nil && v.ConfigFileUsed() != "" {
+			return v.ConfigFileUsed(), nil
+		}
+	}
+
+	// New files go to the XDG configuration directory, unless ~/.aws-bia.yaml already exists
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine home directory: %w", err)
+	}
+	legacy := filepath.Join(homeDir, ".aws-bia.yaml")
+	if _, err := os.Stat(legacy); err == nil || userConfigDir() == "" {
+		return legacy, nil
+	}
+	return filepath.Join(userConfigDir(), appDirName, "aws-bia.yaml"), nil
+}
+
+// writeConfigFile writes the viper settings to path as YAML, creating parent directories
+func writeConfigFile(v *viper.Viper, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	v.SetConfigType("yaml")
+	if err := v.WriteConfigAs(path); err != nil {
+		return fmt.Errorf("failed to write config file '%s': %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file contains helpers for the Bedrock Agent control-plane API used by the
+AWS Bedrock Intelligent Agents CLI. It handles looking up agents and aliases
+so that commands can accept either IDs or human-readable names.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagent"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagent/types"
+)
+
+// ResolveAgentAlias finds an agent alias by ID or name and returns its full definition
+func ResolveAgentAlias(ctx context.Context, client *bedrockagent.Client, agentID, alias string) (*types.AgentAlias, error) {
+	aliasID, err := findAgentAliasID(ctx, client, agentID, alias)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := client.GetAgentAlias(ctx, &bedrockagent.GetAgentAliasInput{
+		AgentId:      aws.String(agentID),
+		AgentAliasId: aws.String(aliasID),
+	})
+	if err != nil {
+		return nil, HandleAWSError(fmt.Errorf("failed to get agent alias '%s': %w", aliasID, err))
+	}
+
+	return out.AgentAlias, nil
+}
+
+// findAgentIDByName returns the ID of the agent with the given name
+func findAgentIDByName(ctx context.Context, client *bedrockagent.Client, name string) (string, error) {
+	paginator := bedrockagent.NewListAgentsPaginator(client, &bedrockagent.ListAgentsInput{})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return "", HandleAWSError(fmt.Errorf("failed to list agents: %w", err))
+		}
+
+		for _, summary := range page.AgentSummaries {
+			if aws.ToString(summary.AgentName) == name {
+				return aws.ToString(summary.AgentId), nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("agent named '%s' not found", name)
+}
+
+// findAgentAliasID returns the alias ID matching the given alias ID or name
+func findAgentAliasID(ctx context.Context, client *bedrockagent.Client, agentID, alias string) (string, error) {
+	paginator := bedrockagent.NewListAgentAliasesPaginator(client, &bedrockagent.ListAgentAliasesInput{
+		AgentId: aws.String(agentID),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return "", HandleAWSError(fmt.Errorf("failed to list agent aliases: %w", err))
+		}
+
+		for _, summary := range page.AgentAliasSummaries {
+			if aws.ToString(summary.AgentAliasId) == alias || aws.ToString(summary.AgentAliasName) == alias {
+				return aws.ToString(summary.AgentAliasId), nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("alias '%s' not found for agent '%s'", alias, agentID)
+}
+
+// aliasRoutedVersion returns the agent version an alias currently routes to
+func aliasRoutedVersion(alias *types.AgentAlias) string {
+	if alias == nil || len(alias.RoutingConfiguration) == 0 {
+		return ""
+	}
+	return aws.ToString(alias.RoutingConfiguration[0].AgentVersion)
+}
+
+// updateAliasVersion points an alias at an agent version, keeping its name and description
+func updateAliasVersion(ctx context.Context, client *bedrockagent.Client, alias *types.AgentAlias, version string) (*types.AgentAlias, error) {
+	out, err := client.UpdateAgentAlias(ctx, &bedrockagent.UpdateAgentAliasInput{
+		AgentId:        alias.AgentId,
+		AgentAliasId:   alias.AgentAliasId,
+		AgentAliasName: alias.AgentAliasName,
+		Description:    alias.Description,
+		RoutingConfiguration: []types.AgentAliasRoutingConfigurationListItem{
+			{AgentVersion: aws.String(version)},
+		},
+	})
+	if err != nil {
+		return nil, HandleAWSError(fmt.Errorf("failed to update agent alias: %w", err))
+	}
+	return out.AgentAlias, nil
+}
+
+// WaitForAgentPrepared polls the agent status until it is PREPARED or FAILED.
+// The progress callback, if not nil, is called after every status check.
+func WaitForAgentPrepared(ctx context.Context, client *bedrockagent.Client, agentID string,
+	interval time.Duration, progress func(status types.AgentStatus, elapsed time.Duration)) (*types.Agent, error) {
+
+	start := time.Now()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		out, err := client.GetAgent(ctx, &bedrockagent.GetAgentInput{AgentId: aws.String(agentID)})
+		if err != nil {
+			return nil, HandleAWSError(fmt.Errorf("failed to get agent status: %w", err))
+		}
+
+		agent := out.Agent
+		if progress != nil {
+			progress(agent.AgentStatus, time.Since(start))
+		}
+
+		switch agent.AgentStatus {
+		case types.AgentStatusPrepared:
+			return agent, nil
+		case types.AgentStatusFailed:
+			reason := strings.Join(agent.FailureReasons, "; ")
+			if reason == "" {
+				reason = "no failure reason reported"
+			}
+			return agent, fmt.Errorf("agent preparation failed: %s", reason)
+		}
+
+		select {
+		case <-ctx.Done():
+			return agent, fmt.Errorf("stopped waiting for agent to be prepared (last status: %s): %w",
+				agent.AgentStatus, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// WaitForAliasPrepared polls the alias status until it is PREPARED or FAILED.
+// The progress callback, if not nil, is called after every status check.
+func WaitForAliasPrepared(ctx context.Context, client *bedrockagent.Client, agentID, aliasID string,
+	interval time.Duration, progress func(status types.AgentAliasStatus, elapsed time.Duration)) (*types.AgentAlias, error) {
+
+	start := time.Now()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		out, err := client.GetAgentAlias(ctx, &bedrockagent.GetAgentAliasInput{
+			AgentId:      aws.String(agentID),
+			AgentAliasId: aws.String(aliasID),
+		})
+		if err != nil {
+			return nil, HandleAWSError(fmt.Errorf("failed to get agent alias status: %w", err))
+		}
+
+		alias := out.AgentAlias
+		if progress != nil {
+			progress(alias.AgentAliasStatus, time.Since(start))
+		}
+
+		switch alias.AgentAliasStatus {
+		case types.AgentAliasStatusPrepared:
+			return alias, nil
+		case types.AgentAliasStatusFailed:
+			reason := strings.Join(alias.FailureReasons, "; ")
+			if reason == "" {
+				reason = "no failure reason reported"
+			}
+			return alias, fmt.Errorf("alias update failed: %s", reason)
+		}
+
+		select {
+		case <-ctx.Done():
+			return alias, fmt.Errorf("stopped waiting for alias to be prepared (last status: %s): %w",
+				alias.AgentAliasStatus, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,469 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the 'daemon' command for AWS Bedrock Intelligent Agents CLI.
+It serves JSON-RPC 2.0 over stdio or a unix socket, one JSON message per line, so an
+editor plugin can keep a single process running instead of starting the binary for
+every call. The AWS configuration, credentials, and runtime client are created once
+and shared by all requests, which removes the cold start from every invocation after
+the first. Requests run concurrently and can be canceled by ID.
+*/
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/types"
+	"github.com/spf13/cobra"
+)
+
+// JSON-RPC 2.0 error codes
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInvokeFailed   = -32000 // The agent invocation failed
+	rpcCanceled       = -32800 // The request was canceled, as in the Language Server Protocol
+)
+
+// DaemonOptions contains all options for the JSON-RPC daemon
+type DaemonOptions struct {
+	ConfigFile   string
+	Socket       string // Unix socket to listen on; stdio when empty
+	Region       string
+	EndpointURL  string
+	UseFIPS      bool
+	UseDualStack bool
+	Timeout      time.Duration
+	TimeoutSet   bool
+	Verbose      bool
+}
+
+// rpcRequest is a JSON-RPC request, or a notification when it has no ID
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// rpcResponse answers one request with either a result or an error
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcNotification is sent by the daemon while a stream request runs
+type rpcNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+// rpcError is the error object of a failed request
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error returns the error message
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// daemonServer holds the default options shared by all connections, including the warm client
+type daemonServer struct {
+	defaults AgentOptions
+}
+
+// rpcConn serves the requests of one stdio session or socket connection
+type rpcConn struct {
+	server  *daemonServer
+	writeMu sync.Mutex
+	out     *json.Encoder
+	mu      sync.Mutex
+	pending map[string]context.CancelFunc // Running requests by raw JSON ID
+	wg      sync.WaitGroup
+}
+
+var daemonOpts DaemonOptions
+
+// daemonCmd represents the daemon command
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Serve agent invocations over JSON-RPC for editor integrations",
+	Long: `Serve agent invocations over JSON-RPC 2.0 on stdio or a unix socket.
+
+Every message is one line of JSON. The AWS client is created once when the daemon
+starts and reused by every request, so only the first call pays for loading the
+configuration and credentials. Requests run concurrently; responses can arrive in
+a different order than the requests were sent.
+
+Methods:
+  invoke        Invoke an agent and return the JSON response of 'invoke --format json'
+  stream        Like invoke, but send chunk, files, and returnControl notifications
+                with the request ID while the answer arrives
+  cancel        Cancel a running request: {"id": <request id>}
+  listPrompts   List the names of the available prompt templates
+
+The params of invoke and stream are the request body of 'serve':
+  {"agentId": "...", "agentAliasId": "...", "input": "...", "sessionId": "...", "memoryId": "...",
+   "prompt": "code-review", "vars": {"language": "Go"}, "timeout": "60s"}
+
+Examples:
+  # Run the daemon on stdio, as spawned by an editor plugin
+  aws-bia daemon --config ~/.aws-bia.yaml
+
+  # Listen on a unix socket shared by several editor windows, where 'aws-bia paths' shows it
+  aws-bia daemon --socket "$XDG_RUNTIME_DIR/aws-bia/daemon.sock"
+
+  # Send a request by hand
+  echo '{"jsonrpc":"2.0","id":1,"method":"invoke","params":{"input":"Hello"}}' | aws-bia daemon
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		daemonOpts.TimeoutSet = cmd.Flags().Changed("timeout")
+		if err := runDaemonCommand(ctx, daemonOpts); err != nil {
+			logError("Error running daemon", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+
+	daemonCmd.Flags().StringVar(&daemonOpts.ConfigFile, "config", "", "Path to configuration file (yaml)")
+	daemonCmd.Flags().StringVar(&daemonOpts.Socket, "socket", "", "Listen on this unix socket instead of stdin and stdout")
+	daemonCmd.Flags().StringVar(&daemonOpts.Region, "region", "", "AWS region to use (defaults to AWS_REGION environment variable)")
+	daemonCmd.Flags().StringVar(&daemonOpts.EndpointURL, "endpoint-url", "", "Send agent runtime requests to this URL instead of the regional endpoint (e.g. a VPC endpoint or local mock)")
+	daemonCmd.Flags().BoolVar(&daemonOpts.UseFIPS, "use-fips", false, "Use FIPS endpoints for AWS requests")
+	daemonCmd.Flags().BoolVar(&daemonOpts.UseDualStack, "use-dualstack", false, "Use dual-stack (IPv4 and IPv6) endpoints for AWS requests")
+	daemonCmd.Flags().DurationVar(&daemonOpts.Timeout, "timeout", DefaultTimeout, "Default timeout for each invocation")
+	daemonCmd.Flags().BoolVar(&daemonOpts.Verbose, "verbose", false, "Enable verbose output (written to stderr)")
+}
+
+// runDaemonCommand creates the shared client and serves JSON-RPC until the input ends or the context is canceled
+func runDaemonCommand(ctx context.Context, opts DaemonOptions) error {
+	InitLogger(opts.Verbose)
+	defer SyncLogger()
+
+	defaults := AgentOptions{
+		Region:         opts.Region,
+		EndpointURL:    opts.EndpointURL,
+		UseFIPS:        opts.UseFIPS,
+		UseDualStack:   opts.UseDualStack,
+		Timeout:        opts.Timeout,
+		MaxDurationSet: opts.TimeoutSet,
+		OutputFormat:   OutputFormatJSON,
+		FileUseCase:    FileUseCaseCodeInterpreter,
+		Verbose:        opts.Verbose,
+		Clients:        NewRuntimeClients(nil),
+	}
+	v, err := LoadConfigForCommand(opts.ConfigFile, "daemon", opts.Verbose)
+	if err != nil {
+		return err
+	}
+	applyAgentConfig(v, &defaults)
+	applyTimeoutSetting(v, &defaults)
+
+	client, err := NewAWSHelper(defaults).CreateClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS client: %w", err)
+	}
+
+	// Resolving credentials now keeps SSO and assume-role lookups out of the first request
+	if _, err := client.Options().Credentials.Retrieve(ctx); err != nil {
+		LogWarn("Could not resolve AWS credentials yet, each request will try again: %v", err)
+	}
+
+	server := &daemonServer{defaults: defaults}
+	if opts.Socket == "" {
+		return server.serveConn(ctx, os.Stdin, os.Stdout)
+	}
+	return server.listen(ctx, opts.Socket)
+}
+
+// listen accepts connections on a unix socket, each one is served like a stdio session
+func (s *daemonServer) listen(ctx context.Context, path string) error {
+	// A socket left behind by a daemon that was killed would make Listen fail
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return fmt.Errorf("another daemon is listening on '%s'", path)
+		}
+		os.Remove(path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on '%s': %w", path, err)
+	}
+	defer os.Remove(path)
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to restrict permissions of '%s': %w", path, err)
+	}
+	fmt.Fprintf(os.Stderr, "Listening on unix://%s\n", path)
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+
+			// Closing the connection on shutdown ends the blocked read of serveConn
+			connCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			go func() {
+				<-connCtx.Done()
+				conn.Close()
+			}()
+			if err := s.serveConn(connCtx, conn, conn); err != nil {
+				logVerbose(s.defaults, "Connection closed: %v", err)
+			}
+		}()
+	}
+}
+
+// serveConn reads requests line by line and answers them until the input ends.
+// Requests still running at the end of the input are answered before it returns.
+func (s *daemonServer) serveConn(ctx context.Context, in io.Reader, out io.Writer) error {
+	c := &rpcConn{server: s, out: json.NewEncoder(out), pending: make(map[string]context.CancelFunc)}
+	defer c.wg.Wait()
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), maxRequestBodySize)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		c.handle(ctx, line)
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to read request: %w", err)
+	}
+	return nil
+}
+
+// handle dispatches one message; invoke and stream run in the background
+func (c *rpcConn) handle(ctx context.Context, line []byte) {
+	var req rpcRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		c.reply(nil, nil, &rpcError{Code: rpcParseError, Message: fmt.Sprintf("invalid JSON: %v", err)})
+		return
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		c.reply(req.ID, nil, &rpcError{Code: rpcInvalidRequest, Message: `request must have "jsonrpc": "2.0" and a method`})
+		return
+	}
+
+	switch req.Method {
+	case "cancel":
+		var params struct {
+			ID json.RawMessage `json:"id"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil || len(params.ID) == 0 {
+			c.reply(req.ID, nil, &rpcError{Code: rpcInvalidParams, Message: `cancel requires {"id": <request id>}`})
+			return
+		}
+		c.reply(req.ID, map[string]bool{"canceled": c.cancel(params.ID)}, nil)
+	case "listPrompts":
+		prompts := NewPromptManager(c.server.defaults.PromptDirs).GetAvailablePrompts()
+		sort.Strings(prompts)
+		if prompts == nil {
+			prompts = []string{}
+		}
+		c.reply(req.ID, map[string][]string{"prompts": prompts}, nil)
+	case "invoke", "stream":
+		if len(req.ID) == 0 {
+			return // Nobody would receive the answer of a notification
+		}
+		reqCtx, cancel := context.WithCancel(ctx)
+		key := string(req.ID)
+		c.mu.Lock()
+		if _, running := c.pending[key]; running {
+			c.mu.Unlock()
+			cancel()
+			c.reply(req.ID, nil, &rpcError{Code: rpcInvalidRequest, Message: fmt.Sprintf("request %s is already running", key)})
+			return
+		}
+		c.pending[key] = cancel
+		c.mu.Unlock()
+
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			defer func() {
+				c.mu.Lock()
+				delete(c.pending, key)
+				c.mu.Unlock()
+				cancel()
+			}()
+			result, err := c.invoke(reqCtx, req)
+			c.reply(req.ID, result, err)
+		}()
+	default:
+		c.reply(req.ID, nil, &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("unknown method '%s'", req.Method)})
+	}
+}
+
+// cancel stops a running request and reports whether there was one
+func (c *rpcConn) cancel(id json.RawMessage) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cancel, ok := c.pending[string(id)]
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// invoke runs an invoke or stream request and returns its JSON response
+func (c *rpcConn) invoke(ctx context.Context, req rpcRequest) (interface{}, *rpcError) {
+	var params InvokeRequest
+	decoder := json.NewDecoder(bytes.NewReader(req.Params))
+	decoder.DisallowUnknownFields()
+	if len(req.Params) > 0 {
+		if err := decoder.Decode(&params); err != nil {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf("invalid params: %v", err)}
+		}
+	}
+	opts, err := requestAgentOptions(c.server.defaults, params)
+	if err != nil {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	result, err := c.runInvocation(timeoutCtx, NewAWSHelper(opts), req)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return nil, &rpcError{Code: rpcCanceled, Message: "request canceled"}
+		}
+		logVerbose(opts, "Request %s failed: %v", req.ID, err)
+		return nil, &rpcError{Code: rpcInvokeFailed, Message: err.Error()}
+	}
+	return result, nil
+}
+
+// runInvocation invokes the agent, relaying stream events as notifications for stream requests
+func (c *rpcConn) runInvocation(ctx context.Context, awsHelper *AWSHelper, req rpcRequest) (interface{}, error) {
+	opts := awsHelper.Options
+	opts.EnableStreaming = req.Method == "stream"
+	awsHelper.Options = opts
+
+	output, err := invokeAgent(ctx, awsHelper)
+	if err != nil {
+		return nil, err
+	}
+
+	if !opts.EnableStreaming {
+		var body bytes.Buffer
+		formatter := NewResponseFormatter(opts, &body)
+		formatter.FileHelper = awsHelper.FileHelper
+		if err := formatter.FormatAndWriteResponse(output); err != nil {
+			return nil, err
+		}
+		return json.RawMessage(bytes.TrimSpace(body.Bytes())), nil
+	}
+
+	stream := output.GetStream()
+	if stream == nil {
+		return nil, fmt.Errorf("no response stream available")
+	}
+	defer stream.Close()
+
+	processor := NewStreamProcessor(opts, io.Discard, false)
+	processor.OnEvent = func(event types.ResponseStream) {
+		switch v := event.(type) {
+		case *types.ResponseStreamMemberChunk:
+			if len(v.Value.Bytes) > 0 {
+				c.notify("chunk", map[string]interface{}{"id": req.ID, "text": string(v.Value.Bytes)})
+			}
+		case *types.ResponseStreamMemberFiles:
+			files := make([]map[string]interface{}, 0, len(v.Value.Files))
+			for _, file := range v.Value.Files {
+				info := map[string]interface{}{"size": len(file.Bytes)}
+				if file.Name != nil {
+					info["name"] = *file.Name
+				}
+				if file.Type != nil {
+					info["type"] = *file.Type
+				}
+				files = append(files, info)
+			}
+			c.notify("files", map[string]interface{}{"id": req.ID, "files": files})
+		case *types.ResponseStreamMemberReturnControl:
+			c.notify("returnControl", map[string]interface{}{"id": req.ID, "returnControl": returnControlPayloadJSON(v.Value)})
+		}
+	}
+
+	result, err := processor.ProcessStream(stream)
+	if err != nil {
+		return nil, err
+	}
+	return NewResponseFormatter(opts, io.Discard).buildJSONResponse(output, result), nil
+}
+
+// reply sends the response to a request; notifications other than invalid ones get none
+func (c *rpcConn) reply(id json.RawMessage, result interface{}, rpcErr *rpcError) {
+	if len(id) == 0 {
+		if rpcErr == nil || (rpcErr.Code != rpcParseError && rpcErr.Code != rpcInvalidRequest) {
+			return
+		}
+		id = json.RawMessage("null")
+	}
+	c.write(rpcResponse{JSONRPC: "2.0", ID: id, Result: result, Error: rpcErr})
+}
+
+// notify sends a notification to the client
+func (c *rpcConn) notify(method string, params interface{}) {
+	c.write(rpcNotification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// write encodes one message as a line; concurrent requests take turns
+func (c *rpcConn) write(message interface{}) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if err := c.out.Encode(message); err != nil {
+		logVerbose(c.server.defaults, "Failed to write JSON-RPC message: %v", err)
+	}
+}
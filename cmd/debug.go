@@ -0,0 +1,141 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the 'debug' command group for the AWS Bedrock Intelligent Agents CLI.
+Its subcommands help investigating problems in responses, such as replaying a stream
+dump through the stream processor and formatter with the original frame timing.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime"
+	"github.com/spf13/cobra"
+)
+
+// DebugReplayStreamOptions contains all options for replaying a stream dump
+type DebugReplayStreamOptions struct {
+	Speed        string
+	OutputFormat string
+	Color        string
+	EnableTrace  bool
+	Verbose      bool
+}
+
+var debugReplayStreamOpts DebugReplayStreamOptions
+
+// debugCmd represents the debug command
+var debugCmd = &cobra.Command{
+	Use:   "debug",
+	Short: "Tools for debugging agent responses",
+	Long:  `Tools for investigating how agent responses are received and rendered.`,
+}
+
+// debugReplayStreamCmd represents the debug replay-stream command
+var debugReplayStreamCmd = &cobra.Command{
+	Use:   "replay-stream <stream.bin>",
+	Short: "Replay a stream dump through the response formatter",
+	Long: `Replay a stream dump written with 'invoke --dump-stream' through the stream
+processor and formatter.
+
+Every event-stream frame is delivered on its own, at the time it originally
+arrived divided by --speed, so problems that only show up with specific chunk
+boundaries or timing can be reproduced without calling AWS.
+
+Examples:
+  # Capture a stream
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Your question" --stream --dump-stream stream.bin
+
+  # Replay it twice as fast
+  aws-bia debug replay-stream stream.bin --speed 2x
+
+  # Render it as JSON without delays
+  aws-bia debug replay-stream stream.bin --speed max --format json`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		if err := runDebugReplayStreamCommand(ctx, args[0], debugReplayStreamOpts); err != nil {
+			logError("Error replaying stream", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(debugCmd)
+	debugCmd.AddCommand(debugReplayStreamCmd)
+
+	debugReplayStreamCmd.Flags().StringVar(&debugReplayStreamOpts.Speed, "speed", "1x", "Playback speed such as 1x, 2x, or 0.5x; max replays without delays")
+	debugReplayStreamCmd.Flags().StringVar(&debugReplayStreamOpts.OutputFormat, "format", OutputFormatText, "Output format: text or json")
+	debugReplayStreamCmd.Flags().StringVar(&debugReplayStreamOpts.Color, "color", ColorAuto, "Colorize text output: auto, always, or never")
+	debugReplayStreamCmd.Flags().BoolVar(&debugReplayStreamOpts.EnableTrace, "trace", false, "Collect trace events contained in the dump (adds token usage to JSON output)")
+	debugReplayStreamCmd.Flags().BoolVar(&debugReplayStreamOpts.Verbose, "verbose", false, "Log every processed event")
+}
+
+// runDebugReplayStreamCommand renders a stream dump at the requested speed
+func runDebugReplayStreamCommand(ctx context.Context, path string, replayOpts DebugReplayStreamOptions) error {
+	InitLogger(replayOpts.Verbose)
+	defer SyncLogger()
+
+	speed, err := parseReplaySpeed(replayOpts.Speed)
+	if err != nil {
+		return err
+	}
+	if replayOpts.OutputFormat != OutputFormatText && replayOpts.OutputFormat != OutputFormatJSON {
+		return fmt.Errorf("unsupported format '%s': must be text or json", replayOpts.OutputFormat)
+	}
+	if err := validateColorMode(replayOpts.Color); err != nil {
+		return err
+	}
+
+	dump, err := LoadStreamDump(path)
+	if err != nil {
+		return err
+	}
+	logVerbose(AgentOptions{Verbose: replayOpts.Verbose}, "Loaded %d frame(s) recorded at %s", len(dump.Frames), dump.Header.RecordedAt)
+
+	opts := AgentOptions{
+		AgentID:         dump.Header.AgentID,
+		AgentAliasID:    dump.Header.AgentAliasID,
+		SessionID:       dump.Header.SessionID,
+		EnableStreaming: true,
+		EnableTrace:     replayOpts.EnableTrace,
+		OutputFormat:    replayOpts.OutputFormat,
+		Color:           replayOpts.Color,
+		Verbose:         replayOpts.Verbose,
+		NoProgress:      true,
+		Warnings:        NewWarningCollector(),
+	}
+
+	// The request only has to pass client-side validation, the response comes from the dump
+	input := &bedrockagentruntime.InvokeAgentInput{
+		AgentId:      aws.String(nonEmpty(opts.AgentID, "replay")),
+		AgentAliasId: aws.String(nonEmpty(opts.AgentAliasID, "replay")),
+		SessionId:    aws.String(nonEmpty(opts.SessionID, "replay")),
+		InputText:    aws.String("replay"),
+	}
+
+	output, err := newStreamReplayClient(dump, speed).InvokeAgent(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to replay stream: %w", err)
+	}
+
+	opts.Warnings.Hold()
+	defer opts.Warnings.Release()
+	return NewResponseFormatter(opts, os.Stdout).FormatAndWriteResponse(output)
+}
+
+// nonEmpty returns value, or fallback when value is empty
+func nonEmpty(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,417 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the 'describe-agent' and 'describe-alias' commands for AWS Bedrock
+Intelligent Agents CLI. They show the configuration of an agent version, its foundation
+model, instruction, action groups, knowledge bases, guardrail, and idle session timeout,
+from the control-plane API. describe-alias first looks up the version an alias routes
+to, which is what invoke actually talks to.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagent"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagent/types"
+	"github.com/spf13/cobra"
+)
+
+// draftAgentVersion is the working draft of an agent
+const draftAgentVersion = "DRAFT"
+
+// DescribeOptions contains all options for describing an agent or alias
+type DescribeOptions struct {
+	ConfigFile   string
+	AgentID      string
+	AgentVersion string
+	Alias        string // Alias ID or alias name
+	Region       string
+	OutputFormat string
+	Verbose      bool
+}
+
+// agentDescription is the configuration of an agent version
+type agentDescription struct {
+	AgentID         string                   `json:"agentId"`
+	AgentName       string                   `json:"agentName"`
+	Version         string                   `json:"version"`
+	Status          string                   `json:"status"`
+	Description     string                   `json:"description,omitempty"`
+	FoundationModel string                   `json:"foundationModel,omitempty"`
+	Instruction     string                   `json:"instruction,omitempty"`
+	IdleSessionTTL  int32                    `json:"idleSessionTtlSeconds,omitempty"`
+	Guardrail       *describedGuardrail      `json:"guardrail,omitempty"`
+	ActionGroups    []describedActionGroup   `json:"actionGroups"`
+	KnowledgeBases  []describedKnowledgeBase `json:"knowledgeBases"`
+	UpdatedAt       *time.Time               `json:"updatedAt,omitempty"`
+	PreparedAt      *time.Time               `json:"preparedAt,omitempty"`
+	FailureReasons  []string                 `json:"failureReasons,omitempty"`
+	ResourceRoleArn string                   `json:"resourceRoleArn,omitempty"`
+	Collaboration   string                   `json:"collaboration,omitempty"`
+	Alias           *aliasDescription        `json:"alias,omitempty"` // Set by describe-alias
+}
+
+// aliasDescription is an alias and the version it routes to
+type aliasDescription struct {
+	AliasID     string     `json:"aliasId"`
+	AliasName   string     `json:"aliasName"`
+	Status      string     `json:"status"`
+	Description string     `json:"description,omitempty"`
+	Version     string     `json:"routedVersion"`
+	UpdatedAt   *time.Time `json:"updatedAt,omitempty"`
+}
+
+// describedGuardrail is the guardrail applied to the agent
+type describedGuardrail struct {
+	ID      string `json:"id"`
+	Version string `json:"version,omitempty"`
+}
+
+// describedActionGroup is an action group of the agent version
+type describedActionGroup struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	State       string `json:"state"`
+	Description string `json:"description,omitempty"`
+}
+
+// describedKnowledgeBase is a knowledge base associated with the agent version
+type describedKnowledgeBase struct {
+	ID          string `json:"id"`
+	State       string `json:"state"`
+	Description string `json:"description,omitempty"`
+}
+
+var describeAgentOpts, describeAliasOpts DescribeOptions
+
+// describeAgentCmd represents the describe-agent command
+var describeAgentCmd = &cobra.Command{
+	Use:   "describe-agent",
+	Short: "Show the configuration of an agent version",
+	Long: `Show the configuration of an agent version: its foundation model, instruction,
+action groups, knowledge bases, guardrail, and idle session timeout.
+
+Without --agent-version the working draft is described, which is what the
+TSTALIASID test alias invokes.
+
+Examples:
+  # Describe the draft of an agent
+  aws-bia describe-agent --agent-id abc123
+
+  # Describe a published version as JSON
+  aws-bia describe-agent --agent-id abc123 --agent-version 7 --format json
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		if err := runDescribeCommand(ctx, describeAgentOpts, false); err != nil {
+			logError("Error describing agent", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// describeAliasCmd represents the describe-alias command
+var describeAliasCmd = &cobra.Command{
+	Use:   "describe-alias",
+	Short: "Show an agent alias and the configuration of the version it routes to",
+	Long: `Show an agent alias and the configuration of the agent version it routes to,
+so you can verify what an invocation through the alias actually runs.
+
+The alias can be given by ID or by name. Without --alias the agent_alias_id of the
+configuration file is used.
+
+Examples:
+  # Describe the prod alias
+  aws-bia describe-alias --agent-id abc123 --alias prod
+
+  # Print the routed version's foundation model
+  aws-bia describe-alias --agent-id abc123 --alias prod --format json | jq -r .foundationModel
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		if err := runDescribeCommand(ctx, describeAliasOpts, true); err != nil {
+			logError("Error describing agent alias", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(describeAgentCmd)
+	rootCmd.AddCommand(describeAliasCmd)
+
+	for _, c := range []struct {
+		cmd  *cobra.Command
+		opts *DescribeOptions
+	}{{describeAgentCmd, &describeAgentOpts}, {describeAliasCmd, &describeAliasOpts}} {
+		c.cmd.Flags().StringVar(&c.opts.ConfigFile, "config", "", "Path to configuration file (yaml)")
+		c.cmd.Flags().StringVar(&c.opts.AgentID, "agent-id", "", "The ID of the agent (can be set in config file)")
+		c.cmd.Flags().StringVar(&c.opts.Region, "region", "", "AWS region to use (defaults to AWS_REGION environment variable)")
+		c.cmd.Flags().StringVar(&c.opts.OutputFormat, "format", OutputFormatText, "Output format: text or json")
+		c.cmd.Flags().BoolVar(&c.opts.Verbose, "verbose", false, "Enable verbose output")
+		registerAgentCompletions(c.cmd)
+	}
+	describeAgentCmd.Flags().StringVar(&describeAgentOpts.AgentVersion, "agent-version", draftAgentVersion, "The agent version to describe")
+	describeAliasCmd.Flags().StringVar(&describeAliasOpts.Alias, "alias", "", "The ID or name of the alias (can be set in config file as agent_alias_id)")
+}
+
+// runDescribeCommand looks up the agent version, through the alias when withAlias is set, and prints it
+func runDescribeCommand(ctx context.Context, opts DescribeOptions, withAlias bool) error {
+	InitLogger(opts.Verbose)
+	defer SyncLogger()
+
+	agentOpts := AgentOptions{
+		AgentID:      opts.AgentID,
+		AgentAliasID: opts.Alias,
+		Region:       opts.Region,
+		OutputFormat: opts.OutputFormat,
+		Verbose:      opts.Verbose,
+		Timeout:      DefaultTimeout,
+	}
+	if err := loadConfig(opts.ConfigFile, "describe", &agentOpts); err != nil {
+		return err
+	}
+
+	if agentOpts.AgentID == "" {
+		return fmt.Errorf("agent ID is required")
+	}
+	if withAlias && agentOpts.AgentAliasID == "" {
+		return fmt.Errorf("alias is required")
+	}
+	if opts.OutputFormat != OutputFormatText && opts.OutputFormat != OutputFormatJSON {
+		return fmt.Errorf("output format must be one of: %s, %s, got '%s'",
+			OutputFormatText, OutputFormatJSON, opts.OutputFormat)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, agentOpts.Timeout)
+	defer cancel()
+
+	client, err := NewAWSHelper(agentOpts).CreateAgentClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS client: %w", err)
+	}
+
+	version := opts.AgentVersion
+	var alias *aliasDescription
+	if withAlias {
+		resolved, err := ResolveAgentAlias(ctx, client, agentOpts.AgentID, agentOpts.AgentAliasID)
+		if err != nil {
+			return err
+		}
+		alias = &aliasDescription{
+			AliasID:     aws.ToString(resolved.AgentAliasId),
+			AliasName:   aws.ToString(resolved.AgentAliasName),
+			Status:      string(resolved.AgentAliasStatus),
+			Description: aws.ToString(resolved.Description),
+			Version:     aliasRoutedVersion(resolved),
+			UpdatedAt:   resolved.UpdatedAt,
+		}
+		version = alias.Version
+		logVerbose(agentOpts, "Alias %s routes to version %s", alias.AliasID, version)
+	}
+
+	desc, err := describeAgentVersion(ctx, client, agentOpts.AgentID, version)
+	if err != nil {
+		return err
+	}
+	desc.Alias = alias
+
+	return NewResponseFormatter(agentOpts, os.Stdout).FormatAgentDescription(desc)
+}
+
+// describeAgentVersion collects the configuration, action groups, and knowledge bases of an agent version
+func describeAgentVersion(ctx context.Context, client *bedrockagent.Client, agentID, version string) (*agentDescription, error) {
+	if version == "" {
+		version = draftAgentVersion
+	}
+
+	var desc *agentDescription
+	if version == draftAgentVersion {
+		out, err := client.GetAgent(ctx, &bedrockagent.GetAgentInput{AgentId: aws.String(agentID)})
+		if err != nil {
+			return nil, HandleAWSError(fmt.Errorf("failed to get agent '%s': %w", agentID, err))
+		}
+		agent := out.Agent
+		desc = &agentDescription{
+			AgentName:       aws.ToString(agent.AgentName),
+			Status:          string(agent.AgentStatus),
+			Description:     aws.ToString(agent.Description),
+			FoundationModel: aws.ToString(agent.FoundationModel),
+			Instruction:     aws.ToString(agent.Instruction),
+			IdleSessionTTL:  aws.ToInt32(agent.IdleSessionTTLInSeconds),
+			Guardrail:       describeGuardrail(agent.GuardrailConfiguration),
+			UpdatedAt:       agent.UpdatedAt,
+			PreparedAt:      agent.PreparedAt,
+			FailureReasons:  agent.FailureReasons,
+			ResourceRoleArn: aws.ToString(agent.AgentResourceRoleArn),
+			Collaboration:   string(agent.AgentCollaboration),
+		}
+	} else {
+		out, err := client.GetAgentVersion(ctx, &bedrockagent.GetAgentVersionInput{
+			AgentId:      aws.String(agentID),
+			AgentVersion: aws.String(version),
+		})
+		if err != nil {
+			return nil, HandleAWSError(fmt.Errorf("failed to get version %s of agent '%s': %w", version, agentID, err))
+		}
+		agent := out.AgentVersion
+		desc = &agentDescription{
+			AgentName:       aws.ToString(agent.AgentName),
+			Status:          string(agent.AgentStatus),
+			Description:     aws.ToString(agent.Description),
+			FoundationModel: aws.ToString(agent.FoundationModel),
+			Instruction:     aws.ToString(agent.Instruction),
+			IdleSessionTTL:  aws.ToInt32(agent.IdleSessionTTLInSeconds),
+			Guardrail:       describeGuardrail(agent.GuardrailConfiguration),
+			UpdatedAt:       agent.UpdatedAt,
+			FailureReasons:  agent.FailureReasons,
+			ResourceRoleArn: aws.ToString(agent.AgentResourceRoleArn),
+			Collaboration:   string(agent.AgentCollaboration),
+		}
+	}
+	desc.AgentID = agentID
+	desc.Version = version
+
+	desc.ActionGroups = []describedActionGroup{}
+	groups := bedrockagent.NewListAgentActionGroupsPaginator(client, &bedrockagent.ListAgentActionGroupsInput{
+		AgentId:      aws.String(agentID),
+		AgentVersion: aws.String(version),
+	})
+	for groups.HasMorePages() {
+		page, err := groups.NextPage(ctx)
+		if err != nil {
+			return nil, HandleAWSError(fmt.Errorf("failed to list action groups: %w", err))
+		}
+		for _, group := range page.ActionGroupSummaries {
+			desc.ActionGroups = append(desc.ActionGroups, describedActionGroup{
+				ID:          aws.ToString(group.ActionGroupId),
+				Name:        aws.ToString(group.ActionGroupName),
+				State:       string(group.ActionGroupState),
+				Description: aws.ToString(group.Description),
+			})
+		}
+	}
+
+	desc.KnowledgeBases = []describedKnowledgeBase{}
+	knowledgeBases := bedrockagent.NewListAgentKnowledgeBasesPaginator(client, &bedrockagent.ListAgentKnowledgeBasesInput{
+		AgentId:      aws.String(agentID),
+		AgentVersion: aws.String(version),
+	})
+	for knowledgeBases.HasMorePages() {
+		page, err := knowledgeBases.NextPage(ctx)
+		if err != nil {
+			return nil, HandleAWSError(fmt.Errorf("failed to list knowledge bases: %w", err))
+		}
+		for _, kb := range page.AgentKnowledgeBaseSummaries {
+			desc.KnowledgeBases = append(desc.KnowledgeBases, describedKnowledgeBase{
+				ID:          aws.ToString(kb.KnowledgeBaseId),
+				State:       string(kb.KnowledgeBaseState),
+				Description: aws.ToString(kb.Description),
+			})
+		}
+	}
+	return desc, nil
+}
+
+// describeGuardrail returns the guardrail of an agent, or nil when none is configured
+func describeGuardrail(config *types.GuardrailConfiguration) *describedGuardrail {
+	if config == nil || aws.ToString(config.GuardrailIdentifier) == "" {
+		return nil
+	}
+	return &describedGuardrail{ID: aws.ToString(config.GuardrailIdentifier), Version: aws.ToString(config.GuardrailVersion)}
+}
+
+// FormatAgentDescription writes the configuration of an agent version in the configured output format
+func (rf *ResponseFormatter) FormatAgentDescription(desc *agentDescription) error {
+	if rf.isJSONFormat {
+		data, err := json.MarshalIndent(desc, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal agent description: %w", err)
+		}
+		_, err = fmt.Fprintln(rf.Writer, string(data))
+		return err
+	}
+
+	label := func(name string) string { return rf.color.style(ansiBold, fmt.Sprintf("%-17s", name+":")) }
+	w := rf.Writer
+
+	if alias := desc.Alias; alias != nil {
+		fmt.Fprintf(w, "%s %s (%s)\n", label("Alias"), alias.AliasName, alias.AliasID)
+		fmt.Fprintf(w, "%s %s\n", label("Alias status"), alias.Status)
+		if alias.Description != "" {
+			fmt.Fprintf(w, "%s %s\n", label("Alias description"), alias.Description)
+		}
+		fmt.Fprintf(w, "%s %s\n\n", label("Routes to"), alias.Version)
+	}
+
+	fmt.Fprintf(w, "%s %s (%s)\n", label("Agent"), desc.AgentName, desc.AgentID)
+	fmt.Fprintf(w, "%s %s\n", label("Version"), desc.Version)
+	fmt.Fprintf(w, "%s %s\n", label("Status"), desc.Status)
+	if desc.Description != "" {
+		fmt.Fprintf(w, "%s %s\n", label("Description"), desc.Description)
+	}
+	fmt.Fprintf(w, "%s %s\n", label("Foundation model"), valueOrNone(desc.FoundationModel))
+	if desc.Guardrail != nil {
+		fmt.Fprintf(w, "%s %s (version %s)\n", label("Guardrail"), desc.Guardrail.ID, valueOrNone(desc.Guardrail.Version))
+	} else {
+		fmt.Fprintf(w, "%s none\n", label("Guardrail"))
+	}
+	if desc.IdleSessionTTL > 0 {
+		ttl := time.Duration(desc.IdleSessionTTL) * time.Second
+		fmt.Fprintf(w, "%s %s\n", label("Idle timeout"), formatDuration(ttl))
+	}
+	if desc.Collaboration != "" && desc.Collaboration != string(types.AgentCollaborationDisabled) {
+		fmt.Fprintf(w, "%s %s\n", label("Collaboration"), desc.Collaboration)
+	}
+	if desc.UpdatedAt != nil {
+		fmt.Fprintf(w, "%s %s\n", label("Updated"), desc.UpdatedAt.Format(time.RFC3339))
+	}
+	if desc.PreparedAt != nil {
+		fmt.Fprintf(w, "%s %s\n", label("Prepared"), desc.PreparedAt.Format(time.RFC3339))
+	}
+	if len(desc.FailureReasons) > 0 {
+		fmt.Fprintf(w, "%s %s\n", label("Failure reasons"), rf.color.Error(strings.Join(desc.FailureReasons, "; ")))
+	}
+
+	fmt.Fprintf(w, "\n%s\n", rf.color.style(ansiBold, fmt.Sprintf("Action groups (%d):", len(desc.ActionGroups))))
+	for _, group := range desc.ActionGroups {
+		fmt.Fprintf(w, "  - %s (%s) %s", group.Name, group.ID, group.State)
+		if group.Description != "" {
+			fmt.Fprintf(w, ": %s", group.Description)
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "\n%s\n", rf.color.style(ansiBold, fmt.Sprintf("Knowledge bases (%d):", len(desc.KnowledgeBases))))
+	for _, kb := range desc.KnowledgeBases {
+		fmt.Fprintf(w, "  - %s %s", kb.ID, kb.State)
+		if kb.Description != "" {
+			fmt.Fprintf(w, ": %s", kb.Description)
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "\n%s\n", rf.color.style(ansiBold, "Instruction:"))
+	fmt.Fprintln(w, valueOrNone(desc.Instruction))
+	return nil
+}
+
+// valueOrNone returns the value, or "none" for an empty one
+func valueOrNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
@@ -0,0 +1,294 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements diagnostic bundles for the AWS Bedrock Intelligent Agents CLI.
+With --diag-bundle a zip archive for bug reports is written when invoke finishes,
+successfully or not. It holds version information, the configuration and effective
+options, relevant environment variables, the recent log entries, and a replayable
+recording of the event stream, with credentials and other secrets redacted.
+*/
+package cmd
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime"
+	"github.com/spf13/viper"
+)
+
+// redacted replaces secret values in a diagnostic bundle
+const redacted = "[REDACTED]"
+
+// secretKeyWords mark setting, header, and variable names whose values are secret when they
+// end the name, so session_token is secret while input_token_price is not
+var secretKeyWords = map[string]bool{
+	"token": true, "secret": true, "password": true, "passwd": true, "credential": true, "credentials": true,
+	"authorization": true, "apikey": true, "cookie": true, "signature": true,
+	"webhook": true, // Webhook URLs carry their token in the path
+}
+
+// secretPatterns find secrets in free text such as log messages
+var secretPatterns = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`\b(AKIA|ASIA)[A-Z0-9]{16}\b`), "${1}" + redacted},
+	{regexp.MustCompile(`(?i)\bBearer\s+[A-Za-z0-9._~+/=-]+`), "Bearer " + redacted},
+	{regexp.MustCompile(`(?i)(Signature=)[0-9a-f]+`), "${1}" + redacted},
+	{regexp.MustCompile(`(?i)\b((?:[a-z0-9]+[_-])*(?:secret[_-]access[_-]key|secret|token|password|passwd|authorization|api[_-]?key)"?\s*[:=]\s*"?)([^"\s,;&\]]+)`), "${1}" + redacted},
+}
+
+// diagEnvPrefixes select the environment variables included in a bundle
+var diagEnvPrefixes = []string{"AWS_", "AWS_BIA_", "OTEL_", "LANG", "LC_", "HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy", "TERM", "EDITOR", "VISUAL"}
+
+// DiagBundle collects the diagnostics of one invocation. A nil bundle does nothing.
+type DiagBundle struct {
+	path     string
+	started  time.Time
+	logs     *logTail
+	recorder *InvocationRecorder // Captures the event stream of the invocation
+	config   *viper.Viper
+	output   *bedrockagentruntime.InvokeAgentOutput
+	result   StreamResult
+}
+
+// NewDiagBundle starts collecting diagnostics for the archive at path, or returns nil for an empty path.
+// It must be called before InitLogger so that log entries are captured.
+func NewDiagBundle(path string) *DiagBundle {
+	if path == "" {
+		return nil
+	}
+	return &DiagBundle{
+		path:     path,
+		started:  time.Now().UTC(),
+		logs:     captureLogs(),
+		recorder: NewInvocationRecorder(),
+	}
+}
+
+// SetConfig records the loaded configuration
+func (d *DiagBundle) SetConfig(v *viper.Viper) {
+	if d != nil {
+		d.config = v
+	}
+}
+
+// SetResponse records the response of the invocation
+func (d *DiagBundle) SetResponse(output *bedrockagentruntime.InvokeAgentOutput, result StreamResult) {
+	if d != nil {
+		d.output, d.result = output, result
+	}
+}
+
+// Write creates the archive from everything collected and the final error of the command
+func (d *DiagBundle) Write(opts AgentOptions, cmdErr error) error {
+	if d == nil {
+		return nil
+	}
+	SyncLogger()
+
+	file, err := os.Create(d.path)
+	if err != nil {
+		return fmt.Errorf("failed to create diagnostics bundle '%s': %w", d.path, err)
+	}
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+	add := func(name string, data []byte) {
+		if err == nil {
+			var w io.Writer
+			if w, err = archive.Create(name); err == nil {
+				_, err = w.Write(data)
+			}
+		}
+	}
+
+	add("version.txt", []byte(diagVersion()))
+	add("summary.txt", []byte(redactText(d.summary(opts, cmdErr))))
+	add("options.json", diagJSON(redactOptions(opts)))
+	add("environment.txt", []byte(diagEnvironment()))
+	if d.config != nil {
+		add("config.json", diagJSON(map[string]interface{}{
+			"file":     d.config.ConfigFileUsed(),
+			"settings": redactValue("", d.config.AllSettings()),
+		}))
+	}
+	add("logs.jsonl", []byte(redactText(string(d.logs.Bytes()))))
+
+	// The recording replays the stream with 'aws-bia invoke --replay recording.json'
+	if recording := d.recorder.Recording(opts, d.output, d.result); len(recording.Stream) > 0 || recording.StatusCode != 0 {
+		for name := range recording.Header {
+			if isSecretKey(name) {
+				recording.Header[name] = []string{redacted}
+			}
+		}
+		add("recording.json", diagJSON(recording))
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to write diagnostics bundle '%s': %w", d.path, err)
+	}
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to write diagnostics bundle '%s': %w", d.path, err)
+	}
+	return file.Close()
+}
+
+// summary describes the outcome of the invocation
+func (d *DiagBundle) summary(opts AgentOptions, cmdErr error) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Command:  %s\n", strings.Join(os.Args, " "))
+	fmt.Fprintf(&b, "Started:  %s\n", d.started.Format(time.RFC3339))
+	fmt.Fprintf(&b, "Duration: %s\n", time.Since(d.started).Round(time.Millisecond))
+	if cmdErr != nil {
+		fmt.Fprintf(&b, "Error:    %v\n", cmdErr)
+	} else {
+		b.WriteString("Error:    none\n")
+	}
+	if d.output != nil && d.output.SessionId != nil {
+		fmt.Fprintf(&b, "Session:  %s\n", *d.output.SessionId)
+	}
+	fmt.Fprintf(&b, "Response: %d characters, %d citation(s), %d file(s), return control: %t\n",
+		len(d.result.Text), len(d.result.Citations), len(d.result.Files), d.result.HasReturnControl)
+	return b.String()
+}
+
+// diagVersion describes the build and platform
+func diagVersion() string {
+	info := getVersionInfo()
+	return fmt.Sprintf("Version:    %s\nCommit:     %s\nBuilt:      %s\nGo version: %s\nOS/Arch:    %s/%s\n",
+		info.version, info.commit, info.date, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
+
+// diagEnvironment lists the environment variables that affect the CLI, with secrets redacted
+func diagEnvironment() string {
+	var lines []string
+	for _, entry := range os.Environ() {
+		name, value, _ := strings.Cut(entry, "=")
+		for _, prefix := range diagEnvPrefixes {
+			if strings.HasPrefix(name, prefix) {
+				if isSecretKey(name) || name == "AWS_ACCESS_KEY_ID" || name == "OTEL_EXPORTER_OTLP_HEADERS" {
+					value = redacted
+				}
+				lines = append(lines, name+"="+redactText(value))
+				break
+			}
+		}
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// redactOptions returns the effective options without secrets or bulky upload content
+func redactOptions(opts AgentOptions) interface{} {
+	opts.Warnings, opts.Telemetry, opts.Clients = nil, nil, nil
+
+	// Inline uploads carry the file content, only their names are useful
+	inline := make([]string, len(opts.InlineUploads))
+	for i, spec := range opts.InlineUploads {
+		name, _, _ := strings.Cut(spec, "=")
+		inline[i] = name + "=" + redacted
+	}
+	opts.InlineUploads = inline
+	uploads := make([]string, len(opts.UploadFiles))
+	for i, entry := range opts.UploadFiles {
+		uploads[i] = entry
+		if isDataURI(entry) {
+			uploads[i] = uploadFileName(entry) + " (inline data)"
+		}
+	}
+	opts.UploadFiles = uploads
+
+	headers := make(map[string]string, len(opts.RequestHeaders))
+	for name := range opts.RequestHeaders {
+		headers[name] = redacted // Custom headers usually carry credentials
+	}
+	opts.RequestHeaders = headers
+
+	var raw interface{}
+	data, err := json.Marshal(opts)
+	if err == nil {
+		err = json.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return map[string]string{"error": err.Error()}
+	}
+	return redactValue("", raw)
+}
+
+// redactValue replaces the values of secret keys in decoded JSON or configuration and redacts strings
+func redactValue(key string, value interface{}) interface{} {
+	if key != "" && isSecretKey(key) {
+		return redacted
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			out[k] = redactValue(k, item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = redactValue("", item)
+		}
+		return out
+	case string:
+		return redactText(v)
+	}
+	return value
+}
+
+// isSecretKey reports whether a name suggests that its value is a secret
+func isSecretKey(name string) bool {
+	// Split snake_case, kebab-case, and CamelCase names into words
+	var words []string
+	start := 0
+	for i, r := range name {
+		switch {
+		case r == '_' || r == '-' || r == '.' || r == ' ':
+			words = append(words, strings.ToLower(name[start:i]))
+			start = i + 1
+		case i > start && unicode.IsUpper(r) && unicode.IsLower(rune(name[i-1])):
+			words = append(words, strings.ToLower(name[start:i]))
+			start = i
+		}
+	}
+	words = append(words, strings.ToLower(name[start:]))
+
+	for _, word := range words {
+		if word == "secret" || word == "password" || word == "passwd" {
+			return true
+		}
+	}
+	return secretKeyWords[words[len(words)-1]] || strings.HasSuffix(strings.Join(words, ""), "apikey")
+}
+
+// redactText replaces secrets found in free text
+func redactText(text string) string {
+	for _, p := range secretPatterns {
+		text = p.pattern.ReplaceAllString(text, p.replacement)
+	}
+	return text
+}
+
+// diagJSON encodes a bundle entry as indented JSON
+func diagJSON(v interface{}) []byte {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return []byte(fmt.Sprintf("{\"error\": %q}\n", err.Error()))
+	}
+	return append(data, '\n')
+}
@@ -0,0 +1,105 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file contains helpers for editing text in the user's editor for the AWS
+Bedrock Intelligent Agents CLI, similar to how 'git commit' composes messages.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// editorCommand returns the editor configured through $VISUAL or $EDITOR
+func editorCommand() string {
+	if editor := os.Getenv("VISUAL"); editor != "" {
+		return editor
+	}
+	if editor := os.Getenv("EDITOR"); editor != "" {
+		return editor
+	}
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+	return "vi"
+}
+
+// editText opens initial content in the user's editor and returns the saved content.
+// The suffix is used as the temporary file extension so editors can pick syntax highlighting.
+func editText(initial, suffix string) (string, error) {
+	file, err := os.CreateTemp("", "aws-bia-*"+suffix)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	path := file.Name()
+	defer os.Remove(path)
+
+	if _, err := file.WriteString(initial); err != nil {
+		file.Close()
+		return "", fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	// The editor setting may include arguments, e.g. "code --wait"
+	editor := strings.Fields(editorCommand())
+	cmd := exec.Command(editor[0], append(editor[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor '%s' failed: %w", strings.Join(editor, " "), err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read edited file: %w", err)
+	}
+	return string(data), nil
+}
+
+// editScissors separates the input from the instructions below it, which are not sent
+const editScissors = "# ------------------------ >8 ------------------------"
+
+// editInstructions explains the buffer below the scissors line
+const editInstructions = editScissors + `
+# Write the input for the agent above this line, then save and close the editor.
+# Everything from the line above down is removed. Save an empty input to cancel.
+`
+
+// composeInEditor lets the user write an input starting from initial and returns it
+// without the instructions; the result is empty when the user cancelled
+func composeInEditor(initial string) (string, error) {
+	if initial != "" && !strings.HasSuffix(initial, "\n") {
+		initial += "\n"
+	}
+
+	edited, err := editText(initial+"\n"+editInstructions, ".md")
+	if err != nil {
+		return "", err
+	}
+	if i := strings.Index(edited, editScissors); i >= 0 {
+		edited = edited[:i]
+	}
+	return strings.TrimSpace(edited), nil
+}
+
+// editInput composes the input in the editor, starting from the prompt and input given so far
+func editInput(opts *AgentOptions) error {
+	edited, err := composeInEditor(opts.InputText)
+	if err != nil {
+		return err
+	}
+	if edited == "" {
+		return fmt.Errorf("aborting invocation due to empty input")
+	}
+
+	opts.InputText = edited
+	logVerbose(*opts, "Composed %d characters of input in the editor", len([]rune(edited)))
+	return nil
+}
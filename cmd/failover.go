@@ -0,0 +1,178 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements multi-region failover for the AWS Bedrock Intelligent Agents CLI.
+With --regions the agent is invoked in the first region, and an invocation that fails with
+throttling or a regional outage-class error is sent to the next region. The agent replicated
+in each region is taken from the "regions" mapping of the configuration file.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/spf13/viper"
+)
+
+// failoverErrorCodes are the API errors that are retried in the next region
+var failoverErrorCodes = map[string]bool{
+	"ThrottlingException":           true,
+	"ServiceQuotaExceededException": true,
+	"InternalServerException":       true,
+	"ServiceUnavailableException":   true,
+	"DependencyFailedException":     true,
+	"BadGatewayException":           true,
+	"ModelNotReadyException":        true,
+}
+
+// regionAgent is the agent replica invoked in one region
+type regionAgent struct {
+	AgentID      string
+	AgentAliasID string
+}
+
+// loadRegionAgents reads the "regions" mapping of region names to agent-id:alias-id
+func loadRegionAgents(v *viper.Viper) (map[string]regionAgent, error) {
+	raw := v.GetStringMapString("regions")
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	agents := make(map[string]regionAgent, len(raw))
+	for region, value := range raw {
+		agentID, aliasID, found := strings.Cut(value, ":")
+		if !found || agentID == "" || aliasID == "" {
+			return nil, fmt.Errorf("invalid agent '%s' for region '%s' in the regions mapping, expected agent-id:alias-id", value, region)
+		}
+		agents[strings.ToLower(region)] = regionAgent{AgentID: agentID, AgentAliasID: aliasID}
+	}
+	return agents, nil
+}
+
+// validateRegions checks the --regions list
+func validateRegions(opts AgentOptions) error {
+	if len(opts.Regions) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool, len(opts.Regions))
+	for _, region := range opts.Regions {
+		if region == "" {
+			return fmt.Errorf("--regions must not contain empty region names")
+		}
+		if seen[region] {
+			return fmt.Errorf("region '%s' is listed more than once in --regions", region)
+		}
+		seen[region] = true
+	}
+	return nil
+}
+
+// resolveRegionAgents loads the agent replicated in each of the --regions. Regions without an
+// entry in the "regions" mapping invoke the agent given with the flags or agent_id settings.
+func resolveRegionAgents(v *viper.Viper, opts *AgentOptions) error {
+	configured, err := loadRegionAgents(v)
+	if err != nil {
+		return err
+	}
+
+	opts.RegionAgents = make(map[string]regionAgent, len(opts.Regions))
+	for _, region := range opts.Regions {
+		agent, ok := configured[strings.ToLower(region)]
+		if !ok {
+			agent = regionAgent{AgentID: opts.AgentID, AgentAliasID: opts.AgentAliasID}
+		}
+		opts.RegionAgents[region] = agent
+	}
+	useRegion(opts, opts.Regions[0])
+	return nil
+}
+
+// useRegion points the options at the region and the agent replicated there
+func useRegion(opts *AgentOptions, region string) {
+	opts.Region = region
+	if agent, ok := opts.RegionAgents[region]; ok {
+		opts.AgentID, opts.AgentAliasID = agent.AgentID, agent.AgentAliasID
+	}
+}
+
+// invokeWithFailover invokes the agent in each of the --regions in turn until one accepts the
+// invocation. Only the call is retried: once the response has started, errors in the stream
+// are reported as they are, since part of the answer may already have been written. The
+// helper's options are left pointing at the region that served the response.
+func invokeWithFailover(ctx context.Context, awsHelper *AWSHelper) (*bedrockagentruntime.InvokeAgentOutput, error) {
+	regions := awsHelper.Options.Regions
+	if len(regions) < 2 || awsHelper.Replay != nil {
+		return invokeAgent(ctx, awsHelper)
+	}
+
+	var failures []string
+	for i, region := range regions {
+		if awsHelper.Options.Region != region {
+			useRegion(&awsHelper.Options, region)
+			awsHelper.InlineAgent = nil // The inline agent definition is looked up in each region
+		}
+
+		output, err := invokeAgent(ctx, awsHelper)
+		if err == nil {
+			if i > 0 {
+				fmt.Fprintf(os.Stderr, "Served from %s after failover (%s)\n", region, strings.Join(failures, ", "))
+			}
+			return output, nil
+		}
+
+		if i == len(regions)-1 || ctx.Err() != nil || !isFailoverError(err) {
+			if len(failures) > 0 {
+				return nil, fmt.Errorf("%w (after failover from %s)", err, strings.Join(failures, ", "))
+			}
+			return nil, err
+		}
+		failures = append(failures, region+": "+failoverReason(err))
+		LogWarn("Invocation in %s failed, failing over to %s: %v", region, regions[i+1], err)
+	}
+	return nil, fmt.Errorf("no region to invoke the agent in")
+}
+
+// isFailoverError reports whether an invocation error is worth retrying in another region:
+// throttling, server-side failures, and requests that could not reach the regional endpoint
+func isFailoverError(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && failoverErrorCodes[apiErr.ErrorCode()] {
+		return true
+	}
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) && respErr.HTTPStatusCode() >= 500 {
+		return true
+	}
+	var sendErr *smithyhttp.RequestSendError
+	if errors.As(err, &sendErr) {
+		return true
+	}
+	var timeoutErr *TimeoutError
+	return errors.As(err, &timeoutErr) && timeoutErr.Limit == "connect timeout"
+}
+
+// failoverReason names the error that caused a failover, for the notice on stderr
+func failoverReason(err error) string {
+	var apiErr smithy.APIError
+	var timeoutErr *TimeoutError
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr.ErrorCode()
+	case errors.As(err, &timeoutErr):
+		return timeoutErr.Limit
+	default:
+		return "unreachable"
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements fan-out of response output to several writers for the AWS Bedrock
+Intelligent Agents CLI. Every writer gets its own queue and goroutine, so a slow writer
+does not hold up the others and a failing writer is dropped without aborting the stream.
+Errors are collected per writer and reported once the output is complete.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// FanoutWriter copies everything written to it to several writers independently
+type FanoutWriter struct {
+	sinks []*fanoutSink
+}
+
+// fanoutSink is one destination of a FanoutWriter with its pending chunks
+type fanoutSink struct {
+	name    string
+	w       io.Writer
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   [][]byte
+	writing bool // A chunk taken from the queue is being written
+	closed  bool
+	err     error
+	done    chan struct{}
+}
+
+// FanoutError reports the failure of a single fan-out writer
+type FanoutError struct {
+	Name string
+	Err  error
+}
+
+// Error implements the error interface
+func (e FanoutError) Error() string {
+	return fmt.Sprintf("output to %s failed: %v", e.Name, e.Err)
+}
+
+// NewFanoutWriter creates a FanoutWriter without any writers
+func NewFanoutWriter() *FanoutWriter {
+	return &FanoutWriter{}
+}
+
+// Add registers a writer under a name used in error reports
+func (f *FanoutWriter) Add(name string, w io.Writer) {
+	sink := &fanoutSink{name: name, w: w, done: make(chan struct{})}
+	sink.cond = sync.NewCond(&sink.mu)
+	f.sinks = append(f.sinks, sink)
+	go sink.run()
+}
+
+// Write queues p for every writer and never fails, so one writer cannot abort the stream
+func (f *FanoutWriter) Write(p []byte) (int, error) {
+	chunk := append([]byte(nil), p...)
+	for _, sink := range f.sinks {
+		sink.enqueue(chunk)
+	}
+	return len(p), nil
+}
+
+// Flush waits until every writer has written or given up on all queued output
+func (f *FanoutWriter) Flush() error {
+	for _, sink := range f.sinks {
+		sink.wait()
+	}
+	return nil
+}
+
+// Close flushes all writers, stops their goroutines, and returns the per-writer failures
+func (f *FanoutWriter) Close() []FanoutError {
+	var failures []FanoutError
+	for _, sink := range f.sinks {
+		sink.mu.Lock()
+		sink.closed = true
+		sink.cond.Broadcast()
+		sink.mu.Unlock()
+		<-sink.done
+
+		if sink.err != nil {
+			failures = append(failures, FanoutError{Name: sink.name, Err: sink.err})
+		}
+	}
+	return failures
+}
+
+// enqueue adds a chunk unless the writer has already failed
+func (s *fanoutSink) enqueue(chunk []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil || s.closed {
+		return
+	}
+	s.queue = append(s.queue, chunk)
+	s.cond.Broadcast()
+}
+
+// wait blocks until the queue is empty and nothing is being written
+func (s *fanoutSink) wait() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for (len(s.queue) > 0 || s.writing) && s.err == nil {
+		s.cond.Wait()
+	}
+}
+
+// run writes queued chunks until the sink is closed and drained or the writer fails
+func (s *fanoutSink) run() {
+	defer close(s.done)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		for len(s.queue) == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		if len(s.queue) == 0 {
+			return
+		}
+
+		chunk := s.queue[0]
+		s.queue = s.queue[1:]
+		s.writing = true
+		s.mu.Unlock()
+
+		_, err := s.w.Write(chunk)
+
+		s.mu.Lock()
+		s.writing = false
+		if err != nil {
+			// Drop the rest of the output for this writer only
+			s.err = err
+			s.queue = nil
+		}
+		s.cond.Broadcast()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// flushWriter waits for buffered output of writers that support it
+func flushWriter(w io.Writer) {
+	if f, ok := w.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			logError("Warning: Error flushing output", err)
+		}
+	}
+}
@@ -7,22 +7,51 @@ It handles file upload, MIME type detection, and file output operations.
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Prefixes of upload file entries that are not read from the local disk
+const (
+	S3URIPrefix   = "s3://" // Fetched from S3
+	DataURIPrefix = "data:" // Content carried inline in the entry itself
+	StdinUpload   = "-"     // Read from stdin
 )
 
 // FileHelper provides methods for file-related operations
 type FileHelper struct {
 	Options         AgentOptions
-	hasUploadFiles  bool // Cache upload files check
-	uploadFileCount int  // Cache count
+	S3Client        *s3.Client // Used to fetch s3:// upload files
+	hasUploadFiles  bool       // Cache upload files check
+	uploadFileCount int        // Cache count
+
+	// Digests of the uploaded content, recorded while preparing the upload
+	uploadDigests map[string]uploadDigest
+}
+
+// uploadDigest describes the exact content that was sent for an upload file
+type uploadDigest struct {
+	Size     int64
+	SHA256   string
+	MimeType string
 }
 
 // NewFileHelper creates a new FileHelper with the given options
@@ -35,45 +64,116 @@ func NewFileHelper(opts AgentOptions) *FileHelper {
 	}
 }
 
+// uploadPreparers is how many upload files are read and inspected at the same time
+const uploadPreparers = 4
+
+// preparedUpload is an upload file read into memory for the request
+type preparedUpload struct {
+	File   types.InputFile
+	Digest uploadDigest
+}
+
 // PrepareInputFiles processes file paths from options and prepares them for upload
-func (f *FileHelper) PrepareInputFiles() ([]types.InputFile, error) {
+func (f *FileHelper) PrepareInputFiles(ctx context.Context) ([]types.InputFile, error) {
 	if !f.hasUploadFiles {
 		return nil, nil
 	}
+	const maxSize = MaxUploadSize
+
+	// Check the total size before reading anything, S3 objects only need a HEAD request for it
+	sizes, err := forEachUpload(f.Options.UploadFiles, func(filePath string) (int64, error) {
+		return f.uploadFileSize(ctx, filePath)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var totalSize int64
+	for _, size := range sizes {
+		totalSize += size
+	}
+	if totalSize > maxSize {
+		return nil, fmt.Errorf("total upload file size exceeds 10MB limit (got %s)", formatSize(totalSize))
+	}
+
+	sizeHints := make(map[string]int64, len(sizes))
+	for i, filePath := range f.Options.UploadFiles {
+		sizeHints[filePath] = sizes[i]
+	}
+	prepared, err := forEachUpload(f.Options.UploadFiles, func(filePath string) (preparedUpload, error) {
+		return f.prepareUploadFile(ctx, filePath, sizeHints[filePath], maxSize)
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	// Pre-allocate with exact capacity
 	inputFiles := make([]types.InputFile, 0, f.uploadFileCount)
-	var totalSize int64
-	const maxSize = 10 * 1024 * 1024 // 10MB limit
+	f.uploadDigests = make(map[string]uploadDigest, f.uploadFileCount)
+	totalSize = 0
+	for i, filePath := range f.Options.UploadFiles {
+		upload := prepared[i]
+		inputFiles = append(inputFiles, upload.File)
+
+		// Remember what was sent so the output can identify the exact data version
+		f.uploadDigests[filePath] = upload.Digest
+		totalSize += upload.Digest.Size
+		logVerbose(f.Options, "Added file '%s' for upload (type: %s, size: %s, sha256: %s)",
+			aws.ToString(upload.File.Name), upload.Digest.MimeType, formatSize(upload.Digest.Size), upload.Digest.SHA256)
+	}
 
-	for _, filePath := range f.Options.UploadFiles {
-		// Get file info
-		fileInfo, err := os.Stat(filePath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get file info for '%s': %w", filePath, err)
-		}
+	// S3 objects may have grown since their size was checked
+	if totalSize > maxSize {
+		return nil, fmt.Errorf("total upload file size exceeds 10MB limit (got %s)", formatSize(totalSize))
+	}
+	return inputFiles, nil
+}
 
-		// Update total size and check early
-		totalSize += fileInfo.Size()
-		if totalSize > maxSize {
-			return nil, fmt.Errorf("total upload file size exceeds 10MB limit (got %.2fMB)",
-				float64(totalSize)/(1024*1024))
-		}
+// forEachUpload runs fn for every upload entry with up to uploadPreparers at once.
+// The results are in the order of the entries, and the error is the one of the first failed entry.
+func forEachUpload[T any](entries []string, fn func(filePath string) (T, error)) ([]T, error) {
+	results := make([]T, len(entries))
+	errs := make([]error, len(entries))
+
+	slots := make(chan struct{}, uploadPreparers)
+	var wg sync.WaitGroup
+	for i, entry := range entries {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, entry string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i], errs[i] = fn(entry)
+		}(i, entry)
+	}
+	wg.Wait()
 
-		// Read the file content
-		fileContent, err := os.ReadFile(filePath)
+	for _, err := range errs {
 		if err != nil {
-			return nil, fmt.Errorf("failed to read file '%s': %w", filePath, err)
+			return nil, err
 		}
+	}
+	return results, nil
+}
 
-		// Cache base name to avoid repeated calls
-		baseName := filepath.Base(filePath)
+// prepareUploadFile reads an upload file, hashing it while it is read, and detects its MIME type
+func (f *FileHelper) prepareUploadFile(ctx context.Context, filePath string, sizeHint, maxSize int64) (preparedUpload, error) {
+	hash := sha256.New()
+	fileContent, err := f.readUploadFile(ctx, filePath, sizeHint, maxSize, hash)
+	if err != nil {
+		return preparedUpload{}, err
+	}
 
-		// Detect MIME type
-		mimeType := DetectMimeType(filePath, fileContent)
+	// Cache base name to avoid repeated calls
+	baseName := uploadFileName(filePath)
 
-		// Create the input file
-		inputFile := types.InputFile{
+	// Detect MIME type, preferring the media type declared by a data: URI
+	mimeType := DetectMimeType(baseName, fileContent)
+	if declared := dataURIMediaType(filePath); declared != "" {
+		mimeType = declared
+	}
+
+	return preparedUpload{
+		File: types.InputFile{
 			Name: aws.String(baseName),
 			Source: &types.FileSource{
 				SourceType: types.FileSourceTypeByteContent,
@@ -83,18 +183,115 @@ func (f *FileHelper) PrepareInputFiles() ([]types.InputFile, error) {
 				},
 			},
 			UseCase: types.FileUseCase(f.Options.FileUseCase),
+		},
+		Digest: uploadDigest{
+			Size:     int64(len(fileContent)),
+			SHA256:   hex.EncodeToString(hash.Sum(nil)),
+			MimeType: mimeType,
+		},
+	}, nil
+}
+
+// uploadFileSize returns the size of a local or S3 upload file
+func (f *FileHelper) uploadFileSize(ctx context.Context, filePath string) (int64, error) {
+	if isDataURI(filePath) {
+		_, _, data, err := parseDataURI(filePath)
+		return int64(len(data)), err
+	}
+	if !isS3URI(filePath) {
+		fileInfo, err := os.Stat(filePath)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get file info for '%s': %w", filePath, err)
+		}
+		return fileInfo.Size(), nil
+	}
+
+	bucket, key, err := parseS3URI(filePath)
+	if err != nil {
+		return 0, err
+	}
+	if f.S3Client == nil {
+		return 0, fmt.Errorf("no S3 client available to fetch '%s'", filePath)
+	}
+
+	out, err := f.S3Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get object info for '%s': %w", filePath, err)
+	}
+	return aws.ToInt64(out.ContentLength), nil
+}
+
+// readUploadFile reads the content of a local or S3 upload file into hash as well, reading at most maxSize bytes.
+// sizeHint is the expected size, so the content is read into a buffer of the right size at once.
+func (f *FileHelper) readUploadFile(ctx context.Context, filePath string, sizeHint, maxSize int64, hash io.Writer) ([]byte, error) {
+	if isDataURI(filePath) {
+		_, _, data, err := parseDataURI(filePath)
+		if err != nil {
+			return nil, err
+		}
+		hash.Write(data)
+		return data, nil
+	}
+	if !isS3URI(filePath) {
+		file, err := os.Open(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file '%s': %w", filePath, err)
 		}
+		defer file.Close()
 
-		inputFiles = append(inputFiles, inputFile)
-		logVerbose(f.Options, "Added file '%s' for upload (type: %s, size: %d bytes)",
-			baseName, mimeType, len(fileContent))
+		fileContent, err := readLimited(file, sizeHint, maxSize, hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file '%s': %w", filePath, err)
+		}
+		if int64(len(fileContent)) > maxSize {
+			return nil, fmt.Errorf("file '%s' exceeds the 10MB upload limit", filePath)
+		}
+		return fileContent, nil
 	}
 
-	return inputFiles, nil
+	bucket, key, err := parseS3URI(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if f.S3Client == nil {
+		return nil, fmt.Errorf("no S3 client available to fetch '%s'", filePath)
+	}
+
+	out, err := f.S3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download '%s': %w", filePath, err)
+	}
+	defer out.Body.Close()
+
+	// The object may have changed since HeadObject, so never read more than the limit
+	fileContent, err := readLimited(out.Body, sizeHint, maxSize, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download '%s': %w", filePath, err)
+	}
+	if int64(len(fileContent)) > maxSize {
+		return nil, fmt.Errorf("object '%s' exceeds the 10MB upload limit", filePath)
+	}
+
+	logVerbose(f.Options, "Downloaded '%s' (%s)", filePath, formatSize(int64(len(fileContent))))
+	return fileContent, nil
 }
 
-// HandleFileOutput processes agent-generated files and optionally saves them to disk
-func (f *FileHelper) HandleFileOutput(files []types.OutputFile) ([]string, error) {
+// readLimited reads up to maxSize+1 bytes from r, copying them to hash, so the caller can detect oversized content
+func readLimited(r io.Reader, sizeHint, maxSize int64, hash io.Writer) ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, 0, min(sizeHint, maxSize)+bytes.MinRead))
+	_, err := buf.ReadFrom(io.TeeReader(io.LimitReader(r, maxSize+1), hash))
+	return buf.Bytes(), err
+}
+
+// HandleFileOutput processes agent-generated files and optionally saves them to disk,
+// recording them in the manifest of the output directory
+func (f *FileHelper) HandleFileOutput(output *bedrockagentruntime.InvokeAgentOutput, files []types.OutputFile) ([]string, error) {
 	if len(files) == 0 || f.Options.FilesOutputDir == "" {
 		return nil, nil
 	}
@@ -105,28 +302,45 @@ func (f *FileHelper) HandleFileOutput(files []types.OutputFile) ([]string, error
 	}
 
 	savedFiles := make([]string, 0, len(files))
-	for i, file := range files {
+	entries := make([]SavedFileEntry, 0, len(files))
+	defer func() {
+		if err := updateSavedFilesManifest(f.Options.FilesOutputDir, entries); err != nil {
+			f.Options.Warnings.Warn(WarningFileSave, "error updating the saved files manifest", err)
+		}
+	}()
+
+	for _, file := range files {
 		if file.Name == nil {
 			continue // Skip files without names
 		}
 
-		// Clean the filename to avoid path traversal attacks
-		safeFileName := filepath.Base(*file.Name)
-		outputPath := filepath.Join(f.Options.FilesOutputDir, safeFileName)
+		// The sanitized name never leaves the output directory, or replaces the manifest
+		name := sanitizeOutputName(*file.Name)
+		if name == savedFilesManifestName {
+			name = "_" + name
+		}
+		outputPath := filepath.Join(f.Options.FilesOutputDir, name)
+		if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+			return savedFiles, fmt.Errorf("failed to create directory for '%s': %w", outputPath, err)
+		}
 
-		// Add index to filename if it already exists
-		if _, err := os.Stat(outputPath); err == nil {
-			ext := filepath.Ext(safeFileName)
-			base := strings.TrimSuffix(safeFileName, ext)
-			outputPath = filepath.Join(f.Options.FilesOutputDir, fmt.Sprintf("%s_%d%s", base, i+1, ext))
+		outputPath, save, err := resolveOutputPath(outputPath, f.Options.OnConflict)
+		if err != nil {
+			return savedFiles, err
+		}
+		if !save {
+			logVerbose(f.Options, "Skipped existing file for %s", *file.Name)
+			continue
 		}
 
 		// Write the file
-		if err := os.WriteFile(outputPath, file.Bytes, 0644); err != nil {
+		if err := writeFileAtomic(outputPath, file.Bytes, 0644); err != nil {
 			return savedFiles, fmt.Errorf("failed to save file '%s': %w", outputPath, err)
 		}
 
 		savedFiles = append(savedFiles, outputPath)
+		entries = append(entries, newSavedFileEntry(f.Options.FilesOutputDir, outputPath, file, output))
+		runSavedFileHook(f.Options, outputPath)
 	}
 
 	return savedFiles, nil
@@ -140,19 +354,100 @@ func (f *FileHelper) FormatUploadedFilesInfo() ([]map[string]interface{}, error)
 
 	uploadedFiles := make([]map[string]interface{}, 0, f.uploadFileCount)
 	for _, file := range f.Options.UploadFiles {
-		if fileInfo, err := os.Stat(file); err == nil {
-			uploadedFiles = append(uploadedFiles, map[string]interface{}{
-				"name": filepath.Base(file),
-				"size": fileInfo.Size(),
-				"path": file,
-			})
+		digest, ok := f.uploadDigests[file]
+		if !ok {
+			if isS3URI(file) || isDataURI(file) {
+				// Remote and inline content is only known once it has been prepared for upload
+				info := map[string]interface{}{"name": uploadFileName(file)}
+				if isS3URI(file) {
+					info["path"] = file
+				} else {
+					info["source"] = "inline"
+				}
+				uploadedFiles = append(uploadedFiles, info)
+				continue
+			}
+
+			var err error
+			digest, err = digestLocalFile(file)
+			if err != nil {
+				continue
+			}
 		}
+
+		info := map[string]interface{}{
+			"name":     uploadFileName(file),
+			"size":     digest.Size,
+			"path":     file,
+			"sha256":   digest.SHA256,
+			"mimeType": digest.MimeType,
+		}
+		if isDataURI(file) {
+			// Inline content has no path worth repeating in the output
+			delete(info, "path")
+			info["source"] = "inline"
+		}
+		uploadedFiles = append(uploadedFiles, info)
 	}
 	return uploadedFiles, nil
 }
 
+// uploadedSize returns the size of an upload file as sent, or as found on disk before the upload
+func (f *FileHelper) uploadedSize(file string) (int64, bool) {
+	if digest, ok := f.uploadDigests[file]; ok {
+		return digest.Size, true
+	}
+	if isS3URI(file) || isDataURI(file) {
+		return 0, false
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return 0, false
+	}
+	return info.Size(), true
+}
+
+// digestLocalFile computes the size, SHA-256 checksum, and MIME type of a local file
+func digestLocalFile(filePath string) (uploadDigest, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return uploadDigest{}, err
+	}
+	defer file.Close()
+
+	// MIME detection only looks at the first 512 bytes
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return uploadDigest{}, err
+	}
+	head = head[:n]
+
+	hash := sha256.New()
+	hash.Write(head)
+	rest, err := io.Copy(hash, file)
+	if err != nil {
+		return uploadDigest{}, err
+	}
+
+	return uploadDigest{
+		Size:     int64(n) + rest,
+		SHA256:   hex.EncodeToString(hash.Sum(nil)),
+		MimeType: DetectMimeType(filePath, head),
+	}, nil
+}
+
+// OutputFileBackups is the number of rotated output files that are kept, <name>.1 being the newest
+const OutputFileBackups = 5
+
+// OutputFileOptions controls how PrepareOutput opens the output file
+type OutputFileOptions struct {
+	Append  bool  // Add to the end of an existing file instead of replacing it
+	MaxSize int64 // Rotate an existing file of at least this size to <name>.1 first, 0 for no rotation
+}
+
 // PrepareOutput sets up the output destination based on the options
-func PrepareOutput(outputFile string) (io.Writer, func(), error) {
+func PrepareOutput(outputFile string, fileOpts OutputFileOptions) (io.Writer, func(), error) {
 	if outputFile == "" {
 		return os.Stdout, nil, nil
 	}
@@ -166,8 +461,18 @@ func PrepareOutput(outputFile string) (io.Writer, func(), error) {
 		}
 	}
 
+	if fileOpts.MaxSize > 0 {
+		if err := rotateOutputFile(outputFile, fileOpts.MaxSize); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	// Try to open the file
-	file, err := os.Create(outputFile)
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if fileOpts.Append {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	file, err := os.OpenFile(outputFile, flags, 0666)
 	if err != nil {
 		if os.IsPermission(err) {
 			return nil, nil, fmt.Errorf("permission denied when creating output file '%s': %w", outputFile, err)
@@ -182,6 +487,37 @@ func PrepareOutput(outputFile string) (io.Writer, func(), error) {
 	}, nil
 }
 
+// rotateOutputFile renames an output file that reached maxSize to <name>.1, shifting older
+// backups up and dropping the oldest, so the next output starts a new file
+func rotateOutputFile(path string, maxSize int64) error {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() < maxSize {
+		return nil
+	}
+
+	for n := OutputFileBackups - 1; n >= 1; n-- {
+		older := fmt.Sprintf("%s.%d", path, n)
+		if err := os.Rename(older, fmt.Sprintf("%s.%d", path, n+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rotate output file '%s': %w", older, err)
+		}
+	}
+	if err := os.Rename(path, path+".1"); err != nil {
+		return fmt.Errorf("failed to rotate output file '%s': %w", path, err)
+	}
+	return nil
+}
+
+// timestampedOutputPath inserts the time into the name of an output file, like
+// response-20250101-120000.txt, and makes it unique when that file already exists
+func timestampedOutputPath(path string, t time.Time) string {
+	ext := filepath.Ext(path)
+	stamped := strings.TrimSuffix(path, ext) + "-" + t.Format("20060102-150405") + ext
+	if unique, _, err := resolveOutputPath(stamped, ConflictRename); err == nil {
+		return unique
+	}
+	return stamped
+}
+
 // DetectMimeType returns the MIME type of a file based on its content and extension
 func DetectMimeType(filePath string, content []byte) string {
 	// First try to detect from content
@@ -218,3 +554,163 @@ func DetectMimeType(filePath string, content []byte) string {
 
 	return mimeType
 }
+
+// isS3URI reports whether an upload file entry refers to an S3 object
+func isS3URI(filePath string) bool {
+	return strings.HasPrefix(filePath, S3URIPrefix)
+}
+
+// parseS3URI splits an s3://bucket/key URI into its bucket and key
+func parseS3URI(uri string) (string, string, error) {
+	bucket, key, found := strings.Cut(strings.TrimPrefix(uri, S3URIPrefix), "/")
+	if !found || bucket == "" || key == "" || strings.HasSuffix(key, "/") {
+		return "", "", fmt.Errorf("invalid S3 URI '%s', expected s3://bucket/key", uri)
+	}
+	return bucket, key, nil
+}
+
+// uploadFileName returns the file name sent to the agent for an upload file entry
+func uploadFileName(filePath string) string {
+	if isDataURI(filePath) {
+		if name, _, _, err := parseDataURI(filePath); err == nil && name != "" {
+			return name
+		}
+		return "inline"
+	}
+	if isS3URI(filePath) {
+		return path.Base(filePath)
+	}
+	return filepath.Base(filePath)
+}
+
+// isDataURI reports whether an upload file entry carries its content inline
+func isDataURI(filePath string) bool {
+	return strings.HasPrefix(filePath, DataURIPrefix)
+}
+
+// parseDataURI decodes a data:[<mediatype>][;name=<name>][;base64],<data> URI
+func parseDataURI(uri string) (name, mediaType string, data []byte, err error) {
+	header, payload, found := strings.Cut(strings.TrimPrefix(uri, DataURIPrefix), ",")
+	if !found {
+		return "", "", nil, fmt.Errorf("invalid data URI, expected data:[<mediatype>][;base64],<data>")
+	}
+
+	params := strings.Split(header, ";")
+	mediaType = params[0]
+	isBase64 := false
+	for _, param := range params[1:] {
+		switch {
+		case param == "base64":
+			isBase64 = true
+		case strings.HasPrefix(param, "name="):
+			if name, err = url.PathUnescape(strings.TrimPrefix(param, "name=")); err != nil {
+				return "", "", nil, fmt.Errorf("invalid name in data URI: %w", err)
+			}
+			name = filepath.Base(name)
+		}
+	}
+
+	if isBase64 {
+		data, err = base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			return "", "", nil, fmt.Errorf("invalid base64 content in data URI for '%s': %w", name, err)
+		}
+	} else {
+		decoded, err := url.PathUnescape(payload)
+		if err != nil {
+			return "", "", nil, fmt.Errorf("invalid content in data URI for '%s': %w", name, err)
+		}
+		data = []byte(decoded)
+	}
+
+	return name, mediaType, data, nil
+}
+
+// dataURIMediaType returns the media type declared by a data: URI entry, if any
+func dataURIMediaType(filePath string) string {
+	if !isDataURI(filePath) {
+		return ""
+	}
+	header, _, _ := strings.Cut(strings.TrimPrefix(filePath, DataURIPrefix), ",")
+	mediaType, _, _ := strings.Cut(header, ";")
+	return mediaType
+}
+
+// readStdinUpload replaces the '-' upload entry with the content of stdin as a data: URI,
+// so it is sized, typed, and sent like an inline upload
+func readStdinUpload(opts *AgentOptions, stdin *os.File) error {
+	index := -1
+	for i, entry := range opts.UploadFiles {
+		if entry != StdinUpload {
+			continue
+		}
+		if index != -1 {
+			return fmt.Errorf("only one upload file can be read from stdin")
+		}
+		index = i
+	}
+
+	if index == -1 {
+		if opts.StdinFilename != "" {
+			return fmt.Errorf("--stdin-filename requires --upload-files -")
+		}
+		return nil
+	}
+	if opts.StdinFilename == "" {
+		return fmt.Errorf("--upload-files - requires --stdin-filename to name the uploaded file")
+	}
+	if isTerminal(stdin) {
+		return fmt.Errorf("--upload-files - reads from stdin, but stdin is a terminal")
+	}
+
+	// Never buffer more than the limit, a runaway producer is cut off instead
+	content, err := io.ReadAll(io.LimitReader(stdin, MaxUploadSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to read upload file from stdin: %w", err)
+	}
+	if len(content) > MaxUploadSize {
+		return fmt.Errorf("upload file '%s' read from stdin exceeds the 10MB upload limit", opts.StdinFilename)
+	}
+	if len(content) == 0 {
+		return fmt.Errorf("upload file '%s' read from stdin is empty", opts.StdinFilename)
+	}
+
+	name := filepath.Base(opts.StdinFilename)
+	logVerbose(*opts, "Read upload file '%s' from stdin (type: %s, size: %s)",
+		name, DetectMimeType(name, content), formatSize(int64(len(content))))
+
+	uploads := append([]string(nil), opts.UploadFiles...)
+	uploads[index] = DataURIPrefix + ";name=" + url.PathEscape(name) + ";base64," + base64.StdEncoding.EncodeToString(content)
+	opts.UploadFiles = uploads
+	return nil
+}
+
+// inlineUploadToDataURI converts a name=BASE64 or name=data:... flag value into a data: URI entry
+func inlineUploadToDataURI(spec string) (string, error) {
+	name, content, found := strings.Cut(spec, "=")
+	if !found || name == "" || content == "" {
+		return "", fmt.Errorf("invalid inline upload, expected name=BASE64 or name=data:...")
+	}
+	nameParam := ";name=" + url.PathEscape(filepath.Base(name))
+
+	if isDataURI(content) {
+		header, payload, found := strings.Cut(content, ",")
+		if !found {
+			return "", fmt.Errorf("invalid data URI for inline upload '%s'", name)
+		}
+		if !strings.Contains(header, ";name=") {
+			// Keep the name next to the media type, before any ;base64 marker
+			mediaType, params, _ := strings.Cut(header, ";")
+			header = mediaType + nameParam
+			if params != "" {
+				header += ";" + params
+			}
+		}
+		return header + "," + payload, nil
+	}
+
+	if _, err := base64.StdEncoding.DecodeString(content); err != nil {
+		return "", fmt.Errorf("invalid base64 content for inline upload '%s': %w", name, err)
+	}
+	return DataURIPrefix + nameParam + ";base64," + content, nil
+}
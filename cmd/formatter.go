@@ -7,83 +7,194 @@ It handles different output formats (text and JSON) and manages the display of s
 package cmd
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/types"
+	"github.com/aws/smithy-go"
 )
 
 // ResponseFormatter handles formatting and output of agent responses
 type ResponseFormatter struct {
 	Options        AgentOptions
 	Writer         io.Writer
+	Notices        io.Writer // Receives the header, session footer, and file notices of text output
 	FileHelper     *FileHelper
-	isJSONFormat   bool // Cache format check
-	hasUploadFiles bool // Cache upload files check
+	isJSONFormat   bool                   // Cache format check
+	isTemplate     bool                   // Cache template format check
+	hasUploadFiles bool                   // Cache upload files check
+	lastResult     StreamResult           // Content collected by the last formatted response
+	lastDocument   map[string]interface{} // JSON document of the last response, when one was built
+	color          colorizer              // Styles text output when writing to a terminal
+	noticeColor    colorizer              // Styles the notices when they are written to a terminal
+
+	// ServedRegion is the region that answered when failing over between --regions
+	ServedRegion string
+
+	// Progress is stopped before any output is written; nil when disabled
+	Progress *progressIndicator
+
+	// RequestSent is when the request was sent, the start of the stream timing
+	RequestSent time.Time
+
+	// Context is canceled when the user interrupts; the partial answer is still written
+	Context context.Context
 }
 
 // NewResponseFormatter creates a new ResponseFormatter
 func NewResponseFormatter(opts AgentOptions, writer io.Writer) *ResponseFormatter {
+	notices := noticeWriter(opts, writer)
 	return &ResponseFormatter{
 		Options:        opts,
 		Writer:         writer,
 		FileHelper:     NewFileHelper(opts),
-		isJSONFormat:   opts.OutputFormat == "json",
+		isJSONFormat:   opts.OutputFormat == OutputFormatJSON,
+		isTemplate:     opts.OutputFormat == OutputFormatTemplate,
 		hasUploadFiles: len(opts.UploadFiles) > 0,
+		color:          colorizer{enabled: useColor(opts.Color, writer)},
+		Notices:        notices,
+		noticeColor:    colorizer{enabled: useColor(opts.Color, notices)},
 	}
 }
 
+// noticeWriter returns where the decorations around the answer go. They are written to
+// stderr, so that piping stdout captures only the answer, unless --verbose-output is given.
+func noticeWriter(opts AgentOptions, writer io.Writer) io.Writer {
+	if opts.VerboseOutput || writer == io.Discard {
+		return writer
+	}
+	return os.Stderr
+}
+
 // FormatAndWriteResponse formats the response based on the output format and writes it to the writer
 func (rf *ResponseFormatter) FormatAndWriteResponse(output *bedrockagentruntime.InvokeAgentOutput) error {
-	if rf.isJSONFormat {
-		return rf.writeJSONResponse(output)
+	rf.lastResult = StreamResult{}
+	rf.lastDocument = nil
+
+	var err error
+	switch {
+	case rf.Options.PostHookReplace && rf.Options.PostHook != "":
+		err = rf.writePostHookResponse(output)
+	case rf.Options.Query != "":
+		err = rf.writeQueryResponse(output)
+	case rf.isJSONFormat:
+		err = rf.writeJSONResponse(output)
+	case rf.isTemplate:
+		err = rf.writeTemplateResponse(output)
+	case rf.Options.Quiet:
+		err = rf.writeQuietResponse(output)
+	default:
+		err = rf.writeTextResponse(output)
+	}
+
+	// Otherwise the post hook observes a response that was handled, if only partly
+	if (err == nil || rf.lastResult.Interrupted) && rf.Options.PostHook != "" && !rf.Options.PostHookReplace {
+		rf.runObservingPostHook(output)
+	}
+
+	// Export the calls the agent handed back so a caller can execute them, unless the local
+	// tools run them; the caller relies on the file, so failing to write it fails the invocation
+	if err == nil && rf.Options.ReturnControlOut != "" && rf.lastResult.ReturnControl != nil &&
+		!rf.Options.Tools.Handles(rf.lastResult.ReturnControl) {
+		sessionID := rf.Options.SessionID
+		if output != nil && output.SessionId != nil {
+			sessionID = *output.SessionId
+		}
+		if err = writeReturnControlPayload(rf.Options.ReturnControlOut, sessionID, *rf.lastResult.ReturnControl); err == nil {
+			logVerbose(rf.Options, "Wrote return-control payload to %s", rf.Options.ReturnControlOut)
+		}
+	}
+
+	return err
+}
+
+// newStreamProcessor creates a stream processor that keeps the progress indicator up to date
+func (rf *ResponseFormatter) newStreamProcessor(writeOutput bool) *StreamProcessor {
+	processor := NewStreamProcessor(rf.Options, rf.Writer, writeOutput)
+	processor.RequestSent = rf.RequestSent
+	processor.Context = rf.Context
+	if rf.Progress != nil {
+		writesText := writeOutput && !rf.isJSONFormat && !rf.isTemplate
+		processor.OnEvent = func(event types.ResponseStream) {
+			rf.Progress.eventReceived(event, writesText)
+		}
 	}
-	return rf.writeTextResponse(output)
+	return processor
+}
+
+// LastResult returns the stream content collected by the last call to FormatAndWriteResponse
+func (rf *ResponseFormatter) LastResult() StreamResult {
+	return rf.lastResult
 }
 
 // writeTextResponse formats the response as text and writes it to the writer
 func (rf *ResponseFormatter) writeTextResponse(output *bedrockagentruntime.InvokeAgentOutput) error {
+	// Pause the spinner while the header is written
+	rf.Progress.Stop()
+	defer rf.Progress.Stop()
+
 	// Write header
-	fmt.Fprintln(rf.Writer, "Agent Response:")
+	fmt.Fprintln(rf.Notices, rf.noticeColor.style(ansiBold, "Agent Response:"))
 
 	// Show uploaded files info if any
 	if rf.hasUploadFiles {
-		fmt.Fprintf(rf.Writer, "[Uploaded %d file(s) to agent]\n", len(rf.Options.UploadFiles))
+		fmt.Fprintf(rf.Notices, "[Uploaded %d file(s) to agent]\n", len(rf.Options.UploadFiles))
 		for i, file := range rf.Options.UploadFiles {
-			baseName := filepath.Base(file)
-			if fileInfo, err := fileInfo(file); err == nil {
-				fmt.Fprintf(rf.Writer, "  %d. %s (%.2f KB)\n", i+1, baseName, float64(fileInfo.Size())/1024)
+			baseName := uploadFileName(file)
+			if size, ok := rf.FileHelper.uploadedSize(file); ok {
+				fmt.Fprintf(rf.Notices, "  %d. %s (%s)\n", i+1, baseName, formatSize(size))
 			} else {
-				fmt.Fprintf(rf.Writer, "  %d. %s\n", i+1, baseName)
+				fmt.Fprintf(rf.Notices, "  %d. %s\n", i+1, baseName)
 			}
 		}
-		fmt.Fprintln(rf.Writer)
+		fmt.Fprintln(rf.Notices)
 	}
 
 	// Process the event stream if available
 	stream := output.GetStream()
 	if stream != nil {
 		// Process the stream and write output in real-time
-		processor := NewStreamProcessor(rf.Options, rf.Writer, true)
-		_, citations, outputFiles, _, err := processor.ProcessStream(stream)
-		if err != nil {
+		// A translation shown in place of the answer is written once the answer is complete
+		rf.Progress.Start(PhaseWaitingForAgent)
+		processor := rf.newStreamProcessor(!rf.replacingWithTranslation())
+		result, err := processor.ProcessStream(stream)
+		rf.Progress.Stop()
+		rf.lastResult = result
+		if err != nil && !result.Interrupted {
 			return err
 		}
 
+		// The answer ends its own line when the footer does not follow it in the output
+		if rf.Notices != rf.Writer && result.Text != "" && !strings.HasSuffix(result.Text, "\n") && !rf.replacingWithTranslation() {
+			fmt.Fprintln(rf.Writer)
+		}
+		if rf.translating() {
+			rf.writeTextTranslation(true)
+		}
+		if result.Interrupted {
+			fmt.Fprintf(rf.Notices, "\n%s", rf.noticeColor.Banner("[Interrupted, the answer is incomplete]"))
+		}
+
 		// Save any generated files if specified
-		if len(outputFiles) > 0 && rf.Options.FilesOutputDir != "" {
-			savedFiles, err := rf.FileHelper.HandleFileOutput(outputFiles)
+		if len(result.Files) > 0 && rf.Options.FilesOutputDir != "" {
+			savedFiles, err := rf.FileHelper.HandleFileOutput(output, result.Files)
+			rf.lastResult.SavedFiles = savedFiles
 			if err != nil {
-				logError("Warning: Error saving files", err)
+				rf.Options.Warnings.Warn(WarningFileSave, "error saving files", err)
 			} else if len(savedFiles) > 0 {
-				fmt.Fprintf(rf.Writer, "\n[Saved %d files to %s]\n", len(savedFiles), rf.Options.FilesOutputDir)
+				fmt.Fprintf(rf.Notices, "\n%s\n", rf.noticeColor.Notice(fmt.Sprintf("[Saved %d files to %s]", len(savedFiles), rf.Options.FilesOutputDir)))
 				for i, file := range savedFiles {
-					fmt.Fprintf(rf.Writer, "  %d. %s\n", i+1, file)
+					fmt.Fprintf(rf.Notices, "  %d. %s\n", i+1, rf.noticeColor.Notice(file))
 				}
 			}
 		}
@@ -91,79 +202,260 @@ func (rf *ResponseFormatter) writeTextResponse(output *bedrockagentruntime.Invok
 		// Print session ID if returned
 		rf.writeSessionInfo(output)
 
-		// Print citation information if available
-		rf.writeCitationsTextOutput(citations)
+		// Print citation information if available, in the style chosen with --citations
+		switch rf.Options.Citations {
+		case CitationsNone:
+		case CitationsJSON:
+			rf.writeCitationsJSONOutput(result.Citations)
+		default:
+			rf.writeCitationsTextOutput(result.Citations)
+		}
+		return err
 	} else {
-		fmt.Fprintln(rf.Writer, "[No response content available]")
+		fmt.Fprintln(rf.Notices, "[No response content available]")
 		rf.writeSessionInfo(output)
 	}
 
 	return nil
 }
 
+// writeQuietResponse writes only the answer text, for capturing it in scripts
+func (rf *ResponseFormatter) writeQuietResponse(output *bedrockagentruntime.InvokeAgentOutput) error {
+	stream := output.GetStream()
+	if stream == nil {
+		return nil
+	}
+
+	result, err := rf.newStreamProcessor(!rf.replacingWithTranslation()).ProcessStream(stream)
+	rf.lastResult = result
+	if result.Text != "" && !strings.HasSuffix(result.Text, "\n") && !rf.replacingWithTranslation() {
+		fmt.Fprintln(rf.Writer)
+	}
+	if err != nil && !result.Interrupted {
+		return err
+	}
+	if rf.translating() {
+		rf.writeTextTranslation(false)
+	}
+
+	// Generated files are still saved, only the notices are left out
+	if len(result.Files) > 0 && rf.Options.FilesOutputDir != "" {
+		savedFiles, saveErr := rf.FileHelper.HandleFileOutput(output, result.Files)
+		rf.lastResult.SavedFiles = savedFiles
+		if saveErr != nil {
+			rf.Options.Warnings.Warn(WarningFileSave, "error saving files", saveErr)
+		}
+	}
+	return err
+}
+
 // writeJSONResponse formats the response as JSON and writes it to the writer
 func (rf *ResponseFormatter) writeJSONResponse(output *bedrockagentruntime.InvokeAgentOutput) error {
 	// Process stream content if available
-	var textContent string
-	var citations []types.Citation
-	var outputFiles []types.OutputFile
-	var hasReturnControl bool
+	var result StreamResult
+	var streamErr error
 
 	stream := output.GetStream()
 	if stream != nil {
-		processor := NewStreamProcessor(rf.Options, rf.Writer, false)
-		var err error
-		textContent, citations, outputFiles, hasReturnControl, err = processor.ProcessStream(stream)
-		if err != nil {
+		processor := rf.newStreamProcessor(false)
+		result, streamErr = processor.ProcessStream(stream)
+		rf.lastResult = result
+	}
+	rf.Progress.Stop()
+
+	// On failure still emit a well-formed document with the partial content and the error
+	response := rf.buildJSONResponse(output, result)
+	if streamErr != nil && !result.Interrupted {
+		response["error"] = jsonErrorObject(streamErr)
+		response["partial"] = true
+	}
+
+	if err := rf.writeJSON(response); err != nil {
+		return err
+	}
+	return streamErr
+}
+
+// writeQueryResponse applies the --query expression to the JSON response and writes the results
+func (rf *ResponseFormatter) writeQueryResponse(output *bedrockagentruntime.InvokeAgentOutput) error {
+	code, err := compileQuery(rf.Options.Query)
+	if err != nil {
+		return err
+	}
+
+	var result StreamResult
+	if stream := output.GetStream(); stream != nil {
+		processor := rf.newStreamProcessor(false)
+		result, err = processor.ProcessStream(stream)
+		rf.lastResult = result
+		rf.Progress.Stop()
+		if err != nil && !result.Interrupted {
 			return err
 		}
 	}
+	rf.Progress.Stop()
+
+	if queryErr := writeQueryResult(rf.Writer, code, rf.buildJSONResponse(output, result)); queryErr != nil {
+		return queryErr
+	}
+	return err
+}
+
+// WriteJSONError writes a JSON document describing an error that occurred before any response was received
+func (rf *ResponseFormatter) WriteJSONError(err error) error {
+	if !rf.isJSONFormat {
+		return nil
+	}
+
+	response := map[string]interface{}{
+		"content":   "",
+		"partial":   true,
+		"error":     jsonErrorObject(err),
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	if warnings := rf.Options.Warnings.Take(); len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+	return rf.writeJSON(response)
+}
+
+// writeJSON marshals a response document and writes it to the writer
+func (rf *ResponseFormatter) writeJSON(response map[string]interface{}) error {
+	jsonData, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal response to JSON: %w", err)
+	}
+
+	_, err = rf.Writer.Write(jsonData)
+	return err
+}
+
+// jsonErrorObject describes an error for JSON output
+func jsonErrorObject(err error) map[string]interface{} {
+	errorInfo := map[string]interface{}{
+		"message": err.Error(),
+	}
+
+	var apiErr smithy.APIError
+	var timeoutErr *TimeoutError
+	switch {
+	case errors.Is(err, context.Canceled):
+		errorInfo["type"] = "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		errorInfo["type"] = "timeout"
+		if errors.As(err, &timeoutErr) {
+			errorInfo["limit"] = timeoutErr.Limit
+		}
+	case errors.As(err, &apiErr):
+		errorInfo["type"] = "aws"
+		errorInfo["code"] = apiErr.ErrorCode()
+	default:
+		errorInfo["type"] = "error"
+	}
+
+	return errorInfo
+}
+
+// buildJSONResponse saves any generated files and assembles the JSON response document
+func (rf *ResponseFormatter) buildJSONResponse(
+	output *bedrockagentruntime.InvokeAgentOutput,
+	result StreamResult) map[string]interface{} {
 
 	// Save any generated files if specified in the options
-	var savedFiles []string
-	if len(outputFiles) > 0 && rf.Options.FilesOutputDir != "" {
-		var err error
-		savedFiles, err = rf.FileHelper.HandleFileOutput(outputFiles)
-		if err != nil {
-			logError("Warning: Error saving files", err)
-		} else if len(savedFiles) > 0 {
-			logVerbose(rf.Options, "Saved %d files to %s", len(savedFiles), rf.Options.FilesOutputDir)
+	savedFiles := rf.saveGeneratedFiles(output, result.Files)
+	response := rf.responseDocument(output, result, savedFiles)
+	if rf.translating() {
+		rf.addTranslation(response)
+	}
+	if rf.Options.FetchActionLogs {
+		ctx := rf.Context
+		if ctx == nil {
+			ctx = context.Background()
 		}
+		response["actionLogs"] = fetchActionLogs(ctx, rf.Options, result.Traces)
+	}
+
+	// Add the warnings reported while handling this response
+	if warnings := rf.Options.Warnings.Take(); len(warnings) > 0 {
+		response["warnings"] = warnings
 	}
 
+	rf.lastDocument = response
+	return response
+}
+
+// responseDocument assembles the JSON response document of files that were already saved
+func (rf *ResponseFormatter) responseDocument(
+	output *bedrockagentruntime.InvokeAgentOutput,
+	result StreamResult, savedFiles []string) map[string]interface{} {
+
 	// Create the base response
 	response := map[string]interface{}{
-		"content":          textContent,
+		"content":          result.Text,
 		"wasStreamingUsed": rf.Options.EnableStreaming,
 		"timestamp":        time.Now().Format(time.RFC3339),
 	}
 
 	// Add metadata to the response
-	rf.addResponseMetadata(response, output, citations, outputFiles, hasReturnControl)
+	rf.addResponseMetadata(response, output, result)
 
 	// Add saved files information if any
 	if len(savedFiles) > 0 {
 		response["savedFiles"] = savedFiles
 	}
 
-	// Marshal and write the JSON response
-	jsonData, err := json.MarshalIndent(response, "", "  ")
+	return response
+}
+
+// writeTemplateResponse renders the response through the user-provided output template
+func (rf *ResponseFormatter) writeTemplateResponse(output *bedrockagentruntime.InvokeAgentOutput) error {
+	tmpl, err := loadOutputTemplate(rf.Options)
 	if err != nil {
-		return fmt.Errorf("failed to marshal response to JSON: %w", err)
+		return err
 	}
 
-	// Write the JSON to the writer
-	_, err = rf.Writer.Write(jsonData)
+	var result StreamResult
+	if stream := output.GetStream(); stream != nil {
+		processor := rf.newStreamProcessor(false)
+		result, err = processor.ProcessStream(stream)
+		rf.lastResult = result
+		rf.Progress.Stop()
+		if err != nil && !result.Interrupted {
+			return err
+		}
+	}
+	rf.Progress.Stop()
+
+	data := newTemplateResponse(output, result, rf.saveGeneratedFiles(output, result.Files))
+	if err := tmpl.Execute(rf.Writer, data); err != nil {
+		return fmt.Errorf("failed to render output template: %w", err)
+	}
 	return err
 }
 
+// saveGeneratedFiles saves generated files when an output directory is configured
+func (rf *ResponseFormatter) saveGeneratedFiles(output *bedrockagentruntime.InvokeAgentOutput, files []types.OutputFile) []string {
+	if len(files) == 0 || rf.Options.FilesOutputDir == "" {
+		return nil
+	}
+
+	savedFiles, err := rf.FileHelper.HandleFileOutput(output, files)
+	if err != nil {
+		rf.Options.Warnings.Warn(WarningFileSave, "error saving files", err)
+		return nil
+	}
+	rf.lastResult.SavedFiles = savedFiles
+	if len(savedFiles) > 0 {
+		logVerbose(rf.Options, "Saved %d files to %s", len(savedFiles), rf.Options.FilesOutputDir)
+	}
+	return savedFiles
+}
+
 // addResponseMetadata adds metadata fields to the JSON response
 func (rf *ResponseFormatter) addResponseMetadata(
 	response map[string]interface{},
 	output *bedrockagentruntime.InvokeAgentOutput,
-	citations []types.Citation,
-	files []types.OutputFile,
-	hasReturnControl bool) {
+	result StreamResult) {
 
 	// Add content type
 	if output.ContentType != nil {
@@ -178,6 +470,10 @@ func (rf *ResponseFormatter) addResponseMetadata(
 		response["generatedSessionId"] = rf.Options.SessionID == ""
 	}
 
+	if rf.ServedRegion != "" {
+		response["region"] = rf.ServedRegion
+	}
+
 	// Add memory ID if available
 	if output.MemoryId != nil {
 		response["memoryId"] = output.MemoryId
@@ -192,31 +488,90 @@ func (rf *ResponseFormatter) addResponseMetadata(
 	}
 
 	// Add citations if available
-	if len(citations) > 0 {
-		response["citations"] = rf.formatCitationsForJSON(citations)
+	if len(result.Citations) > 0 && rf.Options.Citations != CitationsNone {
+		response["citations"] = rf.formatCitationsForJSON(result.Citations)
 	}
 
 	// Add files if available
-	if len(files) > 0 {
-		// Include metadata about files but not the binary content
-		fileInfos := make([]map[string]interface{}, 0, len(files))
-		for _, file := range files {
+	if len(result.Files) > 0 {
+		// The content is only included when asked for, JSON documents stay small otherwise
+		maxContent, _ := parseSize(rf.Options.ContentMaxSize)
+		fileInfos := make([]map[string]interface{}, 0, len(result.Files))
+		for _, file := range result.Files {
 			fileInfo := map[string]interface{}{
-				"name": *file.Name,
-				"size": len(file.Bytes),
+				"name":   *file.Name,
+				"size":   len(file.Bytes),
+				"sha256": fileSHA256(file),
 			}
 			if file.Type != nil {
 				fileInfo["type"] = *file.Type
 			}
+			if rf.Options.IncludeContent {
+				embedFileContent(fileInfo, file, maxContent)
+			}
 			fileInfos = append(fileInfos, fileInfo)
 		}
 		response["files"] = fileInfos
 	}
 
+	// A document of an interrupted stream has whatever arrived before Ctrl-C
+	if result.Interrupted {
+		response["interrupted"] = true
+		response["partial"] = true
+	}
+
 	// Add control return info if available
-	if hasReturnControl {
+	if result.HasReturnControl {
 		response["returnedControl"] = true
 	}
+	if result.ReturnControl != nil {
+		response["returnControl"] = returnControlPayloadJSON(*result.ReturnControl)
+	}
+
+	if rf.Options.LatencyReport {
+		response["timing"] = result.Timing.JSON()
+	}
+
+	// Add token usage if trace events reported any
+	if !result.Usage.IsZero() {
+		response["usage"] = map[string]interface{}{
+			"inputTokens":  result.Usage.InputTokens,
+			"outputTokens": result.Usage.OutputTokens,
+		}
+	}
+}
+
+// embedFileContent adds the content of a generated file to its JSON entry: text files as UTF-8
+// text, other files base64-encoded. Files over maxSize are only marked as omitted.
+func embedFileContent(fileInfo map[string]interface{}, file types.OutputFile, maxSize int64) {
+	if int64(len(file.Bytes)) > maxSize {
+		fileInfo["contentOmitted"] = true
+		LogWarn("Content of generated file '%s' (%s) is larger than --file-content-max-size and was not embedded",
+			aws.ToString(file.Name), formatSize(int64(len(file.Bytes))))
+		return
+	}
+	mediaType := aws.ToString(file.Type)
+	if mediaType == "" {
+		mediaType = DetectMimeType(aws.ToString(file.Name), file.Bytes)
+	}
+	if isTextMediaType(mediaType) && utf8.Valid(file.Bytes) {
+		fileInfo["contentEncoding"] = "utf-8"
+		fileInfo["content"] = string(file.Bytes)
+		return
+	}
+	fileInfo["contentEncoding"] = "base64"
+	fileInfo["content"] = base64.StdEncoding.EncodeToString(file.Bytes)
+}
+
+// isTextMediaType reports whether a MIME type is text that can be embedded in JSON as is
+func isTextMediaType(mediaType string) bool {
+	mediaType, _, _ = strings.Cut(mediaType, ";")
+	mediaType = strings.TrimSpace(strings.ToLower(mediaType))
+	switch mediaType {
+	case "application/json", "application/xml", "application/yaml", "application/x-yaml", "application/javascript":
+		return true
+	}
+	return strings.HasPrefix(mediaType, "text/")
 }
 
 // formatCitationsForJSON formats citations for JSON output
@@ -235,6 +590,9 @@ func (rf *ResponseFormatter) formatCitationsForJSON(citations []types.Citation)
 			citation.GeneratedResponsePart.TextResponsePart.Text != nil {
 			citationInfo["text"] = *citation.GeneratedResponsePart.TextResponsePart.Text
 		}
+		if start, end, ok := citationSpan(citation); ok {
+			citationInfo["span"] = map[string]int{"start": start, "end": end}
+		}
 
 		// Process retrieved references
 		if numRefs := len(citation.RetrievedReferences); numRefs > 0 {
@@ -244,6 +602,12 @@ func (rf *ResponseFormatter) formatCitationsForJSON(citations []types.Citation)
 
 				if ref.Location != nil {
 					refInfo["locationType"] = ref.Location.Type
+					if location := referenceLocation(ref.Location); location != "" {
+						refInfo["location"] = location
+					}
+				}
+				if page, ok := referencePage(ref); ok {
+					refInfo["pageNumber"] = page
 				}
 
 				if ref.Content != nil && ref.Content.Text != nil {
@@ -252,6 +616,8 @@ func (rf *ResponseFormatter) formatCitationsForJSON(citations []types.Citation)
 
 				if len(refInfo) > 0 {
 					refs = append(refs, refInfo)
+				} else {
+					rf.Options.Warnings.Warn(WarningCitation, "skipped a citation reference without location or content", nil)
 				}
 			}
 			if len(refs) > 0 {
@@ -261,6 +627,8 @@ func (rf *ResponseFormatter) formatCitationsForJSON(citations []types.Citation)
 
 		if len(citationInfo) > 0 {
 			formattedCitations = append(formattedCitations, citationInfo)
+		} else {
+			rf.Options.Warnings.Warn(WarningCitation, "skipped a citation without text or references", nil)
 		}
 	}
 	return formattedCitations
@@ -270,17 +638,17 @@ func (rf *ResponseFormatter) formatCitationsForJSON(citations []types.Citation)
 func (rf *ResponseFormatter) writeSessionInfo(output *bedrockagentruntime.InvokeAgentOutput) {
 	// Print session ID if returned
 	if output.SessionId != nil {
-		fmt.Fprintf(rf.Writer, "\n\nSession ID: %s (Use this ID for follow-up questions)\n", *output.SessionId)
+		fmt.Fprintf(rf.Notices, "\n\nSession ID: %s (Use this ID for follow-up questions)\n", *output.SessionId)
 	}
 
 	// Print content type
 	if output.ContentType != nil {
-		fmt.Fprintf(rf.Writer, "Content Type: %s\n", *output.ContentType)
+		fmt.Fprintf(rf.Notices, "Content Type: %s\n", *output.ContentType)
 	}
 
 	// Print memory ID if any
 	if output.MemoryId != nil {
-		fmt.Fprintf(rf.Writer, "Memory ID: %s\n", *output.MemoryId)
+		fmt.Fprintf(rf.Notices, "Memory ID: %s\n", *output.MemoryId)
 	}
 }
 
@@ -290,13 +658,16 @@ func (rf *ResponseFormatter) writeCitationsTextOutput(citations []types.Citation
 		return
 	}
 
-	fmt.Fprintln(rf.Writer, "\nCitations:")
+	fmt.Fprintf(rf.Writer, "\n%s\n", rf.color.style(ansiBold+ansiBlue, "Citations:"))
 	for i, citation := range citations {
 		fmt.Fprintf(rf.Writer, "  %d. ", i+1)
 		if citation.GeneratedResponsePart != nil &&
 			citation.GeneratedResponsePart.TextResponsePart != nil &&
 			citation.GeneratedResponsePart.TextResponsePart.Text != nil {
-			fmt.Fprintf(rf.Writer, "Text: %s", *citation.GeneratedResponsePart.TextResponsePart.Text)
+			fmt.Fprintf(rf.Writer, "Text: %s", rf.color.Citation(*citation.GeneratedResponsePart.TextResponsePart.Text))
+		}
+		if start, end, ok := citationSpan(citation); ok {
+			fmt.Fprintf(rf.Writer, " (characters %d-%d)", start, end)
 		}
 		if len(citation.RetrievedReferences) > 0 {
 			for j, ref := range citation.RetrievedReferences {
@@ -304,18 +675,19 @@ func (rf *ResponseFormatter) writeCitationsTextOutput(citations []types.Citation
 
 				if ref.Location != nil {
 					fmt.Fprintf(rf.Writer, " Type: %s", ref.Location.Type)
+					if location := referenceLocation(ref.Location); location != "" {
+						fmt.Fprintf(rf.Writer, ", Source: %s", location)
+					}
+				}
+				if page, ok := referencePage(ref); ok {
+					fmt.Fprintf(rf.Writer, ", Page: %d", page)
 				}
 
 				if ref.Content != nil && ref.Content.Text != nil {
-					fmt.Fprintf(rf.Writer, ", Text: %s", *ref.Content.Text)
+					fmt.Fprintf(rf.Writer, ", Text: %s", rf.color.Citation(*ref.Content.Text))
 				}
 			}
 		}
 		fmt.Fprintln(rf.Writer)
 	}
 }
-
-// Helper function to get file info
-func fileInfo(filePath string) (os.FileInfo, error) {
-	return os.Stat(filePath)
-}
@@ -0,0 +1,379 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the 'guardrail' command group for the AWS Bedrock Intelligent Agents CLI.
+'guardrail test' sends arbitrary text through the ApplyGuardrail runtime API to show what a
+guardrail would do with it, such as blocked topics, content filters, and masked PII, without
+invoking an agent. The assessments are written by the response formatter as text or JSON.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/spf13/cobra"
+)
+
+// Content sources accepted by 'guardrail test'
+const (
+	GuardrailSourceInput  = "input"
+	GuardrailSourceOutput = "output"
+)
+
+// GuardrailTestOptions contains all options for testing a guardrail
+type GuardrailTestOptions struct {
+	ConfigFile       string
+	GuardrailID      string
+	GuardrailVersion string
+	Text             string
+	InputFile        string
+	Source           string
+	FullOutput       bool
+	OutputFormat     string
+	Region           string
+	Timeout          time.Duration
+	Verbose          bool
+}
+
+var guardrailTestOpts GuardrailTestOptions
+
+// guardrailCmd represents the guardrail command
+var guardrailCmd = &cobra.Command{
+	Use:   "guardrail",
+	Short: "Work with Bedrock guardrails",
+	Long:  `Check Bedrock guardrails directly, without invoking an agent.`,
+}
+
+// guardrailTestCmd represents the guardrail test command
+var guardrailTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Show what a guardrail does with a piece of text",
+	Long: `Send text through a guardrail with the ApplyGuardrail API and show the action taken,
+the text after masking, and the assessment of every policy: denied topics, content
+filters, word filters, sensitive information (PII and regexes), and contextual grounding.
+
+The text is given with --text, read from --file, or read from stdin. With --source
+output the text is assessed as a model response instead of a user prompt.
+
+Examples:
+  # Check a prompt against version 1 of a guardrail
+  aws-bia guardrail test --guardrail-id gr-abc123 --guardrail-version 1 --text "My SSN is 123-45-6789"
+
+  # Assess a saved answer as model output and print JSON
+  aws-bia guardrail test --guardrail-id gr-abc123 --source output --file answer.txt --format json
+
+  # Include filters that did not match, for tuning
+  echo "How should I invest my savings?" | aws-bia guardrail test --guardrail-id gr-abc123 --full
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		if err := runGuardrailTestCommand(ctx, guardrailTestOpts); err != nil {
+			logError("Error testing guardrail", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(guardrailCmd)
+	guardrailCmd.AddCommand(guardrailTestCmd)
+
+	guardrailTestCmd.Flags().StringVar(&guardrailTestOpts.ConfigFile, "config", "", "Path to configuration file (yaml)")
+	guardrailTestCmd.Flags().StringVar(&guardrailTestOpts.GuardrailID, "guardrail-id", "", "ID or ARN of the guardrail (can be set in config file)")
+	guardrailTestCmd.Flags().StringVar(&guardrailTestOpts.GuardrailVersion, "guardrail-version", "", "Guardrail version to apply (default: DRAFT, can be set in config file)")
+	guardrailTestCmd.Flags().StringVar(&guardrailTestOpts.Text, "text", "", "The text to assess")
+	guardrailTestCmd.Flags().StringVar(&guardrailTestOpts.InputFile, "file", "", "Read the text to assess from a file")
+	guardrailTestCmd.Flags().StringVar(&guardrailTestOpts.Source, "source", GuardrailSourceInput, "Assess the text as a user prompt (input) or a model response (output)")
+	guardrailTestCmd.Flags().BoolVar(&guardrailTestOpts.FullOutput, "full", false, "Include filters that did not detect anything")
+	guardrailTestCmd.Flags().StringVar(&guardrailTestOpts.OutputFormat, "format", OutputFormatText, "Output format: text or json")
+	guardrailTestCmd.Flags().StringVar(&guardrailTestOpts.Region, "region", "", "AWS region to use (defaults to AWS_REGION environment variable)")
+	guardrailTestCmd.Flags().DurationVar(&guardrailTestOpts.Timeout, "timeout", DefaultTimeout, "Timeout for the guardrail request")
+	guardrailTestCmd.Flags().BoolVar(&guardrailTestOpts.Verbose, "verbose", false, "Enable verbose output")
+	guardrailTestCmd.MarkFlagsMutuallyExclusive("text", "file")
+}
+
+// runGuardrailTestCommand applies the guardrail to the text and writes the assessment
+func runGuardrailTestCommand(ctx context.Context, opts GuardrailTestOptions) error {
+	InitLogger(opts.Verbose)
+	defer SyncLogger()
+
+	v, err := LoadConfigForCommand(opts.ConfigFile, "guardrail", opts.Verbose)
+	if err != nil {
+		return err
+	}
+	agentOpts := AgentOptions{Region: opts.Region, OutputFormat: opts.OutputFormat, Verbose: opts.Verbose}
+	applyAgentConfig(v, &agentOpts)
+	if opts.GuardrailID == "" && v.InConfig("guardrail_id") {
+		opts.GuardrailID = v.GetString("guardrail_id")
+		logVerbose(agentOpts, "Loaded guardrail ID from config: %s", opts.GuardrailID)
+	}
+	if opts.GuardrailVersion == "" && v.InConfig("guardrail_version") {
+		opts.GuardrailVersion = v.GetString("guardrail_version")
+		logVerbose(agentOpts, "Loaded guardrail version from config: %s", opts.GuardrailVersion)
+	}
+	if opts.GuardrailVersion == "" {
+		opts.GuardrailVersion = "DRAFT"
+	}
+
+	if opts.GuardrailID == "" {
+		return fmt.Errorf("guardrail ID is required (use --guardrail-id or guardrail_id in the config file)")
+	}
+	var source types.GuardrailContentSource
+	switch opts.Source {
+	case GuardrailSourceInput:
+		source = types.GuardrailContentSourceInput
+	case GuardrailSourceOutput:
+		source = types.GuardrailContentSourceOutput
+	default:
+		return fmt.Errorf("source must be one of: %s, %s, got '%s'", GuardrailSourceInput, GuardrailSourceOutput, opts.Source)
+	}
+	if opts.OutputFormat != OutputFormatText && opts.OutputFormat != OutputFormatJSON {
+		return fmt.Errorf("output format must be one of: %s, %s, got '%s'",
+			OutputFormatText, OutputFormatJSON, opts.OutputFormat)
+	}
+
+	text, err := guardrailText(opts)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	client, err := NewAWSHelper(agentOpts).CreateBedrockRuntimeClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	input := &bedrockruntime.ApplyGuardrailInput{
+		GuardrailIdentifier: aws.String(opts.GuardrailID),
+		GuardrailVersion:    aws.String(opts.GuardrailVersion),
+		Source:              source,
+		Content: []types.GuardrailContentBlock{
+			&types.GuardrailContentBlockMemberText{Value: types.GuardrailTextBlock{Text: aws.String(text)}},
+		},
+	}
+	if opts.FullOutput {
+		input.OutputScope = types.GuardrailOutputScopeFull
+	}
+
+	logVerbose(agentOpts, "Applying guardrail %s version %s to %s characters of %s",
+		opts.GuardrailID, opts.GuardrailVersion, formatCount(int64(len(text))), opts.Source)
+	output, err := client.ApplyGuardrail(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to apply guardrail: %w", err)
+	}
+
+	return NewResponseFormatter(agentOpts, os.Stdout).FormatGuardrailAssessment(output)
+}
+
+// guardrailText returns the text to assess from --text, --file, or stdin
+func guardrailText(opts GuardrailTestOptions) (string, error) {
+	text := opts.Text
+	switch {
+	case opts.InputFile != "":
+		data, err := os.ReadFile(opts.InputFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read '%s': %w", opts.InputFile, err)
+		}
+		text = string(data)
+	case text == "" && !isTerminal(os.Stdin):
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read text from stdin: %w", err)
+		}
+		text = string(data)
+	}
+
+	if strings.TrimSpace(text) == "" {
+		return "", fmt.Errorf("text to assess is required (use --text, --file, or stdin)")
+	}
+	return text, nil
+}
+
+// guardrailFinding is one filter result of a guardrail assessment
+type guardrailFinding struct {
+	Policy   string `json:"policy"`
+	Type     string `json:"type"`
+	Name     string `json:"name,omitempty"`
+	Match    string `json:"match,omitempty"`
+	Action   string `json:"action"`
+	Detected bool   `json:"detected"`
+	Details  string `json:"details,omitempty"`
+}
+
+// FormatGuardrailAssessment writes the result of ApplyGuardrail in the configured output format
+func (rf *ResponseFormatter) FormatGuardrailAssessment(output *bedrockruntime.ApplyGuardrailOutput) error {
+	var findings []guardrailFinding
+	for _, assessment := range output.Assessments {
+		findings = append(findings, guardrailFindings(assessment)...)
+	}
+	var outputs []string
+	for _, content := range output.Outputs {
+		outputs = append(outputs, aws.ToString(content.Text))
+	}
+
+	if rf.isJSONFormat {
+		response := map[string]interface{}{
+			"action":    string(output.Action),
+			"outputs":   outputs,
+			"findings":  findings,
+			"timestamp": time.Now().Format(time.RFC3339),
+		}
+		if output.ActionReason != nil {
+			response["actionReason"] = *output.ActionReason
+		}
+		if output.Usage != nil {
+			response["usage"] = guardrailUsageUnits(output.Usage)
+		}
+		return rf.writeJSON(response)
+	}
+
+	action := string(output.Action)
+	if output.Action == types.GuardrailActionGuardrailIntervened {
+		action = rf.color.Error(action)
+	} else {
+		action = rf.color.Notice(action)
+	}
+	fmt.Fprintf(rf.Writer, "%s %s\n", rf.color.style(ansiBold, "Action:"), action)
+	if output.ActionReason != nil {
+		fmt.Fprintf(rf.Writer, "Reason: %s\n", *output.ActionReason)
+	}
+
+	// The outputs hold the text with masked PII or the blocked message
+	if len(outputs) > 0 {
+		fmt.Fprintf(rf.Writer, "\n%s\n", rf.color.style(ansiBold, "Output:"))
+		for _, text := range outputs {
+			fmt.Fprintln(rf.Writer, text)
+		}
+	}
+
+	fmt.Fprintf(rf.Writer, "\n%s\n", rf.color.style(ansiBold, "Assessments:"))
+	if len(findings) == 0 {
+		fmt.Fprintln(rf.Writer, "  No policy matched")
+	}
+	policy := ""
+	for _, finding := range findings {
+		if finding.Policy != policy {
+			policy = finding.Policy
+			fmt.Fprintf(rf.Writer, "  %s:\n", policy)
+		}
+		line := fmt.Sprintf("    - %s %s", finding.Action, finding.Type)
+		if finding.Name != "" {
+			line += fmt.Sprintf(" %q", finding.Name)
+		}
+		if finding.Match != "" {
+			line += fmt.Sprintf(" matched %q", finding.Match)
+		}
+		if finding.Details != "" {
+			line += " (" + finding.Details + ")"
+		}
+		if !finding.Detected {
+			line += " [not detected]"
+		}
+		fmt.Fprintln(rf.Writer, line)
+	}
+
+	if output.Usage != nil {
+		var units []string
+		for name, count := range guardrailUsageUnits(output.Usage) {
+			if count > 0 {
+				units = append(units, fmt.Sprintf("%s %s", formatCount(int64(count)), name))
+			}
+		}
+		if len(units) > 0 {
+			sort.Strings(units)
+			fmt.Fprintf(rf.Writer, "\nUsage: %s units\n", strings.Join(units, ", "))
+		}
+	}
+	return nil
+}
+
+// guardrailFindings flattens the policy assessments into one list, in a fixed policy order
+func guardrailFindings(assessment types.GuardrailAssessment) []guardrailFinding {
+	var findings []guardrailFinding
+	if topics := assessment.TopicPolicy; topics != nil {
+		for _, topic := range topics.Topics {
+			findings = append(findings, guardrailFinding{
+				Policy: "Denied topics", Type: string(topic.Type), Name: aws.ToString(topic.Name),
+				Action: string(topic.Action), Detected: guardrailDetected(topic.Detected),
+			})
+		}
+	}
+	if content := assessment.ContentPolicy; content != nil {
+		for _, filter := range content.Filters {
+			findings = append(findings, guardrailFinding{
+				Policy: "Content filters", Type: string(filter.Type), Action: string(filter.Action),
+				Detected: guardrailDetected(filter.Detected),
+				Details:  fmt.Sprintf("confidence %s, strength %s", filter.Confidence, filter.FilterStrength),
+			})
+		}
+	}
+	if words := assessment.WordPolicy; words != nil {
+		for _, word := range words.CustomWords {
+			findings = append(findings, guardrailFinding{
+				Policy: "Word filters", Type: "CUSTOM_WORD", Match: aws.ToString(word.Match),
+				Action: string(word.Action), Detected: guardrailDetected(word.Detected),
+			})
+		}
+		for _, word := range words.ManagedWordLists {
+			findings = append(findings, guardrailFinding{
+				Policy: "Word filters", Type: string(word.Type), Match: aws.ToString(word.Match),
+				Action: string(word.Action), Detected: guardrailDetected(word.Detected),
+			})
+		}
+	}
+	if sensitive := assessment.SensitiveInformationPolicy; sensitive != nil {
+		for _, entity := range sensitive.PiiEntities {
+			findings = append(findings, guardrailFinding{
+				Policy: "Sensitive information", Type: string(entity.Type), Match: aws.ToString(entity.Match),
+				Action: string(entity.Action), Detected: guardrailDetected(entity.Detected),
+			})
+		}
+		for _, regex := range sensitive.Regexes {
+			findings = append(findings, guardrailFinding{
+				Policy: "Sensitive information", Type: "REGEX", Name: aws.ToString(regex.Name), Match: aws.ToString(regex.Match),
+				Action: string(regex.Action), Detected: guardrailDetected(regex.Detected),
+			})
+		}
+	}
+	if grounding := assessment.ContextualGroundingPolicy; grounding != nil {
+		for _, filter := range grounding.Filters {
+			findings = append(findings, guardrailFinding{
+				Policy: "Contextual grounding", Type: string(filter.Type), Action: string(filter.Action),
+				Detected: guardrailDetected(filter.Detected),
+				Details:  fmt.Sprintf("score %.2f, threshold %.2f", aws.ToFloat64(filter.Score), aws.ToFloat64(filter.Threshold)),
+			})
+		}
+	}
+	return findings
+}
+
+// guardrailDetected treats a missing detected flag as a match, older responses only list matches
+func guardrailDetected(detected *bool) bool {
+	return detected == nil || *detected
+}
+
+// guardrailUsageUnits returns the text units billed per policy
+func guardrailUsageUnits(usage *types.GuardrailUsage) map[string]int32 {
+	return map[string]int32{
+		"topicPolicy":                   aws.ToInt32(usage.TopicPolicyUnits),
+		"contentPolicy":                 aws.ToInt32(usage.ContentPolicyUnits),
+		"wordPolicy":                    aws.ToInt32(usage.WordPolicyUnits),
+		"sensitiveInformationPolicy":    aws.ToInt32(usage.SensitiveInformationPolicyUnits),
+		"sensitiveInformationFreeUnits": aws.ToInt32(usage.SensitiveInformationPolicyFreeUnits),
+		"contextualGroundingPolicy":     aws.ToInt32(usage.ContextualGroundingPolicyUnits),
+	}
+}
@@ -0,0 +1,448 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the 'history' command for AWS Bedrock Intelligent Agents CLI.
+It lists the invocations recorded in the local session store, newest first, searches
+their inputs and answers, and shows a recorded answer again with its sources and files.
+Each invocation is identified by its session ID and turn number, like 1a2b3c4d/2.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	// DefaultHistoryLimit is the number of invocations listed by default
+	DefaultHistoryLimit = 20
+
+	// historyIDLength is the number of characters of the session ID shown in history IDs
+	historyIDLength = 8
+)
+
+// HistoryOptions contains the options of the history commands
+type HistoryOptions struct {
+	Limit   int
+	AgentID string
+	Format  string
+	Color   string
+}
+
+// historyEntry is one recorded invocation, a turn of a stored session
+type historyEntry struct {
+	Session *StoredSession
+	Turn    int // 1-based
+	StoredTurn
+}
+
+// ID returns the short history ID of the entry
+func (e historyEntry) ID() string {
+	sessionID := e.Session.SessionID
+	if len(sessionID) > historyIDLength {
+		sessionID = sessionID[:historyIDLength]
+	}
+	return sessionID + "/" + strconv.Itoa(e.Turn)
+}
+
+var historyOpts HistoryOptions
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List past invocations recorded in the session store",
+	Long: `List past invocations recorded in the local session store, newest first.
+
+Every invoke, chat, and run turn is recorded in the session store unless
+--no-session-store is used. Each line shows the ID of the invocation (the start
+of its session ID and the turn number), when it was made, the agent, and the
+first line of the input and the answer.
+
+Examples:
+  # List the last 20 invocations
+  aws-bia history
+
+  # List the last 50 invocations of one agent as JSON
+  aws-bia history --agent-id abc123 --limit 50 --format json
+
+  # Find invocations that mention a term in the input or the answer
+  aws-bia history search "quarterly report"
+
+  # Show an answer again
+  aws-bia history show 1a2b3c4d/2`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runHistoryCommand(historyOpts, ""); err != nil {
+			logError("Error listing history", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// historySearchCmd represents the history search command
+var historySearchCmd = &cobra.Command{
+	Use:   "search <term>",
+	Short: "Search the inputs and answers of past invocations",
+	Long: `Search the inputs and answers of past invocations, ignoring case.
+
+Several arguments are searched as one phrase. Matching invocations are listed
+newest first with the text around the first match.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runHistoryCommand(historyOpts, strings.Join(args, " ")); err != nil {
+			logError("Error searching history", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// historyShowCmd represents the history show command
+var historyShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show a past invocation with its answer, sources, and files",
+	Long: `Show a past invocation with its answer, sources, and files.
+
+The ID is the one listed by 'aws-bia history'. Any unique start of the session ID
+works, followed by /<turn>; without a turn the last turn of the session is shown.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runHistoryShowCommand(historyOpts, args[0]); err != nil {
+			logError("Error showing invocation", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.AddCommand(historySearchCmd)
+	historyCmd.AddCommand(historyShowCmd)
+
+	historyCmd.PersistentFlags().StringVar(&historyOpts.Format, "format", OutputFormatText, "Output format: text or json")
+	historyCmd.PersistentFlags().StringVar(&historyOpts.Color, "color", ColorAuto, "Color text output: auto, always, or never")
+	for _, cmd := range []*cobra.Command{historyCmd, historySearchCmd} {
+		cmd.Flags().IntVar(&historyOpts.Limit, "limit", DefaultHistoryLimit, "Maximum number of invocations to list (0 for all)")
+		cmd.Flags().StringVar(&historyOpts.AgentID, "agent-id", "", "Only list invocations of this agent")
+	}
+}
+
+// runHistoryCommand lists the recorded invocations, only those containing term when it is not empty
+func runHistoryCommand(opts HistoryOptions, term string) error {
+	if err := validateHistoryFormat(opts); err != nil {
+		return err
+	}
+	if opts.Limit < 0 {
+		return fmt.Errorf("--limit must not be negative")
+	}
+
+	entries, err := loadHistory(opts.AgentID)
+	if err != nil {
+		return err
+	}
+	if term != "" {
+		var matches []historyEntry
+		for _, entry := range entries {
+			if containsFold(entry.Input, term) || containsFold(entry.Answer, term) {
+				matches = append(matches, entry)
+			}
+		}
+		entries = matches
+	}
+	if opts.Limit > 0 && len(entries) > opts.Limit {
+		entries = entries[:opts.Limit]
+	}
+
+	if opts.Format == OutputFormatJSON {
+		items := make([]map[string]interface{}, 0, len(entries))
+		for _, entry := range entries {
+			items = append(items, historyJSON(entry))
+		}
+		return writeHistoryJSON(os.Stdout, items)
+	}
+
+	if len(entries) == 0 {
+		if term != "" {
+			fmt.Fprintf(os.Stderr, "No invocations match '%s'\n", term)
+		} else {
+			fmt.Fprintln(os.Stderr, "No invocations recorded yet")
+		}
+		return nil
+	}
+
+	color := colorizer{enabled: useColor(opts.Color, os.Stdout)}
+	for _, entry := range entries {
+		fmt.Fprintf(os.Stdout, "%s  %s  %s  %s\n",
+			color.style(ansiBold, fmt.Sprintf("%-*s", historyIDLength+4, entry.ID())),
+			entry.Time.Local().Format("2006-01-02 15:04"),
+			entry.Session.AgentID+":"+entry.Session.AgentAliasID,
+			historySummary(entry))
+		if term != "" {
+			text := entry.Input
+			if !containsFold(text, term) {
+				text = entry.Answer
+			}
+			fmt.Fprintf(os.Stdout, "    %s\n", matchContext(text, term, color))
+		}
+	}
+	return nil
+}
+
+// runHistoryShowCommand shows a recorded invocation again
+func runHistoryShowCommand(opts HistoryOptions, id string) error {
+	if err := validateHistoryFormat(opts); err != nil {
+		return err
+	}
+
+	entries, err := loadHistory("")
+	if err != nil {
+		return err
+	}
+	entry, err := findHistoryEntry(entries, id)
+	if err != nil {
+		return err
+	}
+
+	if opts.Format == OutputFormatJSON {
+		return writeHistoryJSON(os.Stdout, historyJSON(entry))
+	}
+	return writeHistoryEntry(os.Stdout, entry, colorizer{enabled: useColor(opts.Color, os.Stdout)})
+}
+
+// validateHistoryFormat checks the --format flag of the history commands
+func validateHistoryFormat(opts HistoryOptions) error {
+	if opts.Format != OutputFormatText && opts.Format != OutputFormatJSON {
+		return fmt.Errorf("format must be %s or %s, got '%s'", OutputFormatText, OutputFormatJSON, opts.Format)
+	}
+	return validateColorMode(opts.Color)
+}
+
+// loadHistory returns every recorded turn, newest first, optionally only those of one agent
+func loadHistory(agentID string) ([]historyEntry, error) {
+	store, err := NewSessionStore()
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []historyEntry
+	for _, session := range sessions {
+		if agentID != "" && session.AgentID != agentID {
+			continue
+		}
+		for i, turn := range session.Turns {
+			entries = append(entries, historyEntry{Session: session, Turn: i + 1, StoredTurn: turn})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.After(entries[j].Time)
+	})
+	return entries, nil
+}
+
+// findHistoryEntry resolves a history ID: a unique start of a session ID, optionally followed by /<turn>
+func findHistoryEntry(entries []historyEntry, id string) (historyEntry, error) {
+	prefix, turnText, hasTurn := strings.Cut(id, "/")
+	turn := 0
+	if hasTurn {
+		n, err := strconv.Atoi(turnText)
+		if err != nil || n < 1 {
+			return historyEntry{}, fmt.Errorf("invalid history ID '%s', expected <session-id>/<turn>", id)
+		}
+		turn = n
+	}
+	if prefix == "" {
+		return historyEntry{}, fmt.Errorf("invalid history ID '%s', expected <session-id>/<turn>", id)
+	}
+
+	// Sessions are matched by prefix, an exact session ID always wins
+	var sessions []*StoredSession
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		sessionID := entry.Session.SessionID
+		if sessionID == prefix {
+			sessions = []*StoredSession{entry.Session}
+			break
+		}
+		if strings.HasPrefix(sessionID, prefix) && !seen[sessionID] {
+			seen[sessionID] = true
+			sessions = append(sessions, entry.Session)
+		}
+	}
+	switch len(sessions) {
+	case 0:
+		return historyEntry{}, fmt.Errorf("no recorded session starts with '%s'", prefix)
+	case 1:
+	default:
+		ids := make([]string, 0, len(sessions))
+		for _, session := range sessions {
+			ids = append(ids, session.SessionID)
+		}
+		return historyEntry{}, fmt.Errorf("'%s' matches %d sessions (%s), use more of the session ID", prefix, len(sessions), strings.Join(ids, ", "))
+	}
+
+	session := sessions[0]
+	if !hasTurn {
+		turn = len(session.Turns)
+	}
+	if turn < 1 || turn > len(session.Turns) {
+		return historyEntry{}, fmt.Errorf("session %s has %d turn(s), there is no turn %d", session.SessionID, len(session.Turns), turn)
+	}
+	return historyEntry{Session: session, Turn: turn, StoredTurn: session.Turns[turn-1]}, nil
+}
+
+// historySummary returns the first lines of the input and the answer for the list
+func historySummary(entry historyEntry) string {
+	summary := excerpt(firstLine(entry.Input), 40)
+	if answer := excerpt(firstLine(entry.Answer), 60); answer != "" {
+		summary += " → " + answer
+	}
+	return summary
+}
+
+// firstLine returns the first non-empty line of text
+func firstLine(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// containsFold reports whether text contains term, ignoring case
+func containsFold(text, term string) bool {
+	return strings.Contains(strings.ToLower(text), strings.ToLower(term))
+}
+
+// matchContext returns the text around the first match of term on one line, with the match highlighted
+func matchContext(text, term string, color colorizer) string {
+	const context = 40
+
+	text = strings.Join(strings.Fields(text), " ")
+	index := strings.Index(strings.ToLower(text), strings.ToLower(term))
+	if index < 0 {
+		return excerpt(text, 2*context)
+	}
+	// Lowercasing can change the byte length of some runes, fall back to the start of the text then
+	if !strings.EqualFold(text[index:min(index+len(term), len(text))], term) {
+		return excerpt(text, 2*context)
+	}
+
+	before, match, after := text[:index], text[index:index+len(term)], text[index+len(term):]
+	if utf8.RuneCountInString(before) > context {
+		runes := []rune(before)
+		before = "…" + string(runes[len(runes)-context:])
+	}
+	if utf8.RuneCountInString(after) > context {
+		after = string([]rune(after)[:context]) + "…"
+	}
+	return before + color.style(ansiBold+ansiYellow, match) + after
+}
+
+// writeHistoryEntry writes a recorded invocation in the layout of a text answer
+func writeHistoryEntry(w io.Writer, entry historyEntry, color colorizer) error {
+	session := entry.Session
+	fmt.Fprintf(w, "%s\n", color.style(ansiBold, fmt.Sprintf("Invocation %s (turn %d of %d)", entry.ID(), entry.Turn, len(session.Turns))))
+	fmt.Fprintf(w, "Session: %s\n", session.SessionID)
+	fmt.Fprintf(w, "Agent: %s (alias %s)\n", session.AgentID, session.AgentAliasID)
+	fmt.Fprintf(w, "Time: %s\n", entry.Time.Local().Format(time.RFC1123))
+	if len(entry.Command) > 0 {
+		fmt.Fprintf(w, "Command: %s\n", formatCommandLine(entry.Command))
+	}
+	if !entry.Usage.IsZero() {
+		fmt.Fprintf(w, "Tokens: %s in / %s out\n", formatCount(entry.Usage.InputTokens), formatCount(entry.Usage.OutputTokens))
+	}
+
+	fmt.Fprintln(w, "\nInput:")
+	for _, line := range strings.Split(strings.TrimRight(entry.Input, "\n"), "\n") {
+		fmt.Fprintf(w, "> %s\n", line)
+	}
+
+	fmt.Fprintln(w, "\nAgent Response:")
+	markdown := newMarkdownWriter(w, color)
+	fmt.Fprintln(markdown, strings.TrimSpace(entry.Answer))
+	markdown.Flush()
+
+	n := 0
+	for _, citation := range entry.Citations {
+		for _, ref := range citation.References {
+			if n == 0 {
+				fmt.Fprintln(w, "\nSources:")
+			}
+			n++
+			location := ref.Location
+			if location == "" {
+				location = ref.LocationType
+			}
+			fmt.Fprintf(w, "  %s %s\n", color.Citation(fmt.Sprintf("[%d]", n)), location)
+		}
+	}
+
+	files := transcriptFiles(entry.StoredTurn)
+	if len(files) > 0 {
+		fmt.Fprintln(w, "\nFiles:")
+		for _, file := range files {
+			line := fmt.Sprintf("  - %s (%s, %s)", file.Name, file.Type, formatSize(int64(file.Size)))
+			if file.Path != "" {
+				line += " saved to " + file.Path
+			}
+			fmt.Fprintln(w, color.Notice(line))
+		}
+	}
+	return nil
+}
+
+// historyJSON returns the JSON document of a recorded invocation
+func historyJSON(entry historyEntry) map[string]interface{} {
+	item := map[string]interface{}{
+		"id":           entry.ID(),
+		"sessionId":    entry.Session.SessionID,
+		"turn":         entry.Turn,
+		"agentId":      entry.Session.AgentID,
+		"agentAliasId": entry.Session.AgentAliasID,
+		"time":         entry.Time.Format(time.RFC3339),
+		"input":        entry.Input,
+		"answer":       entry.Answer,
+	}
+	if len(entry.Citations) > 0 {
+		item["citations"] = entry.Citations
+	}
+	if len(entry.Files) > 0 {
+		item["files"] = entry.Files
+	}
+	if len(entry.SavedFiles) > 0 {
+		item["savedFiles"] = entry.SavedFiles
+	}
+	if len(entry.Command) > 0 {
+		item["command"] = entry.Command
+	}
+	if !entry.Usage.IsZero() {
+		item["usage"] = map[string]interface{}{
+			"inputTokens":  entry.Usage.InputTokens,
+			"outputTokens": entry.Usage.OutputTokens,
+		}
+	}
+	return item
+}
+
+// writeHistoryJSON writes a history document as indented JSON
+func writeHistoryJSON(w io.Writer, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal history to JSON: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
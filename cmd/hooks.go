@@ -0,0 +1,99 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements post-save hooks for the AWS Bedrock Intelligent Agents CLI.
+The on_saved_file setting maps file extensions to shell commands that are run
+after a generated file has been saved, e.g. to open or post-process it. The path
+reaches the command in the AWS_BIA_FILE environment variable.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// savedFileHookPlaceholder is replaced with a reference to the path of the saved file
+const savedFileHookPlaceholder = "{}"
+
+// savedFileHookEnv holds the path of the saved file while its hook runs. The command only
+// refers to the variable, so no character of the file name the agent chose is parsed by the
+// shell; quoting would not be enough for cmd.exe, which expands %VAR% inside double quotes.
+const savedFileHookEnv = "AWS_BIA_FILE"
+
+// loadSavedFileHooks reads the on_saved_file mapping, normalizing extensions to ".ext" in lower case
+func loadSavedFileHooks(v *viper.Viper) map[string]string {
+	raw := v.GetStringMapString("on_saved_file")
+	if len(raw) == 0 {
+		return nil
+	}
+
+	hooks := make(map[string]string, len(raw))
+	for ext, command := range raw {
+		hooks["."+strings.TrimPrefix(strings.ToLower(ext), ".")] = command
+	}
+	return hooks
+}
+
+// runSavedFileHook runs the hook configured for the extension of path, if any
+func runSavedFileHook(opts AgentOptions, path string) {
+	command, ok := opts.SavedFileHooks[strings.ToLower(filepath.Ext(path))]
+	if !ok || command == "" {
+		return
+	}
+
+	reference := shellEnvReference(savedFileHookEnv)
+	if strings.Contains(command, savedFileHookPlaceholder) {
+		command = strings.ReplaceAll(command, savedFileHookPlaceholder, reference)
+	} else {
+		command += " " + reference
+	}
+
+	logVerbose(opts, "Running saved file hook: %s (%s=%s)", command, savedFileHookEnv, path)
+
+	// Hook output goes to stderr so it never mixes with the response on stdout
+	cmd := shellCommand(command)
+	cmd.Env = append(os.Environ(), savedFileHookEnv+"="+path)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		opts.Warnings.Warn(WarningHook, fmt.Sprintf("saved file hook failed for '%s'", path), err)
+	}
+}
+
+// shellCommand builds a command that runs s through the platform shell
+func shellCommand(s string) *exec.Cmd {
+	return shellCommandContext(context.Background(), s)
+}
+
+// shellCommandContext is like shellCommand, but the command is killed when ctx is done
+func shellCommandContext(ctx context.Context, s string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", s)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", s)
+}
+
+// shellEnvReference returns a reference to an environment variable as a single argument
+// for the platform shell
+func shellEnvReference(name string) string {
+	if runtime.GOOS == "windows" {
+		return `"%` + name + `%"`
+	}
+	return `"$` + name + `"`
+}
+
+// shellQuote quotes s as a single argument for the platform shell, for command lines shown to the user
+func shellQuote(s string) string {
+	if runtime.GOOS == "windows" {
+		return `"` + s + `"`
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,132 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the human-friendly number formatting of the AWS Bedrock Intelligent
+Agents CLI. File sizes, durations, and counts in text output use units like KB and 1.2s,
+and digit grouping and the decimal mark follow the locale from LC_ALL, LC_NUMERIC, or LANG.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// numberFormat holds the separators of a locale
+type numberFormat struct {
+	group   string // Thousands separator
+	decimal string // Decimal mark
+}
+
+// localeNumberFormats maps language codes to their separators; other languages use 1,234.5
+var localeNumberFormats = map[string]numberFormat{
+	"da": {".", ","}, "de": {".", ","}, "es": {".", ","}, "id": {".", ","},
+	"it": {".", ","}, "nl": {".", ","}, "pt": {".", ","}, "tr": {".", ","},
+	"cs": {" ", ","}, "fi": {" ", ","}, "fr": {" ", ","}, "nb": {" ", ","},
+	"pl": {" ", ","}, "ru": {" ", ","}, "sv": {" ", ","}, "uk": {" ", ","},
+	"de_ch": {"'", "."},
+}
+
+// currentNumberFormat is resolved once from the environment
+var currentNumberFormat = detectNumberFormat()
+
+// detectNumberFormat picks the separators of the locale set in the environment
+func detectNumberFormat() numberFormat {
+	locale := ""
+	for _, name := range []string{"LC_ALL", "LC_NUMERIC", "LANG"} {
+		if locale = os.Getenv(name); locale != "" {
+			break
+		}
+	}
+
+	// e.g. "de_DE.UTF-8" or "pt-BR"
+	locale = strings.ToLower(strings.ReplaceAll(locale, "-", "_"))
+	locale, _, _ = strings.Cut(locale, ".")
+	locale, _, _ = strings.Cut(locale, "@")
+
+	if format, ok := localeNumberFormats[locale]; ok {
+		return format
+	}
+	language, _, _ := strings.Cut(locale, "_")
+	if format, ok := localeNumberFormats[language]; ok {
+		return format
+	}
+	return numberFormat{group: ",", decimal: "."}
+}
+
+// formatCount formats an integer with the locale's digit grouping, e.g. 12,345
+func formatCount(n int64) string {
+	digits := strconv.FormatInt(n, 10)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+
+	var b strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteString(currentNumberFormat.group)
+		}
+		b.WriteRune(digit)
+	}
+	return sign + b.String()
+}
+
+// formatDecimal formats a number with one decimal place and the locale's decimal mark
+func formatDecimal(f float64) string {
+	return strings.Replace(strconv.FormatFloat(f, 'f', 1, 64), ".", currentNumberFormat.decimal, 1)
+}
+
+// formatSize formats a byte count in binary units, e.g. 512 B, 1.2 KB, or 3.4 MB
+func formatSize(bytes int64) string {
+	if bytes < 1024 {
+		return formatCount(bytes) + " B"
+	}
+
+	value := float64(bytes)
+	for _, unit := range []string{"KB", "MB", "GB"} {
+		value /= 1024
+		if value < 1023.95 || unit == "GB" {
+			return formatDecimal(value) + " " + unit
+		}
+	}
+	return "" // Not reached
+}
+
+// parseSize parses a byte count with an optional binary unit, e.g. 512, 100KB, or 10MB
+func parseSize(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		bytes  int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(value, unit.suffix) {
+			value, multiplier = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix)), unit.bytes
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size '%s', expected a number of bytes or a size like 10MB", s)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// formatDuration formats a duration in the largest fitting unit, e.g. 850ms, 1.2s, or 2m05s
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	case d < time.Minute:
+		return formatDecimal(d.Seconds()) + "s"
+	case d < time.Hour:
+		d = d.Round(time.Second)
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	}
+	d = d.Round(time.Minute)
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
@@ -0,0 +1,28 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements graceful interruption for the AWS Bedrock Intelligent Agents CLI.
+Ctrl-C while an answer streams stops reading the stream, but what has arrived is still
+written like a complete answer, marked as interrupted, and the command exits with 130.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+)
+
+// ExitCodeInterrupted is the exit status after an interrupted answer, as shells use for Ctrl-C
+const ExitCodeInterrupted = 130
+
+// ErrInterrupted is returned once the partial answer of an interrupted stream has been written
+var ErrInterrupted = errors.New("interrupted, the answer is incomplete")
+
+// isInterruption reports whether err was caused by the user canceling ctx rather than by a time limit
+func isInterruption(ctx context.Context, err error) bool {
+	if ctx == nil || err == nil || !errors.Is(ctx.Err(), context.Canceled) {
+		return false
+	}
+	var timeout *TimeoutError
+	return !errors.As(context.Cause(ctx), &timeout)
+}
@@ -9,13 +9,20 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"regexp"
 	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/types"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 // Constants for configuration
@@ -24,8 +31,9 @@ const (
 	DefaultTimeout = 30 * time.Second
 
 	// Output format options
-	OutputFormatText = "text"
-	OutputFormatJSON = "json"
+	OutputFormatText     = "text"
+	OutputFormatJSON     = "json"
+	OutputFormatTemplate = "template"
 
 	// File use case options
 	FileUseCaseCodeInterpreter = "CODE_INTERPRETER"
@@ -33,6 +41,12 @@ const (
 
 	// Maximum number of files that can be uploaded
 	MaxUploadFiles = 5
+
+	// Maximum total size of the uploaded files
+	MaxUploadSize = 10 * 1024 * 1024
+
+	// DefaultFileContentMaxSize is the largest generated file embedded in JSON output by default
+	DefaultFileContentMaxSize = "1MB"
 )
 
 // AgentOptions contains all options for invoking an agent
@@ -42,25 +56,183 @@ type AgentOptions struct {
 	AgentAliasID string
 	InputText    string
 
+	// Human-readable names resolved to AgentID and AgentAliasID through the control plane
+	AgentName string
+	AliasName string
+
 	// Optional options
 	ConfigFile      string // New field for config file path
 	SessionID       string
+	MemoryID        string // Long-term memory thread continued across sessions
 	Region          string
+	Regions         []string               // Regions to fail over between, in order; overrides Region
+	RegionAgents    map[string]regionAgent // Agent replicated in each of the Regions
 	EnableStreaming bool
-	Timeout         time.Duration
+	Timeout         time.Duration // Maximum duration of the whole invocation; 0 means no limit
+	ConnectTimeout  time.Duration // Time allowed until the response starts; 0 means no limit
+	IdleTimeout     time.Duration // Time allowed between stream events; 0 means no limit
+	MaxDurationSet  bool          // The maximum duration was given, so it also bounds streamed answers
 	OutputFormat    string
 	OutputFile      string
+	AppendOutput    bool     // Append to the output file instead of replacing it
+	TimestampOutput bool     // Insert the time into the output file name
+	OutputMaxSize   string   // Size at which the output file is rotated, e.g. 10MB
+	TeeFiles        []string // Additional files that receive a copy of the output
+	IncludeContent  bool     // Embed the content of generated files in JSON output
+	ContentMaxSize  string   // Largest generated file embedded with IncludeContent, e.g. 1MB
 	FilesOutputDir  string
+	OnConflict      string            // What to do when a generated file already exists: rename, overwrite, skip, or error
+	SavedFileHooks  map[string]string // Commands run after saving generated files, keyed by extension
+	RequestHeaders  map[string]string // Headers added to every AWS request
+	RequestHooks    []string          // Commands that observe every AWS request and may add headers
+	PostHook        string            // Command that receives the final JSON response on stdin
+	PostHookReplace bool              // Show the post hook's stdout instead of the response
+	NotifyWebhook   string            // URL that receives a summary when the command finishes or fails
+	EndpointURL     string            // Overrides the agent runtime endpoint, e.g. a VPC endpoint
+	UseFIPS         bool              // Use FIPS endpoints for all AWS requests
+	UseDualStack    bool              // Use dual-stack (IPv4 and IPv6) endpoints for all AWS requests
 	Verbose         bool
+	EnableTrace     bool
+	Latency         string // Model latency profile: standard or optimized; empty uses the agent's setting
+	NoProgress      bool   // Disable the spinner shown on stderr for non-streaming invocations
+	ProgressJSON    bool   // Write JSON progress records to stderr instead of the spinner
+	LatencyReport   bool   // Report when the stream events arrived, with a histogram of the chunk gaps
+	FetchActionLogs bool   // Show the CloudWatch Logs of the Lambda functions of action group invocations
+	Quiet           bool   // Print only the answer text, without headers, footers, and notices
+	VerboseOutput   bool   // Write headers, footers, and notices to the output with the answer instead of stderr
+	Citations       string // Citation style of text output: footnotes, inline, json, or none
+	Open            bool   // Open the output file and saved files directory after a successful invocation
+
+	// Warnings collects non-fatal problems; the pointer is shared by every copy of the options
+	Warnings *WarningCollector
+
+	// Telemetry records spans and metrics exported to OtelEndpoint (or OTEL_EXPORTER_OTLP_ENDPOINT)
+	Telemetry    *Telemetry
+	OtelEndpoint string
+
+	// Clients holds the runtime client reused by every invocation made with a copy of the options
+	Clients *RuntimeClients
+
+	// RateLimiter spaces the requests of every copy of the options to RPS per second
+	RPS         float64
+	Burst       int
+	RateLimiter *RateLimiter
+
+	// UsageBudget stops every copy of the options from sending requests once the limits are used up
+	MaxTokensTotal int64
+	MaxCost        float64
+	UsageBudget    *UsageBudget
+
+	// Session store options
+	SessionTTL     time.Duration // Idle session timeout to assume instead of the agent's idleSessionTTL
+	NoSessionStore bool          // Do not record the session or check it for expiry
+	CommandArgs    []string      // Command and flags as given, recorded with each turn for 'rerun'
+
+	// Remembered sessions in the last-session file, an alternative to passing --session-id
+	RememberSession bool
+	Continue        bool
+
+	// Clipboard integration: copy the answer, or read the input from the clipboard
+	Copy       bool
+	PasteInput bool
+
+	// Edit composes the input in $EDITOR, starting from the prompt and --input
+	Edit bool
+
+	// Assertions on the answer that fail the command, for checks in CI
+	FailIfEmpty         bool
+	ExpectRegex         []string
+	ExpectSimilar       string  // Reference text the answer must resemble
+	SimilarityThreshold float64 // Lowest similarity to ExpectSimilar that passes
+
+	// Translation of the answer with a second model or agent
+	TranslateTo      string // Language to translate the answer into, e.g. ja
+	TranslateModel   string // Model ID of the translator
+	TranslateAgent   string // Translator agent as agent-id:alias-id
+	TranslateReplace bool   // Show the translation instead of the answer
+
+	// ReturnControlOut is the file the return-control payload is written to, and
+	// ReturnControlResults the file with the results of the calls to send back
+	ReturnControlOut     string
+	ReturnControlResults string
+
+	// Local tools loaded from ToolsFile run the calls of return-control responses; the
+	// allowlist only comes from the configuration file
+	ToolsFile      string
+	ToolsAuditLog  string
+	ToolsAllowlist []string
+	Tools          *ToolRegistry
+
+	// Session state loaded from SessionStateFile and written to SaveSessionState after the invocation
+	SessionStateFile     string
+	SaveSessionState     string
+	SessionStateDocument *SessionStateDocument
+
+	// Prompt templates loaded from PromptOverrideFile; the agent is then invoked as an inline agent
+	PromptOverrideFile string
+	PromptOverrides    *types.PromptOverrideConfiguration
+
+	// Inference parameters of the orchestration prompt, added to PromptOverrides; nil keeps the agent's setting
+	Temperature *float32
+	TopP        *float32
+	MaxTokens   *int32
 
 	// File upload options
-	UploadFiles []string
-	FileUseCase string
+	UploadFiles     []string
+	InlineUploads   []string // name=BASE64 or name=data:... values, converted to data: URI upload entries
+	StdinFilename   string   // Name sent for the '-' upload entry, which is read from stdin
+	SplitLargeFiles bool     // Send text files over the upload limit in parts over several turns
+	FileUseCase     string
 
 	// Prompt options
 	PromptFile string   // Path to a specific prompt file
 	PromptName string   // Name of a prompt template from the prompt directory
 	PromptVars []string // Variables to substitute in the prompt template (format: key=value)
+	VarsFile   string   // YAML or JSON file of variables, overridden by PromptVars
+	// Required variables declared in the template's frontmatter are asked for unless NoInteractive is set
+	NoInteractive bool
+	TemplateEnv   []string // Environment variables prompt and output templates may read with env
+	PromptDirs    []string // Searched for --prompt templates before the default directories
+
+	// Knowledge base overrides sent as SessionState.KnowledgeBaseConfigurations
+	KnowledgeBaseIDs []string
+	KBResults        int    // Number of results to retrieve from each knowledge base
+	KBFilter         string // Metadata filter JSON, inline or file://path
+	KBSearchType     string // HYBRID or SEMANTIC
+
+	// Query is a jq expression applied to the JSON response before printing
+	Query string
+
+	// Color controls styled terminal output in text mode: auto, always, or never
+	Color string
+
+	// Output template options (used with --format template)
+	Template     string // Inline Go template
+	TemplateFile string // Path to a Go template file
+
+	// Refine opens each response in $EDITOR and sends added ">>" lines as the next turn
+	Refine bool
+
+	// Watch invokes the agent again whenever the prompt file or an upload file changes
+	Watch bool
+
+	// CompareWith is a stored response the answer is diffed against
+	CompareWith string
+
+	// Record/replay options
+	RecordDir  string // Directory to write the raw event stream and final response to
+	ReplayFile string // Recording to render instead of calling AWS
+	DumpStream string // File to write the timed raw event-stream frames to
+
+	// Preflight checks the region, credentials, and endpoint connection before invoking
+	Preflight bool
+
+	// DiagBundle is the zip archive of diagnostics written when the command finishes
+	DiagBundle string
+
+	// Response cache options
+	Cache    bool          // Serve identical invocations from the response cache
+	CacheTTL time.Duration // How long a cached response is served
 }
 
 var opts AgentOptions
@@ -84,17 +256,114 @@ Examples:
   # With explicit session ID for multi-turn conversations
   aws-bia invoke --agent-id abc123 --agent-alias-id def456 --session-id session123 --input "Follow-up question"
 
+  # Remember the session for the agent, then follow up without copying the session ID
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Your question" --remember-session
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Follow-up question" --continue
+
+  # Compose a long input in $EDITOR, starting from a prompt template
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --prompt code-review --edit
+
+  # Ask about the text on the clipboard and copy the answer back
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --paste-input --copy
+
+  # Use a VPC endpoint of the agent runtime, or FIPS endpoints in GovCloud
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --endpoint-url https://vpce-0123-abcd.bedrock-agent-runtime.us-east-1.vpce.amazonaws.com --input "Hello"
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --region us-gov-west-1 --use-fips --input "Hello"
+
+  # Continue the long-term memory of a user in a new session
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --memory-id user-42 --input "What did we discuss last week?"
+
+  # Test against another knowledge base with custom retrieval settings
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "What changed in 2024?" --kb-id KB12345678 --kb-results 10 --kb-filter '{"equals": {"key": "year", "value": 2024}}'
+
+  # Try an edited orchestration prompt without redeploying the agent
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Where is my order?" --prompt-override-file prompts.json
+
+  # Pipe the JSON response into a script, or show the script's output instead of the answer
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Summarize the incident" --post-hook ./notify-slack.sh
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "List open orders" --post-hook "jq -r .content | sort" --post-hook-replace
+
+  # Show a Japanese translation of the answer below it
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Explain the outage" --translate-to ja --translate-model anthropic.claude-3-haiku-20240307-v1:0
+
+  # Post a summary to Slack when a long code-interpreter job finishes or fails
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --upload-files data.csv --input "Analyze and chart this" --save-files ./out --notify-webhook "$SLACK_WEBHOOK_URL"
+
+  # Fail over to us-west-2 when us-east-1 throttles or is unavailable (agents per region in the config 'regions' mapping)
+  aws-bia invoke --regions us-east-1,us-west-2 --input "Hello"
+
+  # Assume a 30 minute idle session timeout when warning about expired sessions
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --session-id session123 --session-ttl 30m --input "Follow-up question"
+
+  # Check region, credentials, and connectivity before invoking
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Your question" --preflight
+
+  # Export traces and metrics of the invocation to an OpenTelemetry collector
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Your question" --otel-endpoint http://localhost:4318
+
+  # Capture only the answer text in a shell variable
+  answer=$(aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Summarize the report" -q)
+
   # With streaming enabled
   aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Your question" --stream
 
+  # Let a long answer stream for up to 30 minutes, but fail if the agent stays silent for 2 minutes
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Write the quarterly report" --stream --idle-timeout 2m --max-duration 30m
+
   # Save output to a file
   aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Generate a report" --output-file report.txt
 
+  # Stream the answer on stdout with machine-readable progress records on stderr
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Generate a report" --stream --progress-json
+
+  # Find out whether the agent or the stream is slow
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Generate a report" --stream --latency-report
+
+  # Show the CloudWatch Logs of the Lambda functions behind the action groups the agent called
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Where is order 42?" --fetch-action-logs
+
+  # Ctrl-C keeps the partial answer, marked "interrupted": true, and exits with 130
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Write the full report" --format json > report.json
+
+  # Save a streamed answer to a file while watching it on the terminal
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Generate a report" --stream --output-file report.txt --tee -
+
   # Save generated files to a directory
   aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Generate files" --save-files ./files
 
+  # Embed small generated files in the JSON document instead of saving them
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Generate files" --format json --include-file-content
+
   # Upload files to the agent for analysis
   aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Analyze this data" --upload-files data.csv,config.json
+
+  # Upload the output of another command without a temporary file
+  generate_report | aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Summarize the report" --upload-files - --stdin-filename report.csv
+
+  # Check an answer against a stored baseline in CI (exit status 3 when it changed)
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --prompt-file ./faq.md --compare-with baseline.json
+
+  # Fail a CI check when the answer is empty or does not mention the refund period (exit status 5)
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "What is your refund policy?" --fail-if-empty --expect-regex '\d+ days'
+
+  # Grade a free-form answer against a reference answer instead of matching it exactly
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "What is your refund policy?" --expect-similar "Refunds are accepted within 30 days of purchase" --similarity-threshold 0.6
+
+  # Hand the calls of a return-control response to an orchestrator (exit status 6), then send its results back
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "What's the weather in Tokyo?" --roc-out calls.json
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --roc-results results.json
+
+  # Let the agent call local commands and HTTP endpoints for its return-control action groups
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "What's the weather in Tokyo?" --tools tools.yaml
+
+  # Re-run a prompt under development every time the file is saved
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --prompt-file ./draft.md --watch
+
+  # Keep the answers of every --watch run in one log, rotated at 10MB
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --prompt-file ./draft.md --watch --output-file runs.log --append --output-file-max-size 10MB
+
+  # Analyze a CSV file over the 10MB upload limit, sent in parts over several turns
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Plot monthly sales" --upload-files sales.csv --split-large-files
   
   # Use a predefined prompt template
   aws-bia invoke --agent-id abc123 --agent-alias-id def456 --prompt code-review
@@ -102,15 +371,87 @@ Examples:
   # Use a prompt template with variables
   aws-bia invoke --agent-id abc123 --agent-alias-id def456 --prompt translation --var language=Japanese --var text="Hello world"
   
+  # Take structured template variables from a YAML or JSON file
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --prompt-file ./report.md --vars-file vars.yaml --var tone=formal
+  
+  # Never ask for required variables of the template's frontmatter, e.g. in CI
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --prompt translate-text --var text="Hello" --no-interactive
+  
   # Combine a prompt with additional input
   aws-bia invoke --agent-id abc123 --agent-alias-id def456 --prompt system-prompt --input "Generate a Python script"
+
+  # Upload content that is already in memory without writing a temp file
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Summarize this data" \
+    --upload-inline data.csv=$(base64 -w0 data.csv)
+
+  # Refer to the agent and alias by name instead of ID
+  aws-bia invoke --agent-name my-support-agent --alias-name prod --input "Your question"
+
+  # Extract values from the JSON response without jq
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Your question" \
+    --query '.citations[].references[].contentText'
+
+  # Force colored output, e.g. when piping into "less -R"
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Your question" --color always | less -R
+
+  # Render the response through a Go template
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Your question" \
+    --format template --template '{{.Content}}{{range .Citations}}\n- {{.Text}}{{end}}'
+
+  # Record an invocation and replay it later without calling AWS
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Your question" --record ./recordings
+  aws-bia invoke --replay ./recordings/20250101-120000-session123.json --format json
+
+  # Dump the timed event-stream frames for 'aws-bia debug replay-stream'
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Your question" --stream --dump-stream stream.bin
+
+  # Open the generated report with the default application when it is ready
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Create a sales report" --save-files ./reports --open
+
+  # Use the latency-optimized version of the agent's model
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Your question" --latency optimized
+
+  # Try a lower temperature and a shorter answer without updating the agent
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Your question" --temperature 0.2 --max-tokens 512
+
+  # Cache answers while iterating on a prompt template; identical invocations skip the agent
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --prompt code-review --var lang=go --cache --cache-ttl 30m
+
+  # Collect redacted diagnostics to attach to a bug report
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Your question" --diag-bundle diag.zip
+
+  # Revise the answer interactively by adding ">>" comment lines in your editor
+  aws-bia invoke --agent-id abc123 --agent-alias-id def456 --input "Draft a release note" --refine
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Handle interrupts gracefully
 		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 		defer stop()
 
+		opts.CommandArgs = commandLineArgs(cmd)
+		opts.MaxDurationSet = cmd.Flags().Changed("max-duration") || cmd.Flags().Changed("timeout")
+		applyInferenceFlags(cmd.Flags(), &opts)
+		if opts.Watch {
+			if err := runWatchLoop(ctx, opts); err != nil {
+				logError("Error invoking agent", err)
+				os.Exit(1)
+			}
+			return
+		}
+
 		if err := runInvokeCommand(ctx, opts); err != nil {
+			if errors.Is(err, ErrResponseDiffers) {
+				os.Exit(ExitCodeResponseDiffers)
+			}
+			if errors.Is(err, ErrInterrupted) {
+				os.Exit(ExitCodeInterrupted)
+			}
+			if errors.Is(err, ErrAssertionFailed) {
+				os.Exit(ExitCodeAssertionFailed)
+			}
+			if errors.Is(err, ErrReturnControl) {
+				os.Exit(ExitCodeReturnControl)
+			}
 			logError("Error invoking agent", err)
 			os.Exit(1)
 		}
@@ -126,6 +467,10 @@ func init() {
 	// Required flags
 	invokeCmd.Flags().StringVar(&opts.AgentID, "agent-id", "", "The ID of the agent to invoke (can be set in config file)")
 	invokeCmd.Flags().StringVar(&opts.AgentAliasID, "agent-alias-id", "", "The ID of the agent alias to invoke (can be set in config file)")
+	invokeCmd.Flags().StringVar(&opts.AgentName, "agent-name", "", "Name of the agent to invoke, resolved to its ID (cached, see aws-bia paths)")
+	invokeCmd.Flags().StringVar(&opts.AliasName, "alias-name", "", "Name of the agent alias to invoke, resolved to its ID (cached, see aws-bia paths)")
+	invokeCmd.MarkFlagsMutuallyExclusive("agent-id", "agent-name")
+	invokeCmd.MarkFlagsMutuallyExclusive("agent-alias-id", "alias-name")
 	invokeCmd.Flags().StringVar(&opts.InputText, "input", "", "The input text to send to the agent (can be omitted when using --prompt or --prompt-file)")
 
 	// We'll validate input requirements in the validateOptions function
@@ -133,32 +478,195 @@ func init() {
 
 	// Optional flags
 	invokeCmd.Flags().StringVar(&opts.SessionID, "session-id", "", "The session ID for the conversation (if not provided, a random ID will be generated)")
+	invokeCmd.Flags().StringVar(&opts.MemoryID, "memory-id", "", "Agent memory ID to continue a long-term memory thread across sessions (can be set in config file)")
 	invokeCmd.Flags().StringVar(&opts.Region, "region", "", "AWS region to use (defaults to AWS_REGION environment variable)")
+	invokeCmd.Flags().StringSliceVar(&opts.Regions, "regions", []string{}, "Regions to invoke in order, failing over to the next one on throttling or regional errors (comma-separated, agents per region from the config 'regions' mapping)")
+	invokeCmd.Flags().StringVar(&opts.EndpointURL, "endpoint-url", "", "Send agent runtime requests to this URL instead of the regional endpoint (e.g. a VPC endpoint or local mock)")
+	invokeCmd.Flags().BoolVar(&opts.UseFIPS, "use-fips", false, "Use FIPS endpoints for AWS requests")
+	invokeCmd.Flags().BoolVar(&opts.UseDualStack, "use-dualstack", false, "Use dual-stack (IPv4 and IPv6) endpoints for AWS requests")
 	invokeCmd.Flags().BoolVar(&opts.EnableStreaming, "stream", false, "Enable streaming mode for the response")
-	invokeCmd.Flags().DurationVar(&opts.Timeout, "timeout", DefaultTimeout, "Timeout for the request (default: 30s)")
-	invokeCmd.Flags().StringVar(&opts.OutputFormat, "format", OutputFormatText, "Output format: text or json (default: text)")
+	invokeCmd.Flags().DurationVar(&opts.ConnectTimeout, "connect-timeout", DefaultConnectTimeout, "Maximum time until the agent starts responding, 0 for no limit")
+	invokeCmd.Flags().DurationVar(&opts.IdleTimeout, "idle-timeout", DefaultIdleTimeout, "Maximum time between two stream events, 0 for no limit")
+	invokeCmd.Flags().DurationVar(&opts.Timeout, "max-duration", DefaultMaxDuration, "Maximum duration of the whole invocation, 0 for no limit (streamed answers have no limit unless it is given)")
+	invokeCmd.Flags().DurationVar(&opts.Timeout, "timeout", DefaultMaxDuration, "Maximum duration of the whole invocation")
+	_ = invokeCmd.Flags().MarkDeprecated("timeout", "use --max-duration, --connect-timeout, or --idle-timeout instead")
+	invokeCmd.Flags().StringVar(&opts.OutputFormat, "format", OutputFormatText, "Output format: text, json, or template (default: text)")
+	invokeCmd.Flags().StringArrayVar(&opts.TeeFiles, "tee", []string{}, "Also write the output to this file (repeatable; - shows it on stdout as well when using --output-file)")
+	invokeCmd.Flags().StringVar(&opts.Query, "query", "", "jq expression applied to the JSON response before printing (e.g. '.citations[].references[].contentText')")
+	invokeCmd.Flags().StringVar(&opts.Color, "color", ColorAuto, "Colorize text output: auto, always, or never (auto uses color only on a terminal)")
+	invokeCmd.Flags().StringVar(&opts.Citations, "citations", CitationsFootnotes, "Citations in text output: footnotes, inline ([n] markers in the answer), json, or none (none also omits them from JSON)")
+	invokeCmd.Flags().StringVar(&opts.Template, "template", "", "Inline Go template used with --format template (e.g. '{{.Content}}')")
+	invokeCmd.Flags().StringVar(&opts.TemplateFile, "template-file", "", "Go template file used with --format template")
 	invokeCmd.Flags().StringVar(&opts.OutputFile, "output-file", "", "Save the response to a file")
+	invokeCmd.Flags().BoolVar(&opts.AppendOutput, "append", false, "Append to the --output-file instead of replacing it")
+	invokeCmd.Flags().BoolVar(&opts.TimestampOutput, "output-file-timestamped", false, "Insert the time into the --output-file name, e.g. response-20250101-120000.txt, so every run writes a new file")
+	invokeCmd.Flags().StringVar(&opts.OutputMaxSize, "output-file-max-size", "", "Rotate the --output-file to <name>.1 once it reaches this size, e.g. 10MB (keeps 5 rotated files)")
+	invokeCmd.Flags().BoolVar(&opts.IncludeContent, "include-file-content", false, "Embed the content of generated files in the 'files' array of JSON output (text as is, other files base64-encoded)")
+	invokeCmd.Flags().StringVar(&opts.ContentMaxSize, "file-content-max-size", DefaultFileContentMaxSize, "Largest generated file embedded with --include-file-content, e.g. 256KB; larger files are listed without content")
 	invokeCmd.Flags().StringVar(&opts.FilesOutputDir, "save-files", "", "Directory to save any files generated by the agent")
+	invokeCmd.Flags().StringVar(&opts.OnConflict, "on-conflict", ConflictRename, "When a saved file already exists: rename, overwrite, skip, or error")
+	invokeCmd.Flags().BoolVar(&opts.Open, "open", false, "Open the --output-file and the --save-files directory with the default application when done")
 	invokeCmd.Flags().BoolVar(&opts.Verbose, "verbose", false, "Enable verbose output")
-	invokeCmd.Flags().StringSliceVar(&opts.UploadFiles, "upload-files", []string{}, "File paths to upload to the agent (comma-separated)")
+	invokeCmd.Flags().BoolVarP(&opts.Quiet, "quiet", "q", false, "Print only the answer text, without the response header, session footer, and file notices")
+	invokeCmd.Flags().BoolVar(&opts.VerboseOutput, "verbose-output", false, "Write the response header, session footer, and file notices to stdout with the answer instead of stderr")
+	invokeCmd.Flags().StringVar(&opts.ReturnControlOut, "roc-out", "", "Write the function/API call of a return-control response to this JSON file and exit with status 6")
+	invokeCmd.Flags().StringVar(&opts.ToolsFile, "tools", "", "Run the calls of return-control responses with the local tools of this YAML file and continue the invocation")
+	invokeCmd.Flags().StringVar(&opts.ToolsAuditLog, "tools-audit-log", "", "Append every local tool call to this JSON lines file (default tools-audit.log in the data directory, see aws-bia paths)")
+	invokeCmd.Flags().StringVar(&opts.ReturnControlResults, "roc-results", "", "Send the results of a return-control response from this JSON file (the --roc-out file with its response bodies filled in)")
+	invokeCmd.Flags().StringVar(&opts.SessionStateFile, "session-state-file", "", "Load session attributes, files, return-control results, and knowledge base settings from this JSON file")
+	invokeCmd.Flags().StringVar(&opts.PromptOverrideFile, "prompt-override-file", "", "Override the agent's pre-processing, orchestration, or post-processing prompts from this JSON file for this invocation")
+	invokeCmd.Flags().StringVar(&opts.PostHook, "post-hook", "", "Command that receives the final JSON response on stdin, e.g. to notify or open a ticket (can be set in config file)")
+	invokeCmd.Flags().BoolVar(&opts.PostHookReplace, "post-hook-replace", false, "Show the stdout of the --post-hook command instead of the response")
+	invokeCmd.Flags().BoolVar(&opts.FailIfEmpty, "fail-if-empty", false, "Exit with status 5 when the agent returns no answer text")
+	invokeCmd.Flags().StringArrayVar(&opts.ExpectRegex, "expect-regex", []string{}, "Regular expression the answer must match, or the command exits with status 5 (repeatable)")
+	invokeCmd.Flags().StringVar(&opts.ExpectSimilar, "expect-similar", "", "Reference text the answer must resemble by at least --similarity-threshold, or the command exits with status 5")
+	invokeCmd.Flags().Float64Var(&opts.SimilarityThreshold, "similarity-threshold", DefaultSimilarityThreshold, "Word overlap (0-1) with the --expect-similar text an answer needs to pass")
+	invokeCmd.Flags().StringVar(&opts.TranslateTo, "translate-to", "", "Translate the answer into this language, e.g. ja or German (can be set in config file)")
+	invokeCmd.Flags().StringVar(&opts.TranslateModel, "translate-model", "", "Model ID that translates the answer with the Converse API (can be set in config file)")
+	invokeCmd.Flags().StringVar(&opts.TranslateAgent, "translate-agent", "", "Agent that translates the answer, as agent-id:alias-id (can be set in config file)")
+	invokeCmd.Flags().BoolVar(&opts.TranslateReplace, "translate-replace", false, "Show the translation instead of the answer")
+	invokeCmd.Flags().Float64Var(&opts.RPS, "rps", 0, "Send at most this many requests per second, shared by --watch runs and split uploads (0 for no limit, can be set in config file)")
+	invokeCmd.Flags().IntVar(&opts.Burst, "burst", DefaultBurst, "Requests that may be sent at once before --rps applies")
+	invokeCmd.Flags().StringVar(&opts.NotifyWebhook, "notify-webhook", "", "POST a JSON summary of the invocation to this URL (e.g. a Slack incoming webhook) when it finishes or fails (can be set in config file)")
+	invokeCmd.Flags().StringVar(&opts.SaveSessionState, "save-session-state", "", "Write the session state to continue with after the invocation to this JSON file")
+	invokeCmd.Flags().BoolVar(&opts.Preflight, "preflight", false, "Check the region, credentials, and endpoint connection within 2s before invoking")
+	invokeCmd.Flags().StringVar(&opts.OtelEndpoint, "otel-endpoint", "", "OTLP/HTTP collector URL to export traces and metrics to (default: OTEL_EXPORTER_OTLP_ENDPOINT)")
+	invokeCmd.Flags().BoolVar(&opts.ProgressJSON, "progress-json", false, "Write JSON progress records (phase, elapsed time, chunks and bytes received) to stderr every second, also with --stream")
+	invokeCmd.Flags().BoolVar(&opts.LatencyReport, "latency-report", false, "Report the time to the first chunk, the gaps between chunks as a histogram, and the stream duration (on stderr, or as 'timing' in JSON output)")
+	invokeCmd.Flags().BoolVar(&opts.FetchActionLogs, "fetch-action-logs", false, "Fetch the CloudWatch Logs of the Lambda functions the agent invoked for action groups and show them after the answer (enables --trace)")
+	invokeCmd.Flags().BoolVar(&opts.NoProgress, "no-progress", false, "Do not show the progress spinner on stderr while waiting without --stream")
+	invokeCmd.Flags().DurationVar(&opts.SessionTTL, "session-ttl", 0, "Idle session timeout used for expiry warnings (default: the agent's idleSessionTTL)")
+	invokeCmd.Flags().BoolVar(&opts.NoSessionStore, "no-session-store", false, "Do not record this invocation in the session store or check the session for expiry")
+	invokeCmd.Flags().BoolVar(&opts.RememberSession, "remember-session", false, "Remember the session ID of this invocation for the agent, so --continue resumes it")
+	invokeCmd.Flags().BoolVar(&opts.Continue, "continue", false, "Continue the session remembered for the agent with --remember-session")
+	invokeCmd.MarkFlagsMutuallyExclusive("continue", "session-id")
+	invokeCmd.MarkFlagsMutuallyExclusive("continue", "session-state-file")
+	invokeCmd.MarkFlagsMutuallyExclusive("continue", "roc-results")
+	invokeCmd.Flags().BoolVar(&opts.Copy, "copy", false, "Copy the answer to the system clipboard")
+	invokeCmd.Flags().BoolVar(&opts.PasteInput, "paste-input", false, "Use the text on the system clipboard as the input")
+	invokeCmd.MarkFlagsMutuallyExclusive("paste-input", "input")
+	invokeCmd.Flags().BoolVar(&opts.Edit, "edit", false, "Compose the input in $EDITOR, pre-filled with the --prompt/--prompt-file and --input given")
+	invokeCmd.Flags().BoolVar(&opts.EnableTrace, "trace", false, "Enable agent trace events (adds token usage to JSON output)")
+	invokeCmd.Flags().StringVar(&opts.Latency, "latency", "", "Model latency profile for this invocation: standard or optimized (can be set in config file)")
+	invokeCmd.Flags().Float32Var(&inferenceFlags.Temperature, "temperature", 0, "Temperature of the orchestration prompt for this invocation, 0 to 1 (invokes the agent as an inline agent)")
+	invokeCmd.Flags().Float32Var(&inferenceFlags.TopP, "top-p", 0, "Top P of the orchestration prompt for this invocation, 0 to 1 (invokes the agent as an inline agent)")
+	invokeCmd.Flags().Int32Var(&inferenceFlags.MaxTokens, "max-tokens", 0, "Maximum number of tokens the orchestration prompt generates for this invocation (invokes the agent as an inline agent)")
+	invokeCmd.Flags().StringArrayVar(&opts.InlineUploads, "upload-inline", []string{}, "Upload in-memory content as name=BASE64 or name=data:<mediatype>;base64,<data> (repeatable)")
+	invokeCmd.Flags().StringSliceVar(&opts.UploadFiles, "upload-files", []string{}, "File paths or s3://bucket/key URIs to upload to the agent (comma-separated, - reads one file from stdin)")
+	invokeCmd.Flags().BoolVar(&opts.SplitLargeFiles, "split-large-files", false, "Split text/CSV upload files over the 10MB limit into parts sent over several turns of the session")
+	invokeCmd.Flags().StringVar(&opts.StdinFilename, "stdin-filename", "", "File name sent for the upload read from stdin with --upload-files -")
 	invokeCmd.Flags().StringVar(&opts.FileUseCase, "file-use-case", FileUseCaseCodeInterpreter, "File use case: CODE_INTERPRETER or other supported values")
 
+	// Knowledge base override flags
+	invokeCmd.Flags().StringArrayVar(&opts.KnowledgeBaseIDs, "kb-id", []string{}, "Knowledge base to query with the settings below, instead of the agent's configuration (repeatable)")
+	invokeCmd.Flags().IntVar(&opts.KBResults, "kb-results", 0, "Number of results to retrieve from each --kb-id knowledge base")
+	invokeCmd.Flags().StringVar(&opts.KBFilter, "kb-filter", "", "Metadata filter JSON for --kb-id knowledge bases, inline or file://path")
+	invokeCmd.Flags().StringVar(&opts.KBSearchType, "kb-search-type", "", "Search type for --kb-id knowledge bases: HYBRID or SEMANTIC")
+
 	// Prompt flags
 	invokeCmd.Flags().StringVar(&opts.PromptFile, "prompt-file", "", "Path to a prompt file to use")
 	invokeCmd.Flags().StringVar(&opts.PromptName, "prompt", "", "Name of a predefined prompt to use")
 	invokeCmd.Flags().StringSliceVar(&opts.PromptVars, "var", []string{}, "Variables for prompt template (format: key=value)")
+	invokeCmd.Flags().BoolVar(&opts.NoInteractive, "no-interactive", false, "Do not ask for required prompt template variables that were not given, for scripts")
+	invokeCmd.Flags().StringVar(&opts.VarsFile, "vars-file", "", "YAML or JSON file of variables for prompt template, may contain nested maps and lists (--var overrides its keys)")
+
+	// Refinement flags
+	invokeCmd.Flags().StringVar(&opts.CompareWith, "compare-with", "", "Diff the answer against a stored response (JSON from --format json, or text); exits with status 3 when they differ")
+	invokeCmd.Flags().BoolVar(&opts.Watch, "watch", false, "Invoke again whenever the --prompt-file, --vars-file, or a local --upload-files file changes, until interrupted")
+	invokeCmd.Flags().BoolVar(&opts.Refine, "refine", false, "Open each response in $EDITOR and send added '>>' lines back as the next turn")
+	invokeCmd.MarkFlagsMutuallyExclusive("watch", "refine")
+
+	// Record/replay flags
+	invokeCmd.Flags().StringVar(&opts.RecordDir, "record", "", "Directory to save the raw event stream and final response of the invocation")
+	invokeCmd.Flags().StringVar(&opts.ReplayFile, "replay", "", "Render a recorded invocation instead of calling AWS")
+	invokeCmd.Flags().StringVar(&opts.DiagBundle, "diag-bundle", "", "Write a zip of redacted config, options, logs, and the event stream for bug reports to this file")
+	invokeCmd.Flags().StringVar(&opts.DumpStream, "dump-stream", "", "Write the raw event-stream frames with their timing to this file (see 'debug replay-stream')")
+
+	// Response cache flags
+	invokeCmd.Flags().BoolVar(&opts.Cache, "cache", false, "Serve identical invocations from a local response cache instead of calling the agent (can be set in config file)")
+	invokeCmd.Flags().DurationVar(&opts.CacheTTL, "cache-ttl", DefaultCacheTTL, "How long a cached response is served with --cache")
+
+	// Complete agent IDs, alias IDs, and prompt names from AWS and the prompt directories
+	registerAgentCompletions(invokeCmd)
 }
 
 // runInvokeCommand handles the agent invocation based on the provided options
-func runInvokeCommand(ctx context.Context, opts AgentOptions) error {
+func runInvokeCommand(ctx context.Context, opts AgentOptions) (err error) {
+	// Diagnostics capture the log entries, so they are set up before the logger
+	diag := NewDiagBundle(opts.DiagBundle)
+
 	// Initialize logger based on verbose flag
 	InitLogger(opts.Verbose)
 	defer SyncLogger()
 
+	// Non-fatal problems go to stderr and into the JSON document, never into the answer
+	opts.Warnings = NewWarningCollector()
+
+	// Refine and split-upload turns, and the runs of --watch, reuse one runtime client
+	if opts.Clients == nil {
+		opts.Clients = NewRuntimeClients(nil)
+	}
+
+	// Trace the whole command; the export also runs when the invocation was interrupted
+	opts.Telemetry = NewTelemetry()
+	rootSpan := opts.Telemetry.StartRoot("aws-bia invoke")
+	defer func() {
+		opts.Telemetry.Finish(err)
+		if exportErr := opts.Telemetry.Export(context.Background()); exportErr != nil {
+			opts.Warnings.Warn(WarningTelemetry, "error exporting telemetry", exportErr)
+		}
+	}()
+
+	// The bundle is most useful when the invocation failed, so it is written in any case
+	defer func() {
+		if diagErr := diag.Write(opts, err); diagErr != nil {
+			LogWarn("Warning: %v", diagErr)
+		} else if diag != nil {
+			fmt.Fprintf(os.Stderr, "Wrote diagnostics bundle to %s\n", opts.DiagBundle)
+		}
+	}()
+
 	// Load configuration from file if specified
-	if err := loadConfig(opts.ConfigFile, &opts); err != nil {
+	configSpan := opts.Telemetry.Start("config.load")
+	v, err := LoadConfigForCommand(opts.ConfigFile, "invoke", opts.Verbose)
+	configSpan.End(err)
+	if err != nil {
+		return err
+	}
+	diag.SetConfig(v)
+	applyAgentConfig(v, &opts)
+	applyOutputConfig(v, &opts)
+	applyTimeoutConfig(v, &opts)
+	applyCacheConfig(v, &opts)
+	applyPostHookConfig(v, &opts)
+	applyTranslateConfig(v, &opts)
+	if err := applyToolsConfig(v, &opts); err != nil {
 		return err
 	}
+	if err := setupRateLimiter(v, &opts); err != nil {
+		return err
+	}
+	applyNotifyConfig(v, &opts)
+	if opts.OtelEndpoint == "" && v.InConfig("otel_endpoint") {
+		opts.OtelEndpoint = v.GetString("otel_endpoint")
+	}
+	opts.Telemetry.SetEndpoint(opts.OtelEndpoint)
+
+	// Report how the command ended, including the failures from here on
+	var notifier *completionNotifier
+	if opts.NotifyWebhook != "" {
+		if notifier, err = newCompletionNotifier(opts.NotifyWebhook); err != nil {
+			return err
+		}
+		defer func() { notifier.Send(opts, err) }()
+	}
+
+	// The clipboard takes the place of --input, also for the {{input}} of a prompt
+	if opts.PasteInput {
+		if err := pasteInput(&opts); err != nil {
+			return err
+		}
+	}
 
 	// Process prompt if specified
 	if opts.PromptName != "" || opts.PromptFile != "" {
@@ -167,24 +675,142 @@ func runInvokeCommand(ctx context.Context, opts AgentOptions) error {
 		}
 	}
 
+	// The editor starts from the prompt with the input filled in, like a commit message template
+	if opts.Edit {
+		if err := editInput(&opts); err != nil {
+			return err
+		}
+	}
+
+	// Inline uploads are carried as data: URIs alongside the other upload files
+	for _, spec := range opts.InlineUploads {
+		entry, err := inlineUploadToDataURI(spec)
+		if err != nil {
+			return err
+		}
+		opts.UploadFiles = append(opts.UploadFiles, entry)
+	}
+	if err := readStdinUpload(&opts, os.Stdin); err != nil {
+		return err
+	}
+
+	// A saved session state continues its session unless another one is given
+	if opts.SessionStateFile != "" {
+		if opts.SessionStateDocument, err = loadSessionStateFile(opts.SessionStateFile); err != nil {
+			return err
+		}
+		if opts.SessionID == "" {
+			opts.SessionID = opts.SessionStateDocument.SessionID
+		}
+		if opts.InputText == "" && len(opts.SessionStateDocument.ReturnControlInvocationResults) == 0 {
+			return fmt.Errorf("input is required unless the session state file has returnControlInvocationResults")
+		}
+	}
+	if opts.ReturnControlResults != "" {
+		if err := loadReturnControlResults(opts.ReturnControlResults, &opts); err != nil {
+			return err
+		}
+	}
+
+	if opts.PromptOverrideFile != "" {
+		if opts.PromptOverrides, err = loadPromptOverrideFile(opts.PromptOverrideFile); err != nil {
+			return err
+		}
+	}
+	if err := applyInferenceOverrides(&opts); err != nil {
+		return err
+	}
+
+	if opts.ToolsFile != "" {
+		if opts.Tools, err = loadToolRegistry(opts.ToolsFile, opts.ToolsAllowlist); err != nil {
+			return err
+		}
+		if opts.Tools.Audit, err = openToolAuditLog(opts.ToolsAuditLog); err != nil {
+			return err
+		}
+		defer opts.Tools.Audit.Close()
+		logVerbose(opts, "Loaded %d local tool(s) from %s", len(opts.Tools.Tools), opts.ToolsFile)
+	}
+
+	// Fail fast on configuration and network problems, before any AWS call can hang
+	if opts.Preflight && opts.ReplayFile == "" {
+		if err := runPreflight(ctx, NewAWSHelper(opts)); err != nil {
+			return err
+		}
+	}
+
+	// Replay a recorded invocation instead of calling AWS
+	var recording *Recording
+	if opts.ReplayFile != "" {
+		var err error
+		if recording, err = LoadRecording(opts.ReplayFile); err != nil {
+			return err
+		}
+		applyRecording(&opts, recording)
+	} else if err := resolveAgentNames(ctx, &opts); err != nil {
+		return err
+	}
+	if len(opts.Regions) > 0 {
+		if err := resolveRegionAgents(v, &opts); err != nil {
+			return err
+		}
+	}
+
+	// The action group invocations are found in the trace
+	if opts.FetchActionLogs {
+		opts.EnableTrace = true
+	}
+
 	// Validate inputs before proceeding
 	if err := validateOptions(opts); err != nil {
 		return err
 	}
 
-	// Setup context with timeout
-	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
-	defer cancel()
-
-	// Setup AWS helper and client
-	awsHelper := NewAWSHelper(opts)
-	client, err := awsHelper.CreateClient(ctx)
+	// Invalid patterns are reported before the invocation, not after it
+	expect, err := compilePatterns("expect-regex", opts.ExpectRegex)
 	if err != nil {
-		return fmt.Errorf("failed to create AWS client: %w", err)
+		return err
+	}
+
+	// Read the baseline first, a missing file should not cost an invocation
+	var baseline string
+	if opts.CompareWith != "" {
+		if baseline, err = loadBaseline(opts.CompareWith); err != nil {
+			return err
+		}
+	}
+
+	if opts.Continue {
+		if err := continueLastSession(&opts); err != nil {
+			return err
+		}
+	}
+
+	// Warn before continuing a session the service has most likely expired
+	if opts.ReplayFile == "" && !opts.NoSessionStore {
+		store, err := NewSessionStore()
+		if err != nil {
+			return err
+		}
+		if err := checkSessionExpiry(ctx, store, &opts); err != nil {
+			return err
+		}
+	}
+
+	// Files over the upload limit are sent in parts before the actual input
+	if err := sendSplitUploads(ctx, &opts); err != nil {
+		return err
 	}
 
-	// Prepare output writer
-	writer, closer, err := PrepareOutput(opts.OutputFile)
+	// Prepare output writer; with --watch every run gets its own timestamped file
+	if opts.TimestampOutput {
+		opts.OutputFile = timestampedOutputPath(opts.OutputFile, time.Now())
+	}
+	fileOpts, err := outputFileOptions(opts)
+	if err != nil {
+		return err
+	}
+	writer, closer, err := PrepareOutput(opts.OutputFile, fileOpts)
 	if err != nil {
 		return fmt.Errorf("failed to prepare output: %w", err)
 	}
@@ -192,25 +818,260 @@ func runInvokeCommand(ctx context.Context, opts AgentOptions) error {
 		defer closer()
 	}
 
-	// Create response formatter
+	// Without --tee - the answer only goes to the file, so say where it went
+	if opts.OutputFile != "" && !teesToStdout(opts) && !opts.Quiet && opts.OutputFormat == OutputFormatText {
+		fmt.Fprintf(os.Stderr, "Writing the response to %s (add --tee - to also show it here)\n", opts.OutputFile)
+	}
+
+	// Copy the output to --tee files; a slow or failing destination does not affect the others
+	if len(opts.TeeFiles) > 0 {
+		fanout, closeTees, err := newTeeWriter(opts, writer)
+		if err != nil {
+			return err
+		}
+		defer closeTees()
+		writer = fanout
+	}
+
+	// Create the AWS helper and a response formatter sharing its file helper
+	awsHelper := NewAWSHelper(opts)
 	formatter := NewResponseFormatter(opts, writer)
+	formatter.FileHelper = awsHelper.FileHelper
+	awsHelper.Replay = recording
+	awsHelper.Diagnostics = diag
+	if opts.RecordDir != "" {
+		awsHelper.Recorder = NewInvocationRecorder()
+	}
+	if opts.DumpStream != "" && recording == nil {
+		awsHelper.Dumper = NewStreamDumper(opts.DumpStream, opts)
+	}
+	if opts.Cache && recording == nil {
+		if awsHelper.Cache, err = NewResponseCache(opts.CacheTTL); err != nil {
+			return err
+		}
+	}
+
+	logVerbose(opts, "Invoking agent with options: %+v", opts)
+
+	rootSpan.SetAttribute("aws_bia.agent_id", opts.AgentID)
+	rootSpan.SetAttribute("aws_bia.agent_alias_id", opts.AgentAliasID)
+	rootSpan.SetAttribute("aws_bia.streaming", opts.EnableStreaming)
+	rootSpan.SetAttribute("aws_bia.output_format", opts.OutputFormat)
+	opts.Telemetry.SetAttribute("aws_bia.agent_id", opts.AgentID)
+	opts.Telemetry.SetAttribute("aws_bia.agent_alias_id", opts.AgentAliasID)
+
+	// Invoke the agent and process response, running the calls it hands back with the local tools
+	output, err := runInvokeTurnWithTools(ctx, opts, awsHelper, formatter)
+	diag.SetResponse(output, formatter.LastResult())
+	notifier.SetResult(output, formatter.LastResult())
+
+	// Follow-up requests go to the region that served the response
+	if len(opts.Regions) > 1 {
+		useRegion(&opts, awsHelper.Options.Region)
+	}
+
+	// Save the recording even when processing failed, it helps debugging
+	if awsHelper.Recorder != nil && output != nil {
+		path, saveErr := awsHelper.Recorder.Save(opts.RecordDir, opts, output, formatter.LastResult())
+		if saveErr != nil {
+			opts.Warnings.Warn(WarningRecording, "error saving recording", saveErr)
+		} else {
+			fmt.Fprintf(os.Stderr, "Recorded invocation to %s\n", path)
+		}
+	}
+	if awsHelper.Dumper != nil && output != nil {
+		if dumpErr := awsHelper.Dumper.Close(); dumpErr != nil {
+			opts.Warnings.Warn(WarningRecording, "incomplete stream dump", dumpErr)
+		}
+		fmt.Fprintf(os.Stderr, "Dumped event stream to %s\n", awsHelper.Dumper.Path())
+	}
+
+	// A cached answer was already recorded in the session store when it was first received
+	if cached := awsHelper.Cache.Hit(); err == nil && cached != nil {
+		if !opts.Quiet {
+			fmt.Fprintf(os.Stderr, "Served from the response cache (cached %s ago)\n", formatDuration(time.Since(cached.RecordedAt)))
+		}
+	} else if err == nil || errors.Is(err, ErrInterrupted) {
+		// An interrupted answer is not cached, but the turn happened in the session all the same
+		if awsHelper.Cache != nil && err == nil {
+			if storeErr := awsHelper.Cache.Store(opts, output, formatter.LastResult()); storeErr != nil {
+				opts.Warnings.Warn(WarningRecording, "error caching response", storeErr)
+			}
+		}
+		recordSessionTurn(opts, output, formatter.LastResult())
+	}
+
+	// A continued session stays remembered, so --continue can be repeated
+	if (opts.RememberSession || opts.Continue) && output != nil && output.SessionId != nil {
+		remembered := lastSession{SessionID: aws.ToString(output.SessionId), AgentAliasID: opts.AgentAliasID, SavedAt: time.Now()}
+		if rememberErr := rememberSession(opts.AgentID, remembered); rememberErr != nil {
+			opts.Warnings.Warn(WarningSessionStore, "error remembering session", rememberErr)
+		}
+	}
+
+	// Continue the session with feedback written in the editor
+	if err == nil && opts.Refine {
+		err = runRefineLoop(ctx, opts, writer, output, formatter.LastResult())
+	}
+
+	if err == nil && opts.Open {
+		openOutputs(opts, formatter.LastResult())
+	}
+
+	if err == nil && opts.Copy {
+		copyAnswer(opts, formatter.LastResult())
+	}
+
+	if err == nil && opts.SaveSessionState != "" {
+		if err = saveSessionStateFile(opts.SaveSessionState, opts, output, formatter.LastResult()); err == nil {
+			logVerbose(opts, "Wrote session state to %s", opts.SaveSessionState)
+		}
+	}
+
+	// The orchestrator behind --roc-out continues from the payload, there is no answer to check
+	if err == nil && opts.ReturnControlOut != "" && formatter.LastResult().ReturnControl != nil {
+		return ErrReturnControl
+	}
+
+	if err == nil && opts.CompareWith != "" {
+		err = compareWithBaseline(opts, baseline, formatter.LastResult().Text)
+	}
+
+	if err == nil && (opts.FailIfEmpty || len(expect) > 0 || opts.ExpectSimilar != "") {
+		err = checkAssertions(opts, expect, formatter.LastResult().Text)
+	}
+	return err
+}
+
+// runInvokeTurn invokes the agent within the configured time limits and writes the formatted response
+func runInvokeTurn(ctx context.Context, opts AgentOptions, awsHelper *AWSHelper,
+	formatter *ResponseFormatter) (*bedrockagentruntime.InvokeAgentOutput, error) {
+
+	// The connect and idle timeouts are applied while invoking and streaming
+	ctx, cancel := withMaxDuration(ctx, opts.Timeout)
+	defer cancel()
+
+	// Warnings are printed once the response is complete so they do not split the answer
+	opts.Warnings.Hold()
+	defer opts.Warnings.Release()
+
+	// Show a spinner until the response starts, long invocations otherwise look hung
+	progress := newProgressIndicator(opts)
+	awsHelper.Progress = progress
+	formatter.Progress = progress
+	defer progress.Close()
+
+	phase := PhaseWaitingForAgent
+	if len(opts.UploadFiles) > 0 {
+		phase = PhaseUploadingFiles
+	}
+	progress.Start(phase)
+
+	formatter.RequestSent = time.Now()
+	formatter.Context = ctx
+	output, err := invokeWithFailover(ctx, awsHelper)
+	if err != nil {
+		progress.Stop()
+		err = timeoutCause(ctx, err)
+
+		// JSON consumers still get a document describing the failure
+		if writeErr := formatter.WriteJSONError(err); writeErr != nil {
+			logError("Warning: Error writing JSON error", writeErr)
+		}
+		return nil, err
+	}
+
+	// Format and write the response using the formatter
+	if len(opts.Regions) > 0 {
+		formatter.ServedRegion = awsHelper.Options.Region
+	}
+	err = formatter.FormatAndWriteResponse(output)
+	if opts.LatencyReport && opts.OutputFormat != OutputFormatJSON && opts.Query == "" {
+		fmt.Fprintln(os.Stderr)
+		formatter.LastResult().Timing.WriteReport(os.Stderr)
+	}
+	if opts.FetchActionLogs && opts.OutputFormat != OutputFormatJSON && opts.Query == "" {
+		writeActionLogs(os.Stderr, fetchActionLogs(ctx, opts, formatter.LastResult().Traces), colorizer{enabled: useColor(opts.Color, os.Stderr)})
+	}
+	return output, timeoutCause(ctx, err)
+}
+
+// invokeAgent creates a Bedrock Agent runtime client and sends the prepared input to the agent
+func invokeAgent(ctx context.Context, awsHelper *AWSHelper) (*bedrockagentruntime.InvokeAgentOutput, error) {
+	// Setup AWS client
+	client, err := awsHelper.CreateClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS client: %w", err)
+	}
 
 	// Prepare the input for agent invocation
-	input, err := awsHelper.PrepareInvokeInput()
+	telemetry := awsHelper.Options.Telemetry
+	prepareSpan := telemetry.Start("input.prepare")
+	prepareSpan.SetAttribute("aws_bia.upload_files", len(awsHelper.Options.UploadFiles))
+	input, err := awsHelper.PrepareInvokeInput(ctx)
+	prepareSpan.End(err)
 	if err != nil {
-		return fmt.Errorf("failed to prepare invoke input: %w", err)
+		return nil, fmt.Errorf("failed to prepare invoke input: %w", err)
+	}
+	telemetry.Root().SetAttribute("aws_bia.session_id", aws.ToString(input.SessionId))
+
+	// An identical invocation within the cache TTL is answered from the cached event stream
+	replayed := awsHelper.Replay != nil
+	if cache := awsHelper.Cache; cache != nil {
+		if cached := cache.Lookup(awsHelper.Options, client.Options().Region, input); cached != nil {
+			client = newReplayClient(cached)
+			telemetry.Root().SetAttribute("aws_bia.cache_hit", true)
+			replayed = true
+		}
 	}
 
-	logVerbose(opts, "Invoking agent with options: %+v", opts)
+	// Answers from the cache or a recording cost nothing, anything else needs budget left
+	if !replayed {
+		if err := awsHelper.Options.UsageBudget.Check(); err != nil {
+			return nil, err
+		}
+	}
+
+	// A replayed recording is rendered as it is, anything else may be captured again
+	var callOpts []func(*bedrockagentruntime.Options)
+	if awsHelper.Replay == nil {
+		callOpts = awsHelper.invocationOptions()
+	}
+	if limit := rateLimitOption(awsHelper.Options); limit != nil && !replayed {
+		callOpts = append(callOpts, limit)
+	}
 
-	// Invoke the agent and process response
-	output, err := client.InvokeAgent(ctx, input)
+	// Prompt overrides need the inline agent API, recorded responses are served as they are
+	method := "InvokeAgent"
+	if awsHelper.Options.PromptOverrides != nil && !replayed {
+		inlineOpt, err := awsHelper.inlineAgentRequestOption(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		method = "InvokeInlineAgent"
+		callOpts = append(callOpts, inlineOpt)
+	}
+
+	// Only waiting for the response is bounded, the stream is read with the same context afterwards
+	callCtx, stop := withConnectTimeout(ctx, awsHelper.Options.ConnectTimeout)
+	callSpan := telemetry.StartClient("BedrockAgentRuntime/" + method)
+	callSpan.SetAttribute("rpc.system", "aws-api")
+	callSpan.SetAttribute("rpc.service", "BedrockAgentRuntime")
+	callSpan.SetAttribute("rpc.method", method)
+	callSpan.SetAttribute("cloud.region", client.Options().Region)
+	callStart := time.Now()
+	output, err := client.InvokeAgent(callCtx, input, callOpts...)
+	stop()
+	callSpan.End(err)
+	telemetry.RecordLatency("aws_bia.invoke.response_time", time.Since(callStart))
 	if err != nil {
-		return HandleAWSError(fmt.Errorf("failed to invoke agent: %w", err))
+		return nil, HandleAWSError(fmt.Errorf("failed to invoke agent: %w", timeoutCause(callCtx, err)))
 	}
 
-	// Format and write the response using the formatter
-	return formatter.FormatAndWriteResponse(output)
+	// Files are part of the request, so they have been sent once the response arrives
+	awsHelper.Progress.SetPhase(PhaseWaitingForAgent)
+
+	return output, nil
 }
 
 // validateOptions validates the agent options before making API calls
@@ -220,9 +1081,13 @@ func validateOptions(opts AgentOptions) error {
 		return err
 	}
 
-	// Validate timeout
-	if opts.Timeout <= 0 {
-		return fmt.Errorf("timeout must be a positive duration")
+	// Validate time limits
+	if err := validateTimeouts(opts); err != nil {
+		return err
+	}
+
+	if err := validateSimilarityThreshold(opts.SimilarityThreshold); err != nil {
+		return err
 	}
 
 	// Validate output format
@@ -230,6 +1095,44 @@ func validateOptions(opts AgentOptions) error {
 		return err
 	}
 
+	if err := validateFileContentOptions(opts); err != nil {
+		return err
+	}
+
+	// Validate the query expression before calling the agent
+	if opts.Query != "" {
+		if _, err := compileQuery(opts.Query); err != nil {
+			return err
+		}
+	}
+
+	// Validate color mode
+	if err := validateColorMode(opts.Color); err != nil {
+		return err
+	}
+
+	if err := validateCitationStyle(opts.Citations); err != nil {
+		return err
+	}
+
+	if err := validateConflictPolicy(opts.OnConflict); err != nil {
+		return err
+	}
+
+	// Validate the model configuration override
+	if err := validateLatency(opts.Latency); err != nil {
+		return err
+	}
+
+	if err := validateMemoryID(opts.MemoryID); err != nil {
+		return err
+	}
+
+	// Validate knowledge base overrides
+	if err := validateKnowledgeBaseOptions(opts); err != nil {
+		return err
+	}
+
 	// Validate file output directory if specified
 	if err := validateFilesOutputDir(opts); err != nil {
 		return err
@@ -240,9 +1143,79 @@ func validateOptions(opts AgentOptions) error {
 		return err
 	}
 
+	if opts.RecordDir != "" && opts.ReplayFile != "" {
+		return fmt.Errorf("--record and --replay cannot be used together")
+	}
+
+	if _, err := outputFileOptions(opts); err != nil {
+		return err
+	}
+
+	if teesToStdout(opts) && opts.OutputFile == "" {
+		return fmt.Errorf("--tee - requires --output-file, the output already goes to stdout")
+	}
+
+	if opts.Open && opts.OutputFile == "" && opts.FilesOutputDir == "" {
+		return fmt.Errorf("--open requires --output-file or --save-files")
+	}
+
+	if opts.Cache && opts.CacheTTL <= 0 {
+		return fmt.Errorf("cache TTL must be a positive duration")
+	}
+
+	if err := validatePostHookOptions(opts); err != nil {
+		return err
+	}
+
+	if err := validateTranslateOptions(opts); err != nil {
+		return err
+	}
+
+	if err := validateRegions(opts); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// validateLatency checks that a latency profile is one the runtime accepts
+func validateLatency(latency string) error {
+	switch types.PerformanceConfigLatency(latency) {
+	case "", types.PerformanceConfigLatencyStandard, types.PerformanceConfigLatencyOptimized:
+		return nil
+	default:
+		return fmt.Errorf("latency must be one of: %s, %s, got '%s'",
+			types.PerformanceConfigLatencyStandard, types.PerformanceConfigLatencyOptimized, latency)
+	}
+}
+
+// memoryIDPattern is the format of agent memory IDs accepted by InvokeAgent
+var memoryIDPattern = regexp.MustCompile(`^[0-9a-zA-Z._:-]{2,100}$`)
+
+// validateMemoryID checks that a memory ID is one the runtime accepts
+func validateMemoryID(memoryID string) error {
+	if memoryID != "" && !memoryIDPattern.MatchString(memoryID) {
+		return fmt.Errorf("memory ID must be 2-100 letters, digits, or . _ : - characters, got '%s'", memoryID)
+	}
+	return nil
+}
+
+// outputFileOptions returns how the --output-file is opened, checking the options that need it
+func outputFileOptions(opts AgentOptions) (OutputFileOptions, error) {
+	fileOpts := OutputFileOptions{Append: opts.AppendOutput}
+	if opts.OutputMaxSize != "" {
+		size, err := parseSize(opts.OutputMaxSize)
+		if err != nil {
+			return fileOpts, fmt.Errorf("--output-file-max-size: %w", err)
+		}
+		fileOpts.MaxSize = size
+	}
+	if opts.OutputFile == "" && (opts.AppendOutput || opts.TimestampOutput || fileOpts.MaxSize > 0) {
+		return fileOpts, fmt.Errorf("--append, --output-file-timestamped, and --output-file-max-size require --output-file")
+	}
+	return fileOpts, nil
+}
+
 // validateRequiredFields checks that all required fields have values
 func validateRequiredFields(opts AgentOptions) error {
 	if opts.AgentID == "" {
@@ -252,8 +1225,10 @@ func validateRequiredFields(opts AgentOptions) error {
 		return fmt.Errorf("agent alias ID is required")
 	}
 
-	// Input is only required if no prompt or prompt file is specified
-	if opts.InputText == "" && opts.PromptName == "" && opts.PromptFile == "" {
+	// Input is only required if no prompt or prompt file is specified; a session
+	// state file or --roc-results may instead carry the results of a return-control response
+	if opts.InputText == "" && opts.PromptName == "" && opts.PromptFile == "" && opts.SessionStateFile == "" &&
+		opts.ReturnControlResults == "" {
 		return fmt.Errorf("input is required (or use --prompt/--prompt-file)")
 	}
 	return nil
@@ -264,11 +1239,43 @@ func validateOutputFormat(opts AgentOptions) error {
 	// Direct comparison instead of loop for better performance
 	switch opts.OutputFormat {
 	case OutputFormatText, OutputFormatJSON:
+		if opts.Template != "" || opts.TemplateFile != "" {
+			return fmt.Errorf("--template and --template-file require --format %s", OutputFormatTemplate)
+		}
 		return nil
+	case OutputFormatTemplate:
+		return validateTemplateOptions(opts)
 	default:
-		return fmt.Errorf("output format must be one of: %s, %s, got '%s'",
-			OutputFormatText, OutputFormatJSON, opts.OutputFormat)
+		return fmt.Errorf("output format must be one of: %s, %s, %s, got '%s'",
+			OutputFormatText, OutputFormatJSON, OutputFormatTemplate, opts.OutputFormat)
+	}
+}
+
+// validateTemplateOptions checks that exactly one output template is given and that it parses
+func validateTemplateOptions(opts AgentOptions) error {
+	if opts.Template == "" && opts.TemplateFile == "" {
+		return fmt.Errorf("--format %s requires --template or --template-file", OutputFormatTemplate)
+	}
+	if opts.Template != "" && opts.TemplateFile != "" {
+		return fmt.Errorf("--template and --template-file cannot be used together")
+	}
+
+	_, err := loadOutputTemplate(opts)
+	return err
+}
+
+// validateFileContentOptions checks --include-file-content and its size cap
+func validateFileContentOptions(opts AgentOptions) error {
+	if !opts.IncludeContent {
+		return nil
+	}
+	if opts.OutputFormat != OutputFormatJSON && opts.Query == "" {
+		return fmt.Errorf("--include-file-content requires --format json or --query")
+	}
+	if _, err := parseSize(opts.ContentMaxSize); err != nil {
+		return fmt.Errorf("--file-content-max-size: %w", err)
 	}
+	return nil
 }
 
 // validateFilesOutputDir validates the directory for saving files
@@ -298,6 +1305,18 @@ func validateFileUploadOptions(opts AgentOptions) error {
 
 	// Check if files exist
 	for _, filePath := range opts.UploadFiles {
+		if isDataURI(filePath) {
+			if _, _, _, err := parseDataURI(filePath); err != nil {
+				return err
+			}
+			continue
+		}
+		if isS3URI(filePath) {
+			if _, _, err := parseS3URI(filePath); err != nil {
+				return err
+			}
+			continue
+		}
 		if _, err := os.Stat(filePath); os.IsNotExist(err) {
 			return fmt.Errorf("upload file '%s' does not exist", filePath)
 		}
@@ -313,14 +1332,87 @@ func validateFileUploadOptions(opts AgentOptions) error {
 	}
 }
 
+// newTeeWriter fans the output out to the primary writer and every --tee file.
+// The returned function waits for all output and reports writers that failed.
+func newTeeWriter(opts AgentOptions, primary io.Writer) (*FanoutWriter, func(), error) {
+	primaryName := "stdout"
+	if opts.OutputFile != "" {
+		primaryName = opts.OutputFile
+	}
+
+	fanout := NewFanoutWriter()
+	fanout.Add(primaryName, primary)
+
+	files := make([]*os.File, 0, len(opts.TeeFiles))
+	for _, path := range opts.TeeFiles {
+		if path == "-" {
+			fanout.Add("stdout", os.Stdout)
+			continue
+		}
+		file, err := os.Create(path)
+		if err != nil {
+			fanout.Close()
+			for _, f := range files {
+				f.Close()
+			}
+			return nil, nil, fmt.Errorf("failed to create tee file '%s': %w", path, err)
+		}
+		files = append(files, file)
+		fanout.Add(path, file)
+	}
+
+	return fanout, func() {
+		for _, failure := range fanout.Close() {
+			opts.Warnings.Warn(WarningOutput, "output was not completely written", failure)
+		}
+		for _, f := range files {
+			if err := f.Close(); err != nil {
+				opts.Warnings.Warn(WarningOutput, fmt.Sprintf("failed to close tee file '%s'", f.Name()), err)
+			}
+		}
+	}, nil
+}
+
+// teesToStdout reports whether --tee - shows the output on stdout next to the --output-file
+func teesToStdout(opts AgentOptions) bool {
+	for _, path := range opts.TeeFiles {
+		if path == "-" {
+			return true
+		}
+	}
+	return false
+}
+
+// applyRecording fills the options from a recording so a replay does not need them on the command line
+func applyRecording(opts *AgentOptions, recording *Recording) {
+	if opts.AgentID == "" {
+		opts.AgentID = recording.AgentID
+	}
+	if opts.AgentAliasID == "" {
+		opts.AgentAliasID = recording.AgentAliasID
+	}
+	if opts.InputText == "" {
+		opts.InputText = recording.InputText
+	}
+
+	// Files are not uploaded again when replaying
+	opts.UploadFiles = nil
+}
+
 // loadConfig loads agent configuration from a YAML file using Viper
-func loadConfig(configPath string, options *AgentOptions) error {
+func loadConfig(configPath, commandName string, options *AgentOptions) error {
 	// Use the centralized config loading function from root.go
-	v, err := LoadConfigForCommand(configPath, options.Verbose)
+	v, err := LoadConfigForCommand(configPath, commandName, options.Verbose)
 	if err != nil {
 		return err
 	}
 
+	applyAgentConfig(v, options)
+	return nil
+}
+
+// applyAgentConfig applies agent settings from a loaded configuration to options not set via flags
+func applyAgentConfig(v *viper.Viper, options *AgentOptions) {
 	// Check if we found any configuration values
 	settingsFound := false
 
@@ -338,6 +1430,24 @@ func loadConfig(configPath string, options *AgentOptions) error {
 		logVerbose(*options, "Loaded agent alias ID from config: %s", options.AgentAliasID)
 	}
 
+	// Environment variables templates may read can only be allowed in the config
+	if v.InConfig("template_env") {
+		names, err := configStringList(v, "template_env")
+		if err != nil {
+			LogWarn("Ignoring %v", err)
+		}
+		options.TemplateEnv = names
+	}
+
+	if v.InConfig("prompt_dirs") {
+		dirs, err := configStringList(v, "prompt_dirs")
+		if err != nil {
+			LogWarn("Ignoring %v", err)
+		}
+		options.PromptDirs = dirs
+		logVerbose(*options, "Loaded prompt directories from config: %v", options.PromptDirs)
+	}
+
 	// Load region if set in config and not provided via flag
 	if v.InConfig("region") && options.Region == "" {
 		settingsFound = true
@@ -345,24 +1455,92 @@ func loadConfig(configPath string, options *AgentOptions) error {
 		logVerbose(*options, "Loaded region from config: %s", options.Region)
 	}
 
-	// Load timeout if set in config and not provided via flag (check if it's still the default value)
-	if v.InConfig("timeout") && options.Timeout == DefaultTimeout {
+	// Load endpoint settings if set in config and not provided via flag
+	if v.InConfig("endpoint_url") && options.EndpointURL == "" {
 		settingsFound = true
-		timeoutDuration := v.GetDuration("timeout")
-		if timeoutDuration > 0 {
-			options.Timeout = timeoutDuration
-			logVerbose(*options, "Loaded timeout from config: %s", options.Timeout)
-		} else {
-			logVerbose(*options, "Invalid timeout value in config, using default: %s", DefaultTimeout)
+		options.EndpointURL = v.GetString("endpoint_url")
+		logVerbose(*options, "Loaded endpoint URL from config: %s", options.EndpointURL)
+	}
+	if v.InConfig("use_fips") && !options.UseFIPS {
+		settingsFound = true
+		options.UseFIPS = v.GetBool("use_fips")
+	}
+	if v.InConfig("use_dualstack") && !options.UseDualStack {
+		settingsFound = true
+		options.UseDualStack = v.GetBool("use_dualstack")
+	}
+
+	// Load the model latency profile if not provided via flag
+	if v.InConfig("latency") && options.Latency == "" {
+		settingsFound = true
+		options.Latency = v.GetString("latency")
+		logVerbose(*options, "Loaded latency from config: %s", options.Latency)
+	}
+
+	// Load the memory ID if not provided via flag
+	if v.InConfig("memory_id") && options.MemoryID == "" {
+		settingsFound = true
+		options.MemoryID = v.GetString("memory_id")
+		logVerbose(*options, "Loaded memory ID from config: %s", options.MemoryID)
+	}
+
+	// Load post-save hooks for generated files
+	if v.InConfig("on_saved_file") {
+		settingsFound = true
+		options.SavedFileHooks = loadSavedFileHooks(v)
+		logVerbose(*options, "Loaded %d saved file hook(s) from config", len(options.SavedFileHooks))
+	}
+
+	// Load custom request headers and hooks for every AWS request
+	if v.InConfig("request_headers") {
+		settingsFound = true
+		options.RequestHeaders = loadRequestHeaders(v)
+		logVerbose(*options, "Loaded %d request header(s) from config", len(options.RequestHeaders))
+	}
+	if v.InConfig("request_hooks") {
+		settingsFound = true
+		hooks, err := configStringList(v, "request_hooks")
+		if err != nil {
+			LogWarn("Ignoring %v", err)
 		}
+		options.RequestHooks = hooks
+		logVerbose(*options, "Loaded %d request hook(s) from config", len(options.RequestHooks))
 	}
 
-	// If config file was found but had no relevant settings, show a warning
-	if !settingsFound && v.ConfigFileUsed() != "" && options.Verbose {
+	// If config file was found but had no relevant settings, show a warning; the time limits
+	// are applied by applyTimeoutConfig and applyTimeoutSetting
+	if !settingsFound && !v.InConfig("timeout") && v.ConfigFileUsed() != "" && options.Verbose {
 		LogWarn("Config file found but no agent_id, agent_alias_id, region, or timeout settings found")
 	}
+}
 
-	return nil
+// applyOutputConfig applies the output defaults of the invoke command from a loaded configuration
+func applyOutputConfig(v *viper.Viper, options *AgentOptions) {
+	// Flags still at their defaults are overridden by the config file
+	if v.InConfig("stream") && !options.EnableStreaming {
+		options.EnableStreaming = v.GetBool("stream")
+		logVerbose(*options, "Loaded streaming from config: %t", options.EnableStreaming)
+	}
+
+	if v.InConfig("format") && options.OutputFormat == OutputFormatText {
+		options.OutputFormat = v.GetString("format")
+		logVerbose(*options, "Loaded output format from config: %s", options.OutputFormat)
+	}
+
+	if v.InConfig("color") && (options.Color == "" || options.Color == ColorAuto) {
+		options.Color = v.GetString("color")
+		logVerbose(*options, "Loaded color mode from config: %s", options.Color)
+	}
+
+	if v.InConfig("citations") && (options.Citations == "" || options.Citations == CitationsFootnotes) {
+		options.Citations = v.GetString("citations")
+		logVerbose(*options, "Loaded citation style from config: %s", options.Citations)
+	}
+
+	if v.InConfig("on_conflict") && (options.OnConflict == "" || options.OnConflict == ConflictRename) {
+		options.OnConflict = v.GetString("on_conflict")
+		logVerbose(*options, "Loaded saved file conflict policy from config: %s", options.OnConflict)
+	}
 }
 
 // processPrompt loads and processes a prompt template if specified
@@ -373,7 +1551,8 @@ func processPrompt(opts *AgentOptions) error {
 	}
 
 	// Initialize the prompt manager
-	pm := NewPromptManager()
+	pm := NewPromptManager(opts.PromptDirs)
+	pm.AllowEnv(opts.TemplateEnv)
 
 	// Load the prompt content
 	promptContent, err := pm.LoadPrompt(opts.PromptName, opts.PromptFile)
@@ -381,8 +1560,23 @@ func processPrompt(opts *AgentOptions) error {
 		return err
 	}
 
+	frontmatter, promptContent, err := splitPromptFrontmatter(promptContent)
+	if err != nil {
+		return err
+	}
+
 	// Apply template variables if any
-	processedPrompt, err := pm.ProcessPromptTemplate(promptContent, opts.PromptVars)
+	var fileVars map[string]interface{}
+	if opts.VarsFile != "" {
+		if fileVars, err = LoadPromptVarsFile(opts.VarsFile); err != nil {
+			return err
+		}
+	}
+	declared, err := resolvePromptVariables(*opts, frontmatter, givenPromptVariables(fileVars, opts.PromptVars))
+	if err != nil {
+		return err
+	}
+	processedPrompt, err := pm.ProcessPromptTemplate(promptContent, fileVars, append(declared, opts.PromptVars...))
 	if err != nil {
 		return err
 	}
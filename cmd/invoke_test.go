@@ -0,0 +1,160 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file tests the 'invoke' command end to end against the fake agent runtime, from
+the event stream through formatting to the files saved on disk.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime"
+
+	"github.com/hacker65536/aws-bia/internal/fake"
+)
+
+// The real client and the fake are interchangeable
+var (
+	_ BedrockAgentRuntimeAPI = (*bedrockagentruntime.Client)(nil)
+	_ BedrockAgentRuntimeAPI = (*fake.AgentRuntime)(nil)
+)
+
+// fakeInvokeOptions returns the invoke flag defaults with the agent served by runtime.
+// The configuration, cache, and data directories point at an empty temporary home.
+func fakeInvokeOptions(t *testing.T, runtime *fake.AgentRuntime) AgentOptions {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	for _, name := range []string{"XDG_CONFIG_HOME", "XDG_CACHE_HOME", "XDG_DATA_HOME", "XDG_STATE_HOME"} {
+		t.Setenv(name, filepath.Join(home, name))
+	}
+
+	options := opts
+	options.AgentID = "ABCDEFGHIJ"
+	options.AgentAliasID = "KLMNOPQRST"
+	options.SessionID = "test-session"
+	options.Region = "us-east-1"
+	options.InputText = "Create the sales report"
+	options.Color = ColorNever
+	options.NoProgress = true
+	options.NoSessionStore = true
+	options.Clients = NewRuntimeClients(runtime)
+	return options
+}
+
+// captureStdout runs fn and returns what it wrote to stdout
+func captureStdout(t *testing.T, fn func() error) (string, error) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- data
+	}()
+	fnErr := fn()
+	w.Close()
+	return string(<-output), fnErr
+}
+
+func TestInvokeStreamsChunksAndSavesFiles(t *testing.T) {
+	runtime := fake.NewAgentRuntime(fake.Response{Events: []fake.Event{
+		fake.Chunk("Here is "),
+		fake.Chunk("the report."),
+		fake.File("report.csv", "text/csv", []byte("region,sales\nwest,12\n")),
+	}})
+	options := fakeInvokeOptions(t, runtime)
+	options.EnableStreaming = true
+	options.FilesOutputDir = t.TempDir()
+
+	stdout, err := captureStdout(t, func() error { return runInvokeCommand(context.Background(), options) })
+	if err != nil {
+		t.Fatalf("runInvokeCommand() error = %v", err)
+	}
+	if want := "Here is the report."; !strings.Contains(stdout, want) {
+		t.Errorf("stdout = %q, want it to contain %q", stdout, want)
+	}
+
+	data, err := os.ReadFile(filepath.Join(options.FilesOutputDir, "report.csv"))
+	if err != nil {
+		t.Fatalf("saved file: %v", err)
+	}
+	if got, want := string(data), "region,sales\nwest,12\n"; got != want {
+		t.Errorf("saved file = %q, want %q", got, want)
+	}
+
+	inputs := runtime.Inputs()
+	if len(inputs) != 1 {
+		t.Fatalf("InvokeAgent called %d times, want 1", len(inputs))
+	}
+	if got := *inputs[0].InputText; got != options.InputText {
+		t.Errorf("input text = %q, want %q", got, options.InputText)
+	}
+}
+
+func TestInvokeWritesJSONDocument(t *testing.T) {
+	runtime := fake.NewAgentRuntime(fake.Response{Events: []fake.Event{
+		fake.Chunk("The west region grew the most."),
+		fake.File("chart.png", "image/png", []byte{0x89, 'P', 'N', 'G'}),
+	}})
+	options := fakeInvokeOptions(t, runtime)
+	options.OutputFormat = OutputFormatJSON
+	options.FilesOutputDir = t.TempDir()
+
+	stdout, err := captureStdout(t, func() error { return runInvokeCommand(context.Background(), options) })
+	if err != nil {
+		t.Fatalf("runInvokeCommand() error = %v", err)
+	}
+
+	var document struct {
+		Content   string `json:"content"`
+		SessionID string `json:"sessionId"`
+		Files     []struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+			Size int    `json:"size"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &document); err != nil {
+		t.Fatalf("stdout is not a JSON document: %v\n%s", err, stdout)
+	}
+	if want := "The west region grew the most."; document.Content != want {
+		t.Errorf("content = %q, want %q", document.Content, want)
+	}
+	if document.SessionID != "test-session" {
+		t.Errorf("sessionId = %q, want %q", document.SessionID, "test-session")
+	}
+	if len(document.Files) != 1 || document.Files[0].Name != "chart.png" || document.Files[0].Type != "image/png" || document.Files[0].Size != 4 {
+		t.Errorf("files = %+v, want chart.png of type image/png and 4 bytes", document.Files)
+	}
+	if _, err := os.Stat(filepath.Join(options.FilesOutputDir, "chart.png")); err != nil {
+		t.Errorf("saved file: %v", err)
+	}
+}
+
+func TestInvokeReportsStreamException(t *testing.T) {
+	runtime := fake.NewAgentRuntime(fake.Response{Events: []fake.Event{
+		fake.Chunk("Partial"),
+		fake.Exception("throttlingException", "Rate exceeded"),
+	}})
+	options := fakeInvokeOptions(t, runtime)
+	options.EnableStreaming = true
+
+	_, err := captureStdout(t, func() error { return runInvokeCommand(context.Background(), options) })
+	if err == nil || !strings.Contains(err.Error(), "Rate exceeded") {
+		t.Fatalf("runInvokeCommand() error = %v, want the throttling exception", err)
+	}
+}
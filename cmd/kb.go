@@ -0,0 +1,468 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the 'kb list' and 'kb status' commands for AWS Bedrock Intelligent
+Agents CLI. They show the knowledge bases of the account and, for one knowledge base, its
+data sources with the latest ingestion job of each, from the control-plane API. Documents
+are only retrieved once their ingestion job completed, so missing citations are often a
+sync that is still running or failed rather than a problem of the agent.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagent"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagent/types"
+	"github.com/spf13/cobra"
+)
+
+// Sync states of a knowledge base, from the latest ingestion jobs of its data sources
+const (
+	KBSyncInSync    = "in sync"
+	KBSyncSyncing   = "syncing"
+	KBSyncFailed    = "failed"
+	KBSyncNotSynced = "not synced"
+)
+
+// KBOptions contains the options of the kb commands
+type KBOptions struct {
+	ConfigFile      string
+	KnowledgeBaseID string
+	Region          string
+	OutputFormat    string
+	Verbose         bool
+}
+
+// kbSummary is a knowledge base in the list
+type kbSummary struct {
+	ID          string     `json:"knowledgeBaseId"`
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`
+	Description string     `json:"description,omitempty"`
+	UpdatedAt   *time.Time `json:"updatedAt,omitempty"`
+}
+
+// kbStatus is a knowledge base with its data sources and their latest ingestion jobs
+type kbStatus struct {
+	kbSummary
+	Sync           string         `json:"sync"`
+	Type           string         `json:"type,omitempty"`
+	StorageType    string         `json:"storageType,omitempty"`
+	FailureReasons []string       `json:"failureReasons,omitempty"`
+	DataSources    []kbDataSource `json:"dataSources"`
+}
+
+// kbDataSource is a data source of a knowledge base
+type kbDataSource struct {
+	ID        string          `json:"dataSourceId"`
+	Name      string          `json:"name"`
+	Status    string          `json:"status"`
+	UpdatedAt *time.Time      `json:"updatedAt,omitempty"`
+	LastJob   *kbIngestionJob `json:"lastIngestionJob,omitempty"`
+}
+
+// kbIngestionJob is the latest ingestion job of a data source
+type kbIngestionJob struct {
+	ID             string            `json:"ingestionJobId"`
+	Status         string            `json:"status"`
+	StartedAt      *time.Time        `json:"startedAt,omitempty"`
+	UpdatedAt      *time.Time        `json:"updatedAt,omitempty"`
+	Statistics     *kbIngestionStats `json:"statistics,omitempty"`
+	FailureReasons []string          `json:"failureReasons,omitempty"`
+}
+
+// kbIngestionStats counts the documents an ingestion job processed
+type kbIngestionStats struct {
+	Scanned  int64 `json:"scanned"`
+	New      int64 `json:"newIndexed"`
+	Modified int64 `json:"modifiedIndexed"`
+	Deleted  int64 `json:"deleted"`
+	Failed   int64 `json:"failed"`
+}
+
+var kbOpts KBOptions
+
+// kbCmd represents the kb command
+var kbCmd = &cobra.Command{
+	Use:   "kb",
+	Short: "Show knowledge bases and whether their data sources finished syncing",
+	Long: `Show the knowledge bases of the account and the sync status of their data sources.
+
+An agent only finds documents whose ingestion job completed. Before looking into
+missing or outdated citations, check that the latest sync of every data source
+finished without failed documents.`,
+}
+
+// kbListCmd represents the kb list command
+var kbListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the knowledge bases of the account",
+	Long: `List the knowledge bases in the region with their status.
+
+Examples:
+  # List the knowledge bases
+  aws-bia kb list
+
+  # Print the IDs of the active knowledge bases
+  aws-bia kb list --format json | jq -r '.[] | select(.status == "ACTIVE") | .knowledgeBaseId'`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		if err := runKBListCommand(ctx, kbOpts); err != nil {
+			logError("Error listing knowledge bases", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// kbStatusCmd represents the kb status command
+var kbStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the data sources of a knowledge base and their latest ingestion jobs",
+	Long: `Show a knowledge base, its data sources, and the latest ingestion job of each:
+its status, when it ran, and how many documents were scanned, indexed, deleted,
+and failed.
+
+The sync state sums up the data sources: "in sync" when every latest job is
+complete, "syncing" while one is running, "failed" when one failed or was
+stopped, and "not synced" when a data source was never synced.
+
+Examples:
+  # Check that a knowledge base finished syncing
+  aws-bia kb status --kb-id KB12345678
+
+  # Print the sync state in a script
+  aws-bia kb status --kb-id KB12345678 --format json | jq -r .sync`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		if err := runKBStatusCommand(ctx, kbOpts); err != nil {
+			logError("Error getting knowledge base status", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(kbCmd)
+	kbCmd.AddCommand(kbListCmd)
+	kbCmd.AddCommand(kbStatusCmd)
+
+	kbCmd.PersistentFlags().StringVar(&kbOpts.ConfigFile, "config", "", "Path to configuration file (yaml)")
+	kbCmd.PersistentFlags().StringVar(&kbOpts.Region, "region", "", "AWS region to use (defaults to AWS_REGION environment variable)")
+	kbCmd.PersistentFlags().StringVar(&kbOpts.OutputFormat, "format", OutputFormatText, "Output format: text or json")
+	kbCmd.PersistentFlags().BoolVar(&kbOpts.Verbose, "verbose", false, "Enable verbose output")
+
+	kbStatusCmd.Flags().StringVar(&kbOpts.KnowledgeBaseID, "kb-id", "", "The ID of the knowledge base")
+	_ = kbStatusCmd.MarkFlagRequired("kb-id")
+}
+
+// newKBClient loads the configuration and creates the control-plane client for the kb commands
+func newKBClient(ctx context.Context, opts KBOptions) (*bedrockagent.Client, AgentOptions, error) {
+	InitLogger(opts.Verbose)
+
+	agentOpts := AgentOptions{
+		Region:       opts.Region,
+		OutputFormat: opts.OutputFormat,
+		Verbose:      opts.Verbose,
+		Timeout:      DefaultTimeout,
+	}
+	if err := loadConfig(opts.ConfigFile, "kb", &agentOpts); err != nil {
+		return nil, agentOpts, err
+	}
+	if opts.OutputFormat != OutputFormatText && opts.OutputFormat != OutputFormatJSON {
+		return nil, agentOpts, fmt.Errorf("output format must be one of: %s, %s, got '%s'",
+			OutputFormatText, OutputFormatJSON, opts.OutputFormat)
+	}
+
+	client, err := NewAWSHelper(agentOpts).CreateAgentClient(ctx)
+	if err != nil {
+		return nil, agentOpts, fmt.Errorf("failed to create AWS client: %w", err)
+	}
+	return client, agentOpts, nil
+}
+
+// runKBListCommand lists the knowledge bases of the region
+func runKBListCommand(ctx context.Context, opts KBOptions) error {
+	defer SyncLogger()
+	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	client, agentOpts, err := newKBClient(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	knowledgeBases := []kbSummary{}
+	paginator := bedrockagent.NewListKnowledgeBasesPaginator(client, &bedrockagent.ListKnowledgeBasesInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return HandleAWSError(fmt.Errorf("failed to list knowledge bases: %w", err))
+		}
+		for _, summary := range page.KnowledgeBaseSummaries {
+			knowledgeBases = append(knowledgeBases, kbSummary{
+				ID:          aws.ToString(summary.KnowledgeBaseId),
+				Name:        aws.ToString(summary.Name),
+				Status:      string(summary.Status),
+				Description: aws.ToString(summary.Description),
+				UpdatedAt:   summary.UpdatedAt,
+			})
+		}
+	}
+
+	rf := NewResponseFormatter(agentOpts, os.Stdout)
+	if rf.isJSONFormat {
+		return writeKBJSON(knowledgeBases)
+	}
+	if len(knowledgeBases) == 0 {
+		fmt.Fprintln(os.Stderr, "No knowledge bases found")
+		return nil
+	}
+	for _, kb := range knowledgeBases {
+		updated := ""
+		if kb.UpdatedAt != nil {
+			updated = kb.UpdatedAt.Local().Format("2006-01-02 15:04")
+		}
+		// The status is padded before it is colored, escape codes would break the alignment
+		padding := strings.Repeat(" ", max(0, 8-len(kb.Status)))
+		fmt.Fprintf(os.Stdout, "%s  %s%s  %s  %s\n", kb.ID, kbStatusColor(rf.color, kb.Status), padding, updated, kb.Name)
+	}
+	return nil
+}
+
+// runKBStatusCommand shows a knowledge base with the latest ingestion job of each data source
+func runKBStatusCommand(ctx context.Context, opts KBOptions) error {
+	defer SyncLogger()
+	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	client, agentOpts, err := newKBClient(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	status, err := describeKnowledgeBase(ctx, client, opts.KnowledgeBaseID)
+	if err != nil {
+		return err
+	}
+
+	rf := NewResponseFormatter(agentOpts, os.Stdout)
+	if rf.isJSONFormat {
+		return writeKBJSON(status)
+	}
+	rf.writeKBStatus(status)
+	return nil
+}
+
+// describeKnowledgeBase collects the knowledge base, its data sources, and their latest ingestion jobs
+func describeKnowledgeBase(ctx context.Context, client *bedrockagent.Client, kbID string) (*kbStatus, error) {
+	out, err := client.GetKnowledgeBase(ctx, &bedrockagent.GetKnowledgeBaseInput{KnowledgeBaseId: aws.String(kbID)})
+	if err != nil {
+		return nil, HandleAWSError(fmt.Errorf("failed to get knowledge base '%s': %w", kbID, err))
+	}
+	kb := out.KnowledgeBase
+	status := &kbStatus{
+		kbSummary: kbSummary{
+			ID:          aws.ToString(kb.KnowledgeBaseId),
+			Name:        aws.ToString(kb.Name),
+			Status:      string(kb.Status),
+			Description: aws.ToString(kb.Description),
+			UpdatedAt:   kb.UpdatedAt,
+		},
+		FailureReasons: kb.FailureReasons,
+		DataSources:    []kbDataSource{},
+	}
+	if kb.KnowledgeBaseConfiguration != nil {
+		status.Type = string(kb.KnowledgeBaseConfiguration.Type)
+	}
+	if kb.StorageConfiguration != nil {
+		status.StorageType = string(kb.StorageConfiguration.Type)
+	}
+
+	paginator := bedrockagent.NewListDataSourcesPaginator(client, &bedrockagent.ListDataSourcesInput{KnowledgeBaseId: aws.String(kbID)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, HandleAWSError(fmt.Errorf("failed to list data sources: %w", err))
+		}
+		for _, summary := range page.DataSourceSummaries {
+			source := kbDataSource{
+				ID:        aws.ToString(summary.DataSourceId),
+				Name:      aws.ToString(summary.Name),
+				Status:    string(summary.Status),
+				UpdatedAt: summary.UpdatedAt,
+			}
+			if source.LastJob, err = latestIngestionJob(ctx, client, kbID, source.ID); err != nil {
+				return nil, err
+			}
+			status.DataSources = append(status.DataSources, source)
+		}
+	}
+	status.Sync = kbSyncState(status.DataSources)
+	return status, nil
+}
+
+// latestIngestionJob returns the most recently started ingestion job of a data source, or nil if
+// it was never synced. The failure reasons are only part of the full job.
+func latestIngestionJob(ctx context.Context, client *bedrockagent.Client, kbID, dataSourceID string) (*kbIngestionJob, error) {
+	out, err := client.ListIngestionJobs(ctx, &bedrockagent.ListIngestionJobsInput{
+		KnowledgeBaseId: aws.String(kbID),
+		DataSourceId:    aws.String(dataSourceID),
+		SortBy: &types.IngestionJobSortBy{
+			Attribute: types.IngestionJobSortByAttributeStartedAt,
+			Order:     types.SortOrderDescending,
+		},
+		MaxResults: aws.Int32(1),
+	})
+	if err != nil {
+		return nil, HandleAWSError(fmt.Errorf("failed to list ingestion jobs of data source '%s': %w", dataSourceID, err))
+	}
+	if len(out.IngestionJobSummaries) == 0 {
+		return nil, nil
+	}
+
+	summary := out.IngestionJobSummaries[0]
+	job := &kbIngestionJob{
+		ID:        aws.ToString(summary.IngestionJobId),
+		Status:    string(summary.Status),
+		StartedAt: summary.StartedAt,
+		UpdatedAt: summary.UpdatedAt,
+	}
+	if stats := summary.Statistics; stats != nil {
+		job.Statistics = &kbIngestionStats{
+			Scanned:  stats.NumberOfDocumentsScanned,
+			New:      stats.NumberOfNewDocumentsIndexed,
+			Modified: stats.NumberOfModifiedDocumentsIndexed,
+			Deleted:  stats.NumberOfDocumentsDeleted,
+			Failed:   stats.NumberOfDocumentsFailed,
+		}
+	}
+	if summary.Status == types.IngestionJobStatusFailed || (job.Statistics != nil && job.Statistics.Failed > 0) {
+		full, err := client.GetIngestionJob(ctx, &bedrockagent.GetIngestionJobInput{
+			KnowledgeBaseId: aws.String(kbID),
+			DataSourceId:    aws.String(dataSourceID),
+			IngestionJobId:  summary.IngestionJobId,
+		})
+		if err != nil {
+			return nil, HandleAWSError(fmt.Errorf("failed to get ingestion job '%s': %w", job.ID, err))
+		}
+		job.FailureReasons = full.IngestionJob.FailureReasons
+	}
+	return job, nil
+}
+
+// kbSyncState sums up the latest ingestion jobs of the data sources
+func kbSyncState(sources []kbDataSource) string {
+	state := KBSyncInSync
+	for _, source := range sources {
+		if source.LastJob == nil {
+			if state == KBSyncInSync {
+				state = KBSyncNotSynced
+			}
+			continue
+		}
+		switch types.IngestionJobStatus(source.LastJob.Status) {
+		case types.IngestionJobStatusStarting, types.IngestionJobStatusInProgress, types.IngestionJobStatusStopping:
+			return KBSyncSyncing
+		case types.IngestionJobStatusFailed, types.IngestionJobStatusStopped:
+			state = KBSyncFailed
+		}
+	}
+	if len(sources) == 0 {
+		return KBSyncNotSynced
+	}
+	return state
+}
+
+// writeKBStatus writes a knowledge base with its data sources as text
+func (rf *ResponseFormatter) writeKBStatus(status *kbStatus) {
+	label := func(name string) string { return rf.color.style(ansiBold, fmt.Sprintf("%-16s", name+":")) }
+	w := rf.Writer
+
+	fmt.Fprintf(w, "%s %s (%s)\n", label("Knowledge base"), status.Name, status.ID)
+	fmt.Fprintf(w, "%s %s\n", label("Status"), kbStatusColor(rf.color, status.Status))
+	if status.Description != "" {
+		fmt.Fprintf(w, "%s %s\n", label("Description"), status.Description)
+	}
+	if status.Type != "" {
+		kind := status.Type
+		if status.StorageType != "" {
+			kind += ", stored in " + status.StorageType
+		}
+		fmt.Fprintf(w, "%s %s\n", label("Type"), kind)
+	}
+	if status.UpdatedAt != nil {
+		fmt.Fprintf(w, "%s %s\n", label("Updated"), status.UpdatedAt.Format(time.RFC3339))
+	}
+	if len(status.FailureReasons) > 0 {
+		fmt.Fprintf(w, "%s %s\n", label("Failure reasons"), rf.color.Error(strings.Join(status.FailureReasons, "; ")))
+	}
+	sync := status.Sync
+	if sync != KBSyncInSync {
+		sync = rf.color.Banner(sync)
+	}
+	fmt.Fprintf(w, "%s %s\n", label("Sync"), sync)
+
+	fmt.Fprintf(w, "\n%s\n", rf.color.style(ansiBold, fmt.Sprintf("Data sources (%d):", len(status.DataSources))))
+	for _, source := range status.DataSources {
+		fmt.Fprintf(w, "  - %s (%s) %s\n", source.Name, source.ID, source.Status)
+		job := source.LastJob
+		if job == nil {
+			fmt.Fprintf(w, "    Last sync: %s\n", rf.color.Banner("never"))
+			continue
+		}
+
+		fmt.Fprintf(w, "    Last sync: %s", kbStatusColor(rf.color, job.Status))
+		if job.StartedAt != nil {
+			fmt.Fprintf(w, ", started %s", job.StartedAt.Local().Format("2006-01-02 15:04"))
+			if job.UpdatedAt != nil && job.Status == string(types.IngestionJobStatusComplete) {
+				fmt.Fprintf(w, ", took %s", formatDuration(job.UpdatedAt.Sub(*job.StartedAt)))
+			}
+		}
+		fmt.Fprintf(w, " (job %s)\n", job.ID)
+		if stats := job.Statistics; stats != nil {
+			failed := fmt.Sprintf("%d failed", stats.Failed)
+			if stats.Failed > 0 {
+				failed = rf.color.Error(failed)
+			}
+			fmt.Fprintf(w, "    Documents: %d scanned, %d new, %d modified, %d deleted, %s\n",
+				stats.Scanned, stats.New, stats.Modified, stats.Deleted, failed)
+		}
+		for _, reason := range job.FailureReasons {
+			fmt.Fprintf(w, "    %s\n", rf.color.Error(reason))
+		}
+	}
+}
+
+// kbStatusColor highlights statuses that need attention
+func kbStatusColor(color colorizer, status string) string {
+	switch status {
+	case "FAILED", "DELETE_UNSUCCESSFUL", "STOPPED":
+		return color.Error(status)
+	case "ACTIVE", "AVAILABLE", "COMPLETE":
+		return status
+	default:
+		return color.Banner(status)
+	}
+}
+
+// writeKBJSON writes the output of a kb command as indented JSON
+func writeKBJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal knowledge bases: %w", err)
+	}
+	_, err = fmt.Fprintln(os.Stdout, string(data))
+	return err
+}
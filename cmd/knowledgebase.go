@@ -0,0 +1,228 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements per-invocation knowledge base overrides for the AWS Bedrock Intelligent
+Agents CLI. The --kb-* flags are sent as SessionState.KnowledgeBaseConfigurations, so an agent
+can be tested against other knowledge bases or retrieval settings without editing the agent.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/document"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/types"
+)
+
+// MaxKnowledgeBaseResults is the largest number of results a knowledge base query may return
+const MaxKnowledgeBaseResults = 100
+
+// filePrefix marks flag values that are read from a file, like in the AWS CLI
+const filePrefix = "file://"
+
+// validateKnowledgeBaseOptions checks the --kb-* flags before calling the agent
+func validateKnowledgeBaseOptions(opts AgentOptions) error {
+	if len(opts.KnowledgeBaseIDs) == 0 {
+		if opts.KBResults != 0 || opts.KBFilter != "" || opts.KBSearchType != "" {
+			return fmt.Errorf("--kb-results, --kb-filter, and --kb-search-type require --kb-id")
+		}
+		return nil
+	}
+
+	if opts.KBResults < 0 || opts.KBResults > MaxKnowledgeBaseResults {
+		return fmt.Errorf("--kb-results must be between 1 and %d", MaxKnowledgeBaseResults)
+	}
+
+	if opts.KBSearchType != "" {
+		if _, err := parseSearchType(opts.KBSearchType); err != nil {
+			return err
+		}
+	}
+
+	if opts.KBFilter != "" {
+		if _, err := loadRetrievalFilter(opts.KBFilter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildKnowledgeBaseConfigurations returns the session state configuration for every --kb-id
+func buildKnowledgeBaseConfigurations(opts AgentOptions) ([]types.KnowledgeBaseConfiguration, error) {
+	if len(opts.KnowledgeBaseIDs) == 0 {
+		return nil, nil
+	}
+
+	search := &types.KnowledgeBaseVectorSearchConfiguration{}
+	if opts.KBResults > 0 {
+		search.NumberOfResults = aws.Int32(int32(opts.KBResults))
+	}
+	if opts.KBSearchType != "" {
+		searchType, err := parseSearchType(opts.KBSearchType)
+		if err != nil {
+			return nil, err
+		}
+		search.OverrideSearchType = searchType
+	}
+	if opts.KBFilter != "" {
+		filter, err := loadRetrievalFilter(opts.KBFilter)
+		if err != nil {
+			return nil, err
+		}
+		search.Filter = filter
+	}
+
+	configurations := make([]types.KnowledgeBaseConfiguration, 0, len(opts.KnowledgeBaseIDs))
+	for _, id := range opts.KnowledgeBaseIDs {
+		configurations = append(configurations, types.KnowledgeBaseConfiguration{
+			KnowledgeBaseId: aws.String(id),
+			RetrievalConfiguration: &types.KnowledgeBaseRetrievalConfiguration{
+				VectorSearchConfiguration: search,
+			},
+		})
+	}
+	return configurations, nil
+}
+
+// parseSearchType accepts HYBRID or SEMANTIC in any case
+func parseSearchType(value string) (types.SearchType, error) {
+	searchType := types.SearchType(strings.ToUpper(value))
+	for _, known := range searchType.Values() {
+		if searchType == known {
+			return searchType, nil
+		}
+	}
+	return "", fmt.Errorf("invalid knowledge base search type '%s': must be HYBRID or SEMANTIC", value)
+}
+
+// loadRetrievalFilter parses filter JSON given inline or as file://path
+func loadRetrievalFilter(value string) (types.RetrievalFilter, error) {
+	data := []byte(value)
+	if path, ok := strings.CutPrefix(value, filePrefix); ok {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("failed to read knowledge base filter '%s': %w", path, err)
+		}
+	}
+
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid knowledge base filter JSON: %w", err)
+	}
+
+	filter, err := parseRetrievalFilter(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid knowledge base filter: %w", err)
+	}
+	return filter, nil
+}
+
+// comparisonFilters creates the filter for each operator that compares a single attribute
+var comparisonFilters = map[string]func(types.FilterAttribute) types.RetrievalFilter{
+	"equals": func(a types.FilterAttribute) types.RetrievalFilter {
+		return &types.RetrievalFilterMemberEquals{Value: a}
+	},
+	"notEquals": func(a types.FilterAttribute) types.RetrievalFilter {
+		return &types.RetrievalFilterMemberNotEquals{Value: a}
+	},
+	"greaterThan": func(a types.FilterAttribute) types.RetrievalFilter {
+		return &types.RetrievalFilterMemberGreaterThan{Value: a}
+	},
+	"greaterThanOrEquals": func(a types.FilterAttribute) types.RetrievalFilter {
+		return &types.RetrievalFilterMemberGreaterThanOrEquals{Value: a}
+	},
+	"lessThan": func(a types.FilterAttribute) types.RetrievalFilter {
+		return &types.RetrievalFilterMemberLessThan{Value: a}
+	},
+	"lessThanOrEquals": func(a types.FilterAttribute) types.RetrievalFilter {
+		return &types.RetrievalFilterMemberLessThanOrEquals{Value: a}
+	},
+	"in": func(a types.FilterAttribute) types.RetrievalFilter { return &types.RetrievalFilterMemberIn{Value: a} },
+	"notIn": func(a types.FilterAttribute) types.RetrievalFilter {
+		return &types.RetrievalFilterMemberNotIn{Value: a}
+	},
+	"startsWith": func(a types.FilterAttribute) types.RetrievalFilter {
+		return &types.RetrievalFilterMemberStartsWith{Value: a}
+	},
+	"listContains": func(a types.FilterAttribute) types.RetrievalFilter {
+		return &types.RetrievalFilterMemberListContains{Value: a}
+	},
+	"stringContains": func(a types.FilterAttribute) types.RetrievalFilter {
+		return &types.RetrievalFilterMemberStringContains{Value: a}
+	},
+}
+
+// parseRetrievalFilter converts a filter in the JSON shape of the Bedrock API, e.g.
+// {"andAll": [{"equals": {"key": "year", "value": 2024}}, ...]}, into the SDK union type
+func parseRetrievalFilter(raw interface{}) (types.RetrievalFilter, error) {
+	object, ok := raw.(map[string]interface{})
+	if !ok || len(object) != 1 {
+		return nil, fmt.Errorf("each filter must be an object with exactly one operator")
+	}
+
+	for operator, operand := range object {
+		switch operator {
+		case "andAll", "orAll":
+			filters, err := parseRetrievalFilters(operator, operand)
+			if err != nil {
+				return nil, err
+			}
+			if operator == "andAll" {
+				return &types.RetrievalFilterMemberAndAll{Value: filters}, nil
+			}
+			return &types.RetrievalFilterMemberOrAll{Value: filters}, nil
+		}
+
+		newFilter, known := comparisonFilters[operator]
+		if !known {
+			return nil, fmt.Errorf("unknown filter operator '%s'", operator)
+		}
+		attribute, err := parseFilterAttribute(operator, operand)
+		if err != nil {
+			return nil, err
+		}
+		return newFilter(attribute), nil
+	}
+	return nil, nil
+}
+
+// parseRetrievalFilters parses the list of filters combined by andAll or orAll
+func parseRetrievalFilters(operator string, operand interface{}) ([]types.RetrievalFilter, error) {
+	list, ok := operand.([]interface{})
+	if !ok || len(list) < 2 {
+		return nil, fmt.Errorf("'%s' requires a list of at least two filters", operator)
+	}
+
+	filters := make([]types.RetrievalFilter, 0, len(list))
+	for _, item := range list {
+		filter, err := parseRetrievalFilter(item)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
+// parseFilterAttribute parses the {"key": ..., "value": ...} operand of a comparison operator
+func parseFilterAttribute(operator string, operand interface{}) (types.FilterAttribute, error) {
+	object, ok := operand.(map[string]interface{})
+	if !ok {
+		return types.FilterAttribute{}, fmt.Errorf("'%s' requires an object with key and value", operator)
+	}
+
+	key, _ := object["key"].(string)
+	value, hasValue := object["value"]
+	if key == "" || !hasValue {
+		return types.FilterAttribute{}, fmt.Errorf("'%s' requires an object with key and value", operator)
+	}
+
+	return types.FilterAttribute{
+		Key:   aws.String(key),
+		Value: document.NewLazyDocument(value),
+	}, nil
+}
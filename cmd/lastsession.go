@@ -0,0 +1,89 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements remembered sessions for the AWS Bedrock Intelligent Agents CLI.
+With --remember-session the session ID of an invocation is written to the last-session
+file of the data directory under its agent, and --continue sends the next invocation of
+that agent in the same session, without copying the ID from the output.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lastSession is the remembered session of an agent
+type lastSession struct {
+	SessionID    string    `json:"sessionId"`
+	AgentAliasID string    `json:"agentAliasId"`
+	SavedAt      time.Time `json:"savedAt"`
+}
+
+// lastSessionPath returns the location of the remembered sessions
+func lastSessionPath() (string, error) {
+	return dataPath("last-session")
+}
+
+// loadLastSessions reads the remembered sessions by agent ID; a missing file has none
+func loadLastSessions() (map[string]lastSession, error) {
+	sessions := map[string]lastSession{}
+	path, err := lastSessionPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return sessions, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &sessions); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return sessions, nil
+}
+
+// rememberSession records the session of an agent, replacing the one remembered before
+func rememberSession(agentID string, session lastSession) error {
+	sessions, err := loadLastSessions()
+	if err != nil {
+		return err
+	}
+	sessions[agentID] = session
+
+	path, err := lastSessionPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	data, err := json.MarshalIndent(sessions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode remembered sessions: %w", err)
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// continueLastSession sets the session ID to the one remembered for the agent
+func continueLastSession(opts *AgentOptions) error {
+	sessions, err := loadLastSessions()
+	if err != nil {
+		return err
+	}
+	session, ok := sessions[opts.AgentID]
+	if !ok {
+		return fmt.Errorf("no session remembered for agent %s, invoke it with --remember-session first", opts.AgentID)
+	}
+	if session.AgentAliasID != opts.AgentAliasID {
+		LogWarn("Session %s was remembered with alias %s, continuing it with alias %s", session.SessionID, session.AgentAliasID, opts.AgentAliasID)
+	}
+	opts.SessionID = session.SessionID
+	logVerbose(*opts, "Continuing session %s (remembered %s ago)", session.SessionID, formatDuration(time.Since(session.SavedAt)))
+	return nil
+}
@@ -0,0 +1,200 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the stream latency report of the AWS Bedrock Intelligent Agents CLI.
+The stream processor notes when every event arrives, which separates the time the agent
+takes to start answering from the pace at which the answer arrives: a long wait for the
+response or the first chunk points at the agent, long gaps between chunks at the network
+or the model. --latency-report prints the timings with a histogram of the chunk gaps.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// latencyBuckets are the upper bounds of the chunk gap histogram; the last bucket is open
+var latencyBuckets = []time.Duration{
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+}
+
+// latencyBarWidth is the length of the longest histogram bar
+const latencyBarWidth = 30
+
+// StreamTiming is when the events of a response stream arrived, relative to the request
+type StreamTiming struct {
+	ResponseStart time.Duration   // Until the event stream started, after the response headers
+	FirstEvent    time.Duration   // Until the first event of any type
+	FirstChunk    time.Duration   // Until the first text chunk; 0 when there was no text
+	Total         time.Duration   // Until the end of the stream
+	Events        int             // Events received
+	Chunks        int             // Text chunks received
+	ChunkGaps     []time.Duration // Between consecutive text chunks
+}
+
+// streamClock collects the timing while a stream is processed
+type streamClock struct {
+	requestStart time.Time
+	lastChunk    time.Time
+	timing       StreamTiming
+}
+
+// newStreamClock starts timing a stream of a request sent at requestStart, or now if it is zero
+func newStreamClock(requestStart time.Time) *streamClock {
+	now := time.Now()
+	if requestStart.IsZero() {
+		requestStart = now
+	}
+	return &streamClock{
+		requestStart: requestStart,
+		timing:       StreamTiming{ResponseStart: now.Sub(requestStart)},
+	}
+}
+
+// event records an event received at now
+func (c *streamClock) event(now time.Time, isChunk bool) {
+	if c.timing.Events == 0 {
+		c.timing.FirstEvent = now.Sub(c.requestStart)
+	}
+	c.timing.Events++
+	if !isChunk {
+		return
+	}
+
+	if c.timing.Chunks == 0 {
+		c.timing.FirstChunk = now.Sub(c.requestStart)
+	} else {
+		c.timing.ChunkGaps = append(c.timing.ChunkGaps, now.Sub(c.lastChunk))
+	}
+	c.timing.Chunks++
+	c.lastChunk = now
+}
+
+// finish returns the timing of the stream ending now
+func (c *streamClock) finish() StreamTiming {
+	c.timing.Total = time.Since(c.requestStart)
+	return c.timing
+}
+
+// gapStats returns the minimum, median, 95th percentile, and maximum chunk gap
+func (t StreamTiming) gapStats() (shortest, median, p95, longest time.Duration) {
+	if len(t.ChunkGaps) == 0 {
+		return 0, 0, 0, 0
+	}
+	gaps := append([]time.Duration(nil), t.ChunkGaps...)
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+	percentile := func(p float64) time.Duration {
+		return gaps[int(p*float64(len(gaps)-1)+0.5)]
+	}
+	return gaps[0], percentile(0.5), percentile(0.95), gaps[len(gaps)-1]
+}
+
+// histogram counts the chunk gaps per bucket of latencyBuckets, plus one for longer gaps
+func (t StreamTiming) histogram() []int {
+	counts := make([]int, len(latencyBuckets)+1)
+	for _, gap := range t.ChunkGaps {
+		i := sort.Search(len(latencyBuckets), func(i int) bool { return gap < latencyBuckets[i] })
+		counts[i]++
+	}
+	return counts
+}
+
+// bucketLabel names a histogram bucket, e.g. "<100ms" or ">=2.5s"
+func bucketLabel(i int) string {
+	if i == len(latencyBuckets) {
+		return ">=" + formatDuration(latencyBuckets[i-1])
+	}
+	return "<" + formatDuration(latencyBuckets[i])
+}
+
+// Summary describes the timing on one line, for verbose logs
+func (t StreamTiming) Summary() string {
+	summary := fmt.Sprintf("response started after %s, first event after %s", formatDuration(t.ResponseStart), formatDuration(t.FirstEvent))
+	if t.Chunks > 0 {
+		summary += ", first chunk after " + formatDuration(t.FirstChunk)
+	}
+	summary += fmt.Sprintf(", %d event(s) and %d chunk(s) in %s", t.Events, t.Chunks, formatDuration(t.Total))
+	if len(t.ChunkGaps) > 0 {
+		_, median, _, longest := t.gapStats()
+		summary += fmt.Sprintf(", chunk gaps median %s max %s", formatDuration(median), formatDuration(longest))
+	}
+	return summary
+}
+
+// WriteReport writes the timing and a histogram of the chunk gaps
+func (t StreamTiming) WriteReport(w io.Writer) {
+	fmt.Fprintln(w, "Latency report:")
+	fmt.Fprintf(w, "  %-16s %s\n", "Response start:", formatDuration(t.ResponseStart))
+	fmt.Fprintf(w, "  %-16s %s\n", "First event:", formatDuration(t.FirstEvent))
+	if t.Chunks > 0 {
+		fmt.Fprintf(w, "  %-16s %s\n", "First chunk:", formatDuration(t.FirstChunk))
+	}
+	fmt.Fprintf(w, "  %-16s %s (%d event(s), %d chunk(s))\n", "Total:", formatDuration(t.Total), t.Events, t.Chunks)
+	if len(t.ChunkGaps) == 0 {
+		return
+	}
+
+	shortest, median, p95, longest := t.gapStats()
+	fmt.Fprintf(w, "  %-16s min %s, median %s, p95 %s, max %s\n", "Chunk gaps:",
+		formatDuration(shortest), formatDuration(median), formatDuration(p95), formatDuration(longest))
+
+	// Empty buckets below the shortest and above the longest gap are left out
+	counts := t.histogram()
+	first, last, most := -1, 0, 0
+	for i, n := range counts {
+		if n > 0 {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+		most = max(most, n)
+	}
+	for i := first; i <= last; i++ {
+		n := counts[i]
+		bar := strings.Repeat("#", (n*latencyBarWidth+most-1)/most)
+		fmt.Fprintf(w, "  %8s %-*s %d\n", bucketLabel(i), latencyBarWidth, bar, n)
+	}
+}
+
+// JSON returns the timing for JSON output, in milliseconds
+func (t StreamTiming) JSON() map[string]interface{} {
+	ms := func(d time.Duration) float64 {
+		return float64(d.Microseconds()) / 1000
+	}
+	timing := map[string]interface{}{
+		"responseStartMs": ms(t.ResponseStart),
+		"firstEventMs":    ms(t.FirstEvent),
+		"totalMs":         ms(t.Total),
+		"events":          t.Events,
+		"chunks":          t.Chunks,
+	}
+	if t.Chunks > 0 {
+		timing["firstChunkMs"] = ms(t.FirstChunk)
+	}
+	if len(t.ChunkGaps) > 0 {
+		shortest, median, p95, longest := t.gapStats()
+		timing["chunkGapsMs"] = map[string]float64{
+			"min":    ms(shortest),
+			"median": ms(median),
+			"p95":    ms(p95),
+			"max":    ms(longest),
+		}
+		histogram := make([]map[string]interface{}, 0, len(latencyBuckets)+1)
+		for i, n := range t.histogram() {
+			histogram = append(histogram, map[string]interface{}{"bucket": bucketLabel(i), "count": n})
+		}
+		timing["chunkGapHistogram"] = histogram
+	}
+	return timing
+}
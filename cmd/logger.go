@@ -2,20 +2,139 @@
 Copyright © 2025 AWS-BIA Contributors
 
 This file implements centralized logging using go.uber.org/zap library.
+Logs always go to stderr; --log-format and --log-level select the encoding and the
+minimum level independently of --verbose, and --log-redact how much of the input
+text appears in them.
 */
 package cmd
 
 import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+// Log formats accepted by --log-format
+const (
+	LogFormatAuto    = "auto"    // Console logs with --verbose, JSON otherwise
+	LogFormatJSON    = "json"    // One JSON object per line
+	LogFormatConsole = "console" // Human-readable lines
+)
+
+// Policies for the input text in logs accepted by --log-redact
+const (
+	LogRedactTruncate = "truncate" // Only the beginning of the input text
+	LogRedactFull     = "full"     // Only the length of the input text
+	LogRedactNone     = "none"     // The complete input text
+)
+
+// logRedactLength is how many characters of the input text the truncate policy keeps
+const logRedactLength = 80
+
 var (
 	logger      *zap.Logger
 	sugar       *zap.SugaredLogger
 	initialized bool // Track initialization state to avoid redundant calls
+
+	// Set from the global --log-format and --log-level flags
+	logFormat = LogFormatAuto
+	logLevel  string
+	logRedact = LogRedactTruncate // Set from the global --log-redact flag
+
+	// logCapture keeps the most recent entries of every level for --diag-bundle
+	logCapture *logTail
 )
 
+// logTailSize is the amount of recent log output kept by a log capture
+const logTailSize = 1 << 20
+
+// logTail keeps the last logTailSize bytes written to it
+type logTail struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+// Write appends p, dropping the oldest complete lines beyond logTailSize
+func (t *logTail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.buf = append(t.buf, p...)
+	if excess := len(t.buf) - logTailSize; excess > 0 {
+		t.buf = t.buf[excess:]
+		if i := bytes.IndexByte(t.buf, '\n'); i >= 0 {
+			t.buf = t.buf[i+1:]
+		}
+	}
+	return len(p), nil
+}
+
+// Bytes returns a copy of the kept log output
+func (t *logTail) Bytes() []byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]byte(nil), t.buf...)
+}
+
+// Sync implements zapcore.WriteSyncer
+func (t *logTail) Sync() error {
+	return nil
+}
+
+// captureLogs starts keeping recent log entries, including debug entries without --verbose.
+// It must be called before InitLogger.
+func captureLogs() *logTail {
+	if logCapture == nil {
+		logCapture = &logTail{}
+	}
+	return logCapture
+}
+
+// validateLogSettings checks the values of the global --log-format, --log-level, and --log-redact flags
+func validateLogSettings(format, level, redact string) error {
+	switch format {
+	case LogFormatAuto, LogFormatJSON, LogFormatConsole:
+	default:
+		return fmt.Errorf("invalid log format '%s': must be auto, json, or console", format)
+	}
+	if level != "" {
+		if _, err := zapcore.ParseLevel(level); err != nil {
+			return fmt.Errorf("invalid log level '%s': must be debug, info, warn, or error", level)
+		}
+	}
+	switch redact {
+	case LogRedactTruncate, LogRedactFull, LogRedactNone:
+	default:
+		return fmt.Errorf("invalid log redaction '%s': must be truncate, full, or none", redact)
+	}
+	return nil
+}
+
+// redactLogText applies the --log-redact policy to input text that is about to be logged
+func redactLogText(text string) string {
+	switch logRedact {
+	case LogRedactNone:
+		return text
+	case LogRedactFull:
+		return fmt.Sprintf("[redacted, %d characters]", utf8.RuneCountInString(text))
+	}
+	if runes := []rune(text); len(runes) > logRedactLength {
+		return fmt.Sprintf("%s... [%d more characters]", string(runes[:logRedactLength]), len(runes)-logRedactLength)
+	}
+	return text
+}
+
+// debugLogging reports whether --log-level enables debug messages without --verbose
+func debugLogging() bool {
+	return strings.EqualFold(logLevel, "debug")
+}
+
 // InitLogger initializes the global logger based on verbose mode
 func InitLogger(verbose bool) {
 	if initialized {
@@ -23,7 +142,7 @@ func InitLogger(verbose bool) {
 	}
 
 	var config zap.Config
-	if verbose {
+	if logFormat == LogFormatConsole || (logFormat == LogFormatAuto && verbose) {
 		// In verbose mode, use development config for more readable output
 		config = zap.NewDevelopmentConfig()
 		config.Level = zap.NewAtomicLevelAt(zap.DebugLevel)
@@ -40,10 +159,31 @@ func InitLogger(verbose bool) {
 		config.DisableCaller = true
 		config.DisableStacktrace = true
 	}
+	if verbose && logFormat == LogFormatJSON {
+		config.Level = zap.NewAtomicLevelAt(zap.DebugLevel)
+	}
+
+	// An explicit level wins over the one implied by --verbose
+	if level, err := zapcore.ParseLevel(logLevel); logLevel != "" && err == nil {
+		config.Level = zap.NewAtomicLevelAt(level)
+	}
+	config.OutputPaths = []string{"stderr"}
+	config.ErrorOutputPaths = []string{"stderr"}
+
+	// Captured entries are always JSON, whatever is shown on stderr
+	var buildOptions []zap.Option
+	if logCapture != nil {
+		captureEncoder := zap.NewProductionEncoderConfig()
+		captureEncoder.EncodeTime = zapcore.ISO8601TimeEncoder
+		captureCore := zapcore.NewCore(zapcore.NewJSONEncoder(captureEncoder), logCapture, zap.DebugLevel)
+		buildOptions = append(buildOptions, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(core, captureCore)
+		}))
+	}
 
 	// Build the logger
 	var err error
-	logger, err = config.Build()
+	logger, err = config.Build(buildOptions...)
 	if err != nil {
 		// Fallback to basic logger if build fails
 		logger = zap.NewNop()
@@ -71,7 +211,7 @@ func GetSugar() *zap.SugaredLogger {
 
 // LogVerbose logs a debug message using zap (replacement for the old logVerbose function)
 func LogVerbose(opts AgentOptions, format string, args ...interface{}) {
-	if !opts.Verbose {
+	if !verboseLogging(opts) {
 		return // Early return to avoid sugar access when not needed
 	}
 
@@ -82,6 +222,12 @@ func LogVerbose(opts AgentOptions, format string, args ...interface{}) {
 	sugar.Debugf(format, args...)
 }
 
+// verboseLogging reports whether verbose messages are logged or captured, so callers can
+// skip building messages that are expensive to format
+func verboseLogging(opts AgentOptions) bool {
+	return opts.Verbose || debugLogging() || logCapture != nil
+}
+
 // LogError logs an error message using zap (replacement for the old logError function)
 func LogError(message string, err error) {
 	if !initialized {
@@ -109,6 +255,15 @@ func LogWarn(format string, args ...interface{}) {
 	sugar.Warnf(format, args...)
 }
 
+// logNotice writes a verbose status line to stderr, as a debug entry when logs are JSON
+func logNotice(format string, args ...interface{}) {
+	if logFormat == LogFormatJSON {
+		GetSugar().Debugf(format, args...)
+		return
+	}
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+}
+
 // SyncLogger flushes the logger (should be called before program exit)
 func SyncLogger() {
 	if initialized && logger != nil {
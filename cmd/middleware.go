@@ -0,0 +1,153 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements custom request middleware for the AWS Bedrock Intelligent Agents CLI.
+Every AWS request can be stamped with the static headers of the request_headers setting
+and passed to the executables of the request_hooks setting, which may observe it and
+return headers to set, e.g. for custom auth in front of private endpoints. Programs
+embedding the CLI can register their own smithy middleware with RegisterAPIOptions.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/spf13/viper"
+)
+
+// requestHookTimeout bounds each run of a request hook
+const requestHookTimeout = 10 * time.Second
+
+var (
+	apiOptionsMu     sync.Mutex
+	customAPIOptions []func(*middleware.Stack) error
+)
+
+// RegisterAPIOptions adds smithy middleware to every AWS client the CLI creates.
+// Call it before Execute, e.g. from a main package that wraps the CLI.
+func RegisterAPIOptions(fns ...func(*middleware.Stack) error) {
+	apiOptionsMu.Lock()
+	defer apiOptionsMu.Unlock()
+	customAPIOptions = append(customAPIOptions, fns...)
+}
+
+// requestHookInput is written as JSON to the standard input of a request hook
+type requestHookInput struct {
+	Service   string              `json:"service"`
+	Operation string              `json:"operation"`
+	Method    string              `json:"method"`
+	URL       string              `json:"url"`
+	Headers   map[string][]string `json:"headers"`
+}
+
+// requestHookOutput is read as JSON from the standard output of a request hook
+type requestHookOutput struct {
+	Headers map[string]string `json:"headers"` // Headers to set on the request
+}
+
+// loadRequestHeaders reads the request_headers mapping, expanding environment variables in the values
+func loadRequestHeaders(v *viper.Viper) map[string]string {
+	raw := v.GetStringMapString("request_headers")
+	if len(raw) == 0 {
+		return nil
+	}
+
+	// viper lowercases keys, which is fine for HTTP headers
+	headers := make(map[string]string, len(raw))
+	for name, value := range raw {
+		headers[name] = os.ExpandEnv(value)
+	}
+	return headers
+}
+
+// apiOptions returns the middleware to add to the AWS clients created for opts
+func apiOptions(opts AgentOptions) []func(*middleware.Stack) error {
+	apiOptionsMu.Lock()
+	fns := append([]func(*middleware.Stack) error{}, customAPIOptions...)
+	apiOptionsMu.Unlock()
+
+	if len(opts.RequestHeaders) > 0 || len(opts.RequestHooks) > 0 {
+		fns = append(fns, func(stack *middleware.Stack) error {
+			return stack.Finalize.Add(newRequestHookMiddleware(opts), middleware.After)
+		})
+	}
+	return fns
+}
+
+// newRequestHookMiddleware sets the configured headers and runs the hooks after signing, so
+// the added headers are not part of the signature and a proxy may consume them
+func newRequestHookMiddleware(opts AgentOptions) middleware.FinalizeMiddleware {
+	return middleware.FinalizeMiddlewareFunc("AWSBIARequestHooks", func(
+		ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler,
+	) (middleware.FinalizeOutput, middleware.Metadata, error) {
+		req, ok := in.Request.(*smithyhttp.Request)
+		if !ok {
+			return next.HandleFinalize(ctx, in)
+		}
+
+		for name, value := range opts.RequestHeaders {
+			req.Header.Set(name, value)
+		}
+
+		for _, command := range opts.RequestHooks {
+			headers, err := runRequestHook(ctx, opts, command, req)
+			if err != nil {
+				return middleware.FinalizeOutput{}, middleware.Metadata{}, err
+			}
+			for name, value := range headers {
+				req.Header.Set(name, value)
+			}
+		}
+
+		return next.HandleFinalize(ctx, in)
+	})
+}
+
+// runRequestHook passes the request to a hook command and returns the headers it asks to set
+func runRequestHook(ctx context.Context, opts AgentOptions, command string, req *smithyhttp.Request) (map[string]string, error) {
+	input, err := json.Marshal(requestHookInput{
+		Service:   awsmiddleware.GetServiceID(ctx),
+		Operation: awsmiddleware.GetOperationName(ctx),
+		Method:    req.Method,
+		URL:       req.URL.String(),
+		Headers:   req.Header,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request for hook: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestHookTimeout)
+	defer cancel()
+
+	logVerbose(opts, "Running request hook: %s", command)
+
+	var stdout bytes.Buffer
+	cmd := shellCommandContext(ctx, command)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("request hook '%s' failed: %w", command, err)
+	}
+
+	// A hook that prints nothing only observes the request
+	if strings.TrimSpace(stdout.String()) == "" {
+		return nil, nil
+	}
+
+	var output requestHookOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return nil, fmt.Errorf("request hook '%s' returned invalid JSON: %w", command, err)
+	}
+	return output.Headers, nil
+}
@@ -0,0 +1,400 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the 'model' command group for AWS Bedrock Intelligent Agents CLI.
+'model invoke' sends a prompt straight to a foundation model with the Converse and
+ConverseStream APIs, using the same prompt templates and output formats as 'invoke'.
+Comparing the raw model answer with the agent answer shows whether a problem comes from
+the model or from the agent's orchestration, instructions, and tools.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/spf13/cobra"
+)
+
+// ModelOptions contains all options for a direct model call
+type ModelOptions struct {
+	ConfigFile    string
+	ModelID       string
+	AgentID       string // Use the foundation model of this agent when no model ID is given
+	AgentVersion  string
+	InputText     string
+	PromptName    string
+	PromptFile    string
+	PromptVars    []string
+	VarsFile      string
+	NoInteractive bool
+	System        string
+	MaxTokens     int32
+	Temperature   float32
+	TopP          float32
+	Region        string
+	Stream        bool
+	Timeout       time.Duration
+	OutputFormat  string
+	Quiet         bool
+	Verbose       bool
+}
+
+// modelResult is the answer of a direct model call
+type modelResult struct {
+	Text       string
+	StopReason string
+	Usage      TokenUsage
+	Latency    time.Duration // Reported by the service, or measured when it reports none
+}
+
+var modelInvokeOpts ModelOptions
+
+// modelCmd groups the commands that call foundation models directly
+var modelCmd = &cobra.Command{
+	Use:   "model",
+	Short: "Call foundation models directly, without an agent",
+	Long: `Call foundation models directly, without an agent.
+
+Examples:
+  # Ask the model behind an agent the same question as the agent
+  aws-bia model invoke --agent-id abc123 --input "Your question"
+`,
+}
+
+// modelInvokeCmd represents the model invoke command
+var modelInvokeCmd = &cobra.Command{
+	Use:   "invoke",
+	Short: "Send a prompt to a foundation model with the Converse API",
+	Long: `Send a prompt to a foundation model with the Converse API.
+
+The prompt is built the same way as for 'invoke', from --input, --prompt,
+--prompt-file, and --var, and the answer is written in the same text or JSON
+format. Running the same prompt through 'invoke' and 'model invoke' helps find
+out whether an unexpected answer comes from the model or from the agent.
+
+The model is given with --model-id, model_id in the configuration file, or
+--agent-id, which uses the foundation model of that agent's draft (or of
+--agent-version).
+
+Examples:
+  # Ask a model directly
+  aws-bia model invoke --model-id anthropic.claude-3-haiku-20240307-v1:0 --input "Hello"
+
+  # Use the agent's foundation model and the same template as the agent
+  aws-bia model invoke --agent-id abc123 --prompt summarize --var text="Quarterly results..."
+
+  # Stream the answer with a system prompt and inference settings
+  aws-bia model invoke --model-id amazon.nova-lite-v1:0 --input "Write a haiku" \
+    --system "You are a poet" --temperature 0.2 --max-tokens 200 --stream
+
+  # Compare agent and model answers as JSON
+  aws-bia invoke --input "Your question" --format json | jq -r .content
+  aws-bia model invoke --agent-id abc123 --input "Your question" --format json | jq -r .content
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		if err := runModelInvokeCommand(ctx, modelInvokeOpts); err != nil {
+			logError("Error invoking model", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(modelCmd)
+	modelCmd.AddCommand(modelInvokeCmd)
+
+	modelInvokeCmd.Flags().StringVar(&modelInvokeOpts.ConfigFile, "config", "", "Path to configuration file (yaml)")
+	modelInvokeCmd.Flags().StringVar(&modelInvokeOpts.ModelID, "model-id", "", "Model ID or inference profile to call (can be set in config file as model_id)")
+	modelInvokeCmd.Flags().StringVar(&modelInvokeOpts.AgentID, "agent-id", "", "Use the foundation model of this agent when --model-id is not given")
+	modelInvokeCmd.Flags().StringVar(&modelInvokeOpts.AgentVersion, "agent-version", draftAgentVersion, "Agent version whose foundation model is used with --agent-id")
+	modelInvokeCmd.Flags().StringVar(&modelInvokeOpts.InputText, "input", "", "The input text to send to the model")
+	modelInvokeCmd.Flags().StringVar(&modelInvokeOpts.PromptName, "prompt", "", "Name of a prompt template to use")
+	modelInvokeCmd.Flags().StringVar(&modelInvokeOpts.PromptFile, "prompt-file", "", "Path to a prompt template file")
+	modelInvokeCmd.Flags().StringSliceVar(&modelInvokeOpts.PromptVars, "var", []string{}, "Variables for prompt template (format: key=value)")
+	modelInvokeCmd.Flags().BoolVar(&modelInvokeOpts.NoInteractive, "no-interactive", false, "Do not ask for required prompt template variables that were not given")
+	modelInvokeCmd.Flags().StringVar(&modelInvokeOpts.VarsFile, "vars-file", "", "YAML or JSON file of variables for prompt template (--var overrides its keys)")
+	modelInvokeCmd.Flags().StringVar(&modelInvokeOpts.System, "system", "", "System prompt sent with the input")
+	modelInvokeCmd.Flags().Int32Var(&modelInvokeOpts.MaxTokens, "max-tokens", 0, "Maximum number of tokens to generate (0 uses the model default)")
+	modelInvokeCmd.Flags().Float32Var(&modelInvokeOpts.Temperature, "temperature", -1, "Sampling temperature (negative uses the model default)")
+	modelInvokeCmd.Flags().Float32Var(&modelInvokeOpts.TopP, "top-p", -1, "Nucleus sampling probability (negative uses the model default)")
+	modelInvokeCmd.Flags().StringVar(&modelInvokeOpts.Region, "region", "", "AWS region to use (defaults to AWS_REGION environment variable)")
+	modelInvokeCmd.Flags().BoolVar(&modelInvokeOpts.Stream, "stream", false, "Stream the answer with the ConverseStream API")
+	modelInvokeCmd.Flags().DurationVar(&modelInvokeOpts.Timeout, "timeout", DefaultTimeout, "Maximum duration of the model call")
+	modelInvokeCmd.Flags().StringVar(&modelInvokeOpts.OutputFormat, "format", OutputFormatText, "Output format: text or json")
+	modelInvokeCmd.Flags().BoolVarP(&modelInvokeOpts.Quiet, "quiet", "q", false, "Print only the answer text, without the header and usage footer")
+	modelInvokeCmd.Flags().BoolVar(&modelInvokeOpts.Verbose, "verbose", false, "Enable verbose output")
+}
+
+// runModelInvokeCommand builds the prompt, calls the model, and writes the answer
+func runModelInvokeCommand(ctx context.Context, opts ModelOptions) error {
+	InitLogger(opts.Verbose)
+	defer SyncLogger()
+
+	v, err := LoadConfigForCommand(opts.ConfigFile, "model", opts.Verbose)
+	if err != nil {
+		return err
+	}
+
+	// Agent settings are not applied, only the agent given with --agent-id selects a model
+	agentOpts := AgentOptions{
+		InputText:     opts.InputText,
+		PromptName:    opts.PromptName,
+		PromptFile:    opts.PromptFile,
+		PromptVars:    opts.PromptVars,
+		VarsFile:      opts.VarsFile,
+		NoInteractive: opts.NoInteractive,
+		Region:        opts.Region,
+		OutputFormat:  opts.OutputFormat,
+		Quiet:         opts.Quiet,
+		Verbose:       opts.Verbose,
+	}
+	if agentOpts.TemplateEnv, err = configStringList(v, "template_env"); err != nil {
+		LogWarn("Ignoring %v", err)
+	}
+	if agentOpts.Region == "" && v.InConfig("region") {
+		agentOpts.Region = v.GetString("region")
+	}
+	agentOpts.Color = v.GetString("color")
+	if opts.ModelID == "" && v.InConfig("model_id") {
+		opts.ModelID = v.GetString("model_id")
+		logVerbose(agentOpts, "Loaded model ID from config: %s", opts.ModelID)
+	}
+
+	if opts.OutputFormat != OutputFormatText && opts.OutputFormat != OutputFormatJSON {
+		return fmt.Errorf("output format must be one of: %s, %s, got '%s'",
+			OutputFormatText, OutputFormatJSON, opts.OutputFormat)
+	}
+	if opts.Timeout <= 0 {
+		return fmt.Errorf("timeout must be a positive duration")
+	}
+	if err := processPrompt(&agentOpts); err != nil {
+		return err
+	}
+	if strings.TrimSpace(agentOpts.InputText) == "" {
+		return fmt.Errorf("input is required (use --input, --prompt, or --prompt-file)")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	awsHelper := NewAWSHelper(agentOpts)
+	if opts.ModelID == "" && opts.AgentID != "" {
+		agentClient, err := awsHelper.CreateAgentClient(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create AWS client: %w", err)
+		}
+		desc, err := describeAgentVersion(ctx, agentClient, opts.AgentID, opts.AgentVersion)
+		if err != nil {
+			return err
+		}
+		if desc.FoundationModel == "" {
+			return fmt.Errorf("agent '%s' version %s has no foundation model", opts.AgentID, desc.Version)
+		}
+		opts.ModelID = desc.FoundationModel
+		logVerbose(agentOpts, "Using foundation model %s of agent %s version %s", opts.ModelID, opts.AgentID, desc.Version)
+	}
+	if opts.ModelID == "" {
+		return fmt.Errorf("model ID is required (use --model-id, model_id in the config file, or --agent-id)")
+	}
+
+	client, err := awsHelper.CreateBedrockRuntimeClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	formatter := NewResponseFormatter(agentOpts, os.Stdout)
+	if opts.Stream {
+		return formatter.FormatModelStream(ctx, client, converseStreamInput(opts, agentOpts.InputText), opts.ModelID)
+	}
+
+	start := time.Now()
+	output, err := client.Converse(ctx, converseInput(opts, agentOpts.InputText))
+	if err != nil {
+		return HandleAWSError(fmt.Errorf("failed to invoke model '%s': %w", opts.ModelID, err))
+	}
+	result := modelResult{StopReason: string(output.StopReason), Usage: modelUsage(output.Usage), Latency: time.Since(start)}
+	if output.Metrics != nil && output.Metrics.LatencyMs != nil {
+		result.Latency = time.Duration(*output.Metrics.LatencyMs) * time.Millisecond
+	}
+	if message, ok := output.Output.(*types.ConverseOutputMemberMessage); ok {
+		var text strings.Builder
+		for _, block := range message.Value.Content {
+			if t, ok := block.(*types.ContentBlockMemberText); ok {
+				text.WriteString(t.Value)
+			}
+		}
+		result.Text = text.String()
+	}
+	return formatter.FormatModelResponse(opts.ModelID, result)
+}
+
+// converseInput builds the Converse request with a single user message
+func converseInput(opts ModelOptions, text string) *bedrockruntime.ConverseInput {
+	input := &bedrockruntime.ConverseInput{
+		ModelId: aws.String(opts.ModelID),
+		Messages: []types.Message{{
+			Role:    types.ConversationRoleUser,
+			Content: []types.ContentBlock{&types.ContentBlockMemberText{Value: text}},
+		}},
+		InferenceConfig: inferenceConfig(opts),
+	}
+	if opts.System != "" {
+		input.System = []types.SystemContentBlock{&types.SystemContentBlockMemberText{Value: opts.System}}
+	}
+	return input
+}
+
+// converseStreamInput builds the ConverseStream request with the same content as converseInput
+func converseStreamInput(opts ModelOptions, text string) *bedrockruntime.ConverseStreamInput {
+	input := converseInput(opts, text)
+	return &bedrockruntime.ConverseStreamInput{
+		ModelId:         input.ModelId,
+		Messages:        input.Messages,
+		System:          input.System,
+		InferenceConfig: input.InferenceConfig,
+	}
+}
+
+// inferenceConfig returns the inference settings given on the command line, or nil for the model defaults
+func inferenceConfig(opts ModelOptions) *types.InferenceConfiguration {
+	config := &types.InferenceConfiguration{}
+	set := false
+	if opts.MaxTokens > 0 {
+		config.MaxTokens, set = aws.Int32(opts.MaxTokens), true
+	}
+	if opts.Temperature >= 0 {
+		config.Temperature, set = aws.Float32(opts.Temperature), true
+	}
+	if opts.TopP >= 0 {
+		config.TopP, set = aws.Float32(opts.TopP), true
+	}
+	if !set {
+		return nil
+	}
+	return config
+}
+
+// modelUsage converts the token usage reported by the model
+func modelUsage(usage *types.TokenUsage) TokenUsage {
+	if usage == nil {
+		return TokenUsage{}
+	}
+	return TokenUsage{InputTokens: int64(aws.ToInt32(usage.InputTokens)), OutputTokens: int64(aws.ToInt32(usage.OutputTokens))}
+}
+
+// FormatModelStream calls ConverseStream and writes the answer as it arrives in text format,
+// or once it is complete in JSON format
+func (rf *ResponseFormatter) FormatModelStream(ctx context.Context, client *bedrockruntime.Client,
+	input *bedrockruntime.ConverseStreamInput, modelID string) error {
+
+	start := time.Now()
+	output, err := client.ConverseStream(ctx, input)
+	if err != nil {
+		return HandleAWSError(fmt.Errorf("failed to invoke model '%s': %w", modelID, err))
+	}
+	stream := output.GetStream()
+	defer stream.Close()
+
+	writeText := !rf.isJSONFormat
+	if writeText && !rf.Options.Quiet {
+		fmt.Fprintln(rf.Notices, rf.noticeColor.style(ansiBold, "Model Response:"))
+	}
+
+	var result modelResult
+	var text strings.Builder
+	for event := range stream.Events() {
+		switch e := event.(type) {
+		case *types.ConverseStreamOutputMemberContentBlockDelta:
+			if delta, ok := e.Value.Delta.(*types.ContentBlockDeltaMemberText); ok {
+				text.WriteString(delta.Value)
+				if writeText {
+					fmt.Fprint(rf.Writer, delta.Value)
+				}
+			}
+		case *types.ConverseStreamOutputMemberMessageStop:
+			result.StopReason = string(e.Value.StopReason)
+		case *types.ConverseStreamOutputMemberMetadata:
+			result.Usage = modelUsage(e.Value.Usage)
+			if e.Value.Metrics != nil && e.Value.Metrics.LatencyMs != nil {
+				result.Latency = time.Duration(*e.Value.Metrics.LatencyMs) * time.Millisecond
+			}
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return fmt.Errorf("error reading model stream: %w", err)
+	}
+	result.Text = text.String()
+	if result.Latency == 0 {
+		result.Latency = time.Since(start)
+	}
+
+	if writeText {
+		if result.Text != "" && !strings.HasSuffix(result.Text, "\n") {
+			fmt.Fprintln(rf.Writer)
+		}
+		rf.writeModelFooter(modelID, result)
+		return nil
+	}
+	return rf.FormatModelResponse(modelID, result)
+}
+
+// FormatModelResponse writes the answer of a direct model call in the configured output format
+func (rf *ResponseFormatter) FormatModelResponse(modelID string, result modelResult) error {
+	if rf.isJSONFormat {
+		response := map[string]interface{}{
+			"content":    result.Text,
+			"modelId":    modelID,
+			"stopReason": result.StopReason,
+			"latencyMs":  result.Latency.Milliseconds(),
+			"timestamp":  time.Now().Format(time.RFC3339),
+		}
+		if !result.Usage.IsZero() {
+			response["usage"] = map[string]interface{}{
+				"inputTokens":  result.Usage.InputTokens,
+				"outputTokens": result.Usage.OutputTokens,
+			}
+		}
+		if err := rf.writeJSON(response); err != nil {
+			return err
+		}
+		fmt.Fprintln(rf.Writer)
+		return nil
+	}
+
+	if !rf.Options.Quiet {
+		fmt.Fprintln(rf.Notices, rf.noticeColor.style(ansiBold, "Model Response:"))
+	}
+	fmt.Fprint(rf.Writer, result.Text)
+	if result.Text != "" && !strings.HasSuffix(result.Text, "\n") {
+		fmt.Fprintln(rf.Writer)
+	}
+	rf.writeModelFooter(modelID, result)
+	return nil
+}
+
+// writeModelFooter writes the model, stop reason, token usage, and latency after the answer
+func (rf *ResponseFormatter) writeModelFooter(modelID string, result modelResult) {
+	if rf.Options.Quiet {
+		return
+	}
+	parts := []string{"Model: " + modelID}
+	if result.StopReason != "" {
+		parts = append(parts, "stop reason "+result.StopReason)
+	}
+	if !result.Usage.IsZero() {
+		parts = append(parts, fmt.Sprintf("%s in / %s out tokens", formatCount(result.Usage.InputTokens), formatCount(result.Usage.OutputTokens)))
+	}
+	parts = append(parts, formatDuration(result.Latency))
+	fmt.Fprintf(rf.Notices, "\n%s\n", strings.Join(parts, " | "))
+}
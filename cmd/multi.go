@@ -0,0 +1,543 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the 'invoke-multi' command for AWS Bedrock Intelligent Agents CLI.
+It sends the same input to several agents or aliases concurrently and prints a
+side-by-side or JSON comparison of their answers and latencies, which is useful for
+A/B testing a new agent version against production.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	// Default total width of the side-by-side comparison when the terminal width is unknown
+	DefaultMultiWidth = 120
+
+	// Narrowest column used in the side-by-side comparison
+	minMultiColumnWidth = 24
+)
+
+// MultiOptions contains all options for a fan-out invocation
+type MultiOptions struct {
+	ConfigFile      string
+	Targets         []string
+	InputText       string
+	PromptName      string
+	PromptFile      string
+	PromptVars      []string
+	VarsFile        string
+	NoInteractive   bool
+	Region          string
+	EndpointURL     string
+	UseFIPS         bool
+	UseDualStack    bool
+	Timeout         time.Duration
+	TimeoutSet      bool
+	OutputFormat    string
+	Width           int
+	DedupThreshold  float64
+	RetryBudget     int           // Retries shared by all targets
+	RetryBudgetTime time.Duration // Backoff time shared by all targets
+	RPS             float64       // Requests per second shared by all targets
+	Burst           int
+	Verbose         bool
+}
+
+// multiTarget is one agent alias that receives the input
+type multiTarget struct {
+	Name         string
+	AgentID      string
+	AgentAliasID string
+}
+
+// multiResult is the outcome of invoking a single target
+type multiResult struct {
+	Target    multiTarget
+	SessionID string
+	Text      string
+	Latency   time.Duration
+	Usage     TokenUsage
+	Err       error
+}
+
+var multiOpts MultiOptions
+
+// invokeMultiCmd represents the invoke-multi command
+var invokeMultiCmd = &cobra.Command{
+	Use:   "invoke-multi",
+	Short: "Send the same input to several agents and compare the answers",
+	Long: `Send the same input to several agents or aliases concurrently and compare
+their answers and latencies.
+
+Targets are given with repeated --target flags, either as agent-id:alias-id or as
+the name of an entry in the "targets" mapping of the configuration file. Without
+--target, every configured target is used.
+
+  # ~/.aws-bia.yaml
+  targets:
+    prod: "abc123:PRODALIAS"
+    candidate: "abc123:NEWALIAS"
+
+Answers that are effectively identical are reported as one group, so real
+differences stand out.
+
+All targets share one retry budget (--retry-budget and --retry-budget-time). When the
+service throttles, the targets stop retrying once it is spent, so the run ends with the
+answers that arrived and a summary instead of adding more load. To stay under the
+throttling limits in the first place, --rps spaces the requests of all targets.
+
+Examples:
+  # Compare production with a new alias
+  aws-bia invoke-multi --target abc123:PRODALIAS --target abc123:NEWALIAS --input "Your question"
+
+  # Use the targets from the config file and print JSON
+  aws-bia invoke-multi --input "Your question" --format json
+
+  # Give up quickly when the service throttles
+  aws-bia invoke-multi --input "Your question" --retry-budget 3 --retry-budget-time 5s
+
+  # Send at most two requests per second to the account
+  aws-bia invoke-multi --input "Your question" --rps 2
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		multiOpts.TimeoutSet = cmd.Flags().Changed("timeout")
+		if err := runInvokeMultiCommand(ctx, multiOpts); err != nil {
+			logError("Error invoking agents", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(invokeMultiCmd)
+
+	invokeMultiCmd.Flags().StringVar(&multiOpts.ConfigFile, "config", "", "Path to configuration file (yaml)")
+	invokeMultiCmd.Flags().StringArrayVar(&multiOpts.Targets, "target", []string{}, "Target as agent-id:alias-id or a name from the config 'targets' mapping (repeatable)")
+	invokeMultiCmd.Flags().StringVar(&multiOpts.InputText, "input", "", "The input text to send to every agent")
+	invokeMultiCmd.Flags().StringVar(&multiOpts.PromptName, "prompt", "", "Name of a prompt template to use")
+	invokeMultiCmd.Flags().StringVar(&multiOpts.PromptFile, "prompt-file", "", "Path to a prompt template file")
+	invokeMultiCmd.Flags().StringSliceVar(&multiOpts.PromptVars, "var", []string{}, "Variables for prompt template (format: key=value)")
+	invokeMultiCmd.Flags().BoolVar(&multiOpts.NoInteractive, "no-interactive", false, "Do not ask for required prompt template variables that were not given")
+	invokeMultiCmd.Flags().StringVar(&multiOpts.VarsFile, "vars-file", "", "YAML or JSON file of variables for prompt template (--var overrides its keys)")
+	invokeMultiCmd.Flags().StringVar(&multiOpts.Region, "region", "", "AWS region to use (defaults to AWS_REGION environment variable)")
+	invokeMultiCmd.Flags().StringVar(&multiOpts.EndpointURL, "endpoint-url", "", "Send agent runtime requests to this URL instead of the regional endpoint (e.g. a VPC endpoint or local mock)")
+	invokeMultiCmd.Flags().BoolVar(&multiOpts.UseFIPS, "use-fips", false, "Use FIPS endpoints for AWS requests")
+	invokeMultiCmd.Flags().BoolVar(&multiOpts.UseDualStack, "use-dualstack", false, "Use dual-stack (IPv4 and IPv6) endpoints for AWS requests")
+	invokeMultiCmd.Flags().DurationVar(&multiOpts.Timeout, "timeout", DefaultTimeout, "Timeout for each agent invocation")
+	invokeMultiCmd.Flags().Float64Var(&multiOpts.RPS, "rps", 0, "Send at most this many requests per second across all targets (0 for no limit, can be set in config file)")
+	invokeMultiCmd.Flags().IntVar(&multiOpts.Burst, "burst", DefaultBurst, "Requests that may be sent at once before --rps applies")
+	invokeMultiCmd.Flags().StringVar(&multiOpts.OutputFormat, "format", OutputFormatText, "Output format: text (side-by-side) or json")
+	invokeMultiCmd.Flags().IntVar(&multiOpts.Width, "width", 0, "Total width of the side-by-side comparison (defaults to $COLUMNS or 120)")
+	invokeMultiCmd.Flags().Float64Var(&multiOpts.DedupThreshold, "dedup-threshold", DefaultDedupThreshold, "Similarity (0-1) above which answers are grouped as identical")
+	invokeMultiCmd.Flags().IntVar(&multiOpts.RetryBudget, "retry-budget", DefaultRetryBudget, "Total number of retries shared by all targets")
+	invokeMultiCmd.Flags().DurationVar(&multiOpts.RetryBudgetTime, "retry-budget-time", DefaultRetryBudgetTime, "Total retry backoff time shared by all targets")
+	invokeMultiCmd.Flags().BoolVar(&multiOpts.Verbose, "verbose", false, "Enable verbose output")
+
+	registerAgentCompletions(invokeMultiCmd)
+}
+
+// runInvokeMultiCommand invokes every target concurrently and writes the comparison
+func runInvokeMultiCommand(ctx context.Context, opts MultiOptions) error {
+	InitLogger(opts.Verbose)
+	defer SyncLogger()
+
+	v, err := LoadConfigForCommand(opts.ConfigFile, "invoke-multi", opts.Verbose)
+	if err != nil {
+		return err
+	}
+
+	targets, err := resolveMultiTargets(opts.Targets, v.GetStringMapString("targets"))
+	if err != nil {
+		return err
+	}
+
+	baseOpts := AgentOptions{
+		InputText:      opts.InputText,
+		PromptName:     opts.PromptName,
+		PromptFile:     opts.PromptFile,
+		PromptVars:     opts.PromptVars,
+		VarsFile:       opts.VarsFile,
+		NoInteractive:  opts.NoInteractive,
+		Region:         opts.Region,
+		EndpointURL:    opts.EndpointURL,
+		UseFIPS:        opts.UseFIPS,
+		UseDualStack:   opts.UseDualStack,
+		Timeout:        opts.Timeout,
+		MaxDurationSet: opts.TimeoutSet,
+		OutputFormat:   OutputFormatJSON, // Collect the answers without writing them
+		EnableTrace:    true,             // Trace events carry the token usage
+		Verbose:        opts.Verbose,
+		Clients:        NewRuntimeClients(nil), // Targets are invoked concurrently with one client
+		RPS:            opts.RPS,
+		Burst:          opts.Burst,
+	}
+	applyAgentConfig(v, &baseOpts)
+	applyTimeoutSetting(v, &baseOpts)
+	applyRetryBudgetConfig(v, &opts)
+	if err := setupRateLimiter(v, &baseOpts); err != nil {
+		return err
+	}
+
+	if err := processPrompt(&baseOpts); err != nil {
+		return err
+	}
+	if baseOpts.InputText == "" {
+		return fmt.Errorf("input is required (or use --prompt/--prompt-file)")
+	}
+	if baseOpts.Timeout <= 0 {
+		return fmt.Errorf("timeout must be a positive duration")
+	}
+	if opts.OutputFormat != OutputFormatText && opts.OutputFormat != OutputFormatJSON {
+		return fmt.Errorf("output format must be one of: %s, %s, got '%s'",
+			OutputFormatText, OutputFormatJSON, opts.OutputFormat)
+	}
+	if opts.DedupThreshold < 0 || opts.DedupThreshold > 1 {
+		return fmt.Errorf("dedup threshold must be between 0 and 1, got %g", opts.DedupThreshold)
+	}
+
+	if opts.RetryBudget < 0 || opts.RetryBudgetTime < 0 {
+		return fmt.Errorf("retry budget must not be negative")
+	}
+
+	budget := NewRetryBudget(opts.RetryBudget, opts.RetryBudgetTime)
+	results := invokeTargets(ctx, baseOpts, targets, budget)
+
+	groups := groupMultiResults(results, opts.DedupThreshold)
+
+	if opts.OutputFormat == OutputFormatJSON {
+		err = writeMultiJSON(os.Stdout, baseOpts.InputText, results, groups, budget)
+	} else {
+		err = writeMultiText(os.Stdout, results, groups, multiWidth(opts.Width))
+	}
+	if err != nil {
+		return err
+	}
+
+	if retries, _, denied := budget.Stats(); retries > 0 || denied > 0 {
+		fmt.Fprintln(os.Stderr, budget.Summary())
+	}
+
+	failed, exhausted := 0, 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+		if isRetryBudgetError(result.Err) {
+			exhausted++
+		}
+	}
+	if exhausted > 0 {
+		return fmt.Errorf("%d of %d targets failed, %d after the retry budget ran out", failed, len(results), exhausted)
+	}
+	if failed > 0 {
+		return fmt.Errorf("one or more targets failed")
+	}
+	return nil
+}
+
+// applyRetryBudgetConfig applies the retry_budget and retry_budget_time settings unless the flags changed them
+func applyRetryBudgetConfig(v *viper.Viper, opts *MultiOptions) {
+	if v.InConfig("retry_budget") && opts.RetryBudget == DefaultRetryBudget {
+		opts.RetryBudget = v.GetInt("retry_budget")
+		logVerbose(AgentOptions{Verbose: opts.Verbose}, "Loaded retry budget from config: %d", opts.RetryBudget)
+	}
+	if v.InConfig("retry_budget_time") && opts.RetryBudgetTime == DefaultRetryBudgetTime {
+		opts.RetryBudgetTime = v.GetDuration("retry_budget_time")
+		logVerbose(AgentOptions{Verbose: opts.Verbose}, "Loaded retry budget time from config: %s", opts.RetryBudgetTime)
+	}
+}
+
+// resolveMultiTargets turns --target values into targets, falling back to the configured ones
+func resolveMultiTargets(specs []string, configured map[string]string) ([]multiTarget, error) {
+	if len(specs) == 0 {
+		for name := range configured {
+			specs = append(specs, name)
+		}
+		sort.Strings(specs)
+	}
+	if len(specs) < 2 {
+		return nil, fmt.Errorf("at least two targets are required (use --target or the config 'targets' mapping)")
+	}
+
+	targets := make([]multiTarget, 0, len(specs))
+	for _, spec := range specs {
+		name, value := spec, spec
+		if configuredValue, ok := configured[strings.ToLower(spec)]; ok {
+			value = configuredValue
+		}
+
+		agentID, aliasID, found := strings.Cut(value, ":")
+		if !found || agentID == "" || aliasID == "" {
+			return nil, fmt.Errorf("invalid target '%s', expected agent-id:alias-id or a configured target name", spec)
+		}
+		targets = append(targets, multiTarget{Name: name, AgentID: agentID, AgentAliasID: aliasID})
+	}
+	return targets, nil
+}
+
+// invokeTargets sends the input to every target concurrently and returns the results in target order
+func invokeTargets(ctx context.Context, baseOpts AgentOptions, targets []multiTarget, budget *RetryBudget) []multiResult {
+	results := make([]multiResult, len(targets))
+
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target multiTarget) {
+			defer wg.Done()
+
+			targetOpts := baseOpts
+			targetOpts.AgentID = target.AgentID
+			targetOpts.AgentAliasID = target.AgentAliasID
+
+			start := time.Now()
+			results[i] = invokeTarget(ctx, targetOpts, target, budget)
+			results[i].Latency = time.Since(start)
+		}(i, target)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// invokeTarget invokes a single target and collects its answer and token usage
+func invokeTarget(ctx context.Context, opts AgentOptions, target multiTarget, budget *RetryBudget) multiResult {
+	result := multiResult{Target: target}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	awsHelper := NewAWSHelper(opts)
+	awsHelper.RetryBudget = budget
+
+	output, err := invokeAgent(ctx, awsHelper)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.SessionID = aws.ToString(output.SessionId)
+
+	stream := output.GetStream()
+	if stream == nil {
+		result.Err = fmt.Errorf("no response stream available")
+		return result
+	}
+	defer stream.Close()
+
+	streamResult, err := NewStreamProcessor(opts, io.Discard, false).ProcessStream(stream)
+	result.Text = streamResult.Text
+	result.Usage = streamResult.Usage
+	result.Err = err
+	return result
+}
+
+// groupMultiResults groups the successful answers by similarity; failed targets are left out
+func groupMultiResults(results []multiResult, threshold float64) []ResponseGroup {
+	var texts []string
+	var indexes []int
+	for i, result := range results {
+		if result.Err == nil {
+			texts = append(texts, result.Text)
+			indexes = append(indexes, i)
+		}
+	}
+
+	groups := GroupSimilarResponses(texts, threshold)
+	for g := range groups {
+		for m, member := range groups[g].Members {
+			groups[g].Members[m] = indexes[member]
+		}
+	}
+	return groups
+}
+
+// writeMultiJSON writes the comparison as a JSON document
+func writeMultiJSON(w io.Writer, input string, results []multiResult, groups []ResponseGroup, budget *RetryBudget) error {
+	items := make([]map[string]interface{}, 0, len(results))
+	for _, result := range results {
+		item := map[string]interface{}{
+			"target":       result.Target.Name,
+			"agentId":      result.Target.AgentID,
+			"agentAliasId": result.Target.AgentAliasID,
+			"content":      result.Text,
+			"latencyMs":    result.Latency.Milliseconds(),
+		}
+		if result.SessionID != "" {
+			item["sessionId"] = result.SessionID
+		}
+		if !result.Usage.IsZero() {
+			item["usage"] = map[string]interface{}{
+				"inputTokens":  result.Usage.InputTokens,
+				"outputTokens": result.Usage.OutputTokens,
+			}
+		}
+		if result.Err != nil {
+			item["error"] = jsonErrorObject(result.Err)
+		}
+		items = append(items, item)
+	}
+
+	groupItems := make([]map[string]interface{}, 0, len(groups))
+	for _, group := range groups {
+		names := make([]string, 0, len(group.Members))
+		for _, member := range group.Members {
+			names = append(names, results[member].Target.Name)
+		}
+		groupItems = append(groupItems, map[string]interface{}{
+			"targets":    names,
+			"similarity": group.Similarity,
+		})
+	}
+
+	retries, waited, denied := budget.Stats()
+	jsonData, err := json.MarshalIndent(map[string]interface{}{
+		"input":   input,
+		"results": items,
+		"groups":  groupItems,
+		"retryBudget": map[string]interface{}{
+			"retries":    retries,
+			"maxRetries": budget.maxRetries,
+			"waitedMs":   waited.Milliseconds(),
+			"maxTimeMs":  budget.maxTime.Milliseconds(),
+			"refused":    denied,
+		},
+		"timestamp": time.Now().Format(time.RFC3339),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal response to JSON: %w", err)
+	}
+
+	_, err = fmt.Fprintln(w, string(jsonData))
+	return err
+}
+
+// writeMultiText writes the answers as side-by-side columns followed by the similarity groups
+func writeMultiText(w io.Writer, results []multiResult, groups []ResponseGroup, width int) error {
+	const gap = " | "
+	columnWidth := max((width-len(gap)*(len(results)-1))/len(results), minMultiColumnWidth)
+
+	columns := make([][]string, len(results))
+	rows := 0
+	for i, result := range results {
+		header := []string{
+			result.Target.Name,
+			fmt.Sprintf("%s:%s", result.Target.AgentID, result.Target.AgentAliasID),
+			multiStatusLine(result),
+			strings.Repeat("-", columnWidth),
+		}
+		body := result.Text
+		if result.Err != nil {
+			body = "Error: " + result.Err.Error()
+		}
+
+		columns[i] = append(header, wrapText(body, columnWidth)...)
+		rows = max(rows, len(columns[i]))
+	}
+
+	for row := 0; row < rows; row++ {
+		cells := make([]string, len(columns))
+		for i, column := range columns {
+			cell := ""
+			if row < len(column) {
+				cell = column[row]
+			}
+			cells[i] = cell + strings.Repeat(" ", max(columnWidth-utf8.RuneCountInString(cell), 0))
+		}
+		if _, err := fmt.Fprintln(w, strings.TrimRight(strings.Join(cells, gap), " ")); err != nil {
+			return err
+		}
+	}
+
+	// Only report groups when some targets answered alike
+	alike := false
+	for _, group := range groups {
+		alike = alike || len(group.Members) > 1
+	}
+	if !alike {
+		return nil
+	}
+	fmt.Fprintln(w, "\nSimilar answers:")
+	for _, group := range groups {
+		if len(group.Members) < 2 {
+			continue
+		}
+		names := make([]string, 0, len(group.Members))
+		for _, member := range group.Members {
+			names = append(names, results[member].Target.Name)
+		}
+		fmt.Fprintf(w, "  %s (similarity %.0f%%)\n", strings.Join(names, ", "), group.Similarity*100)
+	}
+	return nil
+}
+
+// multiStatusLine summarizes the latency and token usage of a result
+func multiStatusLine(result multiResult) string {
+	status := formatDuration(result.Latency)
+	if !result.Usage.IsZero() {
+		status += fmt.Sprintf(", %s in / %s out tokens", formatCount(result.Usage.InputTokens), formatCount(result.Usage.OutputTokens))
+	}
+	return status
+}
+
+// multiWidth returns the width of the comparison from the flag, $COLUMNS, or the default
+func multiWidth(flagWidth int) int {
+	if flagWidth > 0 {
+		return flagWidth
+	}
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	return DefaultMultiWidth
+}
+
+// wrapText breaks text into lines of at most width runes, wrapping at spaces when possible
+func wrapText(text string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			// Hard-break words longer than a whole line
+			for utf8.RuneCountInString(word) > width {
+				if line != "" {
+					lines = append(lines, line)
+					line = ""
+				}
+				runes := []rune(word)
+				lines = append(lines, string(runes[:width]))
+				word = string(runes[width:])
+			}
+
+			switch {
+			case line == "":
+				line = word
+			case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,164 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements completion notifications for the AWS Bedrock Intelligent Agents CLI.
+With --notify-webhook a compact JSON summary of the invocation is POSTed to a URL when the
+command finishes or fails. The summary carries a "text" line, so Slack incoming webhooks
+can show it as it is, next to the agent, session, duration, answer, and saved files.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime"
+	"github.com/spf13/viper"
+)
+
+const (
+	// notifyTimeout bounds the webhook request, a slow receiver should not hold up the command
+	notifyTimeout = 10 * time.Second
+
+	// notifyAnswerLength is the number of characters of the answer included in the summary
+	notifyAnswerLength = 500
+)
+
+// completionSummary is the document POSTed to the notification webhook
+type completionSummary struct {
+	Text         string   `json:"text"`
+	Status       string   `json:"status"` // succeeded or failed
+	AgentID      string   `json:"agentId,omitempty"`
+	AgentAliasID string   `json:"agentAliasId,omitempty"`
+	SessionID    string   `json:"sessionId,omitempty"`
+	DurationMs   int64    `json:"durationMs"`
+	Answer       string   `json:"answer,omitempty"`
+	SavedFiles   []string `json:"savedFiles,omitempty"` // file:// URLs
+	Error        string   `json:"error,omitempty"`
+	Timestamp    string   `json:"timestamp"`
+}
+
+// completionNotifier collects the outcome of an invocation and reports it to the webhook
+type completionNotifier struct {
+	url       string
+	start     time.Time
+	sessionID string
+	result    StreamResult
+}
+
+// newCompletionNotifier validates the webhook URL and starts timing the invocation
+func newCompletionNotifier(webhook string) (*completionNotifier, error) {
+	if err := validateEndpointURL(webhook); err != nil {
+		return nil, fmt.Errorf("invalid --notify-webhook: %w", err)
+	}
+	return &completionNotifier{url: webhook, start: time.Now()}, nil
+}
+
+// applyNotifyConfig applies the notification webhook of the invoke command from a loaded configuration
+func applyNotifyConfig(v *viper.Viper, options *AgentOptions) {
+	if v.InConfig("notify_webhook") && options.NotifyWebhook == "" {
+		options.NotifyWebhook = v.GetString("notify_webhook")
+		logVerbose(*options, "Loaded notification webhook from config")
+	}
+}
+
+// SetResult records the response to summarize; output may be nil when the invocation failed
+func (n *completionNotifier) SetResult(output *bedrockagentruntime.InvokeAgentOutput, result StreamResult) {
+	if n == nil {
+		return
+	}
+	if output != nil {
+		n.sessionID = aws.ToString(output.SessionId)
+	}
+	n.result = result
+}
+
+// Send POSTs the summary of the finished command; err is the error the command ends with.
+// A failed notification only produces a warning.
+func (n *completionNotifier) Send(opts AgentOptions, err error) {
+	if n == nil {
+		return
+	}
+
+	summary := n.summary(opts, err)
+	body, marshalErr := json.Marshal(summary)
+	if marshalErr != nil {
+		opts.Warnings.Warn(WarningNotify, "error encoding notification", marshalErr)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	req, reqErr := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if reqErr != nil {
+		opts.Warnings.Warn(WarningNotify, "error creating notification request", reqErr)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "aws-bia/"+getVersionInfo().version)
+
+	resp, doErr := http.DefaultClient.Do(req)
+	if doErr != nil {
+		opts.Warnings.Warn(WarningNotify, "error sending notification", doErr)
+		return
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		opts.Warnings.Warn(WarningNotify, "notification webhook returned "+resp.Status, nil)
+		return
+	}
+	logVerbose(opts, "Sent completion notification (%s)", summary.Status)
+}
+
+// summary assembles the notification document
+func (n *completionNotifier) summary(opts AgentOptions, err error) completionSummary {
+	duration := time.Since(n.start)
+	summary := completionSummary{
+		Status:       "succeeded",
+		AgentID:      opts.AgentID,
+		AgentAliasID: opts.AgentAliasID,
+		SessionID:    n.sessionID,
+		DurationMs:   duration.Milliseconds(),
+		Answer:       excerpt(n.result.Text, notifyAnswerLength),
+		Timestamp:    time.Now().UTC().Format(time.RFC3339),
+	}
+	if summary.SessionID == "" {
+		summary.SessionID = opts.SessionID
+	}
+	for _, path := range n.result.SavedFiles {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		summary.SavedFiles = append(summary.SavedFiles, string(fileURL(path)))
+	}
+
+	agent := opts.AgentID
+	if opts.AgentAliasID != "" {
+		agent += ":" + opts.AgentAliasID
+	}
+	if err != nil {
+		summary.Status = "failed"
+		summary.Error = err.Error()
+		summary.Text = fmt.Sprintf("aws-bia invoke of %s failed after %s: %s", agent, formatDuration(duration), excerpt(err.Error(), 200))
+		return summary
+	}
+
+	summary.Text = fmt.Sprintf("aws-bia invoke of %s finished in %s", agent, formatDuration(duration))
+	if len(summary.SavedFiles) > 0 {
+		summary.Text += fmt.Sprintf(", saved %d file(s)", len(summary.SavedFiles))
+	}
+	if summary.Answer != "" {
+		summary.Text += ": " + excerpt(summary.Answer, 200)
+	}
+	return summary
+}
@@ -0,0 +1,63 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements --open for the AWS Bedrock Intelligent Agents CLI. After a
+successful invocation the output file and the directory of saved files are opened
+with the default handler of the platform: open on macOS, the file protocol handler
+on Windows, and xdg-open elsewhere.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// openCommand returns the command that opens a path with the platform's default handler
+func openCommand(path string) *exec.Cmd {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", path)
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", path)
+	}
+	return exec.Command("xdg-open", path)
+}
+
+// openPath starts the default handler for a path without waiting for it to exit
+func openPath(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	cmd := openCommand(absPath)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to run %s: %w", cmd.Args[0], err)
+	}
+	return cmd.Process.Release()
+}
+
+// openOutputs opens the output file and, when files were saved, the saved files directory
+func openOutputs(opts AgentOptions, result StreamResult) {
+	var paths []string
+	if opts.OutputFile != "" {
+		paths = append(paths, opts.OutputFile)
+	}
+	if opts.FilesOutputDir != "" && len(result.SavedFiles) > 0 {
+		paths = append(paths, opts.FilesOutputDir)
+	}
+	if len(paths) == 0 {
+		logVerbose(opts, "Nothing to open: the agent generated no files")
+		return
+	}
+
+	for _, path := range paths {
+		logVerbose(opts, "Opening %s", path)
+		if err := openPath(path); err != nil {
+			opts.Warnings.Warn(WarningOutput, fmt.Sprintf("failed to open '%s'", path), err)
+		}
+	}
+}
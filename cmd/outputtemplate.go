@@ -0,0 +1,177 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements user-provided output templates for the AWS Bedrock Intelligent Agents CLI.
+With --format template the final response is rendered through a Go text/template given
+inline with --template or loaded with --template-file.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/template"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/types"
+)
+
+// TemplateResponse is the data passed to an output template
+type TemplateResponse struct {
+	Content         string
+	SessionID       string
+	ContentType     string
+	MemoryID        string
+	Citations       []TemplateCitation
+	Files           []TemplateFile
+	SavedFiles      []string
+	ReturnedControl bool
+	ReturnControl   map[string]interface{} // Invocation ID and inputs of a return-control response
+	Usage           TokenUsage
+	Traces          []types.TracePart // Only populated with --trace
+}
+
+// TemplateCitation is a citation as exposed to output templates
+type TemplateCitation struct {
+	Text       string
+	Start      int // Character offsets of the cited part within the answer, when reported
+	End        int
+	References []TemplateReference
+}
+
+// TemplateReference is a retrieved reference of a citation
+type TemplateReference struct {
+	LocationType string
+	Location     string // URI, URL, or document ID of the source, when known
+	Page         int    // Page number within the source document, 0 when unknown
+	Text         string
+}
+
+// TemplateFile describes a file generated by the agent
+type TemplateFile struct {
+	Name string
+	Type string
+	Size int
+}
+
+// loadOutputTemplate parses the inline template or the template file from the options
+func loadOutputTemplate(opts AgentOptions) (*template.Template, error) {
+	content := opts.Template
+	if opts.TemplateFile != "" {
+		data, err := os.ReadFile(opts.TemplateFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template file '%s': %w", opts.TemplateFile, err)
+		}
+		content = string(data)
+	}
+
+	tmpl, err := template.New("output").Funcs(templateFuncMap(opts.TemplateEnv)).Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse output template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// newTemplateResponse builds the template data from the invocation output and stream content
+func newTemplateResponse(output *bedrockagentruntime.InvokeAgentOutput,
+	result StreamResult, savedFiles []string) TemplateResponse {
+
+	response := TemplateResponse{
+		Content:         result.Text,
+		SessionID:       aws.ToString(output.SessionId),
+		ContentType:     aws.ToString(output.ContentType),
+		MemoryID:        aws.ToString(output.MemoryId),
+		SavedFiles:      savedFiles,
+		ReturnedControl: result.HasReturnControl,
+		Usage:           result.Usage,
+		Traces:          result.Traces,
+	}
+	if result.ReturnControl != nil {
+		response.ReturnControl = returnControlPayloadJSON(*result.ReturnControl)
+	}
+
+	for _, citation := range result.Citations {
+		var c TemplateCitation
+		if citation.GeneratedResponsePart != nil && citation.GeneratedResponsePart.TextResponsePart != nil {
+			c.Text = aws.ToString(citation.GeneratedResponsePart.TextResponsePart.Text)
+		}
+		if start, end, ok := citationSpan(citation); ok {
+			c.Start, c.End = start, end
+		}
+		for _, ref := range citation.RetrievedReferences {
+			var r TemplateReference
+			if ref.Location != nil {
+				r.LocationType = string(ref.Location.Type)
+				r.Location = referenceLocation(ref.Location)
+			}
+			r.Page, _ = referencePage(ref)
+			if ref.Content != nil {
+				r.Text = aws.ToString(ref.Content.Text)
+			}
+			c.References = append(c.References, r)
+		}
+		response.Citations = append(response.Citations, c)
+	}
+
+	for _, file := range result.Files {
+		response.Files = append(response.Files, TemplateFile{
+			Name: aws.ToString(file.Name),
+			Type: aws.ToString(file.Type),
+			Size: len(file.Bytes),
+		})
+	}
+
+	return response
+}
+
+// referenceLocation returns where a retrieved reference came from, e.g. its S3 URI or web URL
+func referenceLocation(location *types.RetrievalResultLocation) string {
+	switch {
+	case location.S3Location != nil:
+		return aws.ToString(location.S3Location.Uri)
+	case location.WebLocation != nil:
+		return aws.ToString(location.WebLocation.Url)
+	case location.ConfluenceLocation != nil:
+		return aws.ToString(location.ConfluenceLocation.Url)
+	case location.SalesforceLocation != nil:
+		return aws.ToString(location.SalesforceLocation.Url)
+	case location.SharePointLocation != nil:
+		return aws.ToString(location.SharePointLocation.Url)
+	case location.KendraDocumentLocation != nil:
+		return aws.ToString(location.KendraDocumentLocation.Uri)
+	case location.CustomDocumentLocation != nil:
+		return aws.ToString(location.CustomDocumentLocation.Id)
+	case location.SqlLocation != nil:
+		return aws.ToString(location.SqlLocation.Query)
+	}
+	return ""
+}
+
+// documentPageMetadataKey is the metadata attribute carrying the page a knowledge base chunk was taken from
+const documentPageMetadataKey = "x-amz-bedrock-kb-document-page-number"
+
+// referencePage returns the page of the source document a retrieved reference was taken from
+func referencePage(ref types.RetrievedReference) (int, bool) {
+	value, ok := ref.Metadata[documentPageMetadataKey]
+	if !ok || value == nil {
+		return 0, false
+	}
+	var page float64
+	if err := value.UnmarshalSmithyDocument(&page); err != nil || page < 1 {
+		return 0, false
+	}
+	return int(page), true
+}
+
+// citationSpan returns the character offsets of the part of the answer a citation covers
+func citationSpan(citation types.Citation) (int, int, bool) {
+	if citation.GeneratedResponsePart == nil || citation.GeneratedResponsePart.TextResponsePart == nil {
+		return 0, 0, false
+	}
+	span := citation.GeneratedResponsePart.TextResponsePart.Span
+	if span == nil || span.Start == nil || span.End == nil {
+		return 0, 0, false
+	}
+	return int(*span.Start), int(*span.End), true
+}
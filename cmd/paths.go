@@ -0,0 +1,180 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the 'paths' command for AWS Bedrock Intelligent Agents CLI.
+It prints where the configuration file, caches, session store, and prompt directories
+live, whether each exists, and which ones are still in the ~/.aws-bia location of an
+earlier version.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// PathsOptions contains the options of the paths command
+type PathsOptions struct {
+	Format string
+}
+
+// pathLocation is an on-disk location of the CLI
+type pathLocation struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Exists bool   `json:"exists"`
+	Legacy bool   `json:"legacy"`
+}
+
+// pathsReport is everything the paths command prints
+type pathsReport struct {
+	Locations   []pathLocation `json:"locations"`
+	ConfigPaths []string       `json:"configSearchPaths"`
+	PromptDirs  []string       `json:"promptDirs"`
+}
+
+var pathsOpts PathsOptions
+
+// pathsCmd represents the paths command
+var pathsCmd = &cobra.Command{
+	Use:   "paths",
+	Short: "Show where configuration, caches, and sessions are stored",
+	Long: `Show where the files of aws-bia are stored and whether each exists.
+
+Configuration is read from and written to $XDG_CONFIG_HOME/aws-bia
+(~/.config/aws-bia), caches are kept in $XDG_CACHE_HOME/aws-bia (~/.cache/aws-bia),
+and sessions, the tools audit log, and prompts in $XDG_DATA_HOME/aws-bia
+(~/.local/share/aws-bia). A socket for 'aws-bia daemon --socket' belongs in
+$XDG_RUNTIME_DIR/aws-bia. On Windows %APPDATA% and %LOCALAPPDATA% are used.
+Files an earlier version created under ~/.aws-bia keep being used while the new
+location does not exist; they are marked as legacy, and moving them into the new
+directory switches over.`,
+	Example: `  # Show every location
+  aws-bia paths
+
+  # Show the locations as JSON
+  aws-bia paths --format json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runPathsCommand(os.Stdout, pathsOpts); err != nil {
+			logError("Error showing paths", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pathsCmd)
+
+	pathsCmd.Flags().StringVar(&pathsOpts.Format, "format", OutputFormatText, "Output format: text or json")
+}
+
+// runPathsCommand writes the on-disk locations of the CLI
+func runPathsCommand(w io.Writer, opts PathsOptions) error {
+	if opts.Format != OutputFormatText && opts.Format != OutputFormatJSON {
+		return fmt.Errorf("format must be %s or %s, got '%s'", OutputFormatText, OutputFormatJSON, opts.Format)
+	}
+
+	report, err := collectPaths()
+	if err != nil {
+		return err
+	}
+
+	if opts.Format == OutputFormatJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal paths to JSON: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+
+	for _, loc := range report.Locations {
+		state := "missing"
+		if loc.Exists {
+			state = "ok"
+		}
+		line := fmt.Sprintf("%-16s %-8s %s", loc.Name, state, loc.Path)
+		if loc.Legacy {
+			line += "  (legacy location)"
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintln(w, "\nConfig search path:")
+	for _, dir := range report.ConfigPaths {
+		fmt.Fprintf(w, "  %s\n", dir)
+	}
+	fmt.Fprintln(w, "\nPrompt search path:")
+	for _, dir := range report.PromptDirs {
+		fmt.Fprintf(w, "  %s\n", dir)
+	}
+	return nil
+}
+
+// collectPaths resolves every location the way the commands that use them do
+func collectPaths() (*pathsReport, error) {
+	v, err := LoadConfigForCommand(cfgFile, "invoke", false)
+	if err != nil {
+		return nil, err
+	}
+	var opts AgentOptions
+	applyAgentConfig(v, &opts)
+	if err := applyToolsConfig(v, &opts); err != nil {
+		return nil, err
+	}
+
+	configFile, err := configWritePath(cfgFile, true)
+	if err != nil {
+		return nil, err
+	}
+	auditLog := opts.ToolsAuditLog
+	if auditLog == "" {
+		if auditLog, err = defaultToolAuditLogPath(); err != nil {
+			return nil, err
+		}
+	}
+
+	report := &pathsReport{ConfigPaths: configSearchPaths()}
+	for _, loc := range []struct {
+		name string
+		path func() (string, error)
+	}{
+		{"config", func() (string, error) { return configFile, nil }},
+		{"agent cache", agentCachePath},
+		{"response cache", func() (string, error) { return cachePath("responses") }},
+		{"sessions", func() (string, error) { return dataPath("sessions") }},
+		{"last session", lastSessionPath},
+		{"tools audit log", func() (string, error) { return auditLog, nil }},
+		{"prompts", userPromptDir},
+		{"daemon socket", func() (string, error) { return runtimePath("daemon.sock") }},
+	} {
+		path, err := loc.path()
+		if err != nil {
+			return nil, err
+		}
+		_, statErr := os.Stat(path)
+		report.Locations = append(report.Locations, pathLocation{
+			Name:   loc.name,
+			Path:   path,
+			Exists: statErr == nil,
+			Legacy: isLegacyPath(path),
+		})
+	}
+	report.PromptDirs = NewPromptManager(opts.PromptDirs).promptDirs
+	return report, nil
+}
+
+// isLegacyPath reports whether a path is in ~/.aws-bia or is ~/.aws-bia.yaml
+func isLegacyPath(path string) bool {
+	dir, err := legacyAppDir()
+	if err != nil {
+		return false
+	}
+	return path == dir+".yaml" || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
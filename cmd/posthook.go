@@ -0,0 +1,121 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements response post-processing hooks for the AWS Bedrock Intelligent Agents CLI.
+With --post-hook the final JSON response is piped into a command once the invocation is done,
+e.g. to send a notification or open a ticket. With --post-hook-replace the command's stdout is
+shown instead of the response, so it can transform the answer without changes to the CLI.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime"
+	"github.com/spf13/viper"
+)
+
+// postHookTimeout bounds each run of the post hook
+const postHookTimeout = time.Minute
+
+// applyPostHookConfig applies the post hook settings of the invoke command from a loaded configuration
+func applyPostHookConfig(v *viper.Viper, options *AgentOptions) {
+	if v.InConfig("post_hook") && options.PostHook == "" {
+		options.PostHook = v.GetString("post_hook")
+		logVerbose(*options, "Loaded post hook from config: %s", options.PostHook)
+	}
+	if v.InConfig("post_hook_replace") && !options.PostHookReplace {
+		options.PostHookReplace = v.GetBool("post_hook_replace")
+	}
+}
+
+// validatePostHookOptions checks that the post hook flags can be combined with the output options
+func validatePostHookOptions(opts AgentOptions) error {
+	if !opts.PostHookReplace {
+		return nil
+	}
+	if opts.PostHook == "" {
+		return fmt.Errorf("--post-hook-replace requires --post-hook")
+	}
+	if opts.Query != "" || opts.OutputFormat == OutputFormatTemplate {
+		return fmt.Errorf("--post-hook-replace cannot be used with --query or --format template")
+	}
+	return nil
+}
+
+// runPostHook pipes the response document into the post hook and returns what it printed
+func runPostHook(opts AgentOptions, response map[string]interface{}) ([]byte, error) {
+	input, err := json.Marshal(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal response for post hook: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), postHookTimeout)
+	defer cancel()
+
+	logVerbose(opts, "Running post hook: %s", opts.PostHook)
+
+	var stdout bytes.Buffer
+	cmd := shellCommandContext(ctx, opts.PostHook)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			err = fmt.Errorf("timed out after %s", formatDuration(postHookTimeout))
+		}
+		return nil, fmt.Errorf("post hook '%s' failed: %w", opts.PostHook, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// writePostHookResponse reads the whole response and writes the post hook's output in its place
+func (rf *ResponseFormatter) writePostHookResponse(output *bedrockagentruntime.InvokeAgentOutput) error {
+	var result StreamResult
+	var streamErr error
+	if stream := output.GetStream(); stream != nil {
+		result, streamErr = rf.newStreamProcessor(false).ProcessStream(stream)
+		rf.lastResult = result
+	}
+	rf.Progress.Stop()
+
+	// The hook also sees failed invocations, in the same shape as --format json
+	response := rf.buildJSONResponse(output, result)
+	if streamErr != nil && !result.Interrupted {
+		response["error"] = jsonErrorObject(streamErr)
+		response["partial"] = true
+	}
+
+	replacement, err := runPostHook(rf.Options, response)
+	if err != nil {
+		return err
+	}
+	if _, err := rf.Writer.Write(replacement); err != nil {
+		return err
+	}
+	return streamErr
+}
+
+// runObservingPostHook passes the response to the post hook after it has been written.
+// The hook's output goes to stderr, and a failing hook only produces a warning.
+func (rf *ResponseFormatter) runObservingPostHook(output *bedrockagentruntime.InvokeAgentOutput) {
+	response := rf.lastDocument
+	if response == nil {
+		response = rf.responseDocument(output, rf.lastResult, rf.lastResult.SavedFiles)
+	}
+
+	printed, err := runPostHook(rf.Options, response)
+	if err != nil {
+		rf.Options.Warnings.Warn(WarningHook, "post hook failed", err)
+		return
+	}
+	if text := strings.TrimRight(string(printed), "\n"); text != "" {
+		fmt.Fprintln(os.Stderr, text)
+	}
+}
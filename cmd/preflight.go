@@ -0,0 +1,115 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the connection pre-check of the AWS Bedrock Intelligent Agents CLI.
+With --preflight the region, credentials, and a TCP connection to the runtime endpoint
+are checked within a short deadline before the agent is invoked, so configuration and
+network problems fail fast instead of after the full timeout.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime"
+)
+
+// preflightTimeout bounds all checks together
+const preflightTimeout = 2 * time.Second
+
+// runPreflight checks that the runtime endpoint can be reached with usable credentials
+func runPreflight(ctx context.Context, awsHelper *AWSHelper) error {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, preflightTimeout)
+	defer cancel()
+
+	cfg, err := awsHelper.LoadConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("preflight: failed to load AWS config: %w", err)
+	}
+	if cfg.Region == "" {
+		return fmt.Errorf("preflight: no AWS region configured (use --region or AWS_REGION)")
+	}
+
+	if err := checkCredentials(ctx, cfg); err != nil {
+		return fmt.Errorf("preflight: %w", err)
+	}
+
+	address, err := runtimeEndpointAddress(ctx, cfg, awsHelper.Options)
+	if err != nil {
+		return fmt.Errorf("preflight: %w", err)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("preflight: %s did not accept a connection within %s (check the region and network routes)", address, preflightTimeout)
+		}
+		return fmt.Errorf("preflight: cannot connect to %s (check the region and network routes): %w", address, err)
+	}
+	conn.Close()
+
+	logVerbose(awsHelper.Options, "Preflight passed in %s: region %s, endpoint %s", time.Since(start).Round(time.Millisecond), cfg.Region, address)
+	return nil
+}
+
+// checkCredentials retrieves the credentials and rejects ones known to be expired
+func checkCredentials(ctx context.Context, cfg aws.Config) error {
+	if cfg.Credentials == nil {
+		return fmt.Errorf("no AWS credentials configured")
+	}
+
+	creds, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+	if creds.CanExpire && !creds.Expires.After(time.Now()) {
+		return fmt.Errorf("AWS credentials from %s expired at %s", creds.Source, creds.Expires.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// runtimeEndpointAddress returns the host:port the runtime client sends requests to
+func runtimeEndpointAddress(ctx context.Context, cfg aws.Config, opts AgentOptions) (string, error) {
+	if opts.EndpointURL != "" {
+		return endpointAddress(opts.EndpointURL)
+	}
+	if cfg.BaseEndpoint != nil {
+		return endpointAddress(aws.ToString(cfg.BaseEndpoint))
+	}
+
+	endpoint, err := bedrockagentruntime.NewDefaultEndpointResolverV2().ResolveEndpoint(ctx,
+		bedrockagentruntime.EndpointParameters{
+			Region:       aws.String(cfg.Region),
+			UseFIPS:      aws.Bool(opts.UseFIPS),
+			UseDualStack: aws.Bool(opts.UseDualStack),
+		})
+	if err != nil {
+		return "", fmt.Errorf("no Bedrock agent runtime endpoint for region '%s': %w", cfg.Region, err)
+	}
+	return endpointAddress(endpoint.URI.String())
+}
+
+// endpointAddress converts an endpoint URL to host:port, using the default port of the scheme
+func endpointAddress(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Hostname() == "" {
+		return "", fmt.Errorf("invalid endpoint URL '%s'", endpoint)
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
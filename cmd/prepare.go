@@ -0,0 +1,163 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the 'prepare' command for AWS Bedrock Intelligent Agents CLI.
+It calls the control-plane PrepareAgent API and polls the agent status until the
+draft is ready, so it can be tested through the TSTALIASID test alias right away.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagent"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagent/types"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// Default interval between agent status checks
+	DefaultPollInterval = 5 * time.Second
+
+	// Default maximum time to wait for an agent to be prepared
+	DefaultPrepareTimeout = 10 * time.Minute
+)
+
+// PrepareOptions contains all options for preparing an agent
+type PrepareOptions struct {
+	ConfigFile   string
+	AgentID      string
+	Region       string
+	NoWait       bool
+	PollInterval time.Duration
+	Timeout      time.Duration
+	Verbose      bool
+}
+
+var prepareOpts PrepareOptions
+
+// prepareCmd represents the prepare command
+var prepareCmd = &cobra.Command{
+	Use:   "prepare",
+	Short: "Prepare the draft version of an agent",
+	Long: `Prepare the draft version of an agent and wait until it is ready.
+
+This command calls the Bedrock Agent PrepareAgent API and polls the agent
+status until it becomes PREPARED or FAILED. Once prepared, the draft can be
+tested with the TSTALIASID test alias.
+
+Examples:
+  # Prepare an agent and wait for it to finish
+  aws-bia prepare --agent-id abc123
+
+  # Then test the draft version
+  aws-bia invoke --agent-id abc123 --agent-alias-id TSTALIASID --input "Hello"
+
+  # Start preparation without waiting
+  aws-bia prepare --agent-id abc123 --no-wait
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		if err := runPrepareCommand(ctx, prepareOpts); err != nil {
+			logError("Error preparing agent", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(prepareCmd)
+
+	prepareCmd.Flags().StringVar(&prepareOpts.ConfigFile, "config", "", "Path to configuration file (yaml)")
+	prepareCmd.Flags().StringVar(&prepareOpts.AgentID, "agent-id", "", "The ID of the agent to prepare (can be set in config file)")
+	prepareCmd.Flags().StringVar(&prepareOpts.Region, "region", "", "AWS region to use (defaults to AWS_REGION environment variable)")
+	prepareCmd.Flags().BoolVar(&prepareOpts.NoWait, "no-wait", false, "Return immediately after starting preparation")
+	prepareCmd.Flags().DurationVar(&prepareOpts.PollInterval, "poll-interval", DefaultPollInterval, "Interval between status checks")
+	prepareCmd.Flags().DurationVar(&prepareOpts.Timeout, "timeout", DefaultPrepareTimeout, "Maximum time to wait for preparation")
+	prepareCmd.Flags().BoolVar(&prepareOpts.Verbose, "verbose", false, "Enable verbose output")
+
+	registerAgentCompletions(prepareCmd)
+}
+
+// runPrepareCommand starts agent preparation and optionally waits for it to finish
+func runPrepareCommand(ctx context.Context, opts PrepareOptions) error {
+	InitLogger(opts.Verbose)
+	defer SyncLogger()
+
+	agentOpts := AgentOptions{
+		AgentID: opts.AgentID,
+		Region:  opts.Region,
+		Verbose: opts.Verbose,
+		Timeout: DefaultTimeout,
+	}
+	if err := loadConfig(opts.ConfigFile, "prepare", &agentOpts); err != nil {
+		return err
+	}
+	opts.AgentID = agentOpts.AgentID
+
+	if opts.AgentID == "" {
+		return fmt.Errorf("agent ID is required")
+	}
+	if opts.PollInterval <= 0 {
+		return fmt.Errorf("poll interval must be a positive duration")
+	}
+	if opts.Timeout <= 0 {
+		return fmt.Errorf("timeout must be a positive duration")
+	}
+
+	client, err := NewAWSHelper(agentOpts).CreateAgentClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS client: %w", err)
+	}
+
+	return prepareAgent(ctx, client, opts)
+}
+
+// prepareAgent calls PrepareAgent and, unless NoWait is set, polls until the agent is prepared
+func prepareAgent(ctx context.Context, client *bedrockagent.Client, opts PrepareOptions) error {
+	out, err := client.PrepareAgent(ctx, &bedrockagent.PrepareAgentInput{
+		AgentId: aws.String(opts.AgentID),
+	})
+	if err != nil {
+		return HandleAWSError(fmt.Errorf("failed to prepare agent: %w", err))
+	}
+
+	fmt.Printf("Preparing agent %s (status: %s)\n", opts.AgentID, out.AgentStatus)
+	if opts.NoWait {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	agent, err := WaitForAgentPrepared(ctx, client, opts.AgentID, opts.PollInterval, statusProgress[types.AgentStatus]())
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Agent %s is %s", opts.AgentID, agent.AgentStatus)
+	if agent.PreparedAt != nil {
+		fmt.Printf(" (prepared at %s)", agent.PreparedAt.Format(time.RFC3339))
+	}
+	fmt.Println()
+
+	return nil
+}
+
+// statusProgress returns a progress callback that shows the last polled status with a spinner on stderr
+func statusProgress[S ~string]() func(status S, elapsed time.Duration) {
+	frames := []string{"|", "/", "-", "\\"}
+	checks := 0
+	return func(status S, elapsed time.Duration) {
+		fmt.Fprintf(os.Stderr, "\r%s %-14s %s", frames[checks%len(frames)], status, formatDuration(elapsed))
+		checks++
+	}
+}
@@ -0,0 +1,256 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the progress indicator for the AWS Bedrock Intelligent Agents CLI.
+Without --stream the agent sends its answer in one piece at the end, so a spinner
+with the elapsed time and the current phase is shown on stderr until output starts.
+With --progress-json the indicator writes JSON records with the phase, the elapsed
+time, and the chunks and bytes received to stderr instead, for wrappers and UIs.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/types"
+)
+
+// Phases reported by the progress indicator
+const (
+	PhaseUploadingFiles    = "uploading files"
+	PhaseWaitingForAgent   = "waiting for agent"
+	PhaseReceivingResponse = "receiving response"
+	PhaseTranslating       = "translating answer"
+)
+
+// progressRefreshInterval is how often the spinner line is redrawn
+const progressRefreshInterval = 100 * time.Millisecond
+
+// progressJSONInterval is how often a JSON progress record is written while nothing changes
+const progressJSONInterval = time.Second
+
+// progressRecord is a line of --progress-json output
+type progressRecord struct {
+	Type      string `json:"type"` // Always "progress", to tell the records apart from log lines
+	Phase     string `json:"phase"`
+	ElapsedMs int64  `json:"elapsedMs"`
+	Events    int    `json:"events"`
+	Chunks    int    `json:"chunks"`
+	Bytes     int64  `json:"bytes"`
+	Done      bool   `json:"done,omitempty"`
+}
+
+// progressIndicator draws a spinner line on stderr. A nil indicator does nothing,
+// so callers do not need to check whether progress output is enabled.
+type progressIndicator struct {
+	out     io.Writer
+	mu      sync.Mutex
+	phase   string
+	start   time.Time
+	stop    chan struct{}
+	stopped sync.WaitGroup
+
+	// JSON records instead of a spinner, with the stream counters
+	json    bool
+	changed chan struct{}
+	events  int
+	chunks  int
+	bytes   int64
+}
+
+// newProgressIndicator returns an indicator when progress output makes sense, otherwise nil
+func newProgressIndicator(opts AgentOptions) *progressIndicator {
+	// Records are asked for explicitly, so they are written in every mode
+	if opts.ProgressJSON {
+		return &progressIndicator{out: os.Stderr, json: true, changed: make(chan struct{}, 1)}
+	}
+	// Streaming output and verbose logs already show activity, pipes should stay clean
+	if opts.EnableStreaming || opts.NoProgress || opts.Quiet || opts.Verbose || logFormat == LogFormatJSON || !isTerminal(os.Stderr) {
+		return nil
+	}
+	return &progressIndicator{out: os.Stderr}
+}
+
+// Start begins drawing the spinner in the given phase
+func (p *progressIndicator) Start(phase string) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stop != nil {
+		p.phase = phase
+		return
+	}
+
+	// Restarting after a pause keeps counting from the first start
+	p.phase = phase
+	if p.start.IsZero() {
+		p.start = time.Now()
+	}
+	p.stop = make(chan struct{})
+	p.stopped.Add(1)
+	if p.json {
+		go p.runJSON(p.stop)
+		return
+	}
+	go p.run(p.stop)
+}
+
+// SetPhase changes the phase shown next to the spinner
+func (p *progressIndicator) SetPhase(phase string) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	changed := p.phase != phase
+	p.phase = phase
+	p.mu.Unlock()
+	if changed {
+		p.notify()
+	}
+}
+
+// notify makes the JSON writer report a new phase right away
+func (p *progressIndicator) notify() {
+	if p.changed == nil {
+		return
+	}
+	select {
+	case p.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Stop clears the spinner line; it is safe to call more than once and Start resumes it.
+// JSON records do not get in the way of the output, so they continue until Close.
+func (p *progressIndicator) Stop() {
+	if p == nil || p.json {
+		return
+	}
+	p.halt()
+}
+
+// Close ends the progress output for good, with a last record marked done in JSON mode
+func (p *progressIndicator) Close() {
+	if p == nil {
+		return
+	}
+	p.halt()
+}
+
+// halt stops the goroutine drawing the progress
+func (p *progressIndicator) halt() {
+	p.mu.Lock()
+	stop := p.stop
+	p.stop = nil
+	p.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		p.stopped.Wait()
+	}
+}
+
+// eventReceived updates the indicator for a stream event, stopping it before text output begins
+func (p *progressIndicator) eventReceived(event types.ResponseStream, writesText bool) {
+	if p == nil {
+		return
+	}
+
+	// The text streams on stdout next to the records, so counting continues
+	if p.json {
+		p.mu.Lock()
+		p.events++
+		if chunk, ok := event.(*types.ResponseStreamMemberChunk); ok {
+			p.chunks++
+			p.bytes += int64(len(chunk.Value.Bytes))
+		}
+		p.mu.Unlock()
+		p.SetPhase(PhaseReceivingResponse)
+		return
+	}
+
+	switch event.(type) {
+	case *types.ResponseStreamMemberChunk, *types.ResponseStreamMemberFiles, *types.ResponseStreamMemberReturnControl:
+		if writesText {
+			p.Stop()
+			return
+		}
+	}
+	p.SetPhase(PhaseReceivingResponse)
+}
+
+// run redraws the spinner until stop is closed
+func (p *progressIndicator) run(stop chan struct{}) {
+	defer p.stopped.Done()
+
+	ticker := time.NewTicker(progressRefreshInterval)
+	defer ticker.Stop()
+
+	frames := []string{"|", "/", "-", "\\"}
+	for frame := 0; ; frame++ {
+		p.mu.Lock()
+		phase, elapsed := p.phase, time.Since(p.start)
+		p.mu.Unlock()
+
+		fmt.Fprintf(p.out, "\r\033[K%s %s %s", frames[frame%len(frames)], phase, formatDuration(elapsed))
+
+		select {
+		case <-stop:
+			fmt.Fprint(p.out, "\r\033[K")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runJSON writes a progress record every interval and on every phase change until stop is
+// closed, then a last record marked done
+func (p *progressIndicator) runJSON(stop chan struct{}) {
+	defer p.stopped.Done()
+
+	ticker := time.NewTicker(progressJSONInterval)
+	defer ticker.Stop()
+
+	p.writeRecord(false)
+	for {
+		select {
+		case <-stop:
+			p.writeRecord(true)
+			return
+		case <-p.changed:
+		case <-ticker.C:
+		}
+		p.writeRecord(false)
+	}
+}
+
+// writeRecord writes the current progress as one JSON line
+func (p *progressIndicator) writeRecord(done bool) {
+	p.mu.Lock()
+	record := progressRecord{
+		Type:      "progress",
+		Phase:     strings.ReplaceAll(p.phase, " ", "_"),
+		ElapsedMs: time.Since(p.start).Milliseconds(),
+		Events:    p.events,
+		Chunks:    p.chunks,
+		Bytes:     p.bytes,
+		Done:      done,
+	}
+	p.mu.Unlock()
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(p.out, "%s\n", data)
+}
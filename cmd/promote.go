@@ -0,0 +1,158 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the 'promote' command for AWS Bedrock Intelligent Agents CLI.
+It points an existing agent alias at a new agent version using the control-plane
+UpdateAgentAlias API, so a tested version can be released without leaving the CLI.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/spf13/cobra"
+)
+
+// PromoteOptions contains all options for promoting an agent alias
+type PromoteOptions struct {
+	ConfigFile string
+	AgentID    string
+	Alias      string // Alias ID or alias name
+	ToVersion  string
+	Region     string
+	DryRun     bool
+	Yes        bool
+	Verbose    bool
+}
+
+var promoteOpts PromoteOptions
+
+// promoteCmd represents the promote command
+var promoteCmd = &cobra.Command{
+	Use:   "promote",
+	Short: "Point an agent alias at a new agent version",
+	Long: `Point an agent alias at a new agent version.
+
+This command wraps the Bedrock Agent UpdateAgentAlias API. The alias can be
+given by ID or by name. You will be asked for confirmation before the alias
+is updated unless --yes is specified.
+
+Examples:
+  # Promote version 7 to the prod alias
+  aws-bia promote --agent-id abc123 --alias prod --to-version 7
+
+  # Show what would change without updating the alias
+  aws-bia promote --agent-id abc123 --alias prod --to-version 7 --dry-run
+
+  # Skip the confirmation prompt (for CI pipelines)
+  aws-bia promote --agent-id abc123 --alias prod --to-version 7 --yes
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		if err := runPromoteCommand(ctx, promoteOpts); err != nil {
+			logError("Error promoting agent alias", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(promoteCmd)
+
+	promoteCmd.Flags().StringVar(&promoteOpts.ConfigFile, "config", "", "Path to configuration file (yaml)")
+	promoteCmd.Flags().StringVar(&promoteOpts.AgentID, "agent-id", "", "The ID of the agent (can be set in config file)")
+	promoteCmd.Flags().StringVar(&promoteOpts.Alias, "alias", "", "The ID or name of the alias to update")
+	promoteCmd.Flags().StringVar(&promoteOpts.ToVersion, "to-version", "", "The agent version the alias should point to")
+	promoteCmd.Flags().StringVar(&promoteOpts.Region, "region", "", "AWS region to use (defaults to AWS_REGION environment variable)")
+	promoteCmd.Flags().BoolVar(&promoteOpts.DryRun, "dry-run", false, "Show the change without updating the alias")
+	promoteCmd.Flags().BoolVarP(&promoteOpts.Yes, "yes", "y", false, "Skip the confirmation prompt")
+	promoteCmd.Flags().BoolVar(&promoteOpts.Verbose, "verbose", false, "Enable verbose output")
+
+	registerAgentCompletions(promoteCmd)
+}
+
+// runPromoteCommand updates the alias routing configuration to the requested version
+func runPromoteCommand(ctx context.Context, opts PromoteOptions) error {
+	InitLogger(opts.Verbose)
+	defer SyncLogger()
+
+	// Reuse the invoke config loader for agent ID and region defaults
+	agentOpts := AgentOptions{
+		AgentID: opts.AgentID,
+		Region:  opts.Region,
+		Verbose: opts.Verbose,
+		Timeout: DefaultTimeout,
+	}
+	if err := loadConfig(opts.ConfigFile, "promote", &agentOpts); err != nil {
+		return err
+	}
+	opts.AgentID = agentOpts.AgentID
+
+	if opts.AgentID == "" {
+		return fmt.Errorf("agent ID is required")
+	}
+	if opts.Alias == "" {
+		return fmt.Errorf("alias is required")
+	}
+	if opts.ToVersion == "" {
+		return fmt.Errorf("target version is required")
+	}
+
+	client, err := NewAWSHelper(agentOpts).CreateAgentClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS client: %w", err)
+	}
+
+	alias, err := ResolveAgentAlias(ctx, client, opts.AgentID, opts.Alias)
+	if err != nil {
+		return err
+	}
+
+	aliasID := aws.ToString(alias.AgentAliasId)
+	aliasName := aws.ToString(alias.AgentAliasName)
+	currentVersion := aliasRoutedVersion(alias)
+
+	fmt.Printf("Agent:   %s\n", opts.AgentID)
+	fmt.Printf("Alias:   %s (%s)\n", aliasName, aliasID)
+	fmt.Printf("Version: %s -> %s\n", currentVersion, opts.ToVersion)
+
+	if currentVersion == opts.ToVersion {
+		fmt.Println("Alias already points to the requested version, nothing to do")
+		return nil
+	}
+
+	if opts.DryRun {
+		fmt.Println("Dry run: alias was not updated")
+		return nil
+	}
+
+	if !opts.Yes {
+		ok, err := confirmAction(fmt.Sprintf("Update alias '%s' to version %s?", aliasName, opts.ToVersion))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Aborted")
+			return nil
+		}
+	}
+
+	updated, err := updateAliasVersion(ctx, client, alias, opts.ToVersion)
+	if err != nil {
+		return err
+	}
+
+	status := ""
+	if updated != nil {
+		status = string(updated.AgentAliasStatus)
+	}
+	fmt.Printf("Alias '%s' now points to version %s (status: %s)\n", aliasName, opts.ToVersion, status)
+
+	return nil
+}
@@ -0,0 +1,69 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the prompt search path of the AWS Bedrock Intelligent Agents CLI.
+The directories of AWS_BIA_PROMPT_PATH and of prompt_dirs in the configuration file are
+searched before the defaults: ./prompts, the prompts directory of the user that 'prompts
+sync' writes to, and the shared data directories of the platform, %ProgramData% on
+Windows and $XDG_DATA_DIRS elsewhere.
+*/
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// promptPathEnv lists prompt directories to search first, separated like PATH
+const promptPathEnv = "AWS_BIA_PROMPT_PATH"
+
+// promptSearchDirs returns the prompt directories in the order they are searched, without
+// duplicates. Directories that do not exist are kept, they are skipped when searching.
+func promptSearchDirs(configured []string) []string {
+	var dirs []string
+	dirs = append(dirs, filepath.SplitList(os.Getenv(promptPathEnv))...)
+	dirs = append(dirs, configured...)
+	dirs = append(dirs, "prompts")
+	if dir, err := userPromptDir(); err == nil {
+		dirs = append(dirs, dir)
+	}
+	// Templates left in the other of the XDG and ~/.aws-bia locations are still found
+	if dir := userDataDir(); dir != "" {
+		dirs = append(dirs, filepath.Join(dir, appDirName, "prompts"))
+	}
+	if dir, err := legacyAppDir(); err == nil {
+		dirs = append(dirs, filepath.Join(dir, "prompts"))
+	}
+	for _, dir := range systemDataDirs() {
+		dirs = append(dirs, filepath.Join(dir, appDirName, "prompts"))
+	}
+
+	seen := make(map[string]struct{}, len(dirs))
+	unique := dirs[:0]
+	for _, dir := range dirs {
+		if dir = strings.TrimSpace(dir); dir == "" {
+			continue
+		}
+		dir = filepath.Clean(expandHomeDir(dir))
+		if _, ok := seen[dir]; ok {
+			continue
+		}
+		seen[dir] = struct{}{}
+		unique = append(unique, dir)
+	}
+	return unique
+}
+
+// expandHomeDir replaces a leading ~ with the home directory, as a shell would; configured
+// paths do not pass through a shell
+func expandHomeDir(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, `~\`) {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
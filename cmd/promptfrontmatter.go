@@ -0,0 +1,140 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements prompt template frontmatter for the AWS Bedrock Intelligent Agents CLI.
+A template can start with a YAML block between "---" lines that declares its variables with
+a description, a default, and whether they are required. Required variables that were not
+given with --var or --vars-file are asked for on the terminal unless --no-interactive is set.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// promptFrontmatter is the YAML block at the start of a prompt template
+type promptFrontmatter struct {
+	Description string           `yaml:"description"`
+	Variables   []promptVariable `yaml:"-"` // In the order of the template
+}
+
+// promptVariable declares a variable of a prompt template
+type promptVariable struct {
+	Name        string `yaml:"-"`
+	Description string `yaml:"description"`
+	Default     string `yaml:"default"`
+	Required    bool   `yaml:"required"`
+}
+
+// splitPromptFrontmatter separates the frontmatter from the template body. Content without a
+// complete "---" block is returned unchanged with empty frontmatter.
+func splitPromptFrontmatter(content string) (promptFrontmatter, string, error) {
+	var frontmatter promptFrontmatter
+
+	first, rest, ok := strings.Cut(strings.TrimPrefix(content, "\ufeff"), "\n")
+	if !ok || strings.TrimRight(first, " \t\r") != "---" {
+		return frontmatter, content, nil
+	}
+	block, body, found := cutFrontmatterEnd(rest)
+	if !found {
+		return frontmatter, content, nil
+	}
+
+	var raw struct {
+		Description string    `yaml:"description"`
+		Variables   yaml.Node `yaml:"variables"`
+	}
+	if err := yaml.Unmarshal([]byte(block), &raw); err != nil {
+		return frontmatter, "", fmt.Errorf("invalid prompt frontmatter: %w", err)
+	}
+	frontmatter.Description = raw.Description
+
+	switch raw.Variables.Kind {
+	case 0:
+	case yaml.MappingNode:
+		// The mapping is walked as a node so variables are asked for in the order they are declared
+		for i := 0; i+1 < len(raw.Variables.Content); i += 2 {
+			variable := promptVariable{Name: raw.Variables.Content[i].Value}
+			if err := raw.Variables.Content[i+1].Decode(&variable); err != nil {
+				return frontmatter, "", fmt.Errorf("invalid prompt frontmatter for variable '%s': %w", variable.Name, err)
+			}
+			frontmatter.Variables = append(frontmatter.Variables, variable)
+		}
+	default:
+		return frontmatter, "", fmt.Errorf("invalid prompt frontmatter: variables must be a mapping of variable names")
+	}
+	return frontmatter, body, nil
+}
+
+// cutFrontmatterEnd splits the lines after the opening "---" at the closing "---" line
+func cutFrontmatterEnd(rest string) (block, body string, found bool) {
+	offset := 0
+	for _, line := range strings.SplitAfter(rest, "\n") {
+		if strings.TrimRight(line, " \t\r\n") == "---" {
+			return rest[:offset], rest[offset+len(line):], true
+		}
+		offset += len(line)
+	}
+	return "", "", false
+}
+
+// resolvePromptVariables returns key=value variables for the declared variables that were not
+// given. Defaults fill in optional variables; a required variable is asked for on the terminal,
+// or only reported when no one can answer, in which case the template renders it as before.
+func resolvePromptVariables(opts AgentOptions, frontmatter promptFrontmatter, given map[string]bool) ([]string, error) {
+	interactive := !opts.NoInteractive && isTerminal(os.Stdin)
+
+	var resolved []string
+	for _, variable := range frontmatter.Variables {
+		if given[variable.Name] {
+			continue
+		}
+
+		if !variable.Required || !interactive {
+			if variable.Default != "" {
+				resolved = append(resolved, variable.Name+"="+variable.Default)
+			} else if variable.Required {
+				LogWarn("Prompt variable '%s' is required but not set (use --var %s=...)", variable.Name, variable.Name)
+			}
+			continue
+		}
+
+		question := variable.Name
+		if variable.Description != "" {
+			question += " (" + variable.Description + ")"
+		}
+		if variable.Default != "" {
+			question += " [" + variable.Default + "]"
+		}
+		answer, err := readLine(question + ": ")
+		if err != nil {
+			return nil, err
+		}
+		if answer == "" {
+			answer = variable.Default
+		}
+		if answer == "" {
+			return nil, fmt.Errorf("no value entered for required prompt variable '%s'", variable.Name)
+		}
+		resolved = append(resolved, variable.Name+"="+answer)
+	}
+	return resolved, nil
+}
+
+// givenPromptVariables returns the names of the variables set with --vars-file and --var
+func givenPromptVariables(fileVars map[string]interface{}, vars []string) map[string]bool {
+	given := make(map[string]bool, len(fileVars)+len(vars))
+	for name := range fileVars {
+		given[name] = true
+	}
+	for _, v := range vars {
+		if name, _, ok := strings.Cut(v, "="); ok {
+			given[name] = true
+		}
+	}
+	return given
+}
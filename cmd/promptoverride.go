@@ -0,0 +1,480 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements prompt override files for the 'invoke' command. --prompt-override-file
+loads pre-processing, orchestration, knowledge base response generation, post-processing,
+and routing prompt templates from a JSON document in the shape of the Bedrock
+PromptOverrideConfiguration. InvokeAgent cannot override prompts, so the agent version
+behind the alias is read from the control plane and sent as an inline agent with the
+InvokeInlineAgent API instead. The inline response stream has the same events as the
+agent response stream, so the request is rewritten below the SDK and the answer is
+processed, formatted, and recorded exactly like an agent answer. --temperature, --top-p,
+and --max-tokens take the same path as an inference configuration of the orchestration prompt.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagent"
+	agenttypes "github.com/aws/aws-sdk-go-v2/service/bedrockagent/types"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/types"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/spf13/pflag"
+)
+
+// testAliasID is the alias that always invokes the working draft of an agent
+const testAliasID = "TSTALIASID"
+
+// errRequestCaptured stops a request once it has been serialized
+var errRequestCaptured = errors.New("request captured")
+
+// PromptOverrideDocument is the JSON form of a prompt override file
+type PromptOverrideDocument struct {
+	PromptConfigurations []promptOverrideEntry `json:"promptConfigurations"`
+	OverrideLambda       string                `json:"overrideLambda,omitempty"` // Parser Lambda for prompts with parserMode OVERRIDDEN
+}
+
+// promptOverrideEntry overrides the prompt of one step of the agent sequence
+type promptOverrideEntry struct {
+	PromptType             string `json:"promptType"`
+	BasePromptTemplate     string `json:"basePromptTemplate,omitempty"`
+	BasePromptTemplateFile string `json:"basePromptTemplateFile,omitempty"` // Relative to the override file
+	PromptCreationMode     string `json:"promptCreationMode,omitempty"`     // Defaults to OVERRIDDEN when a template is given
+	PromptState            string `json:"promptState,omitempty"`
+	ParserMode             string `json:"parserMode,omitempty"`
+	FoundationModel        string `json:"foundationModel,omitempty"`
+	InferenceConfiguration *struct {
+		Temperature   *float32 `json:"temperature,omitempty"`
+		TopP          *float32 `json:"topP,omitempty"`
+		TopK          *int32   `json:"topK,omitempty"`
+		MaximumLength *int32   `json:"maximumLength,omitempty"`
+		StopSequences []string `json:"stopSequences,omitempty"`
+	} `json:"inferenceConfiguration,omitempty"`
+}
+
+// loadPromptOverrideFile reads a prompt override file and converts it into the SDK type
+func loadPromptOverrideFile(path string) (*types.PromptOverrideConfiguration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt override file '%s': %w", path, err)
+	}
+
+	var doc PromptOverrideDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid prompt override file '%s': %w", path, err)
+	}
+	config, err := doc.promptOverrideConfiguration(filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt override file '%s': %w", path, err)
+	}
+	return config, nil
+}
+
+// promptOverrideConfiguration checks the document and converts it, reading template files relative to dir
+func (doc *PromptOverrideDocument) promptOverrideConfiguration(dir string) (*types.PromptOverrideConfiguration, error) {
+	if len(doc.PromptConfigurations) == 0 {
+		return nil, fmt.Errorf("promptConfigurations must not be empty")
+	}
+
+	config := &types.PromptOverrideConfiguration{OverrideLambda: optionalString(doc.OverrideLambda)}
+	seen := make(map[types.PromptType]bool)
+	for i, entry := range doc.PromptConfigurations {
+		promptType := types.PromptType(entry.PromptType)
+		if !slices.Contains(promptType.Values(), promptType) {
+			return nil, fmt.Errorf("promptConfigurations[%d]: promptType must be one of %v, got '%s'", i, promptType.Values(), entry.PromptType)
+		}
+		if seen[promptType] {
+			return nil, fmt.Errorf("promptConfigurations[%d]: %s is overridden more than once", i, promptType)
+		}
+		seen[promptType] = true
+
+		template := entry.BasePromptTemplate
+		if entry.BasePromptTemplateFile != "" {
+			if template != "" {
+				return nil, fmt.Errorf("promptConfigurations[%d]: use either basePromptTemplate or basePromptTemplateFile", i)
+			}
+			templatePath := entry.BasePromptTemplateFile
+			if !filepath.IsAbs(templatePath) {
+				templatePath = filepath.Join(dir, templatePath)
+			}
+			content, err := os.ReadFile(templatePath)
+			if err != nil {
+				return nil, fmt.Errorf("promptConfigurations[%d]: failed to read template: %w", i, err)
+			}
+			template = string(content)
+		}
+
+		prompt := types.PromptConfiguration{
+			PromptType:         promptType,
+			BasePromptTemplate: optionalString(template),
+			FoundationModel:    optionalString(entry.FoundationModel),
+			PromptCreationMode: types.CreationMode(entry.PromptCreationMode),
+			PromptState:        types.PromptState(entry.PromptState),
+			ParserMode:         types.CreationMode(entry.ParserMode),
+		}
+		if prompt.PromptCreationMode == "" {
+			prompt.PromptCreationMode = types.CreationModeDefault
+			if template != "" {
+				prompt.PromptCreationMode = types.CreationModeOverridden
+			}
+		}
+		for name, value := range map[string]types.CreationMode{"promptCreationMode": prompt.PromptCreationMode, "parserMode": prompt.ParserMode} {
+			if value != "" && !slices.Contains(value.Values(), value) {
+				return nil, fmt.Errorf("promptConfigurations[%d]: %s must be one of %v, got '%s'", i, name, value.Values(), value)
+			}
+		}
+		if prompt.PromptState != "" && !slices.Contains(prompt.PromptState.Values(), prompt.PromptState) {
+			return nil, fmt.Errorf("promptConfigurations[%d]: promptState must be one of %v, got '%s'", i, prompt.PromptState.Values(), prompt.PromptState)
+		}
+		if prompt.PromptCreationMode == types.CreationModeOverridden && template == "" {
+			return nil, fmt.Errorf("promptConfigurations[%d]: promptCreationMode OVERRIDDEN requires a basePromptTemplate", i)
+		}
+		if prompt.ParserMode == types.CreationModeOverridden && doc.OverrideLambda == "" {
+			return nil, fmt.Errorf("promptConfigurations[%d]: parserMode OVERRIDDEN requires an overrideLambda", i)
+		}
+
+		if inference := entry.InferenceConfiguration; inference != nil {
+			prompt.InferenceConfiguration = &types.InferenceConfiguration{
+				Temperature:   inference.Temperature,
+				TopP:          inference.TopP,
+				TopK:          inference.TopK,
+				MaximumLength: inference.MaximumLength,
+				StopSequences: inference.StopSequences,
+			}
+		}
+		config.PromptConfigurations = append(config.PromptConfigurations, prompt)
+	}
+	return config, nil
+}
+
+// inferenceFlags receives --temperature, --top-p, and --max-tokens. Only the flags that were
+// given are copied into the options, so an explicit 0 is told apart from an unset flag.
+var inferenceFlags struct {
+	Temperature float32
+	TopP        float32
+	MaxTokens   int32
+}
+
+// applyInferenceFlags copies the inference flags that were given into the options
+func applyInferenceFlags(flags *pflag.FlagSet, options *AgentOptions) {
+	if flags.Changed("temperature") {
+		options.Temperature = aws.Float32(inferenceFlags.Temperature)
+	}
+	if flags.Changed("top-p") {
+		options.TopP = aws.Float32(inferenceFlags.TopP)
+	}
+	if flags.Changed("max-tokens") {
+		options.MaxTokens = aws.Int32(inferenceFlags.MaxTokens)
+	}
+}
+
+// applyInferenceOverrides adds the inference parameters of the options to the orchestration prompt
+// of the prompt overrides. InvokeAgent has no inference parameters, so giving any of them turns the
+// invocation into an inline agent invocation. Values from a prompt override file are replaced.
+func applyInferenceOverrides(options *AgentOptions) error {
+	if options.Temperature == nil && options.TopP == nil && options.MaxTokens == nil {
+		return nil
+	}
+	if t := options.Temperature; t != nil && (*t < 0 || *t > 1) {
+		return fmt.Errorf("temperature must be between 0 and 1, got %g", *t)
+	}
+	if p := options.TopP; p != nil && (*p < 0 || *p > 1) {
+		return fmt.Errorf("top-p must be between 0 and 1, got %g", *p)
+	}
+	if n := options.MaxTokens; n != nil && *n < 1 {
+		return fmt.Errorf("max-tokens must be at least 1, got %d", *n)
+	}
+
+	if options.PromptOverrides == nil {
+		options.PromptOverrides = &types.PromptOverrideConfiguration{}
+	}
+	prompts := options.PromptOverrides.PromptConfigurations
+	i := slices.IndexFunc(prompts, func(prompt types.PromptConfiguration) bool {
+		return prompt.PromptType == types.PromptTypeOrchestration
+	})
+	if i < 0 {
+		prompts = append(prompts, types.PromptConfiguration{
+			PromptType:         types.PromptTypeOrchestration,
+			PromptCreationMode: types.CreationModeDefault,
+		})
+		i = len(prompts) - 1
+	}
+	inference := &types.InferenceConfiguration{}
+	if prompts[i].InferenceConfiguration != nil {
+		*inference = *prompts[i].InferenceConfiguration
+	}
+	if options.Temperature != nil {
+		inference.Temperature = options.Temperature
+	}
+	if options.TopP != nil {
+		inference.TopP = options.TopP
+	}
+	if options.MaxTokens != nil {
+		inference.MaximumLength = options.MaxTokens
+	}
+	prompts[i].InferenceConfiguration = inference
+	options.PromptOverrides.PromptConfigurations = prompts
+	return nil
+}
+
+// loadInlineAgent reads the configuration of the agent version the alias routes to, for InvokeInlineAgent.
+// The definition is read once per helper, so every turn of a chat uses the same one.
+func (a *AWSHelper) loadInlineAgent(ctx context.Context) (*bedrockagentruntime.InvokeInlineAgentInput, error) {
+	if a.InlineAgent != nil {
+		return a.InlineAgent, nil
+	}
+
+	client, err := a.CreateAgentClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS client: %w", err)
+	}
+
+	agentID := a.Options.AgentID
+	version := draftAgentVersion
+	if a.Options.AgentAliasID != testAliasID {
+		alias, err := ResolveAgentAlias(ctx, client, agentID, a.Options.AgentAliasID)
+		if err != nil {
+			return nil, err
+		}
+		if version = aliasRoutedVersion(alias); version == "" {
+			return nil, fmt.Errorf("alias '%s' does not route to an agent version", a.Options.AgentAliasID)
+		}
+	}
+
+	desc, err := describeAgentVersion(ctx, client, agentID, version)
+	if err != nil {
+		return nil, err
+	}
+	if desc.Collaboration != "" && desc.Collaboration != string(agenttypes.AgentCollaborationDisabled) {
+		return nil, fmt.Errorf("prompt overrides are not supported for multi-agent collaboration (agent '%s' is a %s)", agentID, desc.Collaboration)
+	}
+	logVerbose(a.Options, "Invoking version %s of agent %s as an inline agent with prompt overrides", version, agentID)
+
+	agent := &bedrockagentruntime.InvokeInlineAgentInput{
+		AgentName:       aws.String(desc.AgentName),
+		FoundationModel: aws.String(desc.FoundationModel),
+		Instruction:     aws.String(desc.Instruction),
+	}
+	if desc.IdleSessionTTL > 0 {
+		agent.IdleSessionTTLInSeconds = aws.Int32(desc.IdleSessionTTL)
+	}
+	if desc.Guardrail != nil {
+		agent.GuardrailConfiguration = &types.GuardrailConfigurationWithArn{
+			GuardrailIdentifier: aws.String(desc.Guardrail.ID),
+			GuardrailVersion:    aws.String(desc.Guardrail.Version),
+		}
+	}
+	for _, kb := range desc.KnowledgeBases {
+		if kb.State != string(agenttypes.KnowledgeBaseStateEnabled) {
+			continue
+		}
+		agent.KnowledgeBases = append(agent.KnowledgeBases, types.KnowledgeBase{
+			KnowledgeBaseId: aws.String(kb.ID),
+			Description:     aws.String(kb.Description),
+		})
+	}
+	for _, summary := range desc.ActionGroups {
+		if summary.State != string(agenttypes.ActionGroupStateEnabled) {
+			continue
+		}
+		out, err := client.GetAgentActionGroup(ctx, &bedrockagent.GetAgentActionGroupInput{
+			AgentId:       aws.String(agentID),
+			AgentVersion:  aws.String(version),
+			ActionGroupId: aws.String(summary.ID),
+		})
+		if err != nil {
+			return nil, HandleAWSError(fmt.Errorf("failed to get action group '%s': %w", summary.Name, err))
+		}
+		agent.ActionGroups = append(agent.ActionGroups, inlineActionGroup(out.AgentActionGroup))
+	}
+
+	a.InlineAgent = agent
+	return agent, nil
+}
+
+// inlineActionGroup converts a control-plane action group into its inline agent form
+func inlineActionGroup(group *agenttypes.AgentActionGroup) types.AgentActionGroup {
+	inline := types.AgentActionGroup{
+		ActionGroupName:                  group.ActionGroupName,
+		Description:                      group.Description,
+		ParentActionGroupSignature:       types.ActionGroupSignature(group.ParentActionSignature),
+		ParentActionGroupSignatureParams: group.ParentActionGroupSignatureParams,
+	}
+
+	switch executor := group.ActionGroupExecutor.(type) {
+	case *agenttypes.ActionGroupExecutorMemberLambda:
+		inline.ActionGroupExecutor = &types.ActionGroupExecutorMemberLambda{Value: executor.Value}
+	case *agenttypes.ActionGroupExecutorMemberCustomControl:
+		inline.ActionGroupExecutor = &types.ActionGroupExecutorMemberCustomControl{Value: types.CustomControlMethod(executor.Value)}
+	}
+
+	switch schema := group.ApiSchema.(type) {
+	case *agenttypes.APISchemaMemberPayload:
+		inline.ApiSchema = &types.APISchemaMemberPayload{Value: schema.Value}
+	case *agenttypes.APISchemaMemberS3:
+		inline.ApiSchema = &types.APISchemaMemberS3{Value: types.S3Identifier{
+			S3BucketName: schema.Value.S3BucketName,
+			S3ObjectKey:  schema.Value.S3ObjectKey,
+		}}
+	}
+
+	if schema, ok := group.FunctionSchema.(*agenttypes.FunctionSchemaMemberFunctions); ok {
+		functions := make([]types.FunctionDefinition, 0, len(schema.Value))
+		for _, function := range schema.Value {
+			parameters := make(map[string]types.ParameterDetail, len(function.Parameters))
+			for name, parameter := range function.Parameters {
+				parameters[name] = types.ParameterDetail{
+					Type:        types.ParameterType(parameter.Type),
+					Description: parameter.Description,
+					Required:    parameter.Required,
+				}
+			}
+			functions = append(functions, types.FunctionDefinition{
+				Name:                function.Name,
+				Description:         function.Description,
+				Parameters:          parameters,
+				RequireConfirmation: types.RequireConfirmation(function.RequireConfirmation),
+			})
+		}
+		inline.FunctionSchema = &types.FunctionSchemaMemberFunctions{Value: functions}
+	}
+	return inline
+}
+
+// inlineAgentInput combines the agent definition, the prompt overrides, and the prepared InvokeAgent input
+func inlineAgentInput(agent *bedrockagentruntime.InvokeInlineAgentInput, overrides *types.PromptOverrideConfiguration,
+	input *bedrockagentruntime.InvokeAgentInput) (*bedrockagentruntime.InvokeInlineAgentInput, error) {
+
+	if input.MemoryId != nil {
+		return nil, fmt.Errorf("--memory-id is not supported with prompt overrides, inline agents have no long-term memory")
+	}
+
+	inline := *agent
+	inline.KnowledgeBases = slices.Clone(agent.KnowledgeBases)
+	inline.PromptOverrideConfiguration = overrides
+	inline.SessionId = input.SessionId
+	inline.InputText = input.InputText
+	inline.EnableTrace = input.EnableTrace
+	inline.EndSession = input.EndSession
+	inline.StreamingConfigurations = input.StreamingConfigurations
+	if input.BedrockModelConfigurations != nil {
+		inline.BedrockModelConfigurations = &types.InlineBedrockModelConfigurations{
+			PerformanceConfig: input.BedrockModelConfigurations.PerformanceConfig,
+		}
+	}
+
+	if state := input.SessionState; state != nil {
+		inline.InlineSessionState = &types.InlineSessionState{
+			ConversationHistory:            state.ConversationHistory,
+			Files:                          state.Files,
+			InvocationId:                   state.InvocationId,
+			PromptSessionAttributes:        state.PromptSessionAttributes,
+			ReturnControlInvocationResults: state.ReturnControlInvocationResults,
+			SessionAttributes:              state.SessionAttributes,
+		}
+
+		// Knowledge base settings of the session apply to the knowledge bases of the inline agent
+		for _, kbConfig := range state.KnowledgeBaseConfigurations {
+			i := slices.IndexFunc(inline.KnowledgeBases, func(kb types.KnowledgeBase) bool {
+				return aws.ToString(kb.KnowledgeBaseId) == aws.ToString(kbConfig.KnowledgeBaseId)
+			})
+			if i < 0 {
+				return nil, fmt.Errorf("knowledge base '%s' is not associated with the agent, it cannot be configured with prompt overrides",
+					aws.ToString(kbConfig.KnowledgeBaseId))
+			}
+			inline.KnowledgeBases[i].RetrievalConfiguration = kbConfig.RetrievalConfiguration
+		}
+	}
+	return &inline, nil
+}
+
+// inlineAgentRequestOption prepares an InvokeAgent call that is sent as InvokeInlineAgent
+func (a *AWSHelper) inlineAgentRequestOption(ctx context.Context, input *bedrockagentruntime.InvokeAgentInput) (func(*bedrockagentruntime.Options), error) {
+	agent, err := a.loadInlineAgent(ctx)
+	if err != nil {
+		return nil, err
+	}
+	inline, err := inlineAgentInput(agent, a.Options.PromptOverrides, input)
+	if err != nil {
+		return nil, err
+	}
+	path, body, err := serializeInlineAgentRequest(ctx, inline)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(o *bedrockagentruntime.Options) {
+		o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+			return stack.Serialize.Insert(middleware.SerializeMiddlewareFunc("InlineAgentRequest",
+				func(ctx context.Context, in middleware.SerializeInput, next middleware.SerializeHandler) (middleware.SerializeOutput, middleware.Metadata, error) {
+					req, ok := in.Request.(*smithyhttp.Request)
+					if !ok {
+						return next.HandleSerialize(ctx, in)
+					}
+					// A base path of --endpoint-url stays in front of the operation path
+					prefix := req.URL.Path
+					if i := strings.Index(prefix, "/agents/"); i >= 0 {
+						prefix = prefix[:i]
+					}
+					req.URL.Path, req.URL.RawPath = strings.TrimSuffix(prefix, "/")+path, ""
+					req.Header.Del("X-Amz-Source-Arn")
+					req.Header.Set("Content-Type", "application/json")
+					if req, err = req.SetStream(bytes.NewReader(body)); err != nil {
+						return middleware.SerializeOutput{}, middleware.Metadata{}, err
+					}
+					in.Request = req
+					return next.HandleSerialize(ctx, in)
+				}), "OperationSerializer", middleware.After)
+		})
+	}, nil
+}
+
+// serializeInlineAgentRequest returns the path and body the SDK sends for an InvokeInlineAgent input.
+// The request is validated and serialized by the SDK and then stopped before it is signed or sent.
+func serializeInlineAgentRequest(ctx context.Context, input *bedrockagentruntime.InvokeInlineAgentInput) (string, []byte, error) {
+	capture := &captureHTTPClient{}
+	client := bedrockagentruntime.New(bedrockagentruntime.Options{
+		Region:      "us-east-1",
+		Credentials: aws.AnonymousCredentials{},
+		Retryer:     aws.NopRetryer{},
+		HTTPClient:  capture,
+	})
+	_, err := client.InvokeInlineAgent(ctx, input)
+	if capture.request == nil {
+		return "", nil, fmt.Errorf("invalid inline agent request: %w", err)
+	}
+	return capture.request.URL.Path, capture.body, nil
+}
+
+// captureHTTPClient keeps the first request instead of sending it
+type captureHTTPClient struct {
+	request *http.Request
+	body    []byte
+}
+
+// Do records the request and its body and fails with errRequestCaptured
+func (c *captureHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		c.body = body
+	}
+	c.request = req
+	return nil, errRequestCaptured
+}
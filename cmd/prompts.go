@@ -0,0 +1,622 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the 'prompts' command group for the AWS Bedrock Intelligent Agents CLI.
+'prompts sync' pulls the prompt templates of a shared Git repository or S3 prefix into
+the prompts directory of the user, optionally pinned to a version, so a team uses the same prompt library.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/spf13/cobra"
+)
+
+// promptSyncManifestName is the file in the prompt directory that records the last sync
+const promptSyncManifestName = ".sync.json"
+
+// PromptsSyncOptions contains all options for syncing the prompt library
+type PromptsSyncOptions struct {
+	Source  string // Git repository URL or path, or s3://bucket/prefix
+	Ref     string // Git tag, branch, or commit, or S3 version prefix
+	Path    string // Directory of the prompts within a Git repository
+	Dir     string // Destination directory, userPromptDir by default
+	Region  string
+	Check   bool
+	Verbose bool
+}
+
+// promptSyncManifest records what the last sync wrote, so prompts removed from the source
+// are removed locally while prompts added by hand are kept
+type promptSyncManifest struct {
+	Source   string    `json:"source"`
+	Ref      string    `json:"ref,omitempty"`
+	Revision string    `json:"revision"`
+	SyncedAt time.Time `json:"syncedAt"`
+	Files    []string  `json:"files"`
+}
+
+// promptChange is a difference between the source and the local prompt directory
+type promptChange struct {
+	Name     string
+	Action   string // "add", "update", or "remove"
+	Old, New string
+}
+
+var promptsSyncOpts PromptsSyncOptions
+
+// promptsCmd represents the prompts command group
+var promptsCmd = &cobra.Command{
+	Use:   "prompts",
+	Short: "Manage the prompt template library",
+	Long: `Manage the prompt templates used with --prompt.
+
+Templates are looked up in the directories of AWS_BIA_PROMPT_PATH and of
+prompt_dirs in the configuration file, then in ./prompts and aws-bia/prompts
+in the data directories of the platform: %APPDATA% and %ProgramData% on
+Windows, or $XDG_DATA_HOME (~/.local/share) and $XDG_DATA_DIRS
+(/usr/local/share, /usr/share) elsewhere. ~/.aws-bia/prompts of earlier
+versions is still searched. 'prompts dirs' lists
+them in search order.`,
+}
+
+// promptsSyncCmd represents the prompts sync command
+var promptsSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Pull prompt templates from a Git repository or S3 prefix",
+	Long: `Pull the prompt templates (.txt, .md, and .prompt files) of a shared Git
+repository or S3 prefix into the prompts directory of the user
+(~/.local/share/aws-bia/prompts by default).
+
+The source is set with --source or the prompts_source setting. --ref (or
+prompts_ref) pins a Git tag, branch, or commit; for S3 it selects the
+<prefix>/<ref>/ folder. Templates removed from the source since the last sync
+are removed locally; templates added by hand are kept.
+
+With --check nothing is written: the differences are shown as a diff and the
+command fails if the local templates are out of date.
+
+Examples:
+  # Sync from the default branch of a Git repository
+  aws-bia prompts sync --source https://github.com/example/prompts.git
+
+  # Pin a tag and read the templates from a subdirectory
+  aws-bia prompts sync --source git@github.com:example/tools.git --ref v1.4.0 --path prompts
+
+  # Sync from S3
+  aws-bia prompts sync --source s3://team-bucket/prompts --ref 2025-06
+
+  # Show what would change, e.g. in CI
+  aws-bia prompts sync --check`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		if err := runPromptsSyncCommand(ctx, promptsSyncOpts); err != nil {
+			logError("Error syncing prompts", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// promptsDirsCmd represents the prompts dirs command
+var promptsDirsCmd = &cobra.Command{
+	Use:   "dirs",
+	Short: "List the prompt directories in search order",
+	Long: `List the directories searched for --prompt templates, in the order they are
+searched, and whether each exists. The first directory holding a template wins.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runPromptsDirsCommand(os.Stdout); err != nil {
+			logError("Error listing prompt directories", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(promptsCmd)
+	promptsCmd.AddCommand(promptsSyncCmd)
+	promptsCmd.AddCommand(promptsDirsCmd)
+
+	promptsSyncCmd.Flags().StringVar(&promptsSyncOpts.Source, "source", "", "Git repository URL or path, or s3://bucket/prefix (can be set in config file)")
+	promptsSyncCmd.Flags().StringVar(&promptsSyncOpts.Ref, "ref", "", "Git tag, branch, or commit, or S3 version folder to pin (can be set in config file)")
+	promptsSyncCmd.Flags().StringVar(&promptsSyncOpts.Path, "path", "", "Directory of the templates within the Git repository (can be set in config file)")
+	promptsSyncCmd.Flags().StringVar(&promptsSyncOpts.Dir, "dir", "", "Directory to sync into (default the prompts directory of the user, see aws-bia paths)")
+	promptsSyncCmd.Flags().StringVar(&promptsSyncOpts.Region, "region", "", "AWS region to use for S3 sources")
+	promptsSyncCmd.Flags().BoolVar(&promptsSyncOpts.Check, "check", false, "Show the differences without writing, failing if the templates are out of date")
+	promptsSyncCmd.Flags().BoolVar(&promptsSyncOpts.Verbose, "verbose", false, "Enable verbose output")
+}
+
+// runPromptsDirsCommand writes the prompt directories the invoke command searches
+func runPromptsDirsCommand(w io.Writer) error {
+	v, err := LoadConfigForCommand(cfgFile, "invoke", false)
+	if err != nil {
+		return err
+	}
+	var opts AgentOptions
+	applyAgentConfig(v, &opts)
+
+	for _, dir := range NewPromptManager(opts.PromptDirs).promptDirs {
+		state := "missing"
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			state = "ok"
+		}
+		fmt.Fprintf(w, "%-8s %s\n", state, dir)
+	}
+	return nil
+}
+
+// runPromptsSyncCommand fetches the templates of the source and applies or shows the changes
+func runPromptsSyncCommand(ctx context.Context, opts PromptsSyncOptions) error {
+	InitLogger(opts.Verbose)
+	defer SyncLogger()
+
+	agentOpts := AgentOptions{Region: opts.Region, Verbose: opts.Verbose, Timeout: DefaultTimeout}
+	v, err := LoadConfigForCommand(cfgFile, "", opts.Verbose)
+	if err != nil {
+		return err
+	}
+	applyAgentConfig(v, &agentOpts)
+
+	// The source settings apply together, so a pinned ref never applies to another source
+	if opts.Source == "" {
+		opts.Source = v.GetString("prompts_source")
+		if opts.Ref == "" {
+			opts.Ref = v.GetString("prompts_ref")
+		}
+		if opts.Path == "" {
+			opts.Path = v.GetString("prompts_path")
+		}
+	}
+	if opts.Source == "" {
+		return fmt.Errorf("no prompt source configured (use --source or the prompts_source setting)")
+	}
+	if opts.Dir == "" {
+		if opts.Dir, err = userPromptDir(); err != nil {
+			return err
+		}
+	}
+
+	var remote map[string]string
+	var revision string
+	if isS3URI(opts.Source) {
+		remote, revision, err = fetchS3Prompts(ctx, NewAWSHelper(agentOpts), opts)
+	} else {
+		remote, revision, err = fetchGitPrompts(ctx, agentOpts, opts)
+	}
+	if err != nil {
+		return err
+	}
+	logVerbose(agentOpts, "Fetched %d prompt template(s) from %s at %s", len(remote), opts.Source, revision)
+
+	manifest, err := loadPromptSyncManifest(opts.Dir)
+	if err != nil {
+		return err
+	}
+	changes, err := diffPromptDir(opts.Dir, remote, manifest)
+	if err != nil {
+		return err
+	}
+
+	if opts.Check {
+		for _, change := range changes {
+			oldName, newName := "a/"+change.Name, "b/"+change.Name
+			switch change.Action {
+			case "add":
+				oldName = "/dev/null"
+			case "remove":
+				newName = "/dev/null"
+			}
+			fmt.Print(unifiedDiff(oldName, newName, change.Old, change.New))
+		}
+		if len(changes) > 0 {
+			return fmt.Errorf("%d prompt template(s) in %s differ from %s at %s", len(changes), opts.Dir, opts.Source, revision)
+		}
+		fmt.Printf("Prompt templates in %s are up to date with %s at %s\n", opts.Dir, opts.Source, revision)
+		return nil
+	}
+
+	if err := applyPromptChanges(opts.Dir, changes); err != nil {
+		return err
+	}
+
+	files := make([]string, 0, len(remote))
+	for name := range remote {
+		files = append(files, name)
+	}
+	sort.Strings(files)
+	if err := savePromptSyncManifest(opts.Dir, promptSyncManifest{
+		Source:   opts.Source,
+		Ref:      opts.Ref,
+		Revision: revision,
+		SyncedAt: time.Now().UTC(),
+		Files:    files,
+	}); err != nil {
+		return err
+	}
+
+	counts := map[string]int{}
+	for _, change := range changes {
+		counts[change.Action]++
+		logVerbose(agentOpts, "%s %s", change.Action, change.Name)
+	}
+	fmt.Printf("Synced %d prompt template(s) from %s at %s into %s (%d added, %d updated, %d removed)\n",
+		len(files), opts.Source, revision, opts.Dir, counts["add"], counts["update"], counts["remove"])
+	return nil
+}
+
+// fetchGitPrompts fetches a single revision of the repository and reads its templates
+func fetchGitPrompts(ctx context.Context, agentOpts AgentOptions, opts PromptsSyncOptions) (map[string]string, string, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, "", fmt.Errorf("git is required for Git prompt sources: %w", err)
+	}
+
+	checkout, err := os.MkdirTemp("", "aws-bia-prompts-")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(checkout)
+
+	// git runs in the checkout, so a repository given as a local path must be absolute
+	source := opts.Source
+	if _, err := os.Stat(source); err == nil {
+		if source, err = filepath.Abs(source); err != nil {
+			return nil, "", fmt.Errorf("failed to resolve prompt source: %w", err)
+		}
+	}
+
+	// Fetching a ref works for tags, branches, and commit IDs alike
+	ref := opts.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	steps := [][]string{
+		{"init", "--quiet"},
+		{"fetch", "--quiet", "--depth", "1", source, ref},
+		{"checkout", "--quiet", "FETCH_HEAD"},
+	}
+	for _, args := range steps {
+		if _, err := runGit(ctx, agentOpts, checkout, args...); err != nil {
+			return nil, "", err
+		}
+	}
+
+	revision, err := runGit(ctx, agentOpts, checkout, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, "", err
+	}
+
+	dir := filepath.Join(checkout, filepath.FromSlash(opts.Path))
+	if rel, err := filepath.Rel(checkout, dir); err != nil || strings.HasPrefix(rel, "..") {
+		return nil, "", fmt.Errorf("prompt path '%s' is outside the repository", opts.Path)
+	}
+	prompts, err := readPromptDir(dir)
+	if err != nil {
+		return nil, "", err
+	}
+	return prompts, revision, nil
+}
+
+// runGit runs a git command in dir and returns its trimmed output
+func runGit(ctx context.Context, opts AgentOptions, dir string, args ...string) (string, error) {
+	logVerbose(opts, "Running git %s", strings.Join(args, " "))
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("git %s failed: %s", args[0], message)
+		}
+		return "", fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// fetchS3Prompts reads the templates directly under the S3 prefix, or under <prefix>/<ref>/ when pinned
+func fetchS3Prompts(ctx context.Context, awsHelper *AWSHelper, opts PromptsSyncOptions) (map[string]string, string, error) {
+	bucket, prefix, _ := strings.Cut(strings.TrimPrefix(opts.Source, S3URIPrefix), "/")
+	if bucket == "" {
+		return nil, "", fmt.Errorf("invalid S3 prompt source '%s', expected s3://bucket/prefix", opts.Source)
+	}
+	if opts.Ref != "" {
+		prefix = path.Join(prefix, opts.Ref)
+	}
+	if prefix = strings.Trim(prefix, "/"); prefix != "" {
+		prefix += "/"
+	}
+
+	cfg, err := awsHelper.LoadConfig(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	client := s3.NewFromConfig(cfg)
+
+	prompts := make(map[string]string)
+	var lastModified time.Time
+	paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{
+		Bucket:    aws.String(bucket),
+		Prefix:    aws.String(prefix),
+		Delimiter: aws.String("/"),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, "", HandleAWSError(fmt.Errorf("failed to list s3://%s/%s: %w", bucket, prefix, err))
+		}
+
+		for _, object := range page.Contents {
+			key := aws.ToString(object.Key)
+			name := strings.TrimPrefix(key, prefix)
+			if !isPromptFileName(name) {
+				continue
+			}
+			if !isPlainFileName(name) {
+				LogWarn("Skipping s3://%s/%s, its name would be written outside the prompt directory", bucket, key)
+				continue
+			}
+
+			out, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+			if err != nil {
+				return nil, "", HandleAWSError(fmt.Errorf("failed to get s3://%s/%s: %w", bucket, key, err))
+			}
+			data, err := io.ReadAll(out.Body)
+			out.Body.Close()
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to read s3://%s/%s: %w", bucket, key, err)
+			}
+			prompts[name] = string(data)
+
+			if modified := aws.ToTime(object.LastModified); modified.After(lastModified) {
+				lastModified = modified
+			}
+		}
+	}
+	if len(prompts) == 0 {
+		return nil, "", fmt.Errorf("no prompt templates found in s3://%s/%s", bucket, prefix)
+	}
+
+	// S3 has no commit ID, so the newest object stands in for the revision
+	revision := "last modified " + lastModified.UTC().Format(time.RFC3339)
+	if opts.Ref != "" {
+		revision = opts.Ref + ", " + revision
+	}
+	return prompts, revision, nil
+}
+
+// readPromptDir reads the prompt templates directly in dir
+func readPromptDir(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("failed to read prompt directory %s: %w", dir, err)
+	}
+
+	prompts := make(map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() || !isPromptFileName(entry.Name()) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read prompt template: %w", err)
+		}
+		prompts[entry.Name()] = string(data)
+	}
+	return prompts, nil
+}
+
+// diffPromptDir compares the local templates with the fetched ones, sorted by name
+func diffPromptDir(dir string, remote map[string]string, manifest *promptSyncManifest) ([]promptChange, error) {
+	local, err := readPromptDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []promptChange
+	for name, content := range remote {
+		old, exists := local[name]
+		switch {
+		case !exists:
+			changes = append(changes, promptChange{Name: name, Action: "add", New: content})
+		case old != content:
+			changes = append(changes, promptChange{Name: name, Action: "update", Old: old, New: content})
+		}
+	}
+
+	// Only templates written by an earlier sync are removed
+	if manifest != nil {
+		for _, name := range manifest.Files {
+			if _, upstream := remote[name]; upstream {
+				continue
+			}
+			if old, exists := local[name]; exists {
+				changes = append(changes, promptChange{Name: name, Action: "remove", Old: old})
+			}
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes, nil
+}
+
+// isPlainFileName reports whether name is a file name without directories; both separators are
+// rejected on every platform, since an S3 key may hold a \ that Windows would follow
+func isPlainFileName(name string) bool {
+	return name != "" && name != "." && name != ".." &&
+		!strings.ContainsAny(name, `/\`) && filepath.Base(name) == name
+}
+
+// applyPromptChanges writes and removes templates in dir
+func applyPromptChanges(dir string, changes []promptChange) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create prompt directory %s: %w", dir, err)
+	}
+
+	for _, change := range changes {
+		// Names come from the source and the sync manifest, neither may point outside dir
+		if !isPlainFileName(change.Name) {
+			return fmt.Errorf("invalid prompt template name '%s'", change.Name)
+		}
+		path := filepath.Join(dir, change.Name)
+		if change.Action == "remove" {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove prompt template: %w", err)
+			}
+			continue
+		}
+		if err := os.WriteFile(path, []byte(change.New), 0644); err != nil {
+			return fmt.Errorf("failed to write prompt template: %w", err)
+		}
+	}
+	return nil
+}
+
+// loadPromptSyncManifest reads the record of the last sync, or nil if the directory was never synced
+func loadPromptSyncManifest(dir string) (*promptSyncManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, promptSyncManifestName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt sync manifest: %w", err)
+	}
+
+	var manifest promptSyncManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse prompt sync manifest %s: %w", filepath.Join(dir, promptSyncManifestName), err)
+	}
+	return &manifest, nil
+}
+
+// savePromptSyncManifest records the templates written by a sync
+func savePromptSyncManifest(dir string, manifest promptSyncManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal prompt sync manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, promptSyncManifestName), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write prompt sync manifest: %w", err)
+	}
+	return nil
+}
+
+// unifiedDiffContext is the number of unchanged lines shown around each change
+const unifiedDiffContext = 3
+
+// unifiedDiff returns a unified diff of two versions of a file
+func unifiedDiff(oldName, newName, old, new string) string {
+	a, b := splitDiffLines(old), splitDiffLines(new)
+
+	// Longest common subsequence table; prompt templates and answers are small enough for O(n*m)
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	// Each line of the edit script is prefixed with ' ', '-', or '+'
+	type diffLine struct {
+		op         byte
+		text       string
+		aPos, bPos int // Line numbers before this line, on each side
+	}
+	var script []diffLine
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			script = append(script, diffLine{' ', a[i], i, j})
+			i, j = i+1, j+1
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			script = append(script, diffLine{'-', a[i], i, j})
+			i++
+		default:
+			script = append(script, diffLine{'+', b[j], i, j})
+			j++
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+	for start := 0; start < len(script); {
+		// Find the next change and the end of its hunk
+		for start < len(script) && script[start].op == ' ' {
+			start++
+		}
+		if start == len(script) {
+			break
+		}
+		first := max(start-unifiedDiffContext, 0)
+		end, unchanged := start, 0
+		for ; end < len(script) && unchanged <= 2*unifiedDiffContext; end++ {
+			if script[end].op == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+		end -= max(unchanged-unifiedDiffContext, 0)
+
+		var aCount, bCount int
+		for _, line := range script[first:end] {
+			if line.op != '+' {
+				aCount++
+			}
+			if line.op != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", diffRange(script[first].aPos, aCount), diffRange(script[first].bPos, bCount))
+		for _, line := range script[first:end] {
+			fmt.Fprintf(&out, "%c%s\n", line.op, line.text)
+		}
+		start = end
+	}
+	return out.String()
+}
+
+// diffRange formats the line range of a hunk, where an empty range names the line before it
+func diffRange(pos, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", pos)
+	}
+	return fmt.Sprintf("%d,%d", pos+1, count)
+}
+
+// splitDiffLines splits text into lines without their line endings
+func splitDiffLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -7,55 +7,49 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"text/template"
+
+	"gopkg.in/yaml.v3"
 )
 
+// includePattern matches a template action, which includes another prompt file by name
+var includePattern = regexp.MustCompile(`\{\{-?\s*template\s`)
+
 // PromptManager handles loading and processing prompt templates
 type PromptManager struct {
 	promptDirs []string
 	funcMap    template.FuncMap // Cache template functions
 }
 
-// NewPromptManager creates a new prompt manager with default search locations
-func NewPromptManager() *PromptManager {
-	// Pre-allocate with known capacity
-	promptDirs := make([]string, 0, 3)
-
-	// Add current directory
-	promptDirs = append(promptDirs, "prompts")
-
-	// Add user home directory
-	if home, err := os.UserHomeDir(); err == nil {
-		promptDirs = append(promptDirs, filepath.Join(home, ".aws-bia", "prompts"))
+// NewPromptManager creates a new prompt manager searching the configured prompt
+// directories before the default locations
+func NewPromptManager(configuredDirs []string) *PromptManager {
+	return &PromptManager{
+		promptDirs: promptSearchDirs(configuredDirs),
+		funcMap:    templateFuncMap(nil), // Pre-create to avoid recreation on each template processing
 	}
+}
 
-	// Add global directory if available
-	promptDirs = append(promptDirs, "/usr/local/share/aws-bia/prompts")
-
-	// Pre-create function map to avoid recreation on each template processing
-	funcMap := template.FuncMap{
-		"toLowerCase": strings.ToLower,
-		"toUpperCase": strings.ToUpper,
-		"replace": func(old, new, s string) string {
-			return strings.ReplaceAll(s, old, new)
-		},
-		"join":      strings.Join,
-		"split":     strings.Split,
-		"contains":  strings.Contains,
-		"hasPrefix": strings.HasPrefix,
-		"hasSuffix": strings.HasSuffix,
-		"trim":      strings.TrimSpace,
-	}
+// userPromptDir returns the prompts directory of the user, which 'prompts sync' also writes to
+func userPromptDir() (string, error) {
+	return dataPath("prompts")
+}
 
-	return &PromptManager{
-		promptDirs: promptDirs,
-		funcMap:    funcMap,
+// isPromptFileName reports whether a file name has one of the supported prompt extensions
+func isPromptFileName(name string) bool {
+	switch filepath.Ext(name) {
+	case ".txt", ".md", ".prompt":
+		return true
 	}
+	return false
 }
 
 // GetAvailablePrompts returns a list of available prompts
@@ -63,13 +57,6 @@ func (pm *PromptManager) GetAvailablePrompts() []string {
 	var prompts []string
 	seen := make(map[string]struct{}) // Use struct{} instead of bool for memory efficiency
 
-	// Pre-define supported extensions for faster lookup
-	supportedExts := map[string]struct{}{
-		".txt":    {},
-		".md":     {},
-		".prompt": {},
-	}
-
 	for _, dir := range pm.promptDirs {
 		if _, err := os.Stat(dir); os.IsNotExist(err) {
 			continue
@@ -85,12 +72,11 @@ func (pm *PromptManager) GetAvailablePrompts() []string {
 				continue
 			}
 
-			// Check for supported extensions using map lookup (faster)
+			// Check for supported extensions
 			name := file.Name()
-			ext := filepath.Ext(name)
-			if _, supported := supportedExts[ext]; supported {
+			if isPromptFileName(name) {
 				// Strip extension
-				baseName := strings.TrimSuffix(name, ext)
+				baseName := strings.TrimSuffix(name, filepath.Ext(name))
 				if _, exists := seen[baseName]; !exists {
 					prompts = append(prompts, baseName)
 					seen[baseName] = struct{}{}
@@ -102,6 +88,11 @@ func (pm *PromptManager) GetAvailablePrompts() []string {
 	return prompts
 }
 
+// AllowEnv lets the env function of templates read the named environment variables
+func (pm *PromptManager) AllowEnv(names []string) {
+	pm.funcMap = templateFuncMap(names)
+}
+
 // LoadPrompt loads a prompt by name or file path
 func (pm *PromptManager) LoadPrompt(promptName, promptFile string) (string, error) {
 	var promptContent string
@@ -113,6 +104,8 @@ func (pm *PromptManager) LoadPrompt(promptName, promptFile string) (string, erro
 		if err != nil {
 			return "", fmt.Errorf("failed to load prompt file %s: %w", promptFile, err)
 		}
+		// Includes are looked up next to the file before the prompt directories
+		pm.promptDirs = append([]string{filepath.Dir(promptFile)}, pm.promptDirs...)
 	} else if promptName != "" {
 		// Search for prompt in prompt directories
 		promptContent, err = pm.findPromptByName(promptName)
@@ -127,15 +120,20 @@ func (pm *PromptManager) LoadPrompt(promptName, promptFile string) (string, erro
 	return promptContent, nil
 }
 
-// ProcessPromptTemplate processes template variables in the prompt
-func (pm *PromptManager) ProcessPromptTemplate(promptContent string, vars []string) (string, error) {
-	// If no variables, return the original content
-	if len(vars) == 0 {
+// ProcessPromptTemplate processes template variables in the prompt. The variables of a
+// vars file come first, key=value variables override top-level keys of the same name.
+func (pm *PromptManager) ProcessPromptTemplate(promptContent string, fileVars map[string]interface{}, vars []string) (string, error) {
+	// If no variables and no includes, return the original content
+	includes := includePattern.MatchString(promptContent)
+	if len(vars) == 0 && len(fileVars) == 0 && !includes {
 		return promptContent, nil
 	}
 
 	// Convert vars slice to map with pre-allocated capacity
-	varMap := make(map[string]interface{}, len(vars))
+	varMap := make(map[string]interface{}, len(fileVars)+len(vars))
+	for key, value := range fileVars {
+		varMap[key] = value
+	}
 	for _, v := range vars {
 		parts := strings.SplitN(v, "=", 2)
 		if len(parts) != 2 {
@@ -162,19 +160,97 @@ func (pm *PromptManager) ProcessPromptTemplate(promptContent string, vars []stri
 	}
 
 	// Parse and execute the template using cached function map
-	tmpl, err := template.New("prompt").Funcs(pm.funcMap).Parse(promptContent)
-	if err != nil {
+	tmpl := template.New("prompt").Funcs(pm.funcMap)
+	var partialErrs map[string]error
+	if includes {
+		partialErrs = pm.addPartials(tmpl)
+	}
+	if _, err := tmpl.Parse(promptContent); err != nil {
 		return "", fmt.Errorf("failed to parse prompt template: %w", err)
 	}
 
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, varMap); err != nil {
+		// A broken prompt file is only reported when a template includes it
+		for name, partialErr := range partialErrs {
+			if strings.Contains(err.Error(), fmt.Sprintf("%q", name)) {
+				return "", fmt.Errorf("failed to parse included prompt '%s': %w", name, partialErr)
+			}
+		}
 		return "", fmt.Errorf("failed to apply template variables: %w", err)
 	}
 
 	return buf.String(), nil
 }
 
+// addPartials adds every prompt file of the prompt directories to the template set, named by
+// its path relative to the directory without the extension, e.g. "partials/header". A name found
+// in several directories is taken from the first, as with --prompt. Files that do not parse are
+// left out and returned with their errors.
+func (pm *PromptManager) addPartials(tmpl *template.Template) map[string]error {
+	parseErrs := make(map[string]error)
+	for _, dir := range pm.promptDirs {
+		filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return nil // Missing prompt directories are skipped
+			}
+			if entry.IsDir() {
+				if path != dir && strings.HasPrefix(entry.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !isPromptFileName(entry.Name()) {
+				return nil
+			}
+
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return nil
+			}
+			name := filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel)))
+			if tmpl.Lookup(name) != nil || parseErrs[name] != nil {
+				return nil
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				parseErrs[name] = err
+				return nil
+			}
+			_, body, err := splitPromptFrontmatter(string(data))
+			if err == nil {
+				_, err = tmpl.New(name).Parse(body)
+			}
+			if err != nil {
+				parseErrs[name] = err
+			}
+			return nil
+		})
+	}
+	return parseErrs
+}
+
+// LoadPromptVarsFile reads template variables from a YAML or JSON file. The top level must be
+// a mapping; nested maps and lists are kept, so templates can use them with index and range.
+func LoadPromptVarsFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vars file: %w", err)
+	}
+
+	var vars map[string]interface{}
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		err = json.Unmarshal(data, &vars)
+	} else {
+		err = yaml.Unmarshal(data, &vars)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid vars file '%s' (the top level must be a mapping of variable names): %w", path, err)
+	}
+	return vars, nil
+}
+
 // loadPromptFromFile loads prompt content from a file path
 func (pm *PromptManager) loadPromptFromFile(filePath string) (string, error) {
 	data, err := os.ReadFile(filePath)
@@ -0,0 +1,70 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the --query flag for the AWS Bedrock Intelligent Agents CLI.
+A jq expression is applied to the JSON response document before it is printed,
+so values can be extracted without piping the output to jq.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/itchyny/gojq"
+)
+
+// compileQuery parses and compiles a jq expression
+func compileQuery(expr string) (*gojq.Code, error) {
+	query, err := gojq.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query '%s': %w", expr, err)
+	}
+
+	code, err := gojq.Compile(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query '%s': %w", expr, err)
+	}
+	return code, nil
+}
+
+// writeQueryResult runs the query against the response and writes every result on its own line.
+// Strings are written as-is, like jq -r, other values as indented JSON.
+func writeQueryResult(w io.Writer, code *gojq.Code, response map[string]interface{}) error {
+	// gojq only understands plain JSON values, so normalize pointers and typed slices first
+	data, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("failed to marshal response to JSON: %w", err)
+	}
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return fmt.Errorf("failed to prepare response for query: %w", err)
+	}
+
+	iter := code.Run(document)
+	for {
+		value, ok := iter.Next()
+		if !ok {
+			return nil
+		}
+		if err, ok := value.(error); ok {
+			return fmt.Errorf("query failed: %w", err)
+		}
+
+		if s, ok := value.(string); ok {
+			if _, err := fmt.Fprintln(w, s); err != nil {
+				return err
+			}
+			continue
+		}
+
+		out, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal query result: %w", err)
+		}
+		if _, err := fmt.Fprintln(w, string(out)); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,157 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the client-side rate limiter of the AWS Bedrock Intelligent Agents CLI.
+Commands that send many requests, like run, invoke-multi, and invoke --watch, share one
+token bucket of --rps requests per second with bursts of up to --burst requests, so bulk
+workloads stay under the account's throttling limits instead of failing and retrying.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/spf13/viper"
+)
+
+// DefaultBurst is the number of requests that may be sent at once before --rps applies
+const DefaultBurst = 1
+
+// RateLimiter is a token bucket shared by every request of a run. A nil limiter does not limit.
+type RateLimiter struct {
+	rps   float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	waited time.Duration
+}
+
+// NewRateLimiter creates a limiter of rps requests per second, or returns nil when rps is not positive
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	if rps <= 0 {
+		return nil
+	}
+	burst = max(burst, 1)
+	return &RateLimiter{rps: rps, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Wait blocks until the request may be sent or ctx is done
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	delay := l.reserve()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.cancel(delay)
+		return ctx.Err()
+	}
+}
+
+// reserve takes a token and returns how long to wait until it is available
+func (l *RateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rps)
+	l.last = now
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+
+	delay := time.Duration(-l.tokens / l.rps * float64(time.Second))
+	l.waited += delay
+	return delay
+}
+
+// cancel returns the token of a request that gave up waiting
+func (l *RateLimiter) cancel(delay time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens++
+	l.waited -= delay
+}
+
+// Waited returns the total time requests were delayed by the limiter
+func (l *RateLimiter) Waited() time.Duration {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.waited
+}
+
+// apiOption delays each attempt of a request, retries included, until the limiter allows it
+func (l *RateLimiter) apiOption(opts AgentOptions) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return stack.Finalize.Insert(middleware.FinalizeMiddlewareFunc("AWSBIARateLimit", func(
+			ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler,
+		) (middleware.FinalizeOutput, middleware.Metadata, error) {
+			start := time.Now()
+			if err := l.Wait(ctx); err != nil {
+				return middleware.FinalizeOutput{}, middleware.Metadata{}, err
+			}
+			if waited := time.Since(start); waited >= time.Millisecond {
+				logVerbose(opts, "Rate limit delayed the request by %s", formatDuration(waited))
+			}
+			return next.HandleFinalize(ctx, in)
+		}), "Retry", middleware.After)
+	}
+}
+
+// applyRateLimitConfig applies the rps and burst settings to options not set via flags
+func applyRateLimitConfig(v *viper.Viper, options *AgentOptions) {
+	if v.InConfig("rps") && options.RPS == 0 {
+		options.RPS = v.GetFloat64("rps")
+		logVerbose(*options, "Loaded rate limit from config: %g requests per second", options.RPS)
+	}
+	if v.InConfig("burst") && options.Burst == DefaultBurst {
+		options.Burst = v.GetInt("burst")
+	}
+}
+
+// setupRateLimiter applies the rate limit settings and creates the limiter shared by copies of
+// the options, unless a caller running several commands already shared one
+func setupRateLimiter(v *viper.Viper, options *AgentOptions) error {
+	applyRateLimitConfig(v, options)
+	if options.RPS < 0 {
+		return fmt.Errorf("--rps must not be negative")
+	}
+	if options.Burst < 1 {
+		return fmt.Errorf("--burst must be at least 1")
+	}
+	if options.RateLimiter == nil {
+		options.RateLimiter = NewRateLimiter(options.RPS, options.Burst)
+	}
+	return nil
+}
+
+// rateLimitOption adds the limiter to a runtime call; nil when the options have no limiter
+func rateLimitOption(opts AgentOptions) func(*bedrockagentruntime.Options) {
+	if opts.RateLimiter == nil {
+		return nil
+	}
+	return func(o *bedrockagentruntime.Options) {
+		o.APIOptions = append(o.APIOptions, opts.RateLimiter.apiOption(opts))
+	}
+}
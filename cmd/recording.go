@@ -0,0 +1,202 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements recording and replaying of agent invocations for the AWS Bedrock
+Intelligent Agents CLI. A recording captures the raw HTTP event stream returned by
+InvokeAgent together with its headers and the final response, so it can later be
+rendered through the normal SDK decoding and formatter without calling AWS.
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime"
+)
+
+// recordingFormatVersion is incremented when the recording file layout changes
+const recordingFormatVersion = 1
+
+// Recording is the on-disk representation of a recorded invocation
+type Recording struct {
+	Version      int                    `json:"version"`
+	RecordedAt   time.Time              `json:"recordedAt"`
+	AgentID      string                 `json:"agentId"`
+	AgentAliasID string                 `json:"agentAliasId"`
+	InputText    string                 `json:"inputText"`
+	StatusCode   int                    `json:"statusCode"`
+	Header       http.Header            `json:"header"`
+	Stream       []byte                 `json:"stream"`             // Raw event stream response body
+	Response     map[string]interface{} `json:"response,omitempty"` // Final response as rendered by the CLI
+}
+
+// InvocationRecorder captures the HTTP response of an InvokeAgent call
+type InvocationRecorder struct {
+	mu         sync.Mutex
+	statusCode int
+	header     http.Header
+	stream     bytes.Buffer
+}
+
+// NewInvocationRecorder creates an empty recorder
+func NewInvocationRecorder() *InvocationRecorder {
+	return &InvocationRecorder{}
+}
+
+// wrapHTTPClient returns an HTTP client that tees responses into the recorder
+func (r *InvocationRecorder) wrapHTTPClient(next bedrockagentruntime.HTTPClient) bedrockagentruntime.HTTPClient {
+	return &recordingHTTPClient{next: next, recorder: r}
+}
+
+// Write appends raw stream bytes to the recording
+func (r *InvocationRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stream.Write(p)
+}
+
+// Save writes the recording into dir and returns the path of the written file
+func (r *InvocationRecorder) Save(dir string, opts AgentOptions,
+	output *bedrockagentruntime.InvokeAgentOutput, result StreamResult) (string, error) {
+
+	recording := r.Recording(opts, output, result)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create record directory '%s': %w", dir, err)
+	}
+
+	name := recording.RecordedAt.Format("20060102-150405")
+	if sessionID, _ := recording.Response["sessionId"].(string); sessionID != "" {
+		name += "-" + sessionID
+	}
+	path := filepath.Join(dir, name+".json")
+
+	data, err := json.MarshalIndent(recording, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal recording: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write recording '%s': %w", path, err)
+	}
+
+	return path, nil
+}
+
+// Recording returns the captured response together with the final response as rendered by the CLI
+func (r *InvocationRecorder) Recording(opts AgentOptions,
+	output *bedrockagentruntime.InvokeAgentOutput, result StreamResult) Recording {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	recording := Recording{
+		Version:      recordingFormatVersion,
+		RecordedAt:   time.Now().UTC(),
+		AgentID:      opts.AgentID,
+		AgentAliasID: opts.AgentAliasID,
+		InputText:    opts.InputText,
+		StatusCode:   r.statusCode,
+		Header:       r.header,
+		Stream:       r.stream.Bytes(),
+		Response: map[string]interface{}{
+			"content":         result.Text,
+			"citationCount":   len(result.Citations),
+			"fileCount":       len(result.Files),
+			"returnedControl": result.HasReturnControl,
+		},
+	}
+	if output != nil {
+		recording.Response["sessionId"] = aws.ToString(output.SessionId)
+		recording.Response["contentType"] = aws.ToString(output.ContentType)
+		if output.MemoryId != nil {
+			recording.Response["memoryId"] = *output.MemoryId
+		}
+	}
+	return recording
+}
+
+// LoadRecording reads a recording file written with --record
+func LoadRecording(path string) (*Recording, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording '%s': %w", path, err)
+	}
+
+	var recording Recording
+	if err := json.Unmarshal(data, &recording); err != nil {
+		return nil, fmt.Errorf("failed to parse recording '%s': %w", path, err)
+	}
+	if recording.Version != recordingFormatVersion {
+		return nil, fmt.Errorf("unsupported recording version %d in '%s'", recording.Version, path)
+	}
+	if recording.StatusCode == 0 {
+		recording.StatusCode = http.StatusOK
+	}
+
+	return &recording, nil
+}
+
+// newReplayClient creates a runtime client whose HTTP responses come from the recording
+func newReplayClient(recording *Recording, optFns ...func(*bedrockagentruntime.Options)) *bedrockagentruntime.Client {
+	return bedrockagentruntime.New(bedrockagentruntime.Options{
+		Region:      "us-east-1",
+		Credentials: aws.AnonymousCredentials{},
+		Retryer:     aws.NopRetryer{},
+		HTTPClient:  &replayHTTPClient{recording: recording},
+	}, optFns...)
+}
+
+// recordingHTTPClient tees every response body into an InvocationRecorder
+type recordingHTTPClient struct {
+	next     bedrockagentruntime.HTTPClient
+	recorder *InvocationRecorder
+}
+
+// Do sends the request and records the response as it is read
+func (c *recordingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	resp, err := c.next.Do(req)
+	if err != nil {
+		return resp, err
+	}
+
+	c.recorder.mu.Lock()
+	c.recorder.statusCode = resp.StatusCode
+	c.recorder.header = resp.Header.Clone()
+	c.recorder.stream.Reset()
+	c.recorder.mu.Unlock()
+
+	resp.Body = &teeReadCloser{Reader: io.TeeReader(resp.Body, c.recorder), Closer: resp.Body}
+	return resp, nil
+}
+
+// replayHTTPClient answers every request with the recorded response
+type replayHTTPClient struct {
+	recording *Recording
+}
+
+// Do returns the recorded response without making a network call
+func (c *replayHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		Status:        http.StatusText(c.recording.StatusCode),
+		StatusCode:    c.recording.StatusCode,
+		Header:        c.recording.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(c.recording.Stream)),
+		ContentLength: int64(len(c.recording.Stream)),
+		Request:       req,
+	}, nil
+}
+
+// teeReadCloser combines a tee reader with the original body's Close method
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
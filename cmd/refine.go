@@ -0,0 +1,96 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the editor round-trip refinement loop for the 'invoke' command.
+After each response the answer is opened in $EDITOR; comment lines added with a
+">>" prefix are sent back to the agent as the next turn in the same session.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime"
+)
+
+// refineCommentPrefix marks lines in the edited response that are sent back as feedback
+const refineCommentPrefix = ">>"
+
+// refineHeader explains the refinement workflow at the top of the edited file
+const refineHeader = `# Add lines starting with ">>" anywhere below to send feedback to the agent.
+# Save and close without adding any ">>" lines to finish.
+
+`
+
+// runRefineLoop keeps sending editor feedback to the agent until no comments are added
+func runRefineLoop(ctx context.Context, opts AgentOptions, writer io.Writer,
+	output *bedrockagentruntime.InvokeAgentOutput, result StreamResult) error {
+
+	// Every refinement turn continues the session of the first response
+	if output.SessionId != nil {
+		opts.SessionID = *output.SessionId
+	}
+
+	// Files were already attached to the session by the first turn
+	opts.UploadFiles = nil
+	opts.PromptName = ""
+	opts.PromptFile = ""
+
+	for {
+		// Make sure the previous answer is fully written before the editor takes over the terminal
+		flushWriter(writer)
+
+		edited, err := editText(refineHeader+result.Text, ".md")
+		if err != nil {
+			return err
+		}
+
+		feedback := extractRefineComments(result.Text, edited)
+		if feedback == "" {
+			return nil
+		}
+
+		logVerbose(opts, "Sending refinement feedback: %s", feedback)
+		fmt.Fprintln(os.Stderr, "\n--- Sending refinement feedback ---")
+
+		opts.InputText = feedback
+		formatter := NewResponseFormatter(opts, writer)
+		turnOutput, err := runInvokeTurn(ctx, opts, NewAWSHelper(opts), formatter)
+		if err != nil {
+			return err
+		}
+		result = formatter.LastResult()
+		recordSessionTurn(opts, turnOutput, result)
+	}
+}
+
+// extractRefineComments returns the ">>" lines that were added to the original response,
+// each with the prefix removed, joined into a single feedback message
+func extractRefineComments(original, edited string) string {
+	// Lines already in the response (e.g. quoted text) are not treated as feedback
+	existing := make(map[string]int)
+	for _, line := range strings.Split(original, "\n") {
+		existing[strings.TrimSpace(line)]++
+	}
+
+	var comments []string
+	for _, line := range strings.Split(edited, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, refineCommentPrefix) {
+			continue
+		}
+		if existing[trimmed] > 0 {
+			existing[trimmed]--
+			continue
+		}
+		if comment := strings.TrimSpace(strings.TrimPrefix(trimmed, refineCommentPrefix)); comment != "" {
+			comments = append(comments, comment)
+		}
+	}
+
+	return strings.Join(comments, "\n")
+}
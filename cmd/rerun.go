@@ -0,0 +1,215 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the 'rerun' command for AWS Bedrock Intelligent Agents CLI.
+Every invoke records its command line with the turn in the session store, and rerun runs
+a recorded invocation again with the same agent, input, files, and flags, optionally with
+some flags replaced: to retry after a transient failure or to compare answers over time.
+Turns recorded by chat and run are sent again as an invoke in the same session.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// RerunOptions contains the options of the rerun command
+type RerunOptions struct {
+	Last       bool
+	ID         string
+	NewSession bool
+	DryRun     bool
+}
+
+var rerunOpts RerunOptions
+
+// rerunCmd represents the rerun command
+var rerunCmd = &cobra.Command{
+	Use:   "rerun [--last | --id <id>] [-- <flags>]",
+	Short: "Run a past invocation again",
+	Long: `Run a past invocation from the history again with the same options.
+
+The invocation is the most recent one by default, or the one given with --id as
+listed by 'aws-bia history'. Flags after -- replace the recorded flags of the same
+name, so one option can be changed while the others stay as they were.
+
+The recorded command line includes --session-id only if it was given, so an
+invocation that started a new session starts a new one again. Turns recorded by
+chat and run are sent as an invoke in their session; use --new-session to send
+them without the earlier turns of the conversation.
+
+Relative paths, like those of --upload-files and --prompt-file, are resolved in the
+current directory, and files are read again, so changes to them are picked up.
+
+Examples:
+  # Retry the last invocation
+  aws-bia rerun
+
+  # Run an earlier invocation again with JSON output
+  aws-bia rerun --id 1a2b3c4d/2 -- --format json
+
+  # Ask the same question in a fresh session with another alias
+  aws-bia rerun --new-session -- --agent-alias-id ghi789
+
+  # Print the command line without running it
+  aws-bia rerun --id 1a2b3c4d/2 --dry-run`,
+	Args: cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Only the arguments after -- are passed on to the invocation
+		if len(args) > 0 && cmd.ArgsLenAtDash() != 0 {
+			logError("Error re-running invocation", fmt.Errorf("unexpected argument '%s', put flags for the invocation after --", args[0]))
+			os.Exit(1)
+		}
+		if err := runRerunCommand(rerunOpts, args); err != nil {
+			logError("Error re-running invocation", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rerunCmd)
+
+	rerunCmd.Flags().BoolVar(&rerunOpts.Last, "last", false, "Run the most recent invocation again (the default)")
+	rerunCmd.Flags().StringVar(&rerunOpts.ID, "id", "", "ID of the invocation to run again, as listed by 'aws-bia history'")
+	rerunCmd.Flags().BoolVar(&rerunOpts.NewSession, "new-session", false, "Send the invocation without its session ID, in a new session")
+	rerunCmd.Flags().BoolVar(&rerunOpts.DryRun, "dry-run", false, "Print the command line instead of running it")
+	rerunCmd.MarkFlagsMutuallyExclusive("last", "id")
+}
+
+// runRerunCommand runs a recorded invocation again with the overrides applied
+func runRerunCommand(opts RerunOptions, overrides []string) error {
+	entries, err := loadHistory("")
+	if err != nil {
+		return err
+	}
+	var entry historyEntry
+	if opts.ID != "" {
+		if entry, err = findHistoryEntry(entries, opts.ID); err != nil {
+			return err
+		}
+	} else {
+		if len(entries) == 0 {
+			return fmt.Errorf("no invocations recorded yet")
+		}
+		entry = entries[0]
+	}
+
+	args := rerunArgs(entry, opts.NewSession)
+	target, _, err := rootCmd.Find(args)
+	if err != nil || target == rootCmd {
+		return fmt.Errorf("recorded command '%s' is not available", strings.Join(args, " "))
+	}
+	args = mergeFlagArgs(args, overrides, target)
+
+	if opts.DryRun {
+		fmt.Fprintln(os.Stdout, formatCommandLine(args))
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "Re-running %s: %s\n", entry.ID(), formatCommandLine(args))
+	if dir, err := os.Getwd(); err == nil && entry.Dir != "" && entry.Dir != dir {
+		LogWarn("Invocation %s was run in %s, relative paths are resolved in the current directory", entry.ID(), entry.Dir)
+	}
+
+	rootCmd.SetArgs(args)
+	return rootCmd.Execute()
+}
+
+// rerunArgs returns the command line of a recorded turn, built from the turn for chat and run turns
+func rerunArgs(entry historyEntry, newSession bool) []string {
+	args := append([]string(nil), entry.Command...)
+	if len(args) == 0 {
+		args = []string{
+			invokeCmd.Name(),
+			"--agent-id=" + entry.Session.AgentID,
+			"--agent-alias-id=" + entry.Session.AgentAliasID,
+			"--session-id=" + entry.Session.SessionID,
+			"--input=" + entry.Input,
+		}
+	}
+	if newSession {
+		args = removeFlagArgs(args, map[string]bool{"session-id": true, "continue": true})
+	}
+	return args
+}
+
+// commandLineArgs returns the command path and the flags set on the command line, as
+// --name=value arguments that parse back to the same values
+func commandLineArgs(cmd *cobra.Command) []string {
+	args := strings.Fields(cmd.CommandPath())[1:]
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if f.Value.Type() == "stringArray" {
+			for _, value := range f.Value.(pflag.SliceValue).GetSlice() {
+				args = append(args, "--"+f.Name+"="+value)
+			}
+			return
+		}
+		value := f.Value.String()
+		if _, ok := f.Value.(pflag.SliceValue); ok {
+			// Slices print as [a,b] with the elements quoted like the comma-separated flag syntax
+			value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+		}
+		args = append(args, "--"+f.Name+"="+value)
+	})
+	return args
+}
+
+// formatCommandLine returns the command line of args for the shell, quoting only where needed
+func formatCommandLine(args []string) string {
+	quoted := []string{rootCmd.Name()}
+	for _, arg := range args {
+		if arg == "" || strings.ContainsFunc(arg, func(r rune) bool {
+			return !strings.ContainsRune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-./:=@,+", r)
+		}) {
+			arg = shellQuote(arg)
+		}
+		quoted = append(quoted, arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// mergeFlagArgs replaces the recorded flags that are set again in overrides
+func mergeFlagArgs(recorded, overrides []string, cmd *cobra.Command) []string {
+	names := make(map[string]bool)
+	for _, arg := range overrides {
+		switch {
+		case arg == "--" || !strings.HasPrefix(arg, "-"):
+		case strings.HasPrefix(arg, "--"):
+			name, _, _ := strings.Cut(arg[2:], "=")
+			names[name] = true
+		default:
+			// Short flags may be combined, like -qv
+			for _, c := range arg[1:] {
+				if c > 127 {
+					break
+				}
+				if f := cmd.Flags().ShorthandLookup(string(c)); f != nil {
+					names[f.Name] = true
+				} else if f := cmd.InheritedFlags().ShorthandLookup(string(c)); f != nil {
+					names[f.Name] = true
+				}
+			}
+		}
+	}
+	return append(removeFlagArgs(recorded, names), overrides...)
+}
+
+// removeFlagArgs drops the --name=value arguments of the given flags
+func removeFlagArgs(args []string, names map[string]bool) []string {
+	kept := make([]string, 0, len(args))
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--") {
+			name, _, _ := strings.Cut(arg[2:], "=")
+			if names[name] {
+				continue
+			}
+		}
+		kept = append(kept, arg)
+	}
+	return kept
+}
@@ -0,0 +1,192 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the local response cache of the AWS Bedrock Intelligent Agents CLI.
+With --cache the raw event stream of a completed invocation is kept in the responses
+directory of the cache directory, keyed by the agent, alias, normalized input, and session
+state, and an identical invocation within --cache-ttl replays it instead of calling AWS.
+*/
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime"
+	"github.com/spf13/viper"
+)
+
+// DefaultCacheTTL is how long a cached response is served
+const DefaultCacheTTL = time.Hour
+
+// responseCacheKey holds everything that makes two invocations identical
+type responseCacheKey struct {
+	Region       string `json:"region"`
+	AgentID      string `json:"agentId"`
+	AgentAliasID string `json:"agentAliasId"`
+	Input        string `json:"input"`
+	SessionID    string `json:"sessionId,omitempty"` // Only when given, a continued session has history
+	MemoryID     string `json:"memoryId,omitempty"`  // Memory summaries change the answer like history does
+	EnableTrace  bool   `json:"enableTrace"`
+	Latency      string `json:"latency,omitempty"`
+	SessionState string `json:"sessionState"`      // Hash of the files and knowledge base overrides sent
+	Prompts      string `json:"prompts,omitempty"` // Hash of the prompt overrides, which change every answer
+}
+
+// ResponseCache serves and stores the responses of one invocation
+type ResponseCache struct {
+	dir      string
+	ttl      time.Duration
+	recorder *InvocationRecorder // Captures the response to store on a miss
+	key      string
+	hit      *Recording
+}
+
+// NewResponseCache opens the response cache in the responses directory of the cache directory
+func NewResponseCache(ttl time.Duration) (*ResponseCache, error) {
+	dir, err := cachePath("responses")
+	if err != nil {
+		return nil, err
+	}
+	return &ResponseCache{
+		dir:      dir,
+		ttl:      ttl,
+		recorder: NewInvocationRecorder(),
+	}, nil
+}
+
+// Lookup returns the cached response for the prepared input, or nil on a miss
+func (c *ResponseCache) Lookup(opts AgentOptions, region string, input *bedrockagentruntime.InvokeAgentInput) *Recording {
+	key, err := responseCacheKeyHash(opts, region, input)
+	if err != nil {
+		logVerbose(opts, "Not caching the response: %v", err)
+		return nil
+	}
+	c.key = key
+
+	data, err := os.ReadFile(c.path())
+	if err != nil {
+		logVerbose(opts, "Response cache miss for key %s", key)
+		return nil
+	}
+
+	var entry Recording
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Version != recordingFormatVersion {
+		logVerbose(opts, "Ignoring unreadable response cache entry %s", c.path())
+		return nil
+	}
+	if age := time.Since(entry.RecordedAt); age > c.ttl {
+		logVerbose(opts, "Response cache entry for key %s expired %s ago", key, formatDuration(age-c.ttl))
+		os.Remove(c.path())
+		return nil
+	}
+
+	logVerbose(opts, "Response cache hit for key %s", key)
+	c.hit = &entry
+	return c.hit
+}
+
+// Hit returns the cached response that was served, or nil if the agent was invoked or caching is off
+func (c *ResponseCache) Hit() *Recording {
+	if c == nil {
+		return nil
+	}
+	return c.hit
+}
+
+// Store saves the captured response of a completed invocation under the key of its input
+func (c *ResponseCache) Store(opts AgentOptions, output *bedrockagentruntime.InvokeAgentOutput, result StreamResult) error {
+	if c.key == "" || c.hit != nil {
+		return nil
+	}
+
+	data, err := json.Marshal(c.recorder.Recording(opts, output, result))
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+
+	// Answers may contain sensitive data, so the cache is private like the session store
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create response cache directory: %w", err)
+	}
+	if err := os.WriteFile(c.path(), data, 0600); err != nil {
+		return fmt.Errorf("failed to write response cache entry: %w", err)
+	}
+	logVerbose(opts, "Stored response in the cache for %s", formatDuration(c.ttl))
+	return nil
+}
+
+// path returns the file of the current key
+func (c *ResponseCache) path() string {
+	return filepath.Join(c.dir, c.key+".json")
+}
+
+// applyCacheConfig applies the response cache settings of the invoke command from a loaded configuration
+func applyCacheConfig(v *viper.Viper, options *AgentOptions) {
+	if v.InConfig("cache") && !options.Cache {
+		options.Cache = v.GetBool("cache")
+		logVerbose(*options, "Loaded response cache setting from config: %t", options.Cache)
+	}
+	if v.InConfig("cache_ttl") && options.CacheTTL == DefaultCacheTTL {
+		options.CacheTTL = v.GetDuration("cache_ttl")
+		logVerbose(*options, "Loaded response cache TTL from config: %s", options.CacheTTL)
+	}
+}
+
+// responseCacheKeyHash derives the cache key of a prepared invocation
+func responseCacheKeyHash(opts AgentOptions, region string, input *bedrockagentruntime.InvokeAgentInput) (string, error) {
+	state, err := json.Marshal(input.SessionState)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode session state: %w", err)
+	}
+
+	// The filter is hashed as given, its document values do not survive JSON encoding of the input
+	filter := []byte(opts.KBFilter)
+	if path, ok := strings.CutPrefix(opts.KBFilter, filePrefix); ok {
+		if filter, err = os.ReadFile(path); err != nil {
+			return "", fmt.Errorf("failed to read knowledge base filter '%s': %w", path, err)
+		}
+	}
+	stateHash := sha256.Sum256(append(state, filter...))
+
+	var prompts string
+	if opts.PromptOverrides != nil {
+		data, err := json.Marshal(opts.PromptOverrides)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode prompt overrides: %w", err)
+		}
+		sum := sha256.Sum256(data)
+		prompts = hex.EncodeToString(sum[:])
+	}
+
+	key, err := json.Marshal(responseCacheKey{
+		Region:       region,
+		AgentID:      aws.ToString(input.AgentId),
+		AgentAliasID: aws.ToString(input.AgentAliasId),
+		Input:        normalizeCacheInput(aws.ToString(input.InputText)),
+		SessionID:    opts.SessionID,
+		MemoryID:     opts.MemoryID,
+		EnableTrace:  aws.ToBool(input.EnableTrace),
+		Latency:      opts.Latency,
+		SessionState: hex.EncodeToString(stateHash[:]),
+		Prompts:      prompts,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cache key: %w", err)
+	}
+
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// normalizeCacheInput collapses whitespace so re-indented templates still match
+func normalizeCacheInput(input string) string {
+	return strings.Join(strings.Fields(input), " ")
+}
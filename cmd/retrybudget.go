@@ -0,0 +1,130 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the shared retry budget of the AWS Bedrock Intelligent Agents CLI.
+Commands that send many requests at once, like invoke-multi, give all their clients one
+budget of retries and backoff time. When the service throttles, retries stop once the
+budget is spent, so the run ends with partial results instead of multiplying the load.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// Default limits of the retry budget shared by a run
+const (
+	DefaultRetryBudget     = 10
+	DefaultRetryBudgetTime = 30 * time.Second
+)
+
+// RetryBudget limits the retries and the backoff time of every request in a run. A nil budget
+// leaves the retry behavior of the SDK unchanged.
+type RetryBudget struct {
+	maxRetries int
+	maxTime    time.Duration
+
+	mu      sync.Mutex
+	retries int
+	waited  time.Duration
+	denied  int
+}
+
+// RetryBudgetError reports a request that was not retried because the budget was spent
+type RetryBudgetError struct {
+	Err error
+}
+
+func (e *RetryBudgetError) Error() string {
+	return fmt.Sprintf("retry budget exhausted: %v", e.Err)
+}
+
+func (e *RetryBudgetError) Unwrap() error {
+	return e.Err
+}
+
+// NewRetryBudget creates a budget of maxRetries retries and maxTime of backoff
+func NewRetryBudget(maxRetries int, maxTime time.Duration) *RetryBudget {
+	return &RetryBudget{maxRetries: maxRetries, maxTime: maxTime}
+}
+
+// take reserves one retry after the given backoff, or reports that the budget is spent
+func (b *RetryBudget) take(delay time.Duration) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.retries >= b.maxRetries || b.waited+delay > b.maxTime {
+		b.denied++
+		return false
+	}
+	b.retries++
+	b.waited += delay
+	return true
+}
+
+// Summary describes how much of the budget was used
+func (b *RetryBudget) Summary() string {
+	if b == nil {
+		return ""
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	summary := fmt.Sprintf("Retry budget: %s of %s retries and %s of %s backoff used",
+		formatCount(int64(b.retries)), formatCount(int64(b.maxRetries)), formatDuration(b.waited), formatDuration(b.maxTime))
+	if b.denied > 0 {
+		summary += fmt.Sprintf(", %s retry attempt(s) refused after it ran out", formatCount(int64(b.denied)))
+	}
+	return summary
+}
+
+// Stats returns the used retries, backoff time, and refused retries
+func (b *RetryBudget) Stats() (retries int, waited time.Duration, denied int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.retries, b.waited, b.denied
+}
+
+// wrap returns a retryer that draws every retry of inner from the budget
+func (b *RetryBudget) wrap(inner aws.Retryer) aws.Retryer {
+	return &budgetRetryer{Retryer: inner, budget: b}
+}
+
+// budgetRetryer refuses retries once the shared budget is spent
+type budgetRetryer struct {
+	aws.Retryer
+	budget *RetryBudget
+}
+
+// GetAttemptToken keeps the context-aware attempt tokens of retryers that have them
+func (r *budgetRetryer) GetAttemptToken(ctx context.Context) (func(error) error, error) {
+	if v2, ok := r.Retryer.(aws.RetryerV2); ok {
+		return v2.GetAttemptToken(ctx)
+	}
+	return r.GetInitialToken(), nil
+}
+
+// RetryDelay is asked right before the SDK sleeps for a retry, so the budget is charged here
+func (r *budgetRetryer) RetryDelay(attempt int, err error) (time.Duration, error) {
+	delay, delayErr := r.Retryer.RetryDelay(attempt, err)
+	if delayErr != nil {
+		return delay, delayErr
+	}
+	if !r.budget.take(delay) {
+		return 0, &RetryBudgetError{Err: err}
+	}
+	return delay, nil
+}
+
+// isRetryBudgetError reports whether a request failed because the retry budget was spent
+func isRetryBudgetError(err error) bool {
+	var budgetErr *RetryBudgetError
+	return errors.As(err, &budgetErr)
+}
@@ -0,0 +1,200 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file contains helpers for return-control responses in the AWS Bedrock Intelligent
+Agents CLI. When an action group is configured to return control, the agent sends the
+function or API call it wants to make; these helpers turn that payload into plain JSON
+so it can be printed, included in JSON output, or written to a file with --roc-out.
+
+With --roc-out the invoke command exits with ExitCodeReturnControl after writing the
+payload, and the file also holds the session ID and one result to fill in per requested
+call. An external orchestrator executes the calls, fills in the response bodies, and sends
+the file back with --roc-results on the next call.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/types"
+)
+
+// ExitCodeReturnControl is the exit status of invoke when the agent returned control and the
+// payload was written with --roc-out, so an orchestrator can tell it from a final answer
+const ExitCodeReturnControl = 6
+
+// ErrReturnControl reports that the agent handed calls back to the caller instead of answering
+var ErrReturnControl = errors.New("the agent returned control")
+
+// returnControlResults is the document read by --roc-results, the --roc-out file with its results filled in
+type returnControlResults struct {
+	SessionID                      string                  `json:"sessionId,omitempty"`
+	InvocationID                   string                  `json:"invocationId"`
+	ReturnControlInvocationResults []stateInvocationResult `json:"returnControlInvocationResults"`
+}
+
+// returnControlPayloadJSON converts a return-control payload into a JSON-friendly structure
+func returnControlPayloadJSON(payload types.ReturnControlPayload) map[string]interface{} {
+	inputs := make([]map[string]interface{}, 0, len(payload.InvocationInputs))
+	for _, member := range payload.InvocationInputs {
+		switch v := member.(type) {
+		case *types.InvocationInputMemberMemberApiInvocationInput:
+			inputs = append(inputs, apiInvocationInputJSON(v.Value))
+		case *types.InvocationInputMemberMemberFunctionInvocationInput:
+			inputs = append(inputs, functionInvocationInputJSON(v.Value))
+		}
+	}
+
+	return map[string]interface{}{
+		"invocationId":     aws.ToString(payload.InvocationId),
+		"invocationInputs": inputs,
+	}
+}
+
+// apiInvocationInputJSON describes an API call requested by the agent
+func apiInvocationInputJSON(input types.ApiInvocationInput) map[string]interface{} {
+	info := map[string]interface{}{
+		"type":        "api",
+		"actionGroup": aws.ToString(input.ActionGroup),
+		"apiPath":     aws.ToString(input.ApiPath),
+		"httpMethod":  aws.ToString(input.HttpMethod),
+	}
+	addInvocationAgentInfo(info, input.ActionInvocationType, input.AgentId, input.CollaboratorName)
+
+	parameters := make([]map[string]string, 0, len(input.Parameters))
+	for _, p := range input.Parameters {
+		parameters = append(parameters, parameterJSON(p.Name, p.Type, p.Value))
+	}
+	info["parameters"] = parameters
+
+	if input.RequestBody != nil && len(input.RequestBody.Content) > 0 {
+		body := make(map[string]interface{}, len(input.RequestBody.Content))
+		for contentType, content := range input.RequestBody.Content {
+			properties := make([]map[string]string, 0, len(content.Properties))
+			for _, p := range content.Properties {
+				properties = append(properties, parameterJSON(p.Name, p.Type, p.Value))
+			}
+			body[contentType] = properties
+		}
+		info["requestBody"] = body
+	}
+
+	return info
+}
+
+// functionInvocationInputJSON describes a function call requested by the agent
+func functionInvocationInputJSON(input types.FunctionInvocationInput) map[string]interface{} {
+	info := map[string]interface{}{
+		"type":        "function",
+		"actionGroup": aws.ToString(input.ActionGroup),
+		"function":    aws.ToString(input.Function),
+	}
+	addInvocationAgentInfo(info, input.ActionInvocationType, input.AgentId, input.CollaboratorName)
+
+	parameters := make([]map[string]string, 0, len(input.Parameters))
+	for _, p := range input.Parameters {
+		parameters = append(parameters, parameterJSON(p.Name, p.Type, p.Value))
+	}
+	info["parameters"] = parameters
+
+	return info
+}
+
+// addInvocationAgentInfo adds the invocation type and the agent and collaborator an input came from, when known
+func addInvocationAgentInfo(info map[string]interface{}, invocationType types.ActionInvocationType,
+	agentID, collaboratorName *string) {
+
+	if invocationType != "" {
+		info["actionInvocationType"] = string(invocationType)
+	}
+	if agentID != nil {
+		info["agentId"] = *agentID
+	}
+	if collaboratorName != nil {
+		info["collaboratorName"] = *collaboratorName
+	}
+}
+
+// parameterJSON describes a single named parameter
+func parameterJSON(name, paramType, value *string) map[string]string {
+	return map[string]string{
+		"name":  aws.ToString(name),
+		"type":  aws.ToString(paramType),
+		"value": aws.ToString(value),
+	}
+}
+
+// describeInvocationInput returns a one-line summary of an invocation input for text output
+func describeInvocationInput(member types.InvocationInputMember) string {
+	switch v := member.(type) {
+	case *types.InvocationInputMemberMemberApiInvocationInput:
+		return fmt.Sprintf("%s %s (action group: %s, %d parameter(s))",
+			aws.ToString(v.Value.HttpMethod), aws.ToString(v.Value.ApiPath),
+			aws.ToString(v.Value.ActionGroup), len(v.Value.Parameters))
+	case *types.InvocationInputMemberMemberFunctionInvocationInput:
+		return fmt.Sprintf("function %s (action group: %s, %d parameter(s))",
+			aws.ToString(v.Value.Function), aws.ToString(v.Value.ActionGroup), len(v.Value.Parameters))
+	default:
+		return fmt.Sprintf("%T", member)
+	}
+}
+
+// writeReturnControlPayload writes the payload as indented JSON to path, with the session
+// to continue and a result skeleton for every requested call
+func writeReturnControlPayload(path, sessionID string, payload types.ReturnControlPayload) error {
+	document := returnControlPayloadJSON(payload)
+	if sessionID != "" {
+		document["sessionId"] = sessionID
+	}
+	document["returnControlInvocationResults"] = invocationResultSkeletons(payload)
+
+	data, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal return-control payload: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write return-control payload '%s': %w", path, err)
+	}
+	return nil
+}
+
+// loadReturnControlResults reads a --roc-results file and adds its results to the session
+// state of the request; its session is continued unless another one is given
+func loadReturnControlResults(path string, opts *AgentOptions) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read return-control results '%s': %w", path, err)
+	}
+
+	var results returnControlResults
+	if err := json.Unmarshal(data, &results); err != nil {
+		return fmt.Errorf("invalid return-control results '%s': %w", path, err)
+	}
+	if len(results.ReturnControlInvocationResults) == 0 {
+		return fmt.Errorf("invalid return-control results '%s': returnControlInvocationResults is empty", path)
+	}
+
+	doc := opts.SessionStateDocument
+	if doc == nil {
+		doc = &SessionStateDocument{}
+	} else if len(doc.ReturnControlInvocationResults) > 0 {
+		return fmt.Errorf("the session state file already has returnControlInvocationResults, use either it or --roc-results")
+	}
+	doc.InvocationID = results.InvocationID
+	doc.ReturnControlInvocationResults = results.ReturnControlInvocationResults
+	if _, err := doc.sessionState(); err != nil {
+		return fmt.Errorf("invalid return-control results '%s': %w", path, err)
+	}
+
+	opts.SessionStateDocument = doc
+	if opts.SessionID == "" {
+		opts.SessionID = results.SessionID
+	}
+	logVerbose(*opts, "Loaded %d return-control result(s) for invocation %s from %s",
+		len(results.ReturnControlInvocationResults), results.InvocationID, path)
+	return nil
+}
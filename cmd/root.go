@@ -14,6 +14,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -32,6 +33,9 @@ and test agent functionality from the command line.`,
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	// Run: func(cmd *cobra.Command, args []string) { },
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return validateLogSettings(logFormat, logLevel, logRedact)
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -50,7 +54,10 @@ func init() {
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.aws-bia.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $XDG_CONFIG_HOME/aws-bia/aws-bia.yaml or $HOME/.aws-bia.yaml)")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", LogFormatAuto, "Log format on stderr: auto, json, or console (auto is console with --verbose, json otherwise)")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "", "Minimum log level: debug, info, warn, or error (default: debug with --verbose, warn otherwise)")
+	rootCmd.PersistentFlags().StringVar(&logRedact, "log-redact", LogRedactTruncate, "Input text in logs: truncate (first 80 characters), full (length only), or none (complete text)")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
@@ -68,10 +75,15 @@ func initConfig() {
 	// Each command will load its own config as needed.
 }
 
+// commandSections lists the subcommands that can override settings in their own config section
+var commandSections = []string{"invoke", "invoke-multi", "chat", "serve", "prepare", "promote", "agent", "model", "daemon", "guardrail"}
+
 // LoadConfigForCommand loads configuration values from a file for any command
 // and applies them to the provided options structure.
 // This function should be used by all commands that need configuration values.
-func LoadConfigForCommand(configPath string, verbose bool) (*viper.Viper, error) {
+// Settings in the section named after the command (e.g. "invoke:") override the
+// top-level settings; pass an empty command name to read the file as-is.
+func LoadConfigForCommand(configPath, commandName string, verbose bool) (*viper.Viper, error) {
 	v := viper.New()
 	v.SetConfigType("yaml")
 
@@ -89,7 +101,7 @@ func LoadConfigForCommand(configPath string, verbose bool) (*viper.Viper, error)
 		v.SetConfigFile(absPath)
 
 		if verbose {
-			fmt.Fprintf(os.Stderr, "Using specified config file: %s\n", absPath)
+			logNotice("Using specified config file: %s", absPath)
 		}
 
 		// Read the explicitly specified config file
@@ -98,27 +110,17 @@ func LoadConfigForCommand(configPath string, verbose bool) (*viper.Viper, error)
 		}
 
 		// Return early for explicitly specified config files
-		return v, nil
+		return v, applyCommandSection(v, commandName, verbose)
 	}
 
 	// Variables to track config discovery
 	var configFound bool
 
 	// Otherwise search in standard locations for both naming conventions
-	searchPaths := []string{"."} // Current directory first
-
-	// Add home directory paths if available
-	homeDir, err := os.UserHomeDir()
-	if err == nil {
-		searchPaths = append(
-			searchPaths,
-			homeDir,                            // User's home directory
-			filepath.Join(homeDir, ".aws-bia"), // .aws-bia in home directory
-		)
-	}
+	searchPaths := configSearchPaths()
 
 	if verbose {
-		fmt.Fprintf(os.Stderr, "Searching for config in: [current directory, home directory, ~/.aws-bia]\n")
+		logNotice("Searching for config in: %v", searchPaths)
 	}
 
 	// Try each naming convention, giving preference to non-dotfile
@@ -126,7 +128,7 @@ func LoadConfigForCommand(configPath string, verbose bool) (*viper.Viper, error)
 
 	// First try aws-bia.yaml (without dot prefix)
 	v1 := setupViperInstance("aws-bia", searchPaths)
-	err = v1.ReadInConfig()
+	err := v1.ReadInConfig()
 	if err == nil {
 		configFound = true
 		v = v1
@@ -144,16 +146,66 @@ func LoadConfigForCommand(configPath string, verbose bool) (*viper.Viper, error)
 
 	if !configFound {
 		if verbose {
-			fmt.Fprintln(os.Stderr, "No configuration file found, using command line options only")
+			logNotice("No configuration file found, using command line options only")
 		}
 	}
 
 	// If a config file was found, show where it was loaded from
 	if configFound && verbose {
-		fmt.Fprintf(os.Stderr, "Using config file: %s\n", v.ConfigFileUsed())
+		logNotice("Using config file: %s", v.ConfigFileUsed())
 	}
 
-	return v, nil
+	return v, applyCommandSection(v, commandName, verbose)
+}
+
+// configSearchPaths returns the directories searched for aws-bia.yaml and .aws-bia.yaml
+func configSearchPaths() []string {
+	searchPaths := []string{"."} // Current directory first
+
+	// Then the XDG configuration directory, before the locations of earlier versions
+	if dir := userConfigDir(); dir != "" {
+		searchPaths = append(searchPaths, filepath.Join(dir, appDirName))
+	}
+
+	// Add home directory paths if available
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		searchPaths = append(
+			searchPaths,
+			homeDir,                            // User's home directory
+			filepath.Join(homeDir, ".aws-bia"), // .aws-bia in home directory
+		)
+	}
+	return searchPaths
+}
+
+// applyCommandSection merges the settings of the command's own section over the top-level settings
+func applyCommandSection(v *viper.Viper, commandName string, verbose bool) error {
+	if commandName == "" {
+		return nil
+	}
+
+	section, ok := v.Get(commandName).(map[string]interface{})
+	if !ok || len(section) == 0 {
+		return nil
+	}
+
+	if err := v.MergeConfigMap(section); err != nil {
+		return fmt.Errorf("failed to apply '%s' config section: %w", commandName, err)
+	}
+	if verbose {
+		logNotice("Applied %d setting(s) from the '%s' config section", len(section), commandName)
+	}
+	return nil
+}
+
+// splitCommandSection splits a key like "invoke.timeout" into its command section and setting
+func splitCommandSection(name string) (section, key string) {
+	for _, command := range commandSections {
+		if rest, ok := strings.CutPrefix(name, command+"."); ok {
+			return command, rest
+		}
+	}
+	return "", name
 }
 
 // setupViperInstance creates a new viper instance with the given name and search paths
@@ -0,0 +1,508 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the 'run' command for AWS Bedrock Intelligent Agents CLI.
+It plays a conversation script, a YAML or JSON file with a sequence of turns, against
+one session of an agent. Each turn has its input, optional files to upload, an
+optional pause before it is sent, and an optional reference answer it is graded
+against, and every answer is printed or saved per turn.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// RunOptions contains all options for playing a conversation script
+type RunOptions struct {
+	ConfigFile       string
+	ScriptFile       string
+	AgentID          string
+	AgentAliasID     string
+	SessionID        string
+	MemoryID         string
+	Region           string
+	EndpointURL      string
+	UseFIPS          bool
+	UseDualStack     bool
+	Timeout          time.Duration
+	TimeoutSet       bool // --timeout was given, so the timeout setting is ignored
+	OutputFormat     string
+	OutputDir        string
+	NoPause          bool
+	Checkpoint       string // File the status of every turn is written to
+	Resume           string // Checkpoint of an earlier run to continue
+	RPS              float64
+	Burst            int
+	MaxTokensTotal   int64
+	MaxCost          float64
+	InputTokenPrice  float64
+	OutputTokenPrice float64
+	Verbose          bool
+}
+
+// ConversationScript is a sequence of turns sent in one session
+type ConversationScript struct {
+	AgentID      string       `mapstructure:"agent_id"`
+	AgentAliasID string       `mapstructure:"agent_alias_id"`
+	SessionID    string       `mapstructure:"session_id"`
+	Turns        []ScriptTurn `mapstructure:"turns"`
+}
+
+// ScriptTurn is one input of a conversation script
+type ScriptTurn struct {
+	Name        string        `mapstructure:"name"`
+	Input       string        `mapstructure:"input"`
+	UploadFiles []string      `mapstructure:"upload_files"`
+	Pause       time.Duration `mapstructure:"pause"` // Wait before the turn is sent
+
+	// Reference text the answer must resemble, by at least the threshold (default 0.8)
+	ExpectSimilar       string  `mapstructure:"expect_similar"`
+	SimilarityThreshold float64 `mapstructure:"similarity_threshold"`
+}
+
+// scriptTurnResult is the JSON document of one turn in --format json
+type scriptTurnResult struct {
+	Turn       int             `json:"turn"`
+	Name       string          `json:"name,omitempty"`
+	Input      string          `json:"input"`
+	Response   json.RawMessage `json:"response"`
+	Similarity *float64        `json:"similarity,omitempty"` // Score against expect_similar
+}
+
+var runOpts RunOptions
+
+// runCmd represents the run command
+var runCmd = &cobra.Command{
+	Use:   "run <script>",
+	Short: "Play a conversation script against one session of an agent",
+	Long: `Play a conversation script against one session of an agent.
+
+The script is a YAML or JSON file with the turns to send. Every turn is sent in
+the same session, so the agent keeps the context of the earlier turns. A turn can
+upload files, relative paths are resolved from the directory of the script, and
+a pause waits before the turn is sent, for example to let an action finish.
+
+  # conversation.yaml
+  agent_id: abc123
+  agent_alias_id: def456
+  turns:
+    - name: upload
+      input: "Summarize the attached sales report"
+      upload_files: ["data/sales.csv"]
+    - input: "Which region grew the most?"
+      pause: 5s
+      expect_similar: "The west region grew the most"
+      similarity_threshold: 0.5
+    - input: "Draft an email to that region's manager"
+
+The agent and alias given with flags take precedence over the ones in the script,
+which take precedence over the configuration file. With --output-dir every answer
+is also saved as turn-01.txt, turn-02.txt, and so on (or .json with --format json).
+The run stops at the first turn that fails.
+
+A turn with expect_similar grades its answer by the word overlap with the
+reference text, from 0 to 1. An answer below similarity_threshold (default 0.8)
+is reported on stderr and the run continues, but exits with status 5 at the end.
+With --format json the score of every graded turn is included as "similarity".
+
+With --checkpoint the status of every turn is written to a JSON file after each turn.
+A run that was interrupted or stopped at a failing turn continues with --resume: the
+turns that completed are skipped, and the run picks up at the first turn that did not,
+in the same session. The checkpoint is only accepted for the script it was written for.
+
+With --max-tokens-total or --max-cost the run stops before the next turn once the
+tokens used by the earlier turns reach the limit, and the usage is summarized at the end.
+
+Examples:
+  # Play a script and print every answer
+  aws-bia run conversation.yaml
+
+  # Save the answers of every turn as JSON
+  aws-bia run conversation.yaml --format json --output-dir results
+
+  # Skip the pauses of the script
+  aws-bia run conversation.yaml --no-pause
+
+  # Keep a checkpoint, and continue after an interruption or a failed turn
+  aws-bia run conversation.yaml --checkpoint checkpoint.json
+  aws-bia run conversation.yaml --resume checkpoint.json
+
+  # Stop once 20,000 tokens have been used
+  aws-bia run conversation.yaml --max-tokens-total 20000
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		runOpts.ScriptFile = args[0]
+		runOpts.TimeoutSet = cmd.Flags().Changed("timeout")
+		if err := runScriptCommand(ctx, runOpts); err != nil {
+			if errors.Is(err, ErrAssertionFailed) {
+				os.Exit(ExitCodeAssertionFailed)
+			}
+			logError("Error running conversation script", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+
+	runCmd.Flags().StringVar(&runOpts.ConfigFile, "config", "", "Path to configuration file (yaml)")
+	runCmd.Flags().StringVar(&runOpts.AgentID, "agent-id", "", "The ID of the agent (overrides the script and config file)")
+	runCmd.Flags().StringVar(&runOpts.AgentAliasID, "agent-alias-id", "", "The ID of the agent alias (overrides the script and config file)")
+	runCmd.Flags().StringVar(&runOpts.SessionID, "session-id", "", "Session to run the script in (if not provided, a random ID will be generated)")
+	runCmd.Flags().StringVar(&runOpts.MemoryID, "memory-id", "", "Agent memory ID to continue a long-term memory thread (can be set in config file)")
+	runCmd.Flags().StringVar(&runOpts.Region, "region", "", "AWS region to use (defaults to AWS_REGION environment variable)")
+	runCmd.Flags().StringVar(&runOpts.EndpointURL, "endpoint-url", "", "Send agent runtime requests to this URL instead of the regional endpoint (e.g. a VPC endpoint or local mock)")
+	runCmd.Flags().BoolVar(&runOpts.UseFIPS, "use-fips", false, "Use FIPS endpoints for AWS requests")
+	runCmd.Flags().BoolVar(&runOpts.UseDualStack, "use-dualstack", false, "Use dual-stack (IPv4 and IPv6) endpoints for AWS requests")
+	runCmd.Flags().DurationVar(&runOpts.Timeout, "timeout", DefaultMaxDuration, "Maximum duration of each turn")
+	runCmd.Flags().Float64Var(&runOpts.RPS, "rps", 0, "Send at most this many turns per second (0 for no limit, can be set in config file)")
+	runCmd.Flags().IntVar(&runOpts.Burst, "burst", DefaultBurst, "Turns that may be sent at once before --rps applies")
+	runCmd.Flags().Int64Var(&runOpts.MaxTokensTotal, "max-tokens-total", 0, "Stop before the next turn once this many tokens have been used (0 for no limit, can be set in config file)")
+	runCmd.Flags().Float64Var(&runOpts.MaxCost, "max-cost", 0, "Stop before the next turn once the estimated cost reaches this amount in USD (can be set in config file)")
+	runCmd.Flags().Float64Var(&runOpts.InputTokenPrice, "input-token-price", 0, "USD per 1,000 input tokens for cost estimates (can be set in config file)")
+	runCmd.Flags().Float64Var(&runOpts.OutputTokenPrice, "output-token-price", 0, "USD per 1,000 output tokens for cost estimates (can be set in config file)")
+	runCmd.Flags().StringVar(&runOpts.OutputFormat, "format", OutputFormatText, "Output format: text or json")
+	runCmd.Flags().StringVar(&runOpts.OutputDir, "output-dir", "", "Directory to save the answer of every turn to")
+	runCmd.Flags().BoolVar(&runOpts.NoPause, "no-pause", false, "Send every turn right away, ignoring the pauses of the script")
+	runCmd.Flags().StringVar(&runOpts.Checkpoint, "checkpoint", "", "Write the status of every turn to this file, to continue the run later with --resume")
+	runCmd.Flags().StringVar(&runOpts.Resume, "resume", "", "Continue the run of a checkpoint file, skipping the turns that completed")
+	runCmd.Flags().BoolVar(&runOpts.Verbose, "verbose", false, "Enable verbose output")
+
+	registerAgentCompletions(runCmd)
+}
+
+// runScriptCommand loads the script and sends its turns one after another
+func runScriptCommand(ctx context.Context, opts RunOptions) error {
+	InitLogger(opts.Verbose)
+	defer SyncLogger()
+
+	if opts.OutputFormat != OutputFormatText && opts.OutputFormat != OutputFormatJSON {
+		return fmt.Errorf("format must be %s or %s, got '%s'", OutputFormatText, OutputFormatJSON, opts.OutputFormat)
+	}
+
+	script, err := loadConversationScript(opts.ScriptFile)
+	if err != nil {
+		return err
+	}
+
+	v, err := LoadConfigForCommand(opts.ConfigFile, "run", opts.Verbose)
+	if err != nil {
+		return err
+	}
+
+	var checkpoint *runCheckpoint
+	if opts.Resume != "" {
+		if checkpoint, err = loadRunCheckpoint(opts.Resume, script); err != nil {
+			return err
+		}
+		if opts.SessionID != "" && opts.SessionID != checkpoint.SessionID {
+			return fmt.Errorf("--session-id %s does not match session %s of checkpoint '%s'", opts.SessionID, checkpoint.SessionID, opts.Resume)
+		}
+		opts.SessionID = checkpoint.SessionID
+		if opts.Checkpoint != "" {
+			checkpoint.path = opts.Checkpoint
+		}
+	} else if opts.Checkpoint != "" {
+		checkpoint = newRunCheckpoint(opts.Checkpoint, opts.ScriptFile, script)
+	}
+
+	// Flags take precedence over the script, which takes precedence over the config file
+	agentOpts := AgentOptions{
+		AgentID:        opts.AgentID,
+		AgentAliasID:   opts.AgentAliasID,
+		SessionID:      opts.SessionID,
+		MemoryID:       opts.MemoryID,
+		Region:         opts.Region,
+		EndpointURL:    opts.EndpointURL,
+		UseFIPS:        opts.UseFIPS,
+		UseDualStack:   opts.UseDualStack,
+		Timeout:        opts.Timeout,
+		ConnectTimeout: DefaultConnectTimeout,
+		IdleTimeout:    DefaultIdleTimeout,
+		MaxDurationSet: opts.TimeoutSet,
+		OutputFormat:   opts.OutputFormat,
+		FileUseCase:    FileUseCaseCodeInterpreter,
+		Citations:      CitationsFootnotes,
+		EnableTrace:    opts.OutputFormat == OutputFormatJSON, // Adds the token usage to the JSON documents
+		NoProgress:     true,
+		Verbose:        opts.Verbose,
+		Warnings:       NewWarningCollector(),
+		Clients:        NewRuntimeClients(nil),
+		RPS:            opts.RPS,
+		Burst:          opts.Burst,
+		MaxTokensTotal: opts.MaxTokensTotal,
+		MaxCost:        opts.MaxCost,
+	}
+	if agentOpts.AgentID == "" {
+		agentOpts.AgentID = script.AgentID
+	}
+	if agentOpts.AgentAliasID == "" {
+		agentOpts.AgentAliasID = script.AgentAliasID
+	}
+	if agentOpts.SessionID == "" {
+		agentOpts.SessionID = script.SessionID
+	}
+	applyAgentConfig(v, &agentOpts)
+	applyTimeoutConfig(v, &agentOpts)
+	if err := setupRateLimiter(v, &agentOpts); err != nil {
+		return err
+	}
+	pricing := TokenPricing{
+		InputPer1K:  configFloat(v, "input_token_price", opts.InputTokenPrice),
+		OutputPer1K: configFloat(v, "output_token_price", opts.OutputTokenPrice),
+	}
+	if err := setupUsageBudget(v, &agentOpts, pricing); err != nil {
+		return err
+	}
+	agentOpts.Color = v.GetString("color")
+
+	if agentOpts.AgentID == "" {
+		return fmt.Errorf("agent ID is required (set it with --agent-id, in the script, or in the config file)")
+	}
+	if agentOpts.AgentAliasID == "" {
+		return fmt.Errorf("agent alias ID is required (set it with --agent-alias-id, in the script, or in the config file)")
+	}
+	if agentOpts.Timeout <= 0 {
+		return fmt.Errorf("timeout must be a positive duration")
+	}
+	if agentOpts.SessionID == "" {
+		agentOpts.SessionID = uuid.New().String()
+	}
+	if opts.Resume != "" && (checkpoint.AgentID != agentOpts.AgentID || checkpoint.AgentAliasID != agentOpts.AgentAliasID) {
+		return fmt.Errorf("checkpoint '%s' was written for agent %s (alias %s), not %s (alias %s)", opts.Resume,
+			checkpoint.AgentID, checkpoint.AgentAliasID, agentOpts.AgentID, agentOpts.AgentAliasID)
+	}
+
+	// Missing upload files are reported before the first turn is sent
+	for i, turn := range script.Turns {
+		turnOpts := agentOpts
+		turnOpts.UploadFiles = turn.UploadFiles
+		if err := validateFileUploadOptions(turnOpts); err != nil {
+			return fmt.Errorf("turn %d of script '%s': %w", i+1, opts.ScriptFile, err)
+		}
+	}
+
+	if opts.OutputDir != "" {
+		if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+			return fmt.Errorf("failed to create output directory '%s': %w", opts.OutputDir, err)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Running %s (%d turn(s)) with agent %s (alias %s)\n",
+		opts.ScriptFile, len(script.Turns), agentOpts.AgentID, agentOpts.AgentAliasID)
+	fmt.Fprintf(os.Stderr, "Session ID: %s\n", agentOpts.SessionID)
+
+	start := 0
+	if checkpoint != nil {
+		checkpoint.AgentID, checkpoint.AgentAliasID = agentOpts.AgentID, agentOpts.AgentAliasID
+		checkpoint.SessionID = agentOpts.SessionID
+		if opts.Resume != "" {
+			start = checkpoint.Resume()
+			if start == len(script.Turns) {
+				fmt.Fprintf(os.Stderr, "All turns of checkpoint '%s' have completed\n", opts.Resume)
+			} else {
+				fmt.Fprintf(os.Stderr, "Resuming at turn %d, %d turn(s) completed\n", start+1, start)
+			}
+		}
+		if err := checkpoint.Save(); err != nil {
+			return err
+		}
+	}
+
+	if agentOpts.UsageBudget != nil {
+		defer func() { fmt.Fprintln(os.Stderr, "\n"+agentOpts.UsageBudget.Summary()) }()
+	}
+
+	var results []scriptTurnResult
+	var failedAssertions int
+	for i := 0; i < start; i++ {
+		// The answers of the earlier run complete the JSON document
+		if opts.OutputFormat == OutputFormatJSON {
+			turn := checkpoint.Turns[i]
+			results = append(results, scriptTurnResult{Turn: turn.Turn, Name: turn.Name, Input: turn.Input, Response: turn.Response})
+		}
+	}
+	for i := start; i < len(script.Turns); i++ {
+		turn := script.Turns[i]
+		if err := agentOpts.UsageBudget.Check(); err != nil {
+			if checkpoint != nil {
+				fmt.Fprintf(os.Stderr, "\nContinue the run with --resume %s\n", checkpoint.path)
+			}
+			return fmt.Errorf("stopped before turn %d: %w", i+1, err)
+		}
+
+		if turn.Pause > 0 && !opts.NoPause {
+			fmt.Fprintf(os.Stderr, "\nWaiting %s before turn %d\n", turn.Pause, i+1)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(turn.Pause):
+			}
+		}
+
+		label := fmt.Sprintf("Turn %d/%d", i+1, len(script.Turns))
+		if turn.Name != "" {
+			label += ": " + turn.Name
+		}
+		fmt.Fprintf(os.Stderr, "\n%s %s %s\n", strings.Repeat("=", 10), label, strings.Repeat("=", 10))
+
+		response, answer, err := runScriptTurn(ctx, agentOpts, turn)
+		if err != nil {
+			if saveErr := checkpoint.Failed(i, err); saveErr != nil {
+				LogWarn("%v", saveErr)
+			} else if checkpoint != nil {
+				fmt.Fprintf(os.Stderr, "\nContinue the run with --resume %s\n", checkpoint.path)
+			}
+			return fmt.Errorf("turn %d failed: %w", i+1, err)
+		}
+
+		if opts.OutputDir != "" {
+			extension := "txt"
+			if opts.OutputFormat == OutputFormatJSON {
+				extension = "json"
+			}
+			path := filepath.Join(opts.OutputDir, fmt.Sprintf("turn-%02d.%s", i+1, extension))
+			if err := os.WriteFile(path, response, 0644); err != nil {
+				return fmt.Errorf("failed to save turn %d: %w", i+1, err)
+			}
+			logVerbose(agentOpts, "Saved turn %d to %s", i+1, path)
+		}
+
+		var similarity *float64
+		if turn.ExpectSimilar != "" {
+			score := ResponseSimilarity(answer, turn.ExpectSimilar)
+			similarity = &score
+			if failure := similarityFailure(score, turn.SimilarityThreshold); failure != "" {
+				reportAssertionFailures(agentOpts.Color, []string{fmt.Sprintf("turn %d: %s", i+1, failure)})
+				failedAssertions++
+			} else {
+				logVerbose(agentOpts, "Turn %d is %.2f similar to the expected text", i+1, score)
+			}
+		}
+
+		var kept json.RawMessage
+		if opts.OutputFormat == OutputFormatJSON {
+			kept = response
+			results = append(results, scriptTurnResult{Turn: i + 1, Name: turn.Name, Input: turn.Input, Response: response, Similarity: similarity})
+		}
+		if err := checkpoint.Completed(i, kept); err != nil {
+			return err
+		}
+	}
+
+	// JSON consumers get one document for the whole run
+	if opts.OutputFormat == OutputFormatJSON {
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"sessionId": agentOpts.SessionID,
+			"turns":     results,
+		}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal results: %w", err)
+		}
+		fmt.Fprintln(os.Stdout, string(data))
+	}
+	if failedAssertions > 0 {
+		fmt.Fprintf(os.Stderr, "\n%d turn(s) failed an assertion\n", failedAssertions)
+		return ErrAssertionFailed
+	}
+	return nil
+}
+
+// runScriptTurn sends the input of one turn and returns the formatted response and the answer text.
+// Text answers are written to stdout as they arrive, JSON documents are only returned.
+func runScriptTurn(ctx context.Context, opts AgentOptions, turn ScriptTurn) ([]byte, string, error) {
+	turnOpts := opts
+	turnOpts.InputText = turn.Input
+	turnOpts.UploadFiles = turn.UploadFiles
+
+	var buf bytes.Buffer
+	var writer io.Writer = &buf
+	if opts.OutputFormat != OutputFormatJSON {
+		writer = io.MultiWriter(os.Stdout, &buf)
+	}
+
+	formatter := NewResponseFormatter(turnOpts, writer)
+	output, err := runInvokeTurn(ctx, turnOpts, NewAWSHelper(turnOpts), formatter)
+	if output != nil {
+		opts.UsageBudget.Record(formatter.LastResult().Usage)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	recordSessionTurn(turnOpts, output, formatter.LastResult())
+
+	answer := formatter.LastResult().Text
+	if opts.OutputFormat == OutputFormatJSON {
+		return bytes.TrimSpace(buf.Bytes()), answer, nil
+	}
+	return buf.Bytes(), answer, nil
+}
+
+// loadConversationScript reads a YAML or JSON conversation script
+func loadConversationScript(path string) (*ConversationScript, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".yaml", ".yml":
+	default:
+		v.SetConfigType("yaml")
+	}
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read script '%s': %w", path, err)
+	}
+
+	var script ConversationScript
+	if err := v.Unmarshal(&script); err != nil {
+		return nil, fmt.Errorf("invalid script '%s': %w", path, err)
+	}
+	if len(script.Turns) == 0 {
+		return nil, fmt.Errorf("script '%s' has no turns", path)
+	}
+
+	dir := filepath.Dir(path)
+	for i := range script.Turns {
+		turn := &script.Turns[i]
+		if strings.TrimSpace(turn.Input) == "" {
+			return nil, fmt.Errorf("turn %d of script '%s' has no input", i+1, path)
+		}
+		if turn.Pause < 0 {
+			return nil, fmt.Errorf("turn %d of script '%s' has a negative pause", i+1, path)
+		}
+		if turn.SimilarityThreshold == 0 {
+			turn.SimilarityThreshold = DefaultSimilarityThreshold
+		}
+		if err := validateSimilarityThreshold(turn.SimilarityThreshold); err != nil {
+			return nil, fmt.Errorf("turn %d of script '%s': %w", i+1, path, err)
+		}
+		// Upload paths are relative to the script, so it can be run from any directory
+		for j, file := range turn.UploadFiles {
+			switch {
+			case file == StdinUpload:
+				return nil, fmt.Errorf("turn %d of script '%s' cannot upload from stdin", i+1, path)
+			case !isS3URI(file) && !isDataURI(file) && !filepath.IsAbs(file):
+				turn.UploadFiles[j] = filepath.Join(dir, file)
+			}
+		}
+	}
+	return &script, nil
+}
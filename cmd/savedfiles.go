@@ -0,0 +1,252 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements how files generated by the agent are named and written with
+--save-files. Names are sanitized so they are valid on Windows as well, subdirectories
+in a name are kept when they stay inside the output directory, and --on-conflict decides
+what happens when a file already exists. Files are written to a temporary file that is
+renamed into place, so an interrupted write never leaves a partial file behind.
+A manifest.json in the output directory lists every saved file with its size, MIME
+type, SHA-256, and the session and request it came from.
+*/
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/types"
+)
+
+// Conflict policies accepted by --on-conflict
+const (
+	ConflictRename    = "rename"    // Save as name_2.ext, name_3.ext, ...
+	ConflictOverwrite = "overwrite" // Replace the existing file
+	ConflictSkip      = "skip"      // Keep the existing file and do not save
+	ConflictError     = "error"     // Fail the invocation
+)
+
+// savedFilesManifestName is the manifest written next to the saved files
+const savedFilesManifestName = "manifest.json"
+
+// SavedFilesManifest lists the files saved to an output directory
+type SavedFilesManifest struct {
+	Files []SavedFileEntry `json:"files"`
+}
+
+// SavedFileEntry describes one saved file
+type SavedFileEntry struct {
+	Name      string    `json:"name"` // Name given by the agent
+	Path      string    `json:"path"` // Relative to the output directory
+	Size      int       `json:"size"`
+	MimeType  string    `json:"mimeType"`
+	SHA256    string    `json:"sha256"`
+	SessionID string    `json:"sessionId,omitempty"`
+	RequestID string    `json:"requestId,omitempty"` // AWS request ID of the invocation
+	SavedAt   time.Time `json:"savedAt"`
+}
+
+// invalidFileNameChars cannot appear in file names on Windows
+const invalidFileNameChars = `<>:"/\|?*`
+
+// reservedFileNames are device names on Windows, with or without an extension
+var reservedFileNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// validateConflictPolicy checks the value of --on-conflict
+func validateConflictPolicy(policy string) error {
+	switch policy {
+	case "", ConflictRename, ConflictOverwrite, ConflictSkip, ConflictError:
+		return nil
+	default:
+		return fmt.Errorf("on-conflict must be one of: %s, %s, %s, %s, got '%s'",
+			ConflictRename, ConflictOverwrite, ConflictSkip, ConflictError, policy)
+	}
+}
+
+// sanitizeOutputName turns a file name chosen by the agent into a relative path that is safe
+// to create on any platform. Subdirectories are kept unless the name is absolute or climbs
+// out of the output directory, then only the last element is used.
+func sanitizeOutputName(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool { return r == '/' || r == '\\' })
+
+	unsafe := strings.HasPrefix(name, "/") || strings.HasPrefix(name, `\`) || filepath.VolumeName(name) != ""
+	for _, part := range parts {
+		if part == ".." || strings.Contains(part, ":") {
+			unsafe = true // Also rejects drive letters such as C: on other platforms
+		}
+	}
+	if unsafe && len(parts) > 0 {
+		parts = parts[len(parts)-1:]
+	}
+
+	var cleaned []string
+	for _, part := range parts {
+		if part == "." || part == ".." {
+			continue
+		}
+		if part = sanitizeNameElement(part); part != "" {
+			cleaned = append(cleaned, part)
+		}
+	}
+	if len(cleaned) == 0 {
+		return "file"
+	}
+	return filepath.Join(cleaned...)
+}
+
+// sanitizeNameElement replaces the characters of one path element that Windows rejects
+func sanitizeNameElement(element string) string {
+	element = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(invalidFileNameChars, r) {
+			return '_'
+		}
+		return r
+	}, element)
+
+	// Windows drops trailing dots and spaces, which would change the name
+	element = strings.TrimRight(element, ". ")
+	if element == "" {
+		return ""
+	}
+
+	stem := strings.ToUpper(strings.TrimSpace(strings.SplitN(element, ".", 2)[0]))
+	if reservedFileNames[stem] {
+		element = "_" + element
+	}
+	return element
+}
+
+// resolveOutputPath applies the conflict policy to the path of a file to save.
+// It returns false when the file should not be saved.
+func resolveOutputPath(path, policy string) (string, bool, error) {
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		return path, true, nil
+	}
+
+	switch policy {
+	case ConflictOverwrite:
+		return path, true, nil
+	case ConflictSkip:
+		return "", false, nil
+	case ConflictError:
+		return "", false, fmt.Errorf("file '%s' already exists (use --on-conflict to rename, overwrite, or skip)", path)
+	}
+
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s_%d%s", base, n, ext)
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate, true, nil
+		}
+	}
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it into place
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+	}
+	return err
+}
+
+// fileSHA256 returns the hex SHA-256 of a generated file
+func fileSHA256(file types.OutputFile) string {
+	sum := sha256.Sum256(file.Bytes)
+	return hex.EncodeToString(sum[:])
+}
+
+// newSavedFileEntry describes a file saved to path for the manifest
+func newSavedFileEntry(dir, path string, file types.OutputFile, output *bedrockagentruntime.InvokeAgentOutput) SavedFileEntry {
+	relPath, err := filepath.Rel(dir, path)
+	if err != nil {
+		relPath = filepath.Base(path)
+	}
+	entry := SavedFileEntry{
+		Name:     aws.ToString(file.Name),
+		Path:     filepath.ToSlash(relPath),
+		Size:     len(file.Bytes),
+		MimeType: aws.ToString(file.Type),
+		SHA256:   fileSHA256(file),
+		SavedAt:  time.Now().UTC(),
+	}
+	if entry.MimeType == "" {
+		entry.MimeType = DetectMimeType(path, file.Bytes)
+	}
+	if output != nil {
+		entry.SessionID = aws.ToString(output.SessionId)
+		entry.RequestID, _ = awsmiddleware.GetRequestIDMetadata(output.ResultMetadata)
+	}
+	return entry
+}
+
+// updateSavedFilesManifest adds the entries to the manifest of the output directory.
+// Entries of earlier invocations are kept unless a file was saved to the same path again.
+func updateSavedFilesManifest(dir string, entries []SavedFileEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	path := filepath.Join(dir, savedFilesManifestName)
+
+	var manifest SavedFilesManifest
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return fmt.Errorf("invalid manifest '%s': %w", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read manifest '%s': %w", path, err)
+	}
+
+	replaced := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		replaced[entry.Path] = true
+	}
+	kept := manifest.Files[:0]
+	for _, entry := range manifest.Files {
+		if !replaced[entry.Path] {
+			kept = append(kept, entry)
+		}
+	}
+	manifest.Files = append(kept, entries...)
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if err := writeFileAtomic(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest '%s': %w", path, err)
+	}
+	return nil
+}
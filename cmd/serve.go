@@ -0,0 +1,502 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the 'serve' command for AWS Bedrock Intelligent Agents CLI.
+It runs a small HTTP server that exposes agent invocation as a JSON REST endpoint
+and a Server-Sent Events (SSE) endpoint for streaming, reusing the same AWS helper,
+stream processor, and response formatter as the 'invoke' command.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	// Default listen address for the HTTP server
+	DefaultServeAddr = "127.0.0.1:8080"
+
+	// Maximum accepted size of an invoke request body
+	maxRequestBodySize = 1 << 20
+)
+
+// ServeOptions contains all options for the HTTP server
+type ServeOptions struct {
+	ConfigFile     string
+	Addr           string
+	Region         string
+	EndpointURL    string
+	UseFIPS        bool
+	UseDualStack   bool
+	Timeout        time.Duration // Maximum duration of an invocation; 0 means no limit
+	ConnectTimeout time.Duration
+	IdleTimeout    time.Duration
+	MaxDurationSet bool          // The maximum duration was given, so it also bounds streams
+	MaxConcurrent  int           // Invocations running at once per agent alias
+	MaxQueue       int           // Requests waiting for a slot per agent alias
+	QueueTimeout   time.Duration // Longest time a request waits for a slot
+	Verbose        bool
+}
+
+// InvokeRequest is the JSON body accepted by the server's invoke endpoints.
+// Fields mirror the corresponding AgentOptions; empty fields fall back to the server defaults.
+type InvokeRequest struct {
+	AgentID      string            `json:"agentId"`
+	AgentAliasID string            `json:"agentAliasId"`
+	Input        string            `json:"input"`
+	SessionID    string            `json:"sessionId"`
+	MemoryID     string            `json:"memoryId"`
+	Prompt       string            `json:"prompt"`
+	Vars         map[string]string `json:"vars"`
+	Timeout      string            `json:"timeout"`
+}
+
+var serveOpts ServeOptions
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Expose Bedrock agents through a local HTTP API",
+	Long: `Start an HTTP server that invokes Bedrock agents on behalf of local apps.
+
+Endpoints:
+  POST /invoke           Invoke an agent and return the JSON response
+  POST /invoke/stream    Invoke an agent and stream the response as Server-Sent Events
+  GET  /invoke/stream    Same as above, with the request given as query parameters
+  GET  /healthz          Health check
+
+Request body (all fields optional when defaults are configured):
+  {"agentId": "...", "agentAliasId": "...", "input": "...", "sessionId": "...", "memoryId": "...",
+   "prompt": "code-review", "vars": {"language": "Go"}, "timeout": "60s"}
+
+Streaming events: chunk, files, returnControl, done (full JSON response), and error.
+
+Invocations are bounded by --connect-timeout and --idle-timeout like invoke. A JSON
+response is also bounded by --max-duration; a stream is only when --max-duration or
+the timeout of the request is given.
+
+Each agent alias runs at most --max-concurrent invocations at once and queues up to
+--max-queue more requests. A request that finds the queue full, or waits longer than
+--queue-timeout, is answered with 429 Too Many Requests and a Retry-After header, so a
+busy target does not hold up requests for other targets.
+
+Examples:
+  # Serve on the default address using agent IDs from the config file
+  aws-bia serve --config ~/.aws-bia.yaml
+
+  # Invoke through the server
+  curl -s localhost:8080/invoke -d '{"input": "Hello"}'
+
+  # Stream a response
+  curl -N "localhost:8080/invoke/stream?input=Hello"
+
+  # Allow two invocations per agent alias and reject when eight are waiting
+  aws-bia serve --max-concurrent 2 --max-queue 8
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		serveOpts.MaxDurationSet = cmd.Flags().Changed("max-duration") || cmd.Flags().Changed("timeout")
+		if err := runServeCommand(ctx, serveOpts); err != nil {
+			logError("Error running server", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().StringVar(&serveOpts.ConfigFile, "config", "", "Path to configuration file (yaml)")
+	serveCmd.Flags().StringVar(&serveOpts.Addr, "addr", DefaultServeAddr, "Address to listen on")
+	serveCmd.Flags().StringVar(&serveOpts.Region, "region", "", "AWS region to use (defaults to AWS_REGION environment variable)")
+	serveCmd.Flags().StringVar(&serveOpts.EndpointURL, "endpoint-url", "", "Send agent runtime requests to this URL instead of the regional endpoint (e.g. a VPC endpoint or local mock)")
+	serveCmd.Flags().BoolVar(&serveOpts.UseFIPS, "use-fips", false, "Use FIPS endpoints for AWS requests")
+	serveCmd.Flags().BoolVar(&serveOpts.UseDualStack, "use-dualstack", false, "Use dual-stack (IPv4 and IPv6) endpoints for AWS requests")
+	serveCmd.Flags().DurationVar(&serveOpts.ConnectTimeout, "connect-timeout", DefaultConnectTimeout, "Maximum time until the agent starts responding, 0 for no limit")
+	serveCmd.Flags().DurationVar(&serveOpts.IdleTimeout, "idle-timeout", DefaultIdleTimeout, "Maximum time between two stream events, 0 for no limit")
+	serveCmd.Flags().DurationVar(&serveOpts.Timeout, "max-duration", DefaultMaxDuration, "Maximum duration of each invocation, 0 for no limit (streams have no limit unless it is given)")
+	serveCmd.Flags().DurationVar(&serveOpts.Timeout, "timeout", DefaultMaxDuration, "Maximum duration of each invocation")
+	_ = serveCmd.Flags().MarkDeprecated("timeout", "use --max-duration, --connect-timeout, or --idle-timeout instead")
+	serveCmd.Flags().IntVar(&serveOpts.MaxConcurrent, "max-concurrent", DefaultMaxConcurrentPerTarget, "Maximum concurrent invocations per agent alias")
+	serveCmd.Flags().IntVar(&serveOpts.MaxQueue, "max-queue", DefaultMaxQueuePerTarget, "Maximum requests waiting for a slot per agent alias before answering 429")
+	serveCmd.Flags().DurationVar(&serveOpts.QueueTimeout, "queue-timeout", DefaultQueueTimeout, "Maximum time a request waits for a slot before answering 429")
+	serveCmd.Flags().BoolVar(&serveOpts.Verbose, "verbose", false, "Enable verbose output")
+}
+
+// agentServer handles HTTP requests using a set of default agent options
+type agentServer struct {
+	defaults AgentOptions
+	limiter  *targetLimiter
+}
+
+// runServeCommand starts the HTTP server and blocks until the context is canceled
+func runServeCommand(ctx context.Context, opts ServeOptions) error {
+	InitLogger(opts.Verbose)
+	defer SyncLogger()
+
+	defaults := AgentOptions{
+		Region:         opts.Region,
+		EndpointURL:    opts.EndpointURL,
+		UseFIPS:        opts.UseFIPS,
+		UseDualStack:   opts.UseDualStack,
+		Timeout:        opts.Timeout,
+		ConnectTimeout: opts.ConnectTimeout,
+		IdleTimeout:    opts.IdleTimeout,
+		MaxDurationSet: opts.MaxDurationSet,
+		OutputFormat:   OutputFormatJSON,
+		FileUseCase:    FileUseCaseCodeInterpreter,
+		Verbose:        opts.Verbose,
+		Clients:        NewRuntimeClients(nil),
+	}
+	v, err := LoadConfigForCommand(opts.ConfigFile, "serve", opts.Verbose)
+	if err != nil {
+		return err
+	}
+	applyAgentConfig(v, &defaults)
+	applyTimeoutConfig(v, &defaults)
+	if err := validateTimeouts(defaults); err != nil {
+		return err
+	}
+	applyServeLimitConfig(v, &opts)
+
+	if opts.MaxConcurrent < 1 {
+		return fmt.Errorf("max concurrent invocations must be at least 1, got %d", opts.MaxConcurrent)
+	}
+	if opts.MaxQueue < 0 {
+		return fmt.Errorf("max queue must not be negative, got %d", opts.MaxQueue)
+	}
+	if opts.QueueTimeout <= 0 {
+		return fmt.Errorf("queue timeout must be a positive duration")
+	}
+
+	server := &agentServer{
+		defaults: defaults,
+		limiter:  newTargetLimiter(opts.MaxConcurrent, opts.MaxQueue, opts.QueueTimeout),
+	}
+	httpServer := &http.Server{
+		Addr:              opts.Addr,
+		Handler:           server.routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.ListenAndServe()
+	}()
+	fmt.Fprintf(os.Stderr, "Listening on http://%s\n", opts.Addr)
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("failed to shut down server: %w", err)
+		}
+		if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
+
+// routes registers the server endpoints
+func (s *agentServer) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /invoke", s.handleInvoke)
+	mux.HandleFunc("POST /invoke/stream", s.handleInvokeStream)
+	mux.HandleFunc("GET /invoke/stream", s.handleInvokeStream)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	return mux
+}
+
+// handleInvoke invokes the agent and writes the formatted JSON response
+func (s *agentServer) handleInvoke(w http.ResponseWriter, r *http.Request) {
+	opts, err := s.requestOptions(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	release, ok := s.acquireSlot(w, r, opts)
+	if !ok {
+		return
+	}
+	defer release()
+
+	// The connect and idle timeouts are applied while invoking and reading the stream
+	ctx, cancel := withMaxDuration(r.Context(), opts.Timeout)
+	defer cancel()
+
+	awsHelper := NewAWSHelper(opts)
+	output, err := invokeAgent(ctx, awsHelper)
+	if err != nil {
+		err = timeoutCause(ctx, err)
+		logError("Error invoking agent", err)
+		writeJSONError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	// Buffer the formatted response so a stream error can still produce an error status
+	var body bytes.Buffer
+	formatter := NewResponseFormatter(opts, &body)
+	formatter.FileHelper = awsHelper.FileHelper
+	if err := formatter.FormatAndWriteResponse(output); err != nil {
+		err = timeoutCause(ctx, err)
+		logError("Error processing agent response", err)
+		writeJSONError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body.Bytes())
+}
+
+// handleInvokeStream invokes the agent and relays the event stream as Server-Sent Events
+func (s *agentServer) handleInvokeStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSONError(w, http.StatusInternalServerError, "streaming is not supported by this connection")
+		return
+	}
+
+	opts, err := s.requestOptions(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	opts.EnableStreaming = true
+	applyStreamingMaxDuration(&opts)
+
+	// The slot is held until the whole stream has been relayed
+	release, ok := s.acquireSlot(w, r, opts)
+	if !ok {
+		return
+	}
+	defer release()
+
+	ctx, cancel := withMaxDuration(r.Context(), opts.Timeout)
+	defer cancel()
+
+	output, err := invokeAgent(ctx, NewAWSHelper(opts))
+	if err != nil {
+		err = timeoutCause(ctx, err)
+		logError("Error invoking agent", err)
+		writeJSONError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(event string, data interface{}) {
+		if err := writeSSEEvent(w, event, data); err != nil {
+			logVerbose(opts, "Failed to write SSE event: %v", err)
+			return
+		}
+		flusher.Flush()
+	}
+
+	stream := output.GetStream()
+	if stream == nil {
+		send("error", map[string]string{"error": "no response stream available"})
+		return
+	}
+	defer stream.Close()
+
+	processor := NewStreamProcessor(opts, io.Discard, false)
+	processor.OnEvent = func(event types.ResponseStream) {
+		switch v := event.(type) {
+		case *types.ResponseStreamMemberChunk:
+			if len(v.Value.Bytes) > 0 {
+				send("chunk", map[string]string{"text": string(v.Value.Bytes)})
+			}
+		case *types.ResponseStreamMemberFiles:
+			files := make([]map[string]interface{}, 0, len(v.Value.Files))
+			for _, file := range v.Value.Files {
+				info := map[string]interface{}{"size": len(file.Bytes)}
+				if file.Name != nil {
+					info["name"] = *file.Name
+				}
+				if file.Type != nil {
+					info["type"] = *file.Type
+				}
+				files = append(files, info)
+			}
+			send("files", map[string]interface{}{"files": files})
+		case *types.ResponseStreamMemberReturnControl:
+			send("returnControl", returnControlPayloadJSON(v.Value))
+		}
+	}
+
+	result, err := processor.ProcessStream(stream)
+	if err != nil {
+		err = timeoutCause(ctx, err)
+		logError("Error processing agent response", err)
+		send("error", map[string]string{"error": err.Error()})
+		return
+	}
+
+	formatter := NewResponseFormatter(opts, io.Discard)
+	send("done", formatter.buildJSONResponse(output, result))
+}
+
+// acquireSlot waits for an invocation slot of the request's target, answering 429 when it is saturated
+func (s *agentServer) acquireSlot(w http.ResponseWriter, r *http.Request, opts AgentOptions) (func(), bool) {
+	target := opts.AgentID + ":" + opts.AgentAliasID
+	release, err := s.limiter.Acquire(r.Context(), target)
+	if err == nil {
+		return release, true
+	}
+
+	var busy *TargetBusyError
+	if errors.As(err, &busy) {
+		logVerbose(opts, "Rejecting request: %v", busy)
+		w.Header().Set("Retry-After", strconv.Itoa(int(busy.RetryAfter.Seconds())))
+		writeJSONError(w, http.StatusTooManyRequests, busy.Error())
+		return nil, false
+	}
+
+	// The client went away while waiting, there is nobody to answer
+	logVerbose(opts, "Request for %s canceled while queued: %v", target, err)
+	return nil, false
+}
+
+// applyServeLimitConfig applies the per-target limits from a loaded configuration unless the flags changed them
+func applyServeLimitConfig(v *viper.Viper, opts *ServeOptions) {
+	if v.InConfig("max_concurrent") && opts.MaxConcurrent == DefaultMaxConcurrentPerTarget {
+		opts.MaxConcurrent = v.GetInt("max_concurrent")
+	}
+	if v.InConfig("max_queue") && opts.MaxQueue == DefaultMaxQueuePerTarget {
+		opts.MaxQueue = v.GetInt("max_queue")
+	}
+	if v.InConfig("queue_timeout") && opts.QueueTimeout == DefaultQueueTimeout {
+		opts.QueueTimeout = v.GetDuration("queue_timeout")
+	}
+}
+
+// requestOptions builds the agent options for a request on top of the server defaults
+func (s *agentServer) requestOptions(r *http.Request) (AgentOptions, error) {
+	var req InvokeRequest
+
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		req = InvokeRequest{
+			AgentID:      query.Get("agentId"),
+			AgentAliasID: query.Get("agentAliasId"),
+			Input:        query.Get("input"),
+			SessionID:    query.Get("sessionId"),
+			MemoryID:     query.Get("memoryId"),
+			Prompt:       query.Get("prompt"),
+			Timeout:      query.Get("timeout"),
+		}
+		for _, v := range query["var"] {
+			key, value, found := strings.Cut(v, "=")
+			if !found {
+				return AgentOptions{}, fmt.Errorf("invalid variable format '%s', expected 'key=value'", v)
+			}
+			if req.Vars == nil {
+				req.Vars = make(map[string]string)
+			}
+			req.Vars[key] = value
+		}
+	} else {
+		decoder := json.NewDecoder(io.LimitReader(r.Body, maxRequestBodySize))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&req); err != nil {
+			return AgentOptions{}, fmt.Errorf("invalid request body: %w", err)
+		}
+	}
+
+	return requestAgentOptions(s.defaults, req)
+}
+
+// requestAgentOptions applies an invoke request on top of default agent options and validates the result
+func requestAgentOptions(defaults AgentOptions, req InvokeRequest) (AgentOptions, error) {
+	opts := defaults
+	if req.AgentID != "" {
+		opts.AgentID = req.AgentID
+	}
+	if req.AgentAliasID != "" {
+		opts.AgentAliasID = req.AgentAliasID
+	}
+	opts.InputText = req.Input
+	opts.SessionID = req.SessionID
+	if req.MemoryID != "" {
+		opts.MemoryID = req.MemoryID
+	}
+	opts.PromptName = req.Prompt
+	opts.PromptVars = nil
+	opts.NoInteractive = true // Nobody is at the terminal to answer for an HTTP request
+	for key, value := range req.Vars {
+		opts.PromptVars = append(opts.PromptVars, key+"="+value)
+	}
+
+	if req.Timeout != "" {
+		timeout, err := time.ParseDuration(req.Timeout)
+		if err != nil {
+			return AgentOptions{}, fmt.Errorf("invalid timeout '%s': %w", req.Timeout, err)
+		}
+		if timeout <= 0 {
+			return AgentOptions{}, fmt.Errorf("timeout must be a positive duration")
+		}
+		opts.Timeout = timeout
+		opts.MaxDurationSet = true
+	}
+
+	if opts.PromptName != "" {
+		if err := processPrompt(&opts); err != nil {
+			return AgentOptions{}, err
+		}
+	}
+
+	if err := validateOptions(opts); err != nil {
+		return AgentOptions{}, err
+	}
+
+	return opts, nil
+}
+
+// writeSSEEvent writes a single Server-Sent Event with a JSON payload
+func writeSSEEvent(w io.Writer, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event data: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	return err
+}
+
+// writeJSON writes v as a JSON document with the given HTTP status code
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeJSONError writes a JSON error message with the given HTTP status code
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,101 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the 'sessions' command group for the AWS Bedrock Intelligent Agents CLI.
+Its subcommands work with the conversations recorded in the local session store, such as
+exporting a session as a Markdown or HTML transcript.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// SessionsExportOptions contains all options for exporting a stored session
+type SessionsExportOptions struct {
+	SessionID  string
+	Format     string
+	OutputFile string
+}
+
+var sessionsExportOpts SessionsExportOptions
+
+// sessionsCmd represents the sessions command
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "Work with sessions recorded in the local session store",
+	Long: `Work with the conversations recorded in the session store.
+
+Every invoke and chat turn is recorded with its input, answer, citations, and
+generated files unless --no-session-store is used.`,
+}
+
+// sessionsExportCmd represents the sessions export command
+var sessionsExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export a stored session as a Markdown or HTML transcript",
+	Long: `Export a stored session as a shareable transcript.
+
+The transcript contains every user input and agent answer, the sources of
+citations (web locations are linked), and the generated files. Markdown
+transcripts link saved files by path; HTML transcripts are a single page
+that embeds saved images.
+
+Examples:
+  # Print a Markdown transcript
+  aws-bia sessions export --session-id session123
+
+  # Write an HTML transcript
+  aws-bia sessions export --session-id session123 --format html --output-file transcript.html`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runSessionsExportCommand(sessionsExportOpts); err != nil {
+			logError("Error exporting session", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sessionsCmd)
+	sessionsCmd.AddCommand(sessionsExportCmd)
+
+	sessionsExportCmd.Flags().StringVar(&sessionsExportOpts.SessionID, "session-id", "", "The session to export")
+	sessionsExportCmd.Flags().StringVar(&sessionsExportOpts.Format, "format", TranscriptFormatMarkdown, "Transcript format: md or html")
+	sessionsExportCmd.Flags().StringVar(&sessionsExportOpts.OutputFile, "output-file", "", "Write the transcript to a file instead of stdout")
+	sessionsExportCmd.MarkFlagRequired("session-id")
+}
+
+// runSessionsExportCommand renders a stored session in the requested format
+func runSessionsExportCommand(opts SessionsExportOptions) error {
+	if opts.Format != TranscriptFormatMarkdown && opts.Format != TranscriptFormatHTML {
+		return fmt.Errorf("unsupported transcript format '%s': must be md or html", opts.Format)
+	}
+
+	store, err := NewSessionStore()
+	if err != nil {
+		return err
+	}
+	session, err := store.Load(opts.SessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		return fmt.Errorf("session '%s' not found in the session store", opts.SessionID)
+	}
+
+	writer, closer, err := PrepareOutput(opts.OutputFile, OutputFileOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to prepare output: %w", err)
+	}
+	if closer != nil {
+		defer closer()
+	}
+
+	if opts.Format == TranscriptFormatHTML {
+		return writeHTMLTranscript(writer, session)
+	}
+	return writeMarkdownTranscript(writer, session)
+}
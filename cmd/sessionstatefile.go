@@ -0,0 +1,282 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements session state files for the 'invoke' command. --session-state-file
+loads session attributes, files, return-control results, and knowledge base settings from
+a JSON document in the shape of the Bedrock API into the SessionState of the request, and
+--save-session-state writes the state to continue with after the invocation. When the agent
+returned control, the saved state carries the invocation ID and a result skeleton for each
+requested call, so a script only fills in the response bodies and invokes again.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/types"
+)
+
+// SessionStateDocument is the JSON form of a session state file
+type SessionStateDocument struct {
+	SessionID                      string                       `json:"sessionId,omitempty"`
+	SessionAttributes              map[string]string            `json:"sessionAttributes,omitempty"`
+	PromptSessionAttributes        map[string]string            `json:"promptSessionAttributes,omitempty"`
+	InvocationID                   string                       `json:"invocationId,omitempty"`
+	ReturnControlInvocationResults []stateInvocationResult      `json:"returnControlInvocationResults,omitempty"`
+	Files                          []stateFile                  `json:"files,omitempty"`
+	KnowledgeBaseConfigurations    []stateKnowledgeBaseSettings `json:"knowledgeBaseConfigurations,omitempty"`
+
+	// Written by --save-session-state for reference, ignored when loading
+	ReturnControl map[string]interface{} `json:"returnControl,omitempty"`
+}
+
+// stateInvocationResult is the result of a function or API call the agent handed back
+type stateInvocationResult struct {
+	FunctionResult *stateActionResult `json:"functionResult,omitempty"`
+	ApiResult      *stateActionResult `json:"apiResult,omitempty"`
+}
+
+// stateActionResult holds the fields of a function result and an API result
+type stateActionResult struct {
+	ActionGroup       string                      `json:"actionGroup"`
+	Function          string                      `json:"function,omitempty"`
+	ApiPath           string                      `json:"apiPath,omitempty"`
+	HttpMethod        string                      `json:"httpMethod,omitempty"`
+	HttpStatusCode    int32                       `json:"httpStatusCode,omitempty"`
+	AgentID           string                      `json:"agentId,omitempty"`
+	ConfirmationState string                      `json:"confirmationState,omitempty"`
+	ResponseState     string                      `json:"responseState,omitempty"`
+	ResponseBody      map[string]stateContentBody `json:"responseBody,omitempty"`
+}
+
+// stateContentBody is a response body of an action result
+type stateContentBody struct {
+	Body string `json:"body"`
+}
+
+// stateFile is a file attached to the session, from S3 or with its content
+type stateFile struct {
+	Name    string `json:"name"`
+	UseCase string `json:"useCase,omitempty"`
+	Source  struct {
+		SourceType string `json:"sourceType,omitempty"`
+		S3Location *struct {
+			URI string `json:"uri"`
+		} `json:"s3Location,omitempty"`
+		ByteContent *struct {
+			MediaType string `json:"mediaType"`
+			Data      []byte `json:"data"` // Base64 in JSON
+		} `json:"byteContent,omitempty"`
+	} `json:"source"`
+}
+
+// stateKnowledgeBaseSettings overrides the retrieval settings of a knowledge base
+type stateKnowledgeBaseSettings struct {
+	KnowledgeBaseID        string `json:"knowledgeBaseId"`
+	RetrievalConfiguration struct {
+		VectorSearchConfiguration struct {
+			NumberOfResults    int32           `json:"numberOfResults,omitempty"`
+			OverrideSearchType string          `json:"overrideSearchType,omitempty"`
+			Filter             json.RawMessage `json:"filter,omitempty"`
+		} `json:"vectorSearchConfiguration"`
+	} `json:"retrievalConfiguration"`
+}
+
+// loadSessionStateFile reads and checks a session state file
+func loadSessionStateFile(path string) (*SessionStateDocument, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session state file '%s': %w", path, err)
+	}
+
+	var doc SessionStateDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid session state file '%s': %w", path, err)
+	}
+	if _, err := doc.sessionState(); err != nil {
+		return nil, fmt.Errorf("invalid session state file '%s': %w", path, err)
+	}
+	return &doc, nil
+}
+
+// sessionState converts the document into the SDK type
+func (doc *SessionStateDocument) sessionState() (*types.SessionState, error) {
+	state := &types.SessionState{
+		SessionAttributes:       doc.SessionAttributes,
+		PromptSessionAttributes: doc.PromptSessionAttributes,
+	}
+	if doc.InvocationID != "" {
+		state.InvocationId = aws.String(doc.InvocationID)
+	}
+
+	for i, result := range doc.ReturnControlInvocationResults {
+		switch {
+		case result.FunctionResult != nil && result.ApiResult == nil:
+			r := result.FunctionResult
+			state.ReturnControlInvocationResults = append(state.ReturnControlInvocationResults,
+				&types.InvocationResultMemberMemberFunctionResult{Value: types.FunctionResult{
+					ActionGroup:       aws.String(r.ActionGroup),
+					Function:          optionalString(r.Function),
+					AgentId:           optionalString(r.AgentID),
+					ConfirmationState: types.ConfirmationState(r.ConfirmationState),
+					ResponseState:     types.ResponseState(r.ResponseState),
+					ResponseBody:      r.contentBodies(),
+				}})
+		case result.ApiResult != nil && result.FunctionResult == nil:
+			r := result.ApiResult
+			apiResult := types.ApiResult{
+				ActionGroup:       aws.String(r.ActionGroup),
+				ApiPath:           optionalString(r.ApiPath),
+				HttpMethod:        optionalString(r.HttpMethod),
+				AgentId:           optionalString(r.AgentID),
+				ConfirmationState: types.ConfirmationState(r.ConfirmationState),
+				ResponseState:     types.ResponseState(r.ResponseState),
+				ResponseBody:      r.contentBodies(),
+			}
+			if r.HttpStatusCode != 0 {
+				apiResult.HttpStatusCode = aws.Int32(r.HttpStatusCode)
+			}
+			state.ReturnControlInvocationResults = append(state.ReturnControlInvocationResults,
+				&types.InvocationResultMemberMemberApiResult{Value: apiResult})
+		default:
+			return nil, fmt.Errorf("returnControlInvocationResults[%d] must have exactly one of functionResult or apiResult", i)
+		}
+	}
+	if len(state.ReturnControlInvocationResults) > 0 && state.InvocationId == nil {
+		return nil, fmt.Errorf("returnControlInvocationResults require the invocationId of the return-control response")
+	}
+
+	for i, file := range doc.Files {
+		inputFile := types.InputFile{Name: aws.String(file.Name), UseCase: types.FileUseCase(file.UseCase)}
+		switch {
+		case file.Source.S3Location != nil:
+			inputFile.Source = &types.FileSource{
+				SourceType: types.FileSourceTypeS3,
+				S3Location: &types.S3ObjectFile{Uri: aws.String(file.Source.S3Location.URI)},
+			}
+		case file.Source.ByteContent != nil:
+			inputFile.Source = &types.FileSource{
+				SourceType: types.FileSourceTypeByteContent,
+				ByteContent: &types.ByteContentFile{
+					Data:      file.Source.ByteContent.Data,
+					MediaType: aws.String(file.Source.ByteContent.MediaType),
+				},
+			}
+		default:
+			return nil, fmt.Errorf("files[%d] needs an s3Location or byteContent source", i)
+		}
+		state.Files = append(state.Files, inputFile)
+	}
+
+	for i, kb := range doc.KnowledgeBaseConfigurations {
+		settings := kb.RetrievalConfiguration.VectorSearchConfiguration
+		search := &types.KnowledgeBaseVectorSearchConfiguration{}
+		if settings.NumberOfResults > 0 {
+			search.NumberOfResults = aws.Int32(settings.NumberOfResults)
+		}
+		if settings.OverrideSearchType != "" {
+			searchType, err := parseSearchType(settings.OverrideSearchType)
+			if err != nil {
+				return nil, fmt.Errorf("knowledgeBaseConfigurations[%d]: %w", i, err)
+			}
+			search.OverrideSearchType = searchType
+		}
+		if len(settings.Filter) > 0 {
+			filter, err := loadRetrievalFilter(string(settings.Filter))
+			if err != nil {
+				return nil, fmt.Errorf("knowledgeBaseConfigurations[%d]: %w", i, err)
+			}
+			search.Filter = filter
+		}
+		state.KnowledgeBaseConfigurations = append(state.KnowledgeBaseConfigurations, types.KnowledgeBaseConfiguration{
+			KnowledgeBaseId:        aws.String(kb.KnowledgeBaseID),
+			RetrievalConfiguration: &types.KnowledgeBaseRetrievalConfiguration{VectorSearchConfiguration: search},
+		})
+	}
+	return state, nil
+}
+
+// contentBodies converts the response bodies of an action result
+func (r *stateActionResult) contentBodies() map[string]types.ContentBody {
+	if len(r.ResponseBody) == 0 {
+		return nil
+	}
+	bodies := make(map[string]types.ContentBody, len(r.ResponseBody))
+	for contentType, body := range r.ResponseBody {
+		bodies[contentType] = types.ContentBody{Body: aws.String(body.Body)}
+	}
+	return bodies
+}
+
+// optionalString returns nil for an empty string, so it is left out of the request
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return aws.String(value)
+}
+
+// saveSessionStateFile writes the state to continue the session with after an invocation.
+// Session attributes and knowledge base settings persist, prompt session attributes, files,
+// and return-control results only apply to the invocation they were sent with.
+func saveSessionStateFile(path string, opts AgentOptions, output *bedrockagentruntime.InvokeAgentOutput, result StreamResult) error {
+	doc := SessionStateDocument{}
+	if output != nil {
+		doc.SessionID = aws.ToString(output.SessionId)
+	}
+	if doc.SessionID == "" {
+		doc.SessionID = opts.SessionID
+	}
+
+	if loaded := opts.SessionStateDocument; loaded != nil {
+		doc.SessionAttributes = loaded.SessionAttributes
+		doc.KnowledgeBaseConfigurations = loaded.KnowledgeBaseConfigurations
+	}
+
+	// Answering a return-control response needs its invocation ID and one result per requested call
+	if payload := result.ReturnControl; payload != nil {
+		doc.InvocationID = aws.ToString(payload.InvocationId)
+		doc.ReturnControl = returnControlPayloadJSON(*payload)
+		doc.ReturnControlInvocationResults = invocationResultSkeletons(*payload)
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal session state: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write session state '%s': %w", path, err)
+	}
+	return nil
+}
+
+// invocationResultSkeletons returns a result to fill in for every call requested by the agent
+func invocationResultSkeletons(payload types.ReturnControlPayload) []stateInvocationResult {
+	var results []stateInvocationResult
+	for _, member := range payload.InvocationInputs {
+		switch v := member.(type) {
+		case *types.InvocationInputMemberMemberFunctionInvocationInput:
+			results = append(results, stateInvocationResult{FunctionResult: &stateActionResult{
+				ActionGroup:  aws.ToString(v.Value.ActionGroup),
+				Function:     aws.ToString(v.Value.Function),
+				AgentID:      aws.ToString(v.Value.AgentId),
+				ResponseBody: map[string]stateContentBody{"TEXT": {}},
+			}})
+		case *types.InvocationInputMemberMemberApiInvocationInput:
+			results = append(results, stateInvocationResult{ApiResult: &stateActionResult{
+				ActionGroup:    aws.ToString(v.Value.ActionGroup),
+				ApiPath:        aws.ToString(v.Value.ApiPath),
+				HttpMethod:     strings.ToUpper(aws.ToString(v.Value.HttpMethod)),
+				HttpStatusCode: 200,
+				AgentID:        aws.ToString(v.Value.AgentId),
+				ResponseBody:   map[string]stateContentBody{"application/json": {}},
+			}})
+		}
+	}
+	return results
+}
@@ -0,0 +1,279 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the local session store for the AWS Bedrock Intelligent Agents CLI.
+Every invoke and chat turn is recorded in sessions/<session-id>.json of the data directory
+(~/.local/share/aws-bia by default) with the agent, the turns of the conversation, and
+when the session was last used, so commands can warn about sessions the service has
+most likely expired.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagent"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime"
+)
+
+// StoredSession is a conversation recorded in the session store
+type StoredSession struct {
+	SessionID      string       `json:"sessionId"`
+	AgentID        string       `json:"agentId"`
+	AgentAliasID   string       `json:"agentAliasId"`
+	CreatedAt      time.Time    `json:"createdAt"`
+	LastUsedAt     time.Time    `json:"lastUsedAt"`
+	IdleSessionTTL int32        `json:"idleSessionTtlSeconds,omitempty"` // From the agent definition, 0 if unknown
+	Turns          []StoredTurn `json:"turns"`
+}
+
+// StoredTurn is a single input and answer of a stored session
+type StoredTurn struct {
+	Time       time.Time          `json:"time"`
+	Input      string             `json:"input"`
+	Answer     string             `json:"answer"`
+	Citations  []TemplateCitation `json:"citations,omitempty"`
+	Files      []TemplateFile     `json:"files,omitempty"`
+	SavedFiles []string           `json:"savedFiles,omitempty"`
+	Usage      TokenUsage         `json:"usage"`
+
+	// How the turn was invoked, for 'rerun'; only recorded for invoke
+	Command []string `json:"command,omitempty"`
+	Dir     string   `json:"dir,omitempty"`
+}
+
+// SessionStore reads and writes stored sessions in a directory
+type SessionStore struct {
+	dir string
+}
+
+// NewSessionStore opens the session store in the sessions directory of the data directory
+func NewSessionStore() (*SessionStore, error) {
+	dir, err := dataPath("sessions")
+	if err != nil {
+		return nil, err
+	}
+	return &SessionStore{dir: dir}, nil
+}
+
+// path returns the file of a session; ':' is not allowed in file names on every platform
+func (s *SessionStore) path(sessionID string) string {
+	return filepath.Join(s.dir, strings.ReplaceAll(filepath.Base(sessionID), ":", "_")+".json")
+}
+
+// Load returns the stored session, or nil if the session is not in the store
+func (s *SessionStore) Load(sessionID string) (*StoredSession, error) {
+	data, err := os.ReadFile(s.path(sessionID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stored session '%s': %w", sessionID, err)
+	}
+
+	var session StoredSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("failed to parse stored session '%s': %w", sessionID, err)
+	}
+	return &session, nil
+}
+
+// Save writes the session to the store
+func (s *SessionStore) Save(session *StoredSession) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create session store directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+	if err := os.WriteFile(s.path(session.SessionID), data, 0600); err != nil {
+		return fmt.Errorf("failed to write stored session '%s': %w", session.SessionID, err)
+	}
+	return nil
+}
+
+// List returns all stored sessions, most recently used first
+func (s *SessionStore) List() ([]*StoredSession, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session store: %w", err)
+	}
+
+	sessions := make([]*StoredSession, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		session, err := s.Load(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			LogWarn("Skipping unreadable stored session %s: %v", entry.Name(), err)
+			continue
+		}
+		if session != nil {
+			sessions = append(sessions, session)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+	return sessions, nil
+}
+
+// RecordTurn appends a turn to the session, creating the session when it is new
+func (s *SessionStore) RecordTurn(opts AgentOptions, output *bedrockagentruntime.InvokeAgentOutput, result StreamResult) error {
+	sessionID := aws.ToString(output.SessionId)
+	session, err := s.Load(sessionID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	if session == nil {
+		session = &StoredSession{
+			SessionID:      sessionID,
+			AgentID:        opts.AgentID,
+			AgentAliasID:   opts.AgentAliasID,
+			CreatedAt:      now,
+			IdleSessionTTL: int32(opts.SessionTTL / time.Second),
+		}
+	}
+	session.LastUsedAt = now
+
+	// Absolute paths keep links to saved files working from exported transcripts
+	savedFiles := make([]string, 0, len(result.SavedFiles))
+	for _, path := range result.SavedFiles {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		savedFiles = append(savedFiles, path)
+	}
+
+	var dir string
+	if len(opts.CommandArgs) > 0 {
+		dir, _ = os.Getwd()
+	}
+
+	// Reuse the template conversion so stored turns match what templates see
+	data := newTemplateResponse(output, result, savedFiles)
+	session.Turns = append(session.Turns, StoredTurn{
+		Time:       now,
+		Input:      opts.InputText,
+		Answer:     result.Text,
+		Citations:  data.Citations,
+		Files:      data.Files,
+		SavedFiles: data.SavedFiles,
+		Usage:      result.Usage,
+		Command:    opts.CommandArgs,
+		Dir:        dir,
+	})
+
+	return s.Save(session)
+}
+
+// recordSessionTurn stores a completed turn unless the session store is disabled or the turn was replayed
+func recordSessionTurn(opts AgentOptions, output *bedrockagentruntime.InvokeAgentOutput, result StreamResult) {
+	if opts.NoSessionStore || opts.ReplayFile != "" || output == nil || output.SessionId == nil {
+		return
+	}
+
+	store, err := NewSessionStore()
+	if err == nil {
+		err = store.RecordTurn(opts, output, result)
+	}
+	if err != nil {
+		opts.Warnings.Warn(WarningSessionStore, "error recording session", err)
+	}
+}
+
+// sessionExpiredBy returns how long ago an idle session most likely expired, or 0 if it did not
+func (session *StoredSession) sessionExpiredBy(now time.Time) time.Duration {
+	if session == nil || session.IdleSessionTTL <= 0 || session.LastUsedAt.IsZero() {
+		return 0
+	}
+	expiry := session.LastUsedAt.Add(time.Duration(session.IdleSessionTTL) * time.Second)
+	if now.Before(expiry) {
+		return 0
+	}
+	return now.Sub(expiry)
+}
+
+// checkSessionExpiry warns before reusing a session the service has most likely expired
+// and, when the user agrees, clears the session ID so a fresh session is started
+func checkSessionExpiry(ctx context.Context, store *SessionStore, opts *AgentOptions) error {
+	if opts.SessionID == "" {
+		return nil
+	}
+
+	session, err := store.Load(opts.SessionID)
+	if err != nil || session == nil {
+		return err
+	}
+
+	// The TTL comes from --session-ttl, otherwise from the agent definition once per session
+	if opts.SessionTTL > 0 {
+		session.IdleSessionTTL = int32(opts.SessionTTL / time.Second)
+	} else if session.IdleSessionTTL == 0 {
+		ttl, err := fetchIdleSessionTTL(ctx, *opts, session.AgentID)
+		if err != nil {
+			logVerbose(*opts, "Could not determine idle session TTL: %v", err)
+			return nil
+		}
+		session.IdleSessionTTL = ttl
+		if err := store.Save(session); err != nil {
+			LogWarn("Could not update stored session: %v", err)
+		}
+	}
+
+	expiredBy := session.sessionExpiredBy(time.Now())
+	if expiredBy == 0 {
+		return nil
+	}
+
+	question := fmt.Sprintf("Session %s likely expired %d minutes ago, starting fresh?",
+		opts.SessionID, int(expiredBy.Minutes()))
+	if !isTerminal(os.Stdin) {
+		LogWarn("%s (not asking, stdin is not a terminal)", question)
+		return nil
+	}
+
+	fresh, err := confirmAction(question)
+	if err != nil {
+		return err
+	}
+	if fresh {
+		opts.SessionID = ""
+	}
+	return nil
+}
+
+// fetchIdleSessionTTL returns the idle session timeout configured on the agent, in seconds
+func fetchIdleSessionTTL(ctx context.Context, opts AgentOptions, agentID string) (int32, error) {
+	if agentID == "" {
+		agentID = opts.AgentID
+	}
+
+	client, err := NewAWSHelper(opts).CreateAgentClient(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	out, err := client.GetAgent(ctx, &bedrockagent.GetAgentInput{AgentId: aws.String(agentID)})
+	if err != nil {
+		return 0, HandleAWSError(fmt.Errorf("failed to get agent: %w", err))
+	}
+	return aws.ToInt32(out.Agent.IdleSessionTTLInSeconds), nil
+}
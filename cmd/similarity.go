@@ -0,0 +1,93 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file contains text similarity helpers for the AWS Bedrock Intelligent Agents CLI.
+They are used to detect when several agent targets return effectively the same
+answer so that reports can collapse duplicates and focus on real differences, and
+to grade free-form answers against a reference text with expect-similar.
+*/
+package cmd
+
+import (
+	"strings"
+	"unicode"
+)
+
+// DefaultDedupThreshold is the similarity above which two answers are treated as identical
+const DefaultDedupThreshold = 0.95
+
+// DefaultSimilarityThreshold is the similarity an answer needs to pass an expect-similar assertion
+const DefaultSimilarityThreshold = 0.8
+
+// ResponseGroup is a set of targets whose answers are effectively identical
+type ResponseGroup struct {
+	Members    []int   // Indexes of the responses in the group, first one is the representative
+	Similarity float64 // Lowest similarity between a member and the representative
+}
+
+// normalizeResponseTokens lowercases the text, drops punctuation, and splits it into words
+func normalizeResponseTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// ResponseSimilarity returns a score between 0 and 1 describing how much two answers
+// overlap, using the Sørensen–Dice coefficient over normalized word counts
+func ResponseSimilarity(a, b string) float64 {
+	return tokenSimilarity(normalizeResponseTokens(a), normalizeResponseTokens(b))
+}
+
+// tokenSimilarity computes the Sørensen–Dice coefficient of two token multisets
+func tokenSimilarity(a, b []string) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	counts := make(map[string]int, len(a))
+	for _, token := range a {
+		counts[token]++
+	}
+
+	common := 0
+	for _, token := range b {
+		if counts[token] > 0 {
+			counts[token]--
+			common++
+		}
+	}
+
+	return 2 * float64(common) / float64(len(a)+len(b))
+}
+
+// GroupSimilarResponses groups responses whose similarity to the first response of a
+// group is at least threshold. Groups keep the order in which responses were given.
+func GroupSimilarResponses(responses []string, threshold float64) []ResponseGroup {
+	tokens := make([][]string, len(responses))
+	for i, response := range responses {
+		tokens[i] = normalizeResponseTokens(response)
+	}
+
+	var groups []ResponseGroup
+	for i := range responses {
+		placed := false
+		for g := range groups {
+			representative := groups[g].Members[0]
+			score := tokenSimilarity(tokens[representative], tokens[i])
+			if score >= threshold {
+				groups[g].Members = append(groups[g].Members, i)
+				groups[g].Similarity = min(groups[g].Similarity, score)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			groups = append(groups, ResponseGroup{Members: []int{i}, Similarity: 1})
+		}
+	}
+
+	return groups
+}
@@ -0,0 +1,304 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the 'agent smoke-test' command for AWS Bedrock Intelligent Agents CLI.
+It sends a probe prompt in a new session and checks that an answer arrives within a
+latency budget, matches every --expect pattern, and matches no --reject pattern. A failed
+check exits with ExitCodeSmokeTestFailed, for post-deployment verification in pipelines.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// Default prompt sent by the smoke test
+	DefaultSmokeProbe = "Hello"
+
+	// Default time the answer to the probe may take
+	DefaultLatencyBudget = 30 * time.Second
+
+	// ExitCodeSmokeTestFailed is the exit status when the agent answered but a check failed.
+	// It is distinct from the exit status 1 of invocation and configuration errors.
+	ExitCodeSmokeTestFailed = 4
+)
+
+// ErrSmokeTestFailed reports a smoke test with at least one failed check
+var ErrSmokeTestFailed = errors.New("smoke test failed")
+
+// SmokeTestOptions contains all options for smoke-testing an agent
+type SmokeTestOptions struct {
+	ConfigFile    string
+	AgentID       string
+	AgentAliasID  string
+	Region        string
+	EndpointURL   string
+	Probe         string
+	LatencyBudget time.Duration
+	Expect        []string
+	Reject        []string
+	Timeout       time.Duration
+	TimeoutSet    bool
+	OutputFormat  string
+	Verbose       bool
+}
+
+// smokeCheck is the outcome of one assertion of the smoke test
+type smokeCheck struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+var smokeTestOpts SmokeTestOptions
+
+// agentSmokeTestCmd represents the agent smoke-test command
+var agentSmokeTestCmd = &cobra.Command{
+	Use:   "smoke-test",
+	Short: "Invoke an agent with a probe prompt and check the answer",
+	Long: `Invoke an agent with a probe prompt in a new session and check the answer.
+
+The test passes when an answer arrives within --latency-budget, matches every
+--expect regular expression, and matches none of the --reject expressions. Use
+(?i) in a pattern to match regardless of case. When a check fails the command
+exits with status 4, errors such as a missing alias exit with status 1.
+
+The probe, patterns, and budget can be set in the smoke_test section of the
+configuration file:
+
+  smoke_test:
+    probe: "What is your return policy?"
+    latency_budget: 20s
+    expect: ["(?i)return"]
+    reject: ["(?i)error", "(?i)I don't know"]
+
+Examples:
+  # Check that the staging alias answers within 10 seconds
+  aws-bia agent smoke-test --agent-id abc123 --agent-alias-id staging --latency-budget 10s
+
+  # Assert on the content of the answer
+  aws-bia agent smoke-test --agent-id abc123 --agent-alias-id staging \
+    --probe "What is 2+2?" --expect "\b4\b" --reject "(?i)sorry"
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		smokeTestOpts.TimeoutSet = cmd.Flags().Changed("timeout")
+		if err := runSmokeTestCommand(ctx, smokeTestOpts); err != nil {
+			if errors.Is(err, ErrSmokeTestFailed) {
+				os.Exit(ExitCodeSmokeTestFailed)
+			}
+			logError("Error running smoke test", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	agentCmd.AddCommand(agentSmokeTestCmd)
+
+	agentSmokeTestCmd.Flags().StringVar(&smokeTestOpts.ConfigFile, "config", "", "Path to configuration file (yaml)")
+	agentSmokeTestCmd.Flags().StringVar(&smokeTestOpts.AgentID, "agent-id", "", "The ID of the agent to test (can be set in config file)")
+	agentSmokeTestCmd.Flags().StringVar(&smokeTestOpts.AgentAliasID, "agent-alias-id", "", "The ID of the agent alias to test (can be set in config file)")
+	agentSmokeTestCmd.Flags().StringVar(&smokeTestOpts.Region, "region", "", "AWS region to use (defaults to AWS_REGION environment variable)")
+	agentSmokeTestCmd.Flags().StringVar(&smokeTestOpts.EndpointURL, "endpoint-url", "", "Send agent runtime requests to this URL instead of the regional endpoint (e.g. a VPC endpoint or local mock)")
+	agentSmokeTestCmd.Flags().StringVar(&smokeTestOpts.Probe, "probe", DefaultSmokeProbe, "Prompt sent to the agent (can be set in config file)")
+	agentSmokeTestCmd.Flags().DurationVar(&smokeTestOpts.LatencyBudget, "latency-budget", DefaultLatencyBudget, "Maximum time until the whole answer has arrived")
+	agentSmokeTestCmd.Flags().StringArrayVar(&smokeTestOpts.Expect, "expect", []string{}, "Regular expression the answer must match (repeatable)")
+	agentSmokeTestCmd.Flags().StringArrayVar(&smokeTestOpts.Reject, "reject", []string{}, "Regular expression the answer must not match (repeatable)")
+	agentSmokeTestCmd.Flags().DurationVar(&smokeTestOpts.Timeout, "timeout", DefaultTimeout, "Give up on the invocation after this long")
+	agentSmokeTestCmd.Flags().StringVar(&smokeTestOpts.OutputFormat, "format", OutputFormatText, "Output format: text or json")
+	agentSmokeTestCmd.Flags().BoolVar(&smokeTestOpts.Verbose, "verbose", false, "Enable verbose output")
+
+	registerAgentCompletions(agentSmokeTestCmd)
+}
+
+// runSmokeTestCommand invokes the agent with the probe and reports the checks
+func runSmokeTestCommand(ctx context.Context, opts SmokeTestOptions) error {
+	InitLogger(opts.Verbose)
+	defer SyncLogger()
+
+	v, err := LoadConfigForCommand(opts.ConfigFile, "agent", opts.Verbose)
+	if err != nil {
+		return err
+	}
+
+	agentOpts := AgentOptions{
+		AgentID:        opts.AgentID,
+		AgentAliasID:   opts.AgentAliasID,
+		SessionID:      uuid.New().String(), // A fresh session, the probe should not depend on earlier turns
+		Region:         opts.Region,
+		EndpointURL:    opts.EndpointURL,
+		Timeout:        opts.Timeout,
+		MaxDurationSet: opts.TimeoutSet,
+		ConnectTimeout: DefaultConnectTimeout,
+		OutputFormat:   OutputFormatText,
+		Verbose:        opts.Verbose,
+	}
+	applyAgentConfig(v, &agentOpts)
+	applyTimeoutSetting(v, &agentOpts)
+	agentOpts.Color = v.GetString("color")
+
+	// Flags still at their defaults are overridden by the smoke_test section
+	if v.IsSet("smoke_test.probe") && opts.Probe == DefaultSmokeProbe {
+		opts.Probe = v.GetString("smoke_test.probe")
+	}
+	if v.IsSet("smoke_test.latency_budget") && opts.LatencyBudget == DefaultLatencyBudget {
+		opts.LatencyBudget = v.GetDuration("smoke_test.latency_budget")
+	}
+	if v.IsSet("smoke_test.expect") && len(opts.Expect) == 0 {
+		opts.Expect = v.GetStringSlice("smoke_test.expect")
+	}
+	if v.IsSet("smoke_test.reject") && len(opts.Reject) == 0 {
+		opts.Reject = v.GetStringSlice("smoke_test.reject")
+	}
+
+	if agentOpts.AgentID == "" {
+		return fmt.Errorf("agent ID is required")
+	}
+	if agentOpts.AgentAliasID == "" {
+		return fmt.Errorf("agent alias ID is required")
+	}
+	if strings.TrimSpace(opts.Probe) == "" {
+		return fmt.Errorf("probe prompt must not be empty")
+	}
+	if opts.LatencyBudget <= 0 {
+		return fmt.Errorf("latency budget must be a positive duration")
+	}
+	if agentOpts.Timeout <= 0 {
+		return fmt.Errorf("timeout must be a positive duration")
+	}
+	if opts.OutputFormat != OutputFormatText && opts.OutputFormat != OutputFormatJSON {
+		return fmt.Errorf("output format must be one of: %s, %s, got '%s'",
+			OutputFormatText, OutputFormatJSON, opts.OutputFormat)
+	}
+	expect, err := compilePatterns("expect", opts.Expect)
+	if err != nil {
+		return err
+	}
+	reject, err := compilePatterns("reject", opts.Reject)
+	if err != nil {
+		return err
+	}
+	agentOpts.InputText = opts.Probe
+
+	logVerbose(agentOpts, "Smoke-testing agent %s (alias %s) in session %s", agentOpts.AgentID, agentOpts.AgentAliasID, agentOpts.SessionID)
+	answer, sessionID, latency, err := invokeProbe(ctx, agentOpts)
+	if err != nil {
+		return err
+	}
+
+	checks := []smokeCheck{{
+		Name:   "response received",
+		Passed: strings.TrimSpace(answer) != "",
+		Detail: fmt.Sprintf("%s characters", formatCount(int64(len([]rune(answer))))),
+	}, {
+		Name:   "latency within budget",
+		Passed: latency <= opts.LatencyBudget,
+		Detail: fmt.Sprintf("%s of %s", formatDuration(latency), formatDuration(opts.LatencyBudget)),
+	}}
+	for _, pattern := range expect {
+		checks = append(checks, smokeCheck{Name: "contains /" + pattern.String() + "/", Passed: pattern.MatchString(answer)})
+	}
+	for _, pattern := range reject {
+		check := smokeCheck{Name: "excludes /" + pattern.String() + "/", Passed: true}
+		if match := pattern.FindString(answer); match != "" {
+			check.Passed, check.Detail = false, fmt.Sprintf("found %q", match)
+		}
+		checks = append(checks, check)
+	}
+
+	passed := true
+	for _, check := range checks {
+		passed = passed && check.Passed
+	}
+
+	if opts.OutputFormat == OutputFormatJSON {
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"passed":    passed,
+			"agentId":   agentOpts.AgentID,
+			"aliasId":   agentOpts.AgentAliasID,
+			"sessionId": sessionID,
+			"latencyMs": latency.Milliseconds(),
+			"response":  answer,
+			"checks":    checks,
+		}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal smoke test result: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		color := colorizer{enabled: useColor(agentOpts.Color, os.Stdout)}
+		for _, check := range checks {
+			status := color.Notice("PASS")
+			if !check.Passed {
+				status = color.Error("FAIL")
+			}
+			line := fmt.Sprintf("%s  %s", status, check.Name)
+			if check.Detail != "" {
+				line += " (" + check.Detail + ")"
+			}
+			fmt.Println(line)
+		}
+		if !passed {
+			fmt.Printf("\nResponse:\n%s\n", answer)
+		}
+	}
+
+	if !passed {
+		return ErrSmokeTestFailed
+	}
+	return nil
+}
+
+// invokeProbe sends the probe and returns the whole answer, the session ID, and the time it took
+func invokeProbe(ctx context.Context, opts AgentOptions) (string, string, time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	output, err := invokeAgent(ctx, NewAWSHelper(opts))
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	stream := output.GetStream()
+	if stream == nil {
+		return "", "", 0, fmt.Errorf("no response stream available")
+	}
+	defer stream.Close()
+
+	result, err := NewStreamProcessor(opts, io.Discard, false).ProcessStream(stream)
+	if err != nil {
+		return "", "", 0, err
+	}
+	return result.Text, aws.ToString(output.SessionId), time.Since(start), nil
+}
+
+// compilePatterns compiles the regular expressions given with the named flag
+func compilePatterns(flag string, patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s pattern '%s': %w", flag, pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
@@ -0,0 +1,247 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements --split-large-files for the 'invoke' command. Text and CSV upload
+files that do not fit into the 10MB limit of a request are split at line boundaries
+into parts that are sent one per turn in the same session. Every part but the last
+asks the agent to wait, and the actual input is sent with the last part, so the agent
+can put the file back together before analyzing it, e.g. with the code interpreter.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// minSplitPartSize is the smallest part worth a turn of its own
+const minSplitPartSize = 64 * 1024
+
+// splitPart is one piece of a split upload file, carried as a data: URI
+type splitPart struct {
+	File  string // Name of the original file
+	Index int    // 1-based position within the file
+	Count int    // Number of parts of the file
+	Entry string // data: URI upload entry of the part
+}
+
+// sendSplitUploads splits the local text upload files that do not fit into one request and sends
+// all parts but the last in preceding turns. The options are left ready for the final turn.
+func sendSplitUploads(ctx context.Context, opts *AgentOptions) error {
+	parts, err := planSplitUploads(opts)
+	if err != nil || len(parts) == 0 {
+		return err
+	}
+
+	// Every part goes to the same session, so a new one is started up front
+	if opts.SessionID == "" {
+		opts.SessionID = uuid.New().String()
+		logVerbose(*opts, "Generated session ID for the split upload: %s", opts.SessionID)
+	}
+
+	for _, part := range parts[:len(parts)-1] {
+		if !opts.Quiet {
+			fmt.Fprintf(os.Stderr, "Uploading part %d of %d of %s\n", part.Index, part.Count, part.File)
+		}
+
+		turnOpts := *opts
+		turnOpts.UploadFiles = []string{part.Entry}
+		turnOpts.InputText = splitPartMessage(part)
+		turnOpts.FilesOutputDir = ""
+		turnOpts.Cache = false
+
+		// The acknowledgements of the agent are not part of the answer
+		formatter := NewResponseFormatter(turnOpts, io.Discard)
+		if _, err := runInvokeTurn(ctx, turnOpts, NewAWSHelper(turnOpts), formatter); err != nil {
+			return fmt.Errorf("failed to upload part %d of %d of '%s': %w", part.Index, part.Count, part.File, err)
+		}
+	}
+
+	last := parts[len(parts)-1]
+	opts.UploadFiles = append(opts.UploadFiles, last.Entry)
+	opts.InputText = splitFinalMessage(parts) + opts.InputText
+	return nil
+}
+
+// planSplitUploads splits the text files that do not fit next to the other upload files.
+// The split files are removed from the upload entries, which then hold what the final turn sends along.
+func planSplitUploads(opts *AgentOptions) ([]splitPart, error) {
+	if !opts.SplitLargeFiles || opts.ReplayFile != "" {
+		return nil, nil
+	}
+
+	// Remote, inline, and binary files always go with the final turn. The size of S3 objects
+	// is not known without a request, it is checked when the final turn is prepared.
+	var fixed int64
+	var candidates []string
+	sizes := make(map[string]int64)
+	for _, entry := range opts.UploadFiles {
+		if isS3URI(entry) {
+			continue
+		}
+		if isDataURI(entry) {
+			_, _, data, err := parseDataURI(entry)
+			if err != nil {
+				return nil, err
+			}
+			fixed += int64(len(data))
+			continue
+		}
+		info, err := os.Stat(entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get file info for '%s': %w", entry, err)
+		}
+		sizes[entry] = info.Size()
+		if isSplittableFile(entry) {
+			candidates = append(candidates, entry)
+		} else {
+			fixed += info.Size()
+		}
+	}
+
+	total := fixed
+	for _, entry := range candidates {
+		total += sizes[entry]
+	}
+	if total <= MaxUploadSize {
+		return nil, nil
+	}
+
+	// Text files that still fit stay whole, the rest are split
+	var split []string
+	for _, entry := range candidates {
+		if fixed+sizes[entry] <= MaxUploadSize {
+			fixed += sizes[entry]
+		} else {
+			split = append(split, entry)
+		}
+	}
+	if len(split) == 0 {
+		return nil, nil
+	}
+	partLimit := MaxUploadSize - fixed
+	if partLimit < minSplitPartSize {
+		return nil, fmt.Errorf("the other upload files leave only %s per request for the parts of split files", formatSize(partLimit))
+	}
+
+	var parts []splitPart
+	for _, entry := range split {
+		content, err := os.ReadFile(entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file '%s': %w", entry, err)
+		}
+		chunks, err := splitTextContent(entry, content, partLimit)
+		if err != nil {
+			return nil, err
+		}
+
+		name := filepath.Base(entry)
+		ext := filepath.Ext(name)
+		for i, chunk := range chunks {
+			partName := fmt.Sprintf("%s.part%dof%d%s", strings.TrimSuffix(name, ext), i+1, len(chunks), ext)
+			parts = append(parts, splitPart{
+				File:  name,
+				Index: i + 1,
+				Count: len(chunks),
+				Entry: DataURIPrefix + ";name=" + url.PathEscape(partName) + ";base64," + base64.StdEncoding.EncodeToString(chunk),
+			})
+		}
+		logVerbose(*opts, "Split '%s' (%s) into %d parts of at most %s", entry, formatSize(sizes[entry]), len(chunks), formatSize(partLimit))
+	}
+
+	kept := make([]string, 0, len(opts.UploadFiles))
+	for _, entry := range opts.UploadFiles {
+		if !slices.Contains(split, entry) {
+			kept = append(kept, entry)
+		}
+	}
+	opts.UploadFiles = kept
+	return parts, nil
+}
+
+// splitTextContent cuts content at line boundaries into chunks of at most limit bytes.
+// The header row of CSV and TSV files is repeated in every chunk.
+func splitTextContent(path string, content []byte, limit int64) ([][]byte, error) {
+	var header []byte
+	if isDelimitedFile(path) {
+		if end := bytes.IndexByte(content, '\n'); end != -1 {
+			header, content = content[:end+1], content[end+1:]
+		}
+	}
+	room := int(limit) - len(header)
+
+	var chunks [][]byte
+	for len(content) > 0 {
+		size := min(room, len(content))
+		if size < len(content) {
+			end := bytes.LastIndexByte(content[:size], '\n')
+			if end == -1 {
+				return nil, fmt.Errorf("cannot split '%s': a line is longer than %s", path, formatSize(int64(room)))
+			}
+			size = end + 1
+		}
+		chunk := make([]byte, 0, len(header)+size)
+		chunk = append(append(chunk, header...), content[:size]...)
+		chunks = append(chunks, chunk)
+		content = content[size:]
+	}
+	return chunks, nil
+}
+
+// isSplittableFile reports whether an upload file is text that can be split at line boundaries
+func isSplittableFile(path string) bool {
+	if isDelimitedFile(path) {
+		return true
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(file, head)
+	return strings.HasPrefix(DetectMimeType(path, head[:n]), "text/")
+}
+
+// isDelimitedFile reports whether a file is CSV or TSV, whose first line is a header row
+func isDelimitedFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv", ".tsv":
+		return true
+	}
+	return false
+}
+
+// splitPartMessage is the input sent with a part that is not the last one
+func splitPartMessage(part splitPart) string {
+	message := fmt.Sprintf("This message carries part %d of %d of the file %s, which was split because it exceeds the upload size limit.",
+		part.Index, part.Count, part.File)
+	if isDelimitedFile(part.File) {
+		message += " Every part repeats the header row."
+	}
+	return message + " More parts follow. Do not analyze anything yet, reply only with \"OK\"."
+}
+
+// splitFinalMessage introduces the actual input sent with the last part
+func splitFinalMessage(parts []splitPart) string {
+	var files []string
+	for _, part := range parts {
+		if part.Index == part.Count {
+			files = append(files, fmt.Sprintf("%s (%d parts)", part.File, part.Count))
+		}
+	}
+	return fmt.Sprintf("This message carries the last part of the split file(s) %s. "+
+		"Combine the parts of each file in order, keeping a repeated header row only once, and treat the result as the complete file.\n\n",
+		strings.Join(files, ", "))
+}
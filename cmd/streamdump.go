@@ -0,0 +1,364 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements stream dumps for the AWS Bedrock Intelligent Agents CLI. A dump
+captures every raw event-stream frame of an InvokeAgent response together with the time
+it arrived, so the response can be fed through the stream processor again with the
+original frame boundaries and pacing, or faster.
+*/
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime"
+)
+
+// streamDumpMagic starts every stream dump file, the last byte is the format version
+var streamDumpMagic = []byte("BIASTRM\x01")
+
+// maxStreamFrameSize guards against reading a corrupt dump into memory
+const maxStreamFrameSize = 64 << 20
+
+// StreamDumpHeader describes the response a stream dump was taken from
+type StreamDumpHeader struct {
+	RecordedAt   time.Time   `json:"recordedAt"`
+	AgentID      string      `json:"agentId"`
+	AgentAliasID string      `json:"agentAliasId"`
+	SessionID    string      `json:"sessionId"`
+	StatusCode   int         `json:"statusCode"`
+	Header       http.Header `json:"header"`
+}
+
+// StreamFrame is one raw event-stream message and when it arrived after the response headers
+type StreamFrame struct {
+	Offset time.Duration
+	Data   []byte
+}
+
+// StreamDump is a stream dump file loaded into memory
+type StreamDump struct {
+	Header StreamDumpHeader
+	Frames []StreamFrame
+}
+
+// StreamDumper writes the frames of an InvokeAgent response to a dump file as they arrive.
+// Frames are written unbuffered, so a dump of a hung or killed invocation is still usable.
+type StreamDumper struct {
+	mu      sync.Mutex
+	path    string
+	opts    AgentOptions
+	file    *os.File
+	start   time.Time
+	pending []byte // Bytes of a frame that has not been received completely yet
+	err     error
+}
+
+// NewStreamDumper creates a dumper that writes to path once the response arrives
+func NewStreamDumper(path string, opts AgentOptions) *StreamDumper {
+	return &StreamDumper{path: path, opts: opts}
+}
+
+// wrapHTTPClient returns an HTTP client that dumps the frames of every response
+func (d *StreamDumper) wrapHTTPClient(next bedrockagentruntime.HTTPClient) bedrockagentruntime.HTTPClient {
+	return &dumpingHTTPClient{next: next, dumper: d}
+}
+
+// begin creates the dump file and writes the header of a response
+func (d *StreamDumper) begin(resp *http.Response) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.file != nil {
+		d.file.Close()
+	}
+
+	file, err := os.Create(d.path)
+	if err != nil {
+		return fmt.Errorf("failed to create stream dump '%s': %w", d.path, err)
+	}
+
+	header, err := json.Marshal(StreamDumpHeader{
+		RecordedAt:   time.Now().UTC(),
+		AgentID:      d.opts.AgentID,
+		AgentAliasID: d.opts.AgentAliasID,
+		SessionID:    resp.Header.Get("x-amz-bedrock-agent-session-id"),
+		StatusCode:   resp.StatusCode,
+		Header:       resp.Header.Clone(),
+	})
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to marshal stream dump header: %w", err)
+	}
+
+	var prefix bytes.Buffer
+	prefix.Write(streamDumpMagic)
+	binary.Write(&prefix, binary.BigEndian, uint32(len(header)))
+	prefix.Write(header)
+	if _, err := file.Write(prefix.Bytes()); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write stream dump '%s': %w", d.path, err)
+	}
+
+	d.file = file
+	d.start = time.Now()
+	d.pending = nil
+	d.err = nil
+	return nil
+}
+
+// Write splits raw body bytes into event-stream frames and appends the complete ones to the dump
+func (d *StreamDumper) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.file == nil || d.err != nil {
+		return len(p), nil
+	}
+
+	offset := time.Since(d.start)
+	d.pending = append(d.pending, p...)
+
+	// Every event-stream message starts with its total length
+	for len(d.pending) >= 4 {
+		size := int(binary.BigEndian.Uint32(d.pending))
+		if size < 4 || size > maxStreamFrameSize {
+			d.err = fmt.Errorf("invalid event-stream frame length %d", size)
+			break
+		}
+		if len(d.pending) < size {
+			break
+		}
+		if err := d.writeFrame(offset, d.pending[:size]); err != nil {
+			d.err = err
+			break
+		}
+		d.pending = d.pending[size:]
+	}
+
+	// Dumping problems never interrupt the invocation itself
+	return len(p), nil
+}
+
+// writeFrame appends one frame record: offset in nanoseconds, length, and data
+func (d *StreamDumper) writeFrame(offset time.Duration, data []byte) error {
+	record := make([]byte, 12, 12+len(data))
+	binary.BigEndian.PutUint64(record, uint64(offset))
+	binary.BigEndian.PutUint32(record[8:], uint32(len(data)))
+	record = append(record, data...)
+
+	if _, err := d.file.Write(record); err != nil {
+		return fmt.Errorf("failed to write stream dump '%s': %w", d.path, err)
+	}
+	return nil
+}
+
+// Close finishes the dump file and reports any problem that occurred while writing it
+func (d *StreamDumper) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.file == nil {
+		return d.err
+	}
+	err := d.file.Close()
+	d.file = nil
+
+	if d.err != nil {
+		return d.err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to close stream dump '%s': %w", d.path, err)
+	}
+	if len(d.pending) > 0 {
+		return fmt.Errorf("stream ended inside a frame, %d trailing byte(s) not dumped", len(d.pending))
+	}
+	return nil
+}
+
+// Path returns the file the dump is written to
+func (d *StreamDumper) Path() string {
+	return d.path
+}
+
+// dumpingHTTPClient copies every response body into a StreamDumper
+type dumpingHTTPClient struct {
+	next   bedrockagentruntime.HTTPClient
+	dumper *StreamDumper
+}
+
+// Do sends the request and dumps the response frames as they are read
+func (c *dumpingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	resp, err := c.next.Do(req)
+	if err != nil {
+		return resp, err
+	}
+
+	if err := c.dumper.begin(resp); err != nil {
+		c.dumper.opts.Warnings.Warn(WarningRecording, "error dumping stream", err)
+		return resp, nil
+	}
+
+	resp.Body = &teeReadCloser{Reader: io.TeeReader(resp.Body, c.dumper), Closer: resp.Body}
+	return resp, nil
+}
+
+// LoadStreamDump reads a stream dump file written with --dump-stream
+func LoadStreamDump(path string) (*StreamDump, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stream dump '%s': %w", path, err)
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+
+	magic := make([]byte, len(streamDumpMagic))
+	if _, err := io.ReadFull(reader, magic); err != nil || !bytes.Equal(magic, streamDumpMagic) {
+		return nil, fmt.Errorf("'%s' is not a stream dump written with --dump-stream", path)
+	}
+
+	var headerSize uint32
+	if err := binary.Read(reader, binary.BigEndian, &headerSize); err != nil || headerSize > maxStreamFrameSize {
+		return nil, fmt.Errorf("failed to read stream dump header of '%s'", path)
+	}
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, fmt.Errorf("failed to read stream dump header of '%s': %w", path, err)
+	}
+
+	var dump StreamDump
+	if err := json.Unmarshal(header, &dump.Header); err != nil {
+		return nil, fmt.Errorf("failed to parse stream dump header of '%s': %w", path, err)
+	}
+	if dump.Header.StatusCode == 0 {
+		dump.Header.StatusCode = http.StatusOK
+	}
+
+	for {
+		var record [12]byte
+		if _, err := io.ReadFull(reader, record[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("truncated frame in stream dump '%s'", path)
+		}
+
+		size := binary.BigEndian.Uint32(record[8:])
+		if size > maxStreamFrameSize {
+			return nil, fmt.Errorf("invalid frame length %d in stream dump '%s'", size, path)
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, fmt.Errorf("truncated frame in stream dump '%s'", path)
+		}
+
+		dump.Frames = append(dump.Frames, StreamFrame{
+			Offset: time.Duration(binary.BigEndian.Uint64(record[:8])),
+			Data:   data,
+		})
+	}
+
+	return &dump, nil
+}
+
+// parseReplaySpeed parses a playback speed such as 1x, 2x, 0.5x, or max (no delays)
+func parseReplaySpeed(value string) (float64, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "max" {
+		return 0, nil
+	}
+
+	speed, err := strconv.ParseFloat(strings.TrimSuffix(value, "x"), 64)
+	if err != nil || speed <= 0 {
+		return 0, fmt.Errorf("invalid speed '%s' (use values like 1x, 2x, 0.5x, or max)", value)
+	}
+	return speed, nil
+}
+
+// newStreamReplayClient creates a runtime client whose response frames come from the dump
+// at the recorded pace divided by speed; a speed of 0 replays without delays
+func newStreamReplayClient(dump *StreamDump, speed float64) *bedrockagentruntime.Client {
+	return bedrockagentruntime.New(bedrockagentruntime.Options{
+		Region:      "us-east-1",
+		Credentials: aws.AnonymousCredentials{},
+		Retryer:     aws.NopRetryer{},
+		HTTPClient:  &streamReplayHTTPClient{dump: dump, speed: speed},
+	})
+}
+
+// streamReplayHTTPClient answers every request with the dumped response
+type streamReplayHTTPClient struct {
+	dump  *StreamDump
+	speed float64
+}
+
+// Do returns the dumped response whose body delivers one frame at a time
+func (c *streamReplayHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		Status:     http.StatusText(c.dump.Header.StatusCode),
+		StatusCode: c.dump.Header.StatusCode,
+		Header:     c.dump.Header.Header.Clone(),
+		Body: &pacedFrameReader{
+			request: req,
+			frames:  c.dump.Frames,
+			speed:   c.speed,
+			start:   time.Now(),
+		},
+		ContentLength: -1,
+		Request:       req,
+	}, nil
+}
+
+// pacedFrameReader returns each frame on its own, not before its scaled offset
+type pacedFrameReader struct {
+	request *http.Request
+	frames  []StreamFrame
+	current []byte
+	speed   float64
+	start   time.Time
+}
+
+// Read waits for the next frame when the current one has been consumed
+func (r *pacedFrameReader) Read(p []byte) (int, error) {
+	if len(r.current) == 0 {
+		if len(r.frames) == 0 {
+			return 0, io.EOF
+		}
+		frame := r.frames[0]
+		r.frames = r.frames[1:]
+
+		if r.speed > 0 {
+			due := r.start.Add(time.Duration(float64(frame.Offset) / r.speed))
+			select {
+			case <-time.After(time.Until(due)):
+			case <-r.request.Context().Done():
+				return 0, r.request.Context().Err()
+			}
+		}
+		r.current = frame.Data
+	}
+
+	n := copy(p, r.current)
+	r.current = r.current[n:]
+	return n, nil
+}
+
+// Close stops the replay
+func (r *pacedFrameReader) Close() error {
+	r.frames = nil
+	r.current = nil
+	return nil
+}
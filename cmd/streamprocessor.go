@@ -8,9 +8,12 @@ file outputs, and control events.
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/types"
@@ -20,91 +23,204 @@ import (
 type StreamProcessor struct {
 	Options     AgentOptions
 	Writer      io.Writer
+	Notices     io.Writer // Receives the file and return-control notices of text output
 	WriteOutput bool
-	isVerbose   bool // Cache verbose flag to avoid repeated checks
+	OnEvent     func(event types.ResponseStream) // Optional hook called for every received event
+	RequestSent time.Time                        // Start of the stream timing; processing start if zero
+	Context     context.Context                  // Canceled when the user interrupts; nil if not interruptible
+	isVerbose   bool                             // Cache verbose flag to avoid repeated checks
+	color       colorizer                        // Styles text output when writing to a terminal
+	noticeColor colorizer                        // Styles the notices when they are written to a terminal
 }
 
 // NewStreamProcessor creates a new StreamProcessor
 func NewStreamProcessor(opts AgentOptions, writer io.Writer, writeOutput bool) *StreamProcessor {
+	notices := noticeWriter(opts, writer)
 	return &StreamProcessor{
 		Options:     opts,
 		Writer:      writer,
+		Notices:     notices,
 		WriteOutput: writeOutput,
 		isVerbose:   opts.Verbose, // Cache the verbose flag
+		color:       colorizer{enabled: useColor(opts.Color, writer)},
+		noticeColor: colorizer{enabled: useColor(opts.Color, notices)},
 	}
 }
 
+// StreamResult contains everything collected from an agent event stream
+type StreamResult struct {
+	Text             string
+	Citations        []types.Citation
+	Files            []types.OutputFile
+	HasReturnControl bool
+	ReturnControl    *types.ReturnControlPayload // Function or API call the agent handed back to the caller
+	Usage            TokenUsage                  // Only populated when trace events are enabled
+	Traces           []types.TracePart           // Only populated when trace events are enabled
+	SavedFiles       []string                    // Paths generated files were saved to by the formatter
+	Timing           StreamTiming                // When the events arrived
+	Interrupted      bool                        // The user stopped the stream, the content is partial
+	Translation      string                      // The answer translated with --translate-to
+}
+
 // ProcessStream processes an event stream and returns the collected content.
 // This is a helper function to avoid code duplication between streaming and non-streaming handling.
-func (sp *StreamProcessor) ProcessStream(stream *bedrockagentruntime.InvokeAgentEventStream) (
-	string, []types.Citation, []types.OutputFile, bool, error) {
-
+func (sp *StreamProcessor) ProcessStream(stream *bedrockagentruntime.InvokeAgentEventStream) (result StreamResult, err error) {
 	var textResponse strings.Builder
-	var citations []types.Citation
-	var outputFiles []types.OutputFile
-	var hasReturnControl bool
 
 	// Pre-compute format check to avoid repeated string comparisons
 	isTextFormat := sp.Options.OutputFormat == "text"
 	writeTextOutput := sp.WriteOutput && isTextFormat
+	writeNotices := writeTextOutput && !sp.Options.Quiet // --quiet prints only the answer text
+
+	// Agent text is styled line by line when color is enabled
+	var textWriter io.Writer = sp.Writer
+	var markdown *markdownWriter
+	if writeTextOutput && sp.color.enabled {
+		markdown = newMarkdownWriter(sp.Writer, sp.color)
+		textWriter = markdown
+	}
+	flushText := func() {
+		if markdown != nil {
+			markdown.Flush()
+		}
+	}
+
+	// A stream that stays silent for longer than the idle timeout is abandoned
+	var idle <-chan time.Time
+	var idleTimer *time.Timer
+	if sp.Options.IdleTimeout > 0 {
+		idleTimer = time.NewTimer(sp.Options.IdleTimeout)
+		defer idleTimer.Stop()
+		idle = idleTimer.C
+	}
+
+	// Every received event is recorded with the gap since the previous one
+	telemetry := sp.Options.Telemetry
+	streamSpan := telemetry.Start("stream.process")
+	lastEvent := time.Now()
+	eventCount := 0
+	defer func() {
+		streamSpan.SetAttribute("aws_bia.stream.events", eventCount)
+		if sp.Options.EnableTrace {
+			streamSpan.SetAttribute("aws_bia.tokens.input", result.Usage.InputTokens)
+			streamSpan.SetAttribute("aws_bia.tokens.output", result.Usage.OutputTokens)
+			telemetry.Add("aws_bia.tokens.input", result.Usage.InputTokens)
+			telemetry.Add("aws_bia.tokens.output", result.Usage.OutputTokens)
+		}
+		streamSpan.End(err)
+	}()
+
+	clock := newStreamClock(sp.RequestSent)
+	defer func() {
+		result.Timing = clock.finish()
+		logVerbose(sp.Options, "Stream timing: %s", result.Timing.Summary())
+	}()
 
 	// Process the streaming response
-	for event := range stream.Events() {
+	written := 0 // Characters of the answer received so far, for placing citation markers
+	events := stream.Events()
+	for {
+		var event types.ResponseStream
+		var ok bool
+		select {
+		case event, ok = <-events:
+		case <-idle:
+			flushText()
+			result.Text = textResponse.String()
+			stream.Close()
+			return result, &TimeoutError{Limit: "idle timeout", Duration: sp.Options.IdleTimeout}
+		}
+		if !ok {
+			break
+		}
+		if idleTimer != nil {
+			idleTimer.Reset(sp.Options.IdleTimeout)
+		}
+
+		now := time.Now()
+		if eventCount == 0 {
+			telemetry.RecordLatency("aws_bia.stream.first_event", now.Sub(lastEvent))
+		}
+		eventCount++
+		telemetry.Add("aws_bia.stream.events", 1)
+		streamSpan.AddEvent(streamEventName(event), map[string]interface{}{
+			"aws_bia.event.gap_ms": float64(now.Sub(lastEvent)) / float64(time.Millisecond),
+		})
+		lastEvent = now
+		_, isChunk := event.(*types.ResponseStreamMemberChunk)
+		clock.event(now, isChunk)
+
 		if sp.isVerbose {
 			logVerbose(sp.Options, "Processing event type: %T", event)
 		}
 
+		if sp.OnEvent != nil {
+			sp.OnEvent(event)
+		}
+
 		switch v := event.(type) {
 		case *types.ResponseStreamMemberChunk:
 			// This is a text chunk from the agent
-			if len(v.Value.Bytes) > 0 {
-				chunk := string(v.Value.Bytes)
-				textResponse.WriteString(chunk)
+			chunk := string(v.Value.Bytes)
+			textResponse.WriteString(chunk)
 
-				// Write the output if requested (for streaming mode or text format)
-				if writeTextOutput {
-					fmt.Fprint(sp.Writer, chunk)
+			// Write the output if requested (for streaming mode or text format)
+			if writeTextOutput {
+				shown := chunk
+				if writeNotices && sp.Options.Citations == CitationsInline && v.Value.Attribution != nil {
+					shown = insertCitationMarkers(chunk, written, len(result.Citations)+1, v.Value.Attribution.Citations)
 				}
+				fmt.Fprint(textWriter, shown)
 			}
+			written += utf8.RuneCountInString(chunk)
 
 			// Process citations if available
 			if v.Value.Attribution != nil && len(v.Value.Attribution.Citations) > 0 {
-				citations = append(citations, v.Value.Attribution.Citations...)
+				result.Citations = append(result.Citations, v.Value.Attribution.Citations...)
 			}
 
 		case *types.ResponseStreamMemberFiles:
 			// Handle file output
 			if len(v.Value.Files) > 0 {
-				outputFiles = append(outputFiles, v.Value.Files...)
+				result.Files = append(result.Files, v.Value.Files...)
 
-				if writeTextOutput {
-					fmt.Fprintf(sp.Writer, "\n\n[Generated %d file(s)]\n", len(v.Value.Files))
+				if writeNotices {
+					flushText()
+					fmt.Fprintf(sp.Notices, "\n\n%s\n", sp.noticeColor.Notice(fmt.Sprintf("[Generated %d file(s)]", len(v.Value.Files))))
 					for i, file := range v.Value.Files {
-						fmt.Fprintf(sp.Writer, "  %d. %s", i+1, *file.Name)
+						notice := fmt.Sprintf("  %d. %s", i+1, *file.Name)
 						if file.Type != nil {
-							fmt.Fprintf(sp.Writer, " (type: %s)", *file.Type)
+							notice += fmt.Sprintf(" (type: %s)", *file.Type)
 						}
-						fmt.Fprintf(sp.Writer, " (%d bytes)\n", len(file.Bytes))
+						fmt.Fprintf(sp.Notices, "%s (%s)\n", sp.noticeColor.Notice(notice), formatSize(int64(len(file.Bytes))))
 					}
 				}
 			}
 
 		case *types.ResponseStreamMemberTrace:
-			// Just log trace events in verbose mode
+			// Collect token usage reported by model invocations
+			result.Usage.Add(traceUsage(v.Value))
+			result.Traces = append(result.Traces, v.Value)
 			if sp.isVerbose {
 				logVerbose(sp.Options, "Received trace event")
 			}
 
 		case *types.ResponseStreamMemberReturnControl:
 			// When agent returns control (for custom control flows)
-			hasReturnControl = true
-			if writeTextOutput {
-				fmt.Fprintln(sp.Writer, "\n[Agent returned control]")
+			result.HasReturnControl = true
+			payload := v.Value
+			result.ReturnControl = &payload
+			if writeNotices {
+				flushText()
+				fmt.Fprintf(sp.Notices, "\n%s\n", sp.noticeColor.Banner("[Agent returned control]"))
 				if v.Value.InvocationId != nil {
-					fmt.Fprintf(sp.Writer, "Invocation ID: %s\n", *v.Value.InvocationId)
+					fmt.Fprintf(sp.Notices, "Invocation ID: %s\n", *v.Value.InvocationId)
 				}
 				if v.Value.InvocationInputs != nil {
-					fmt.Fprintf(sp.Writer, "Invocation inputs: %d item(s)\n", len(v.Value.InvocationInputs))
+					fmt.Fprintf(sp.Notices, "Invocation inputs: %d item(s)\n", len(v.Value.InvocationInputs))
+					for i, input := range v.Value.InvocationInputs {
+						fmt.Fprintf(sp.Notices, "  %d. %s\n", i+1, describeInvocationInput(input))
+					}
 				}
 			}
 
@@ -115,11 +231,17 @@ func (sp *StreamProcessor) ProcessStream(stream *bedrockagentruntime.InvokeAgent
 		}
 	}
 
+	flushText()
+	result.Text = textResponse.String()
+
 	// Check for any errors that occurred during streaming
 	if err := stream.Err(); err != nil {
-		return textResponse.String(), citations, outputFiles, hasReturnControl,
-			handleAWSError(fmt.Errorf("error during streaming: %w", err))
+		if isInterruption(sp.Context, err) {
+			result.Interrupted = true
+			return result, ErrInterrupted
+		}
+		return result, handleAWSError(fmt.Errorf("error during streaming: %w", err))
 	}
 
-	return textResponse.String(), citations, outputFiles, hasReturnControl, nil
+	return result, nil
 }
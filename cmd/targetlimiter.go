@@ -0,0 +1,139 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the per-target concurrency limits of the 'serve' command.
+Every agent alias gets its own pool of invocation slots and a bounded queue, so a
+client flooding one target cannot starve the others behind the same server. When a
+target's queue is full, or a request waited too long, the server answers 429 with a
+Retry-After estimated from how long invocations of that target usually take.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Default limits applied to each agent alias served by 'serve'
+const (
+	DefaultMaxConcurrentPerTarget = 4
+	DefaultMaxQueuePerTarget      = 16
+	DefaultQueueTimeout           = 30 * time.Second
+)
+
+// TargetBusyError reports a request that was turned away because its target is saturated
+type TargetBusyError struct {
+	Target     string
+	RetryAfter time.Duration
+	Reason     string
+}
+
+func (e *TargetBusyError) Error() string {
+	return fmt.Sprintf("target %s is busy: %s, retry after %s", e.Target, e.Reason, formatDuration(e.RetryAfter))
+}
+
+// targetLimiter hands out invocation slots per target
+type targetLimiter struct {
+	maxActive    int
+	maxQueue     int
+	queueTimeout time.Duration
+
+	mu      sync.Mutex
+	targets map[string]*targetSlots
+}
+
+// targetSlots tracks the running and waiting invocations of one target
+type targetSlots struct {
+	slots   chan struct{} // Holds a token for every running invocation
+	waiting int
+	average time.Duration // Moving average of how long an invocation holds its slot
+}
+
+// newTargetLimiter creates a limiter allowing maxActive invocations and maxQueue waiting requests per target
+func newTargetLimiter(maxActive, maxQueue int, queueTimeout time.Duration) *targetLimiter {
+	return &targetLimiter{
+		maxActive:    maxActive,
+		maxQueue:     maxQueue,
+		queueTimeout: queueTimeout,
+		targets:      make(map[string]*targetSlots),
+	}
+}
+
+// Acquire waits for a free slot of the target and returns the function that releases it
+func (l *targetLimiter) Acquire(ctx context.Context, target string) (func(), error) {
+	l.mu.Lock()
+	t, ok := l.targets[target]
+	if !ok {
+		t = &targetSlots{slots: make(chan struct{}, l.maxActive)}
+		l.targets[target] = t
+	}
+
+	// A free slot is taken right away, without counting as queued
+	select {
+	case t.slots <- struct{}{}:
+		l.mu.Unlock()
+		return l.releaser(t), nil
+	default:
+	}
+
+	if t.waiting >= l.maxQueue {
+		retryAfter := l.retryAfter(t)
+		l.mu.Unlock()
+		return nil, &TargetBusyError{Target: target, RetryAfter: retryAfter, Reason: "queue is full"}
+	}
+	t.waiting++
+	l.mu.Unlock()
+
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case t.slots <- struct{}{}:
+	case <-timer.C:
+		err = &TargetBusyError{Target: target, Reason: fmt.Sprintf("no slot within %s", formatDuration(l.queueTimeout))}
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	l.mu.Lock()
+	t.waiting--
+	if busy, ok := err.(*TargetBusyError); ok {
+		busy.RetryAfter = l.retryAfter(t)
+	}
+	l.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+	return l.releaser(t), nil
+}
+
+// releaser returns the function that frees a slot and records how long it was held
+func (l *targetLimiter) releaser(t *targetSlots) func() {
+	start := time.Now()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			held := time.Since(start)
+			l.mu.Lock()
+			if t.average == 0 {
+				t.average = held
+			} else {
+				t.average = (t.average*4 + held) / 5
+			}
+			l.mu.Unlock()
+			<-t.slots
+		})
+	}
+}
+
+// retryAfter estimates when the queue of a target will have drained; the caller holds the lock
+func (l *targetLimiter) retryAfter(t *targetSlots) time.Duration {
+	rounds := float64(t.waiting+1) / float64(l.maxActive)
+	estimate := time.Duration(math.Ceil(rounds) * float64(t.average))
+	return max(estimate.Round(time.Second), time.Second)
+}
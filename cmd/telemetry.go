@@ -0,0 +1,518 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements OpenTelemetry export for the AWS Bedrock Intelligent Agents CLI.
+An invocation is recorded as a trace of spans (config load, file preparation, the
+InvokeAgent call, and stream processing with one event per stream event) plus a few
+latency and volume metrics, which are sent to an OTLP/HTTP collector as JSON when the
+command finishes. A TRACEPARENT environment variable links the trace to a pipeline run.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/types"
+)
+
+const (
+	// telemetryScope identifies the instrumentation in exported data
+	telemetryScope = "github.com/hacker65536/aws-bia"
+
+	// telemetryExportTimeout bounds the export when the command finishes
+	telemetryExportTimeout = 5 * time.Second
+
+	// OTLP span kinds and status codes used by the CLI
+	spanKindInternal = 1
+	spanKindClient   = 3
+	statusCodeError  = 2
+)
+
+// latencyBounds are the histogram bucket bounds of the latency metrics, in milliseconds
+var latencyBounds = []float64{100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000, 120000, 300000}
+
+// Telemetry records the spans and metrics of one command run. A nil Telemetry records nothing.
+type Telemetry struct {
+	mu           sync.Mutex
+	endpoint     string
+	headers      map[string]string
+	serviceName  string
+	traceID      string
+	parentSpanID string // Span of the calling pipeline from TRACEPARENT
+	root         *Span
+	spans        []*Span
+	sums         map[string]int64
+	latencies    map[string][]float64
+	attributes   map[string]interface{} // Attributes of every metric data point
+	start        time.Time
+}
+
+// Span is a timed operation within a trace
+type Span struct {
+	telemetry  *Telemetry
+	id         string
+	parentID   string
+	name       string
+	kind       int
+	start      time.Time
+	end        time.Time
+	attributes map[string]interface{}
+	events     []spanEvent
+	err        error
+}
+
+// spanEvent is a point in time within a span
+type spanEvent struct {
+	name       string
+	time       time.Time
+	attributes map[string]interface{}
+}
+
+// NewTelemetry creates a recorder continuing the trace from TRACEPARENT, if set
+func NewTelemetry() *Telemetry {
+	t := &Telemetry{
+		headers:     parseOTLPHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")),
+		serviceName: os.Getenv("OTEL_SERVICE_NAME"),
+		traceID:     randomHex(16),
+		sums:        map[string]int64{},
+		latencies:   map[string][]float64{},
+		attributes:  map[string]interface{}{},
+		start:       time.Now(),
+	}
+	if t.serviceName == "" {
+		t.serviceName = "aws-bia"
+	}
+
+	// Continue the trace of the pipeline step that started the CLI
+	if traceID, spanID, ok := parseTraceparent(os.Getenv("TRACEPARENT")); ok {
+		t.traceID = traceID
+		t.parentSpanID = spanID
+	}
+	return t
+}
+
+// SetEndpoint sets the OTLP/HTTP collector URL; when empty OTEL_EXPORTER_OTLP_ENDPOINT is used.
+// Without any endpoint nothing is exported.
+func (t *Telemetry) SetEndpoint(endpoint string) {
+	if t == nil {
+		return
+	}
+	if endpoint == "" {
+		endpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.endpoint = strings.TrimSuffix(endpoint, "/")
+}
+
+// Enabled reports whether the telemetry will be exported
+func (t *Telemetry) Enabled() bool {
+	if t == nil {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.endpoint != ""
+}
+
+// StartRoot starts the span covering the whole command; later spans become its children
+func (t *Telemetry) StartRoot(name string) *Span {
+	if t == nil {
+		return nil
+	}
+	span := t.newSpan(name, t.parentSpanID, spanKindInternal)
+	t.mu.Lock()
+	t.root = span
+	t.mu.Unlock()
+	return span
+}
+
+// Root returns the span started with StartRoot
+func (t *Telemetry) Root() *Span {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.root
+}
+
+// Finish ends the root span with the result of the command and records its duration
+func (t *Telemetry) Finish(err error) {
+	if t == nil {
+		return
+	}
+	t.Root().End(err)
+	t.RecordLatency("aws_bia.invocation.duration", time.Since(t.start))
+}
+
+// Start starts a span as a child of the root span
+func (t *Telemetry) Start(name string) *Span {
+	return t.startChild(name, spanKindInternal)
+}
+
+// StartClient starts a span for a call to a remote service
+func (t *Telemetry) StartClient(name string) *Span {
+	return t.startChild(name, spanKindClient)
+}
+
+// startChild creates a child span of the root span with the given kind
+func (t *Telemetry) startChild(name string, kind int) *Span {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	parentID := t.parentSpanID
+	if t.root != nil {
+		parentID = t.root.id
+	}
+	t.mu.Unlock()
+	return t.newSpan(name, parentID, kind)
+}
+
+// newSpan creates and registers a started span
+func (t *Telemetry) newSpan(name, parentID string, kind int) *Span {
+	span := &Span{
+		telemetry:  t,
+		id:         randomHex(8),
+		parentID:   parentID,
+		name:       name,
+		kind:       kind,
+		start:      time.Now(),
+		attributes: map[string]interface{}{},
+	}
+	t.mu.Lock()
+	t.spans = append(t.spans, span)
+	t.mu.Unlock()
+	return span
+}
+
+// SetAttribute sets an attribute included in every metric data point
+func (t *Telemetry) SetAttribute(key string, value interface{}) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.attributes[key] = value
+}
+
+// Add increments a counter metric
+func (t *Telemetry) Add(name string, value int64) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sums[name] += value
+}
+
+// RecordLatency records a duration in a latency histogram metric
+func (t *Telemetry) RecordLatency(name string, d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.latencies[name] = append(t.latencies[name], float64(d)/float64(time.Millisecond))
+}
+
+// SetAttribute sets an attribute of the span
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.telemetry.mu.Lock()
+	defer s.telemetry.mu.Unlock()
+	s.attributes[key] = value
+}
+
+// AddEvent records a point in time within the span
+func (s *Span) AddEvent(name string, attributes map[string]interface{}) {
+	if s == nil {
+		return
+	}
+	s.telemetry.mu.Lock()
+	defer s.telemetry.mu.Unlock()
+	s.events = append(s.events, spanEvent{name: name, time: time.Now(), attributes: attributes})
+}
+
+// End finishes the span; a non-nil err marks it as failed
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.telemetry.mu.Lock()
+	defer s.telemetry.mu.Unlock()
+	if s.end.IsZero() {
+		s.end = time.Now()
+		s.err = err
+	}
+}
+
+// Export sends the recorded spans and metrics to the collector
+func (t *Telemetry) Export(ctx context.Context) error {
+	if !t.Enabled() {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, telemetryExportTimeout)
+	defer cancel()
+
+	t.mu.Lock()
+	traces := t.tracesDocument()
+	metrics := t.metricsDocument()
+	t.mu.Unlock()
+
+	if err := t.post(ctx, "/v1/traces", traces); err != nil {
+		return err
+	}
+	return t.post(ctx, "/v1/metrics", metrics)
+}
+
+// post sends one OTLP/HTTP JSON request
+func (t *Telemetry) post(ctx context.Context, path string, document interface{}) error {
+	body, err := json.Marshal(document)
+	if err != nil {
+		return fmt.Errorf("failed to marshal telemetry: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid OTLP endpoint '%s': %w", t.endpoint, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export telemetry to %s: %w", t.endpoint+path, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to export telemetry to %s: %s", t.endpoint+path, resp.Status)
+	}
+	return nil
+}
+
+// resource describes the process that produced the telemetry
+func (t *Telemetry) resource() map[string]interface{} {
+	return map[string]interface{}{
+		"attributes": otlpAttributes(map[string]interface{}{
+			"service.name":    t.serviceName,
+			"service.version": getVersionInfo().version,
+		}),
+	}
+}
+
+// scope identifies the instrumentation library
+func (t *Telemetry) scope() map[string]interface{} {
+	return map[string]interface{}{"name": telemetryScope, "version": getVersionInfo().version}
+}
+
+// tracesDocument builds an OTLP ExportTraceServiceRequest; spans still running end now
+func (t *Telemetry) tracesDocument() map[string]interface{} {
+	now := time.Now()
+	spans := make([]map[string]interface{}, 0, len(t.spans))
+	for _, s := range t.spans {
+		end := s.end
+		if end.IsZero() {
+			end = now
+		}
+
+		span := map[string]interface{}{
+			"traceId":           t.traceID,
+			"spanId":            s.id,
+			"name":              s.name,
+			"kind":              s.kind,
+			"startTimeUnixNano": unixNano(s.start),
+			"endTimeUnixNano":   unixNano(end),
+			"attributes":        otlpAttributes(s.attributes),
+		}
+		if s.parentID != "" {
+			span["parentSpanId"] = s.parentID
+		}
+		if s.err != nil {
+			span["status"] = map[string]interface{}{"code": statusCodeError, "message": s.err.Error()}
+		}
+
+		events := make([]map[string]interface{}, 0, len(s.events))
+		for _, e := range s.events {
+			events = append(events, map[string]interface{}{
+				"name":         e.name,
+				"timeUnixNano": unixNano(e.time),
+				"attributes":   otlpAttributes(e.attributes),
+			})
+		}
+		span["events"] = events
+		spans = append(spans, span)
+	}
+
+	return map[string]interface{}{
+		"resourceSpans": []interface{}{map[string]interface{}{
+			"resource":   t.resource(),
+			"scopeSpans": []interface{}{map[string]interface{}{"scope": t.scope(), "spans": spans}},
+		}},
+	}
+}
+
+// metricsDocument builds an OTLP ExportMetricsServiceRequest with cumulative data points
+func (t *Telemetry) metricsDocument() map[string]interface{} {
+	now := unixNano(time.Now())
+	start := unixNano(t.start)
+	attributes := otlpAttributes(t.attributes)
+
+	var metrics []interface{}
+	for name, value := range t.sums {
+		metrics = append(metrics, map[string]interface{}{
+			"name": name,
+			"sum": map[string]interface{}{
+				"aggregationTemporality": 2,
+				"isMonotonic":            true,
+				"dataPoints": []interface{}{map[string]interface{}{
+					"attributes":        attributes,
+					"startTimeUnixNano": start,
+					"timeUnixNano":      now,
+					"asInt":             strconv.FormatInt(value, 10),
+				}},
+			},
+		})
+	}
+
+	for name, values := range t.latencies {
+		counts := make([]string, len(latencyBounds)+1)
+		buckets := make([]int, len(latencyBounds)+1)
+		var sum float64
+		for _, value := range values {
+			sum += value
+			bucket := len(latencyBounds)
+			for i, bound := range latencyBounds {
+				if value <= bound {
+					bucket = i
+					break
+				}
+			}
+			buckets[bucket]++
+		}
+		for i, count := range buckets {
+			counts[i] = strconv.Itoa(count)
+		}
+
+		metrics = append(metrics, map[string]interface{}{
+			"name": name,
+			"unit": "ms",
+			"histogram": map[string]interface{}{
+				"aggregationTemporality": 2,
+				"dataPoints": []interface{}{map[string]interface{}{
+					"attributes":        attributes,
+					"startTimeUnixNano": start,
+					"timeUnixNano":      now,
+					"count":             strconv.Itoa(len(values)),
+					"sum":               sum,
+					"bucketCounts":      counts,
+					"explicitBounds":    latencyBounds,
+				}},
+			},
+		})
+	}
+
+	return map[string]interface{}{
+		"resourceMetrics": []interface{}{map[string]interface{}{
+			"resource":     t.resource(),
+			"scopeMetrics": []interface{}{map[string]interface{}{"scope": t.scope(), "metrics": metrics}},
+		}},
+	}
+}
+
+// streamEventName names a stream event in traces
+func streamEventName(event types.ResponseStream) string {
+	switch event.(type) {
+	case *types.ResponseStreamMemberChunk:
+		return "chunk"
+	case *types.ResponseStreamMemberFiles:
+		return "files"
+	case *types.ResponseStreamMemberTrace:
+		return "trace"
+	case *types.ResponseStreamMemberReturnControl:
+		return "return_control"
+	}
+	return fmt.Sprintf("%T", event)
+}
+
+// otlpAttributes converts attributes to OTLP key/value pairs
+func otlpAttributes(attributes map[string]interface{}) []interface{} {
+	result := make([]interface{}, 0, len(attributes))
+	for key, value := range attributes {
+		var otlpValue map[string]interface{}
+		switch v := value.(type) {
+		case bool:
+			otlpValue = map[string]interface{}{"boolValue": v}
+		case int:
+			otlpValue = map[string]interface{}{"intValue": strconv.Itoa(v)}
+		case int64:
+			otlpValue = map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}
+		case float64:
+			otlpValue = map[string]interface{}{"doubleValue": v}
+		default:
+			otlpValue = map[string]interface{}{"stringValue": fmt.Sprint(v)}
+		}
+		result = append(result, map[string]interface{}{"key": key, "value": otlpValue})
+	}
+	return result
+}
+
+// parseOTLPHeaders parses the key1=value1,key2=value2 format of OTEL_EXPORTER_OTLP_HEADERS
+func parseOTLPHeaders(value string) map[string]string {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		key, val, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			continue
+		}
+		if decoded, err := url.QueryUnescape(strings.TrimSpace(val)); err == nil {
+			val = decoded
+		}
+		headers[strings.TrimSpace(key)] = val
+	}
+	return headers
+}
+
+// parseTraceparent extracts the trace and span ID of a W3C traceparent header value
+func parseTraceparent(value string) (traceID, spanID string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return "", "", false
+	}
+	if _, err := hex.DecodeString(parts[1] + parts[2]); err != nil {
+		return "", "", false
+	}
+	return strings.ToLower(parts[1]), strings.ToLower(parts[2]), true
+}
+
+// randomHex returns n random bytes in hex
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// unixNano formats a time as the decimal string OTLP JSON uses for 64-bit integers
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
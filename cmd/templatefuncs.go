@@ -0,0 +1,163 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the template function library of the AWS Bedrock Intelligent Agents CLI.
+Prompt templates and --format template output templates share the same functions: string
+helpers, defaults and conditionals, date formatting, JSON encoding and decoding, regular
+expressions, and lookups of the environment variables allowed by the template_env setting.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templateFuncMap returns the functions available in prompt and output templates.
+// env only reads the environment variables named in allowedEnv.
+func templateFuncMap(allowedEnv []string) template.FuncMap {
+	return template.FuncMap{
+		"toLowerCase": strings.ToLower,
+		"toUpperCase": strings.ToUpper,
+		"replace": func(old, new, s string) string {
+			return strings.ReplaceAll(s, old, new)
+		},
+		"join":      strings.Join,
+		"split":     strings.Split,
+		"contains":  strings.Contains,
+		"hasPrefix": strings.HasPrefix,
+		"hasSuffix": strings.HasSuffix,
+		"trim":      strings.TrimSpace,
+
+		// Defaults and conditionals, e.g. {{.tone | default "neutral"}}
+		"default": func(fallback, value interface{}) interface{} {
+			if isEmptyValue(value) {
+				return fallback
+			}
+			return value
+		},
+		"empty": isEmptyValue,
+		"ternary": func(yes, no, condition interface{}) interface{} {
+			if isEmptyValue(condition) {
+				return no
+			}
+			return yes
+		},
+
+		// Layout, e.g. {{.notes | indent 4}}
+		"indent": indentText,
+		"nindent": func(spaces int, s string) string {
+			return "\n" + indentText(spaces, s)
+		},
+
+		// Dates use Go layouts, e.g. {{now | date "2006-01-02"}}
+		"now":  time.Now,
+		"date": formatTemplateDate,
+
+		"toJson": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+		"toPrettyJson": func(v interface{}) (string, error) {
+			data, err := json.MarshalIndent(v, "", "  ")
+			return string(data), err
+		},
+		"fromJson": func(s string) (interface{}, error) {
+			var v interface{}
+			if err := json.Unmarshal([]byte(s), &v); err != nil {
+				return nil, fmt.Errorf("fromJson: %w", err)
+			}
+			return v, nil
+		},
+
+		// The text is the last argument, as with replace, so both work in pipelines
+		"regexMatch": func(pattern, s string) (bool, error) {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return false, err
+			}
+			return re.MatchString(s), nil
+		},
+		"regexReplace": func(pattern, replacement, s string) (string, error) {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return "", err
+			}
+			return re.ReplaceAllString(s, replacement), nil
+		},
+
+		// Templates may come from a shared library, so they only see the variables allowed in the config
+		"env": func(name string) (string, error) {
+			for _, allowed := range allowedEnv {
+				if allowed == name {
+					return os.Getenv(name), nil
+				}
+			}
+			return "", fmt.Errorf("environment variable '%s' is not listed in the template_env setting", name)
+		},
+	}
+}
+
+// isEmptyValue reports whether a template value is missing or the zero value of its type
+func isEmptyValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		return v.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	default:
+		return v.IsZero()
+	}
+}
+
+// indentText prefixes every non-empty line of s with the given number of spaces
+func indentText(spaces int, s string) string {
+	pad := strings.Repeat(" ", spaces)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = pad + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatTemplateDate formats a time, an RFC 3339 or YYYY-MM-DD string, or Unix seconds
+func formatTemplateDate(layout string, value interface{}) (string, error) {
+	var t time.Time
+	switch v := value.(type) {
+	case time.Time:
+		t = v
+	case *time.Time:
+		if v == nil {
+			return "", fmt.Errorf("date: no time given")
+		}
+		t = *v
+	case string:
+		var err error
+		if t, err = time.Parse(time.RFC3339, v); err != nil {
+			if t, err = time.Parse(time.DateOnly, v); err != nil {
+				return "", fmt.Errorf("date: cannot parse '%s' as an RFC 3339 time or a YYYY-MM-DD date", v)
+			}
+		}
+	case int:
+		t = time.Unix(int64(v), 0)
+	case int64:
+		t = time.Unix(v, 0)
+	case float64: // Numbers of --var and vars files
+		t = time.Unix(int64(v), 0)
+	default:
+		return "", fmt.Errorf("date: unsupported value of type %T", value)
+	}
+	return t.Format(layout), nil
+}
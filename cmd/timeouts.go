@@ -0,0 +1,141 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the invocation time limits of the AWS Bedrock Intelligent Agents CLI.
+Instead of a single timeout around the whole call, an invocation is bounded by a connect
+timeout until the response starts, an idle timeout between stream events, and a maximum
+overall duration, so long answers keep streaming while a silent stream fails fast. The
+default maximum duration only applies to non-streaming calls, a streamed answer is only cut
+short by a maximum duration that was given.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// Default time until the agent starts responding
+	DefaultConnectTimeout = 30 * time.Second
+
+	// Default maximum time between two stream events
+	DefaultIdleTimeout = 60 * time.Second
+
+	// Default maximum duration of a whole invocation
+	DefaultMaxDuration = 15 * time.Minute
+)
+
+// TimeoutError reports which time limit stopped an invocation
+type TimeoutError struct {
+	Limit    string // "connect timeout", "idle timeout", or "maximum duration"
+	Duration time.Duration
+}
+
+// Error implements the error interface
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s of %s exceeded", e.Limit, e.Duration)
+}
+
+// Is makes timeouts match context.DeadlineExceeded, so they are reported like other timeouts
+func (e *TimeoutError) Is(target error) bool {
+	return target == context.DeadlineExceeded
+}
+
+// withMaxDuration bounds the whole invocation; a zero duration means no limit
+func withMaxDuration(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeoutCause(ctx, d, &TimeoutError{Limit: "maximum duration", Duration: d})
+}
+
+// withConnectTimeout returns a context that is canceled unless the returned stop function is
+// called within d. The context stays usable for reading the stream after stop is called.
+func withConnectTimeout(ctx context.Context, d time.Duration) (context.Context, func()) {
+	if d <= 0 {
+		return ctx, func() {}
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	timer := time.AfterFunc(d, func() {
+		cancel(&TimeoutError{Limit: "connect timeout", Duration: d})
+	})
+	return ctx, func() { timer.Stop() }
+}
+
+// timeoutCause replaces an error caused by one of the time limits with the limit that was hit
+func timeoutCause(ctx context.Context, err error) error {
+	var timeout *TimeoutError
+	if err == nil || !errors.As(context.Cause(ctx), &timeout) {
+		return err
+	}
+	return fmt.Errorf("%w (try raising the limit): %v", timeout, err)
+}
+
+// validateTimeouts checks the time limits of an invocation
+func validateTimeouts(opts AgentOptions) error {
+	if opts.Timeout < 0 {
+		return fmt.Errorf("max duration must not be negative")
+	}
+	if opts.ConnectTimeout < 0 {
+		return fmt.Errorf("connect timeout must not be negative")
+	}
+	if opts.IdleTimeout < 0 {
+		return fmt.Errorf("idle timeout must not be negative")
+	}
+	return nil
+}
+
+// applyTimeoutConfig applies the time limits of the invoke command from a loaded configuration
+func applyTimeoutConfig(v *viper.Viper, options *AgentOptions) {
+	// The old single timeout setting is treated as the maximum duration, max_duration wins over it
+	if !options.MaxDurationSet && v.InConfig("max_duration") {
+		options.Timeout = v.GetDuration("max_duration")
+		options.MaxDurationSet = true
+		logVerbose(*options, "Loaded max duration from config: %s", options.Timeout)
+	} else {
+		applyTimeoutSetting(v, options)
+	}
+
+	if v.InConfig("connect_timeout") && options.ConnectTimeout == DefaultConnectTimeout {
+		options.ConnectTimeout = v.GetDuration("connect_timeout")
+		logVerbose(*options, "Loaded connect timeout from config: %s", options.ConnectTimeout)
+	}
+
+	if v.InConfig("idle_timeout") && options.IdleTimeout == DefaultIdleTimeout {
+		options.IdleTimeout = v.GetDuration("idle_timeout")
+		logVerbose(*options, "Loaded idle timeout from config: %s", options.IdleTimeout)
+	}
+
+	applyStreamingMaxDuration(options)
+}
+
+// applyTimeoutSetting applies the timeout setting unless the time limit was given on the command
+// line; commands with a single --timeout use it directly
+func applyTimeoutSetting(v *viper.Viper, options *AgentOptions) {
+	if options.MaxDurationSet || !v.InConfig("timeout") {
+		return
+	}
+	if duration := v.GetDuration("timeout"); duration > 0 {
+		options.Timeout = duration
+		logVerbose(*options, "Loaded timeout from config: %s", options.Timeout)
+	} else {
+		options.Warnings.Warn(WarningConfig, fmt.Sprintf("invalid timeout '%s' in config, using %s",
+			v.GetString("timeout"), options.Timeout), nil)
+	}
+}
+
+// applyStreamingMaxDuration lifts the maximum duration of streamed answers unless it was given
+// on the command line or as max_duration; the old timeout setting does not count. A long code
+// interpreter run keeps streaming events, and a stalled one is ended by the idle timeout.
+func applyStreamingMaxDuration(options *AgentOptions) {
+	if options.EnableStreaming && !options.MaxDurationSet && options.Timeout > 0 {
+		options.Timeout = 0
+		logVerbose(*options, "No maximum duration for the streamed answer, the connect and idle timeouts apply")
+	}
+}
@@ -0,0 +1,273 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the safeguards of local tools for the AWS Bedrock Intelligent Agents
+CLI. The agent chooses the calls and their parameters, so before a tool runs:
+
+  - tools_allowlist in the configuration file, not in the tools file a project may ship,
+    limits the programs and URLs tools may use;
+  - the parameters schema of a tool checks the values the agent sends;
+  - a tool with confirm: true only runs after the user agreed in the terminal.
+
+Every call, whether it ran, failed, was rejected, or was declined, is appended to an audit
+log of JSON lines, tools-audit.log in the data directory unless --tools-audit-log says otherwise.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Outcomes of a tool call in the audit log
+const (
+	toolCallRan      = "ran"
+	toolCallFailed   = "failed"
+	toolCallRejected = "rejected"
+	toolCallDeclined = "declined"
+)
+
+// toolShellOperators are the shell characters that would let an allowed command run others
+const toolShellOperators = ";|&`<>\n"
+
+// toolParameter is the schema of a parameter of a tool
+type toolParameter struct {
+	Type      string   `yaml:"type"` // string (the default), integer, number, or boolean
+	Required  bool     `yaml:"required"`
+	Pattern   string   `yaml:"pattern"`
+	Enum      []string `yaml:"enum"`
+	MaxLength int      `yaml:"max_length"`
+
+	pattern *regexp.Regexp
+}
+
+// compile checks the schema of a parameter
+func (p *toolParameter) compile() error {
+	switch p.Type {
+	case "", "string", "integer", "number", "boolean":
+	default:
+		return fmt.Errorf("unknown type '%s', expected string, integer, number, or boolean", p.Type)
+	}
+	if p.Pattern != "" {
+		pattern, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+		p.pattern = pattern
+	}
+	return nil
+}
+
+// check reports why a value does not match the schema
+func (p *toolParameter) check(value string) error {
+	switch p.Type {
+	case "integer":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return fmt.Errorf("'%s' is not an integer", value)
+		}
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("'%s' is not a number", value)
+		}
+	case "boolean":
+		if value != "true" && value != "false" {
+			return fmt.Errorf("'%s' is not true or false", value)
+		}
+	}
+	if p.MaxLength > 0 && utf8.RuneCountInString(value) > p.MaxLength {
+		return fmt.Errorf("longer than %d characters", p.MaxLength)
+	}
+	if len(p.Enum) > 0 && !containsString(p.Enum, value) {
+		return fmt.Errorf("'%s' is not one of %s", value, strings.Join(p.Enum, ", "))
+	}
+	if p.pattern != nil && !p.pattern.MatchString(value) {
+		return fmt.Errorf("'%s' does not match %s", value, p.Pattern)
+	}
+	return nil
+}
+
+// checkParameters validates the parameters of a call against the schema of the tool. With
+// a schema, parameters it does not declare are rejected too.
+func (t *localTool) checkParameters(call toolCall) error {
+	if len(t.Parameters) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(call.Parameters)+len(t.Parameters))
+	for name := range call.Parameters {
+		names = append(names, name)
+	}
+	for name, schema := range t.Parameters {
+		if _, ok := call.Parameters[name]; !ok && schema.Required {
+			return fmt.Errorf("parameter '%s' is required", name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		schema, ok := t.Parameters[name]
+		if !ok {
+			return fmt.Errorf("parameter '%s' is not declared by the tool", name)
+		}
+		if err := schema.check(call.Parameters[name]); err != nil {
+			return fmt.Errorf("parameter '%s': %w", name, err)
+		}
+	}
+	return nil
+}
+
+// checkAllowlist reports why a tool is not allowed by the tools_allowlist entries. Commands
+// must start with an allowed program and cannot chain others; URLs must start with an
+// allowed http:// or https:// prefix.
+func (t *localTool) checkAllowlist(allowlist []string) error {
+	if len(allowlist) == 0 {
+		return nil
+	}
+
+	if t.URL != "" {
+		for _, entry := range allowlist {
+			if isHTTPPrefix(entry) && strings.HasPrefix(t.URL, entry) {
+				return nil
+			}
+		}
+		return fmt.Errorf("url '%s' is not in tools_allowlist", t.URL)
+	}
+
+	if strings.ContainsAny(t.Command, toolShellOperators) || strings.Contains(t.Command, "$(") {
+		return fmt.Errorf("command '%s' uses shell operators, which tools_allowlist does not permit", t.Command)
+	}
+	fields := strings.Fields(t.Command)
+	if len(fields) == 0 {
+		return fmt.Errorf("command is empty")
+	}
+	program := strings.Trim(fields[0], `"'`)
+	for _, entry := range allowlist {
+		if isHTTPPrefix(entry) {
+			continue
+		}
+		if matched, _ := filepath.Match(entry, program); matched || entry == program {
+			return nil
+		}
+	}
+	return fmt.Errorf("program '%s' is not in tools_allowlist", program)
+}
+
+// isHTTPPrefix reports whether an allowlist entry is a URL prefix
+func isHTTPPrefix(entry string) bool {
+	return strings.HasPrefix(entry, "http://") || strings.HasPrefix(entry, "https://")
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// confirmToolCall asks the user whether to run a call; without a terminal it is declined
+func confirmToolCall(name string, call toolCall) (bool, error) {
+	if !isTerminal(os.Stdin) {
+		return false, fmt.Errorf("tool '%s' needs confirmation, but stdin is not a terminal", name)
+	}
+
+	fmt.Fprintf(os.Stderr, "\nThe agent wants to run local tool '%s' for %s/%s", name, call.ActionGroup, call.describe())
+	if len(call.Parameters) == 0 {
+		fmt.Fprintln(os.Stderr, " without parameters")
+	} else {
+		fmt.Fprintln(os.Stderr, " with:")
+		names := make([]string, 0, len(call.Parameters))
+		for n := range call.Parameters {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			fmt.Fprintf(os.Stderr, "  %s = %s\n", n, call.Parameters[n])
+		}
+	}
+	return confirmAction("Run it?")
+}
+
+// toolAuditEntry is a line of the audit log
+type toolAuditEntry struct {
+	Time         time.Time         `json:"time"`
+	Tool         string            `json:"tool"`
+	Outcome      string            `json:"outcome"`
+	AgentID      string            `json:"agentId"`
+	SessionID    string            `json:"sessionId,omitempty"`
+	InvocationID string            `json:"invocationId,omitempty"`
+	ActionGroup  string            `json:"actionGroup"`
+	Function     string            `json:"function,omitempty"`
+	ApiPath      string            `json:"apiPath,omitempty"`
+	HttpMethod   string            `json:"httpMethod,omitempty"`
+	Parameters   map[string]string `json:"parameters"`
+	Command      string            `json:"command,omitempty"`
+	URL          string            `json:"url,omitempty"`
+	DurationMs   int64             `json:"durationMs,omitempty"`
+	OutputBytes  int               `json:"outputBytes,omitempty"`
+	Error        string            `json:"error,omitempty"`
+}
+
+// toolAuditLog appends the tool calls of an invocation to the audit log
+type toolAuditLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// defaultToolAuditLogPath returns the location of the audit log
+func defaultToolAuditLogPath() (string, error) {
+	return dataPath("tools-audit.log")
+}
+
+// openToolAuditLog opens the audit log for appending. Tools do not run when it cannot be
+// opened, so no call goes unrecorded.
+func openToolAuditLog(path string) (*toolAuditLog, error) {
+	if path == "" {
+		var err error
+		if path, err = defaultToolAuditLogPath(); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tools audit log '%s': %w", path, err)
+	}
+	return &toolAuditLog{file: file}, nil
+}
+
+// Record appends an entry; a failed write only produces a warning
+func (l *toolAuditLog) Record(opts AgentOptions, entry toolAuditEntry) {
+	if l == nil {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err == nil {
+		l.mu.Lock()
+		_, err = l.file.Write(append(data, '\n'))
+		l.mu.Unlock()
+	}
+	if err != nil {
+		opts.Warnings.Warn(WarningTool, "failed to write the tools audit log", err)
+	}
+}
+
+// Close closes the audit log
+func (l *toolAuditLog) Close() error {
+	if l == nil {
+		return nil
+	}
+	return l.file.Close()
+}
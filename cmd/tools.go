@@ -0,0 +1,469 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements local tools for the 'invoke' command of the AWS Bedrock Intelligent
+Agents CLI. A tools file (--tools, or tools_file in the config) maps the functions and API
+paths of return-control action groups to shell commands or HTTP endpoints. When the agent
+returns control and every requested call has a tool, the tools are run with the parameters
+of the calls, their output is sent back as the results, and the invocation continues until
+the agent answers, so an agent can use local tools through the CLI. The safeguards that
+limit what runs are in toolpolicy.go.
+
+	tools:
+	  get_weather:                  # function name, or action-group/function
+	    command: ./weather.sh "$AWS_BIA_PARAM_CITY"
+	    timeout: 30s
+	    confirm: true
+	    parameters:
+	      city: {required: true, pattern: '^[A-Za-z ]+$'}
+	  "GET /orders/{orderId}":      # API path, optionally with its method
+	    url: http://localhost:8080/orders
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/types"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// defaultToolTimeout bounds a tool without a timeout of its own
+	defaultToolTimeout = time.Minute
+
+	// maxToolRounds stops an agent that keeps returning control instead of answering
+	maxToolRounds = 10
+
+	// toolEnvPrefix starts the environment variables holding the parameters of a call
+	toolEnvPrefix = "AWS_BIA_PARAM_"
+)
+
+// toolEnvUnsafe matches the characters of a parameter name that cannot be in a variable name
+var toolEnvUnsafe = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// ToolRegistry holds the local tools loaded from a tools file
+type ToolRegistry struct {
+	Path  string
+	Tools map[string]*localTool
+	Audit *toolAuditLog // Records every call
+}
+
+// localTool runs a call of the agent with a shell command or an HTTP request
+type localTool struct {
+	Command string            `yaml:"command"`
+	URL     string            `yaml:"url"`
+	Method  string            `yaml:"method"`  // HTTP method of url, POST by default
+	Headers map[string]string `yaml:"headers"` // Added to the HTTP request
+	Timeout string            `yaml:"timeout"`
+
+	Confirm    bool                      `yaml:"confirm"`    // Ask before every run
+	Parameters map[string]*toolParameter `yaml:"parameters"` // Schema of the parameters, none checked without
+	timeout    time.Duration
+}
+
+// toolCall is a call of the agent as passed to a tool: as JSON on the stdin of a command,
+// or as the body of an HTTP request
+type toolCall struct {
+	ActionGroup string                       `json:"actionGroup"`
+	Function    string                       `json:"function,omitempty"`
+	ApiPath     string                       `json:"apiPath,omitempty"`
+	HttpMethod  string                       `json:"httpMethod,omitempty"`
+	Parameters  map[string]string            `json:"parameters"`
+	RequestBody map[string]map[string]string `json:"requestBody,omitempty"`
+}
+
+// applyToolsConfig applies the local tool settings of the invoke command from a loaded configuration
+func applyToolsConfig(v *viper.Viper, options *AgentOptions) error {
+	if v.InConfig("tools_file") && options.ToolsFile == "" {
+		options.ToolsFile = v.GetString("tools_file")
+		logVerbose(*options, "Loaded tools file from config: %s", options.ToolsFile)
+	}
+	if v.InConfig("tools_audit_log") && options.ToolsAuditLog == "" {
+		options.ToolsAuditLog = v.GetString("tools_audit_log")
+	}
+	// The allowlist is only read from the configuration, a tools file cannot extend it
+	if v.InConfig("tools_allowlist") {
+		allowlist, err := configStringList(v, "tools_allowlist")
+		if err != nil {
+			return err
+		}
+		options.ToolsAllowlist = allowlist
+	}
+	return nil
+}
+
+// loadToolRegistry reads and checks a tools file; every tool must pass the allowlist
+func loadToolRegistry(path string, allowlist []string) (*ToolRegistry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tools file '%s': %w", path, err)
+	}
+
+	var file struct {
+		Tools map[string]*localTool `yaml:"tools"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid tools file '%s': %w", path, err)
+	}
+	if len(file.Tools) == 0 {
+		return nil, fmt.Errorf("invalid tools file '%s': no tools are defined under 'tools'", path)
+	}
+
+	for name, tool := range file.Tools {
+		// A command of only whitespace would run nothing, so it counts as missing
+		if tool == nil || (strings.TrimSpace(tool.Command) == "") == (tool.URL == "") {
+			return nil, fmt.Errorf("invalid tools file '%s': tool '%s' needs either a command or a url", path, name)
+		}
+		if tool.URL != "" && !strings.HasPrefix(tool.URL, "http://") && !strings.HasPrefix(tool.URL, "https://") {
+			return nil, fmt.Errorf("invalid tools file '%s': the url of tool '%s' must start with http:// or https://", path, name)
+		}
+		if err := tool.checkAllowlist(allowlist); err != nil {
+			return nil, fmt.Errorf("tool '%s' of '%s' is not allowed: %w", name, path, err)
+		}
+		for param, schema := range tool.Parameters {
+			if schema == nil {
+				schema = &toolParameter{}
+				tool.Parameters[param] = schema
+			}
+			if err := schema.compile(); err != nil {
+				return nil, fmt.Errorf("invalid tools file '%s': parameter '%s' of tool '%s': %w", path, param, name, err)
+			}
+		}
+		tool.timeout = defaultToolTimeout
+		if tool.Timeout != "" {
+			if tool.timeout, err = time.ParseDuration(tool.Timeout); err != nil || tool.timeout <= 0 {
+				return nil, fmt.Errorf("invalid tools file '%s': invalid timeout '%s' of tool '%s'", path, tool.Timeout, name)
+			}
+		}
+	}
+	return &ToolRegistry{Path: path, Tools: file.Tools}, nil
+}
+
+// lookup returns the tool for a requested call, trying the most specific name first
+func (r *ToolRegistry) lookup(member types.InvocationInputMember) (string, *localTool) {
+	var names []string
+	switch v := member.(type) {
+	case *types.InvocationInputMemberMemberFunctionInvocationInput:
+		function := aws.ToString(v.Value.Function)
+		names = []string{aws.ToString(v.Value.ActionGroup) + "/" + function, function}
+	case *types.InvocationInputMemberMemberApiInvocationInput:
+		path := aws.ToString(v.Value.ApiPath)
+		names = []string{strings.ToUpper(aws.ToString(v.Value.HttpMethod)) + " " + path, path}
+	}
+	for _, name := range names {
+		if tool, ok := r.Tools[name]; ok {
+			return name, tool
+		}
+	}
+	return "", nil
+}
+
+// Handles reports whether there is a tool for every call of a return-control payload
+func (r *ToolRegistry) Handles(payload *types.ReturnControlPayload) bool {
+	if r == nil || payload == nil || len(payload.InvocationInputs) == 0 {
+		return false
+	}
+	for _, member := range payload.InvocationInputs {
+		if _, tool := r.lookup(member); tool == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// newToolCall describes a requested call for its tool
+func newToolCall(member types.InvocationInputMember) toolCall {
+	call := toolCall{Parameters: map[string]string{}}
+	switch v := member.(type) {
+	case *types.InvocationInputMemberMemberFunctionInvocationInput:
+		call.ActionGroup = aws.ToString(v.Value.ActionGroup)
+		call.Function = aws.ToString(v.Value.Function)
+		for _, p := range v.Value.Parameters {
+			call.Parameters[aws.ToString(p.Name)] = aws.ToString(p.Value)
+		}
+	case *types.InvocationInputMemberMemberApiInvocationInput:
+		call.ActionGroup = aws.ToString(v.Value.ActionGroup)
+		call.ApiPath = aws.ToString(v.Value.ApiPath)
+		call.HttpMethod = strings.ToUpper(aws.ToString(v.Value.HttpMethod))
+		for _, p := range v.Value.Parameters {
+			call.Parameters[aws.ToString(p.Name)] = aws.ToString(p.Value)
+		}
+		if v.Value.RequestBody != nil && len(v.Value.RequestBody.Content) > 0 {
+			call.RequestBody = make(map[string]map[string]string, len(v.Value.RequestBody.Content))
+			for contentType, content := range v.Value.RequestBody.Content {
+				properties := make(map[string]string, len(content.Properties))
+				for _, p := range content.Properties {
+					properties[aws.ToString(p.Name)] = aws.ToString(p.Value)
+				}
+				call.RequestBody[contentType] = properties
+			}
+		}
+	}
+	return call
+}
+
+// describe names the call in notices
+func (c toolCall) describe() string {
+	if c.Function != "" {
+		return c.Function
+	}
+	return c.HttpMethod + " " + c.ApiPath
+}
+
+// env returns the environment of a command tool: the call and one variable per parameter
+func (c toolCall) env() []string {
+	env := append(os.Environ(),
+		"AWS_BIA_ACTION_GROUP="+c.ActionGroup,
+		"AWS_BIA_FUNCTION="+c.Function,
+		"AWS_BIA_API_PATH="+c.ApiPath,
+		"AWS_BIA_HTTP_METHOD="+c.HttpMethod,
+	)
+	names := make([]string, 0, len(c.Parameters))
+	for name := range c.Parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env = append(env, toolEnvPrefix+toolEnvUnsafe.ReplaceAllString(strings.ToUpper(name), "_")+"="+c.Parameters[name])
+	}
+	return env
+}
+
+// run makes the call with the tool and returns its output and, for HTTP tools, the status code
+func (t *localTool) run(ctx context.Context, call toolCall) (string, int, error) {
+	input, err := json.Marshal(call)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to marshal the call: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	if t.URL != "" {
+		output, status, err := t.request(ctx, input)
+		if err != nil && ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("timed out after %s", formatDuration(t.timeout))
+		}
+		return output, status, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := shellCommandContext(ctx, t.Command)
+	cmd.Env = call.env()
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("timed out after %s", formatDuration(t.timeout))
+		} else if message := strings.TrimSpace(stderr.String()); message != "" {
+			err = fmt.Errorf("%w: %s", err, message)
+		}
+		return stdout.String(), 0, err
+	}
+	return stdout.String(), 0, nil
+}
+
+// request sends the call to the URL of the tool; a status other than 2xx is an error
+func (t *localTool) request(ctx context.Context, input []byte) (string, int, error) {
+	method := strings.ToUpper(t.Method)
+	if method == "" {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequestWithContext(ctx, method, t.URL, bytes.NewReader(input))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "aws-bia/"+getVersionInfo().version)
+	for name, value := range t.Headers {
+		req.Header.Set(name, os.ExpandEnv(value))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", resp.StatusCode, fmt.Errorf("failed to read the response: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return string(body), resp.StatusCode, fmt.Errorf("%s returned %s", t.URL, resp.Status)
+	}
+	return string(body), resp.StatusCode, nil
+}
+
+// runToolCalls runs the tool of every call in the payload and returns the results to send
+// back. A call that fails, breaks the parameter schema, or is declined is reported to the
+// agent with the FAILURE response state, so it can tell the user instead of the invocation
+// failing.
+func runToolCalls(ctx context.Context, opts AgentOptions, payload types.ReturnControlPayload) []stateInvocationResult {
+	var results []stateInvocationResult
+	for _, member := range payload.InvocationInputs {
+		name, tool := opts.Tools.lookup(member)
+		call := newToolCall(member)
+		entry := toolAuditEntry{
+			Time:         time.Now().UTC(),
+			Tool:         name,
+			AgentID:      opts.AgentID,
+			SessionID:    opts.SessionID,
+			InvocationID: aws.ToString(payload.InvocationId),
+			ActionGroup:  call.ActionGroup,
+			Function:     call.Function,
+			ApiPath:      call.ApiPath,
+			HttpMethod:   call.HttpMethod,
+			Parameters:   call.Parameters,
+			Command:      tool.Command,
+			URL:          tool.URL,
+		}
+
+		output, status, err := runToolCall(ctx, opts, name, tool, call, &entry)
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		opts.Tools.Audit.Record(opts, entry)
+
+		result := &stateActionResult{ActionGroup: call.ActionGroup, Function: call.Function, ApiPath: call.ApiPath, HttpMethod: call.HttpMethod}
+		if err != nil {
+			opts.Warnings.Warn(WarningTool, fmt.Sprintf("local tool '%s' %s", name, entry.Outcome), err)
+			result.ResponseState = string(types.ResponseStateFailure)
+			if output == "" {
+				output = err.Error()
+			}
+		}
+
+		switch {
+		case call.Function != "":
+			result.ResponseBody = map[string]stateContentBody{"TEXT": {Body: output}}
+			results = append(results, stateInvocationResult{FunctionResult: result})
+		default:
+			result.HttpStatusCode = int32(status)
+			if status == 0 {
+				result.HttpStatusCode = 200
+				if err != nil {
+					result.HttpStatusCode = 500
+				}
+			}
+			contentType := "application/json"
+			if !json.Valid([]byte(output)) {
+				contentType = "text/plain"
+			}
+			result.ResponseBody = map[string]stateContentBody{contentType: {Body: output}}
+			results = append(results, stateInvocationResult{ApiResult: result})
+		}
+	}
+	return results
+}
+
+// runToolCall checks a call against the schema of its tool, asks for confirmation when the
+// tool wants it, and runs it; the outcome is set on the audit entry
+func runToolCall(ctx context.Context, opts AgentOptions, name string, tool *localTool, call toolCall,
+	entry *toolAuditEntry) (string, int, error) {
+
+	if err := tool.checkParameters(call); err != nil {
+		entry.Outcome = toolCallRejected
+		return "", http.StatusBadRequest, fmt.Errorf("invalid call: %w", err)
+	}
+	if tool.Confirm {
+		confirmed, err := confirmToolCall(name, call)
+		if err == nil && !confirmed {
+			err = fmt.Errorf("the user declined to run the tool")
+		}
+		if err != nil {
+			entry.Outcome = toolCallDeclined
+			return "", http.StatusForbidden, err
+		}
+	}
+
+	if !opts.Quiet {
+		fmt.Fprintf(os.Stderr, "Running local tool '%s' for %s\n", name, call.describe())
+	}
+	logVerbose(opts, "Calling local tool '%s' with parameters %v", name, call.Parameters)
+	start := time.Now()
+	output, status, err := tool.run(ctx, call)
+	output = strings.TrimSpace(output)
+	entry.DurationMs = time.Since(start).Milliseconds()
+	entry.OutputBytes = len(output)
+
+	if err != nil {
+		entry.Outcome = toolCallFailed
+		return output, status, err
+	}
+	entry.Outcome = toolCallRan
+	logVerbose(opts, "Local tool '%s' finished in %s with %d bytes of output", name, formatDuration(time.Since(start)), len(output))
+	return output, status, nil
+}
+
+// runInvokeTurnWithTools runs a turn and, as long as the agent hands back calls the local
+// tools can make, runs them and sends their results back in another turn of the session.
+// Without a tools file it is runInvokeTurn.
+func runInvokeTurnWithTools(ctx context.Context, opts AgentOptions, awsHelper *AWSHelper,
+	formatter *ResponseFormatter) (*bedrockagentruntime.InvokeAgentOutput, error) {
+
+	if opts.Tools == nil {
+		return runInvokeTurn(ctx, opts, awsHelper, formatter)
+	}
+
+	// Only the final response is a valid document in the structured formats; the ones
+	// handing back calls are held and dropped once the tools take over
+	writer := formatter.Writer
+	structured := opts.OutputFormat != OutputFormatText || opts.Query != "" || opts.PostHookReplace
+	defer func() { formatter.Writer = writer }()
+
+	for round := 0; ; round++ {
+		var held bytes.Buffer
+		if structured {
+			formatter.Writer = &held
+		}
+		output, err := runInvokeTurn(ctx, opts, awsHelper, formatter)
+		payload := formatter.LastResult().ReturnControl
+		if err != nil || !opts.Tools.Handles(payload) {
+			if structured {
+				if _, writeErr := writer.Write(held.Bytes()); writeErr != nil && err == nil {
+					err = writeErr
+				}
+			}
+			return output, err
+		}
+		if round+1 >= maxToolRounds {
+			return output, fmt.Errorf("the agent returned control %d times in a row, stopping", maxToolRounds)
+		}
+
+		// The results go back to the session of the response; the input, files, and prompt
+		// were sent with the first turn
+		if output.SessionId != nil {
+			opts.SessionID = *output.SessionId
+		}
+		results := runToolCalls(ctx, opts, *payload)
+		opts.InputText = ""
+		opts.UploadFiles = nil
+		opts.PromptName = ""
+		opts.PromptFile = ""
+		opts.SessionStateDocument = &SessionStateDocument{
+			InvocationID:                   aws.ToString(payload.InvocationId),
+			ReturnControlInvocationResults: results,
+		}
+
+		next := NewAWSHelper(opts)
+		next.FileHelper = awsHelper.FileHelper
+		next.Diagnostics = awsHelper.Diagnostics
+		awsHelper = next
+		formatter.Options = opts
+	}
+}
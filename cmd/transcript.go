@@ -0,0 +1,228 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file renders stored sessions as shareable transcripts for the AWS Bedrock Intelligent
+Agents CLI. Markdown transcripts link generated files by path; HTML transcripts are a single
+self-contained page that embeds saved images and links the other files.
+*/
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// Transcript formats supported by 'sessions export'
+	TranscriptFormatMarkdown = "md"
+	TranscriptFormatHTML     = "html"
+
+	// maxEmbeddedFileSize limits the size of images embedded into HTML transcripts
+	maxEmbeddedFileSize = 5 * 1024 * 1024
+)
+
+// transcriptFile is a generated file of a turn, with the path it was saved to if any
+type transcriptFile struct {
+	TemplateFile
+	Path string
+}
+
+// transcriptFiles pairs the generated files of a turn with their saved paths
+func transcriptFiles(turn StoredTurn) []transcriptFile {
+	files := make([]transcriptFile, 0, len(turn.Files))
+	for i, file := range turn.Files {
+		f := transcriptFile{TemplateFile: file}
+		// Files are saved in the order they were generated
+		if i < len(turn.SavedFiles) {
+			f.Path = turn.SavedFiles[i]
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+// isWebLink reports whether a reference location can be opened in a browser
+func isWebLink(location string) bool {
+	return strings.HasPrefix(location, "https://") || strings.HasPrefix(location, "http://")
+}
+
+// writeMarkdownTranscript writes the session as a Markdown document
+func writeMarkdownTranscript(w io.Writer, session *StoredSession) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Conversation %s\n\n", session.SessionID)
+	fmt.Fprintf(&b, "- Agent: `%s` (alias `%s`)\n", session.AgentID, session.AgentAliasID)
+	fmt.Fprintf(&b, "- Started: %s\n", session.CreatedAt.Local().Format(time.RFC1123))
+	fmt.Fprintf(&b, "- Turns: %d\n", len(session.Turns))
+
+	for i, turn := range session.Turns {
+		fmt.Fprintf(&b, "\n## Turn %d (%s)\n\n", i+1, turn.Time.Local().Format(time.Kitchen))
+
+		b.WriteString("**User:**\n\n")
+		for _, line := range strings.Split(strings.TrimRight(turn.Input, "\n"), "\n") {
+			fmt.Fprintf(&b, "> %s\n", line)
+		}
+
+		fmt.Fprintf(&b, "\n**Agent:**\n\n%s\n", strings.TrimSpace(turn.Answer))
+
+		if sources := markdownSources(turn.Citations); sources != "" {
+			fmt.Fprintf(&b, "\n**Sources:**\n\n%s", sources)
+		}
+
+		files := transcriptFiles(turn)
+		if len(files) > 0 {
+			b.WriteString("\n**Files:**\n\n")
+			for _, file := range files {
+				switch {
+				case file.Path != "" && strings.HasPrefix(file.Type, "image/"):
+					fmt.Fprintf(&b, "![%s](<%s>)\n\n", file.Name, file.Path)
+				case file.Path != "":
+					fmt.Fprintf(&b, "- [%s](<%s>) (%s, %s)\n", file.Name, file.Path, file.Type, formatSize(int64(file.Size)))
+				default:
+					fmt.Fprintf(&b, "- `%s` (%s, %s, not saved)\n", file.Name, file.Type, formatSize(int64(file.Size)))
+				}
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownSources lists the retrieved references of the citations, linking web locations
+func markdownSources(citations []TemplateCitation) string {
+	var b strings.Builder
+	n := 0
+	for _, citation := range citations {
+		for _, ref := range citation.References {
+			n++
+			location := ref.Location
+			if location == "" {
+				location = ref.LocationType
+			}
+			if isWebLink(location) {
+				fmt.Fprintf(&b, "%d. [%s](%s)", n, location, location)
+			} else {
+				fmt.Fprintf(&b, "%d. `%s`", n, location)
+			}
+			if text := excerpt(ref.Text, 200); text != "" {
+				fmt.Fprintf(&b, ": %s", text)
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// excerpt shortens text to a single line of at most n runes
+func excerpt(text string, n int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > n {
+		return string(runes[:n]) + "…"
+	}
+	return text
+}
+
+// htmlTranscriptTemplate is the self-contained page written by writeHTMLTranscript
+const htmlTranscriptTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Conversation {{.SessionID}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; max-width: 860px; margin: 2em auto; padding: 0 1em; color: #1f2328; }
+header { border-bottom: 1px solid #d0d7de; margin-bottom: 1.5em; }
+.meta { color: #656d76; font-size: 0.9em; }
+.turn { margin-bottom: 2em; }
+.message { white-space: pre-wrap; padding: 0.75em 1em; border-radius: 6px; }
+.user { background: #ddf4ff; }
+.agent { background: #f6f8fa; }
+.label { font-weight: 600; margin: 0.75em 0 0.25em; }
+.sources li, .files li { margin-bottom: 0.4em; }
+.excerpt { color: #656d76; }
+img { max-width: 100%; border: 1px solid #d0d7de; }
+</style>
+</head>
+<body>
+<header>
+<h1>Conversation {{.SessionID}}</h1>
+<p class="meta">Agent <code>{{.AgentID}}</code> (alias <code>{{.AgentAliasID}}</code>) &middot; started {{.CreatedAt.Local.Format "Mon, 02 Jan 2006 15:04 MST"}} &middot; {{len .Turns}} turn(s)</p>
+</header>
+{{range $i, $turn := .Turns}}
+<section class="turn">
+<h2>Turn {{inc $i}} <span class="meta">{{$turn.Time.Local.Format "15:04:05"}}</span></h2>
+<div class="label">User</div>
+<div class="message user">{{$turn.Input}}</div>
+<div class="label">Agent</div>
+<div class="message agent">{{trim $turn.Answer}}</div>
+{{- with $turn.Citations}}
+<div class="label">Sources</div>
+<ol class="sources">
+{{- range .}}{{range .References}}
+<li>{{if isWebLink .Location}}<a href="{{.Location}}">{{.Location}}</a>{{else if .Location}}<code>{{.Location}}</code>{{else}}<code>{{.LocationType}}</code>{{end}}{{with excerpt .Text 200}} <span class="excerpt">{{.}}</span>{{end}}</li>
+{{- end}}{{end}}
+</ol>
+{{- end}}
+{{- with files $turn}}
+<div class="label">Files</div>
+<ul class="files">
+{{- range .}}{{$image := embed .}}
+<li>{{if $image}}<figure><img src="{{$image}}" alt="{{.Name}}"><figcaption>{{end}}{{if .Path}}<a href="{{fileURL .Path}}">{{.Name}}</a>{{else}}<code>{{.Name}}</code>{{end}} <span class="meta">({{.Type}}, {{size .Size}}{{if not .Path}}, not saved{{end}})</span>{{if $image}}</figcaption></figure>{{end}}</li>
+{{- end}}
+</ul>
+{{- end}}
+</section>
+{{- end}}
+</body>
+</html>
+`
+
+// writeHTMLTranscript writes the session as a single HTML page
+func writeHTMLTranscript(w io.Writer, session *StoredSession) error {
+	tmpl, err := template.New("transcript").Funcs(template.FuncMap{
+		"inc":       func(i int) int { return i + 1 },
+		"trim":      strings.TrimSpace,
+		"excerpt":   excerpt,
+		"isWebLink": isWebLink,
+		"files":     transcriptFiles,
+		"embed":     embedImage,
+		"size":      func(n int) string { return formatSize(int64(n)) },
+		"fileURL":   fileURL,
+	}).Parse(htmlTranscriptTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to parse transcript template: %w", err)
+	}
+
+	if err := tmpl.Execute(w, session); err != nil {
+		return fmt.Errorf("failed to render transcript: %w", err)
+	}
+	return nil
+}
+
+// embedImage returns a saved image as a data URI, or nothing for other or missing files
+func embedImage(file transcriptFile) template.URL {
+	if file.Path == "" || !strings.HasPrefix(file.Type, "image/") {
+		return ""
+	}
+
+	info, err := os.Stat(file.Path)
+	if err != nil || info.Size() > maxEmbeddedFileSize {
+		return ""
+	}
+	data, err := os.ReadFile(file.Path)
+	if err != nil {
+		return ""
+	}
+	return template.URL(DataURIPrefix + file.Type + ";base64," + base64.StdEncoding.EncodeToString(data))
+}
+
+// fileURL links a saved file on the local disk
+func fileURL(path string) template.URL {
+	return template.URL("file://" + filepath.ToSlash(path))
+}
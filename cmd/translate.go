@@ -0,0 +1,243 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements --translate-to for the AWS Bedrock Intelligent Agents CLI. The
+answer of the agent is sent through a second Bedrock call, a foundation model with the
+Converse API or another agent, and the translation is shown after the answer, or in its
+place with --translate-replace. A failed translation only produces a warning, the
+original answer is always shown.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/spf13/viper"
+)
+
+// translateInstructions asks the translator for the translation and nothing else
+const translateInstructions = "Translate the text you are given into the language %q. Keep the formatting, " +
+	"Markdown, code, URLs, and names unchanged. Reply with the translation only, without any comments."
+
+// applyTranslateConfig applies the translation settings of the invoke command from a loaded configuration
+func applyTranslateConfig(v *viper.Viper, options *AgentOptions) {
+	if v.InConfig("translate_to") && options.TranslateTo == "" {
+		options.TranslateTo = v.GetString("translate_to")
+		logVerbose(*options, "Loaded translation language from config: %s", options.TranslateTo)
+	}
+	if v.InConfig("translate_model") && options.TranslateModel == "" && options.TranslateAgent == "" {
+		options.TranslateModel = v.GetString("translate_model")
+	}
+	if v.InConfig("translate_agent") && options.TranslateModel == "" && options.TranslateAgent == "" {
+		options.TranslateAgent = v.GetString("translate_agent")
+	}
+	if v.InConfig("translate_replace") && !options.TranslateReplace {
+		options.TranslateReplace = v.GetBool("translate_replace")
+	}
+}
+
+// validateTranslateOptions checks that a translation has exactly one translator
+func validateTranslateOptions(opts AgentOptions) error {
+	if opts.TranslateTo == "" {
+		if opts.TranslateReplace {
+			return fmt.Errorf("--translate-replace requires --translate-to")
+		}
+		return nil
+	}
+	if opts.TranslateModel == "" && opts.TranslateAgent == "" {
+		return fmt.Errorf("--translate-to requires --translate-model or --translate-agent (or translate_model/translate_agent in the config file)")
+	}
+	if opts.TranslateModel != "" && opts.TranslateAgent != "" {
+		return fmt.Errorf("use either --translate-model or --translate-agent, not both")
+	}
+	if opts.TranslateAgent != "" {
+		if _, _, err := parseTranslateAgent(opts.TranslateAgent); err != nil {
+			return err
+		}
+	}
+	if opts.OutputFormat == OutputFormatTemplate {
+		return fmt.Errorf("--translate-to cannot be used with --format template")
+	}
+	return nil
+}
+
+// parseTranslateAgent splits an agent-id:alias-id translator
+func parseTranslateAgent(spec string) (agentID, aliasID string, err error) {
+	agentID, aliasID, found := strings.Cut(spec, ":")
+	if !found || agentID == "" || aliasID == "" {
+		return "", "", fmt.Errorf("invalid translation agent '%s', expected agent-id:alias-id", spec)
+	}
+	return agentID, aliasID, nil
+}
+
+// translator describes the model or agent that translates, for notices and JSON output
+func translator(opts AgentOptions) string {
+	if opts.TranslateAgent != "" {
+		return "agent " + opts.TranslateAgent
+	}
+	return "model " + opts.TranslateModel
+}
+
+// translateText sends text to the configured translator and returns the translation
+func translateText(ctx context.Context, opts AgentOptions, text string) (string, error) {
+	instructions := fmt.Sprintf(translateInstructions, opts.TranslateTo)
+	if opts.TranslateAgent == "" {
+		return translateWithModel(ctx, opts, instructions, text)
+	}
+
+	agentID, aliasID, err := parseTranslateAgent(opts.TranslateAgent)
+	if err != nil {
+		return "", err
+	}
+
+	// The translator answers in a session of its own, with none of the options of the first agent
+	translatorOpts := AgentOptions{
+		Region:       opts.Region,
+		EndpointURL:  opts.EndpointURL,
+		UseFIPS:      opts.UseFIPS,
+		UseDualStack: opts.UseDualStack,
+		Timeout:      opts.Timeout,
+		OutputFormat: OutputFormatJSON,
+		InputText:    instructions + "\n\n" + text,
+		Verbose:      opts.Verbose,
+		Clients:      opts.Clients,
+	}
+	target := multiTarget{Name: opts.TranslateAgent, AgentID: agentID, AgentAliasID: aliasID}
+	translatorOpts.AgentID, translatorOpts.AgentAliasID = agentID, aliasID
+	result := invokeTarget(ctx, translatorOpts, target, nil)
+	if result.Err != nil {
+		return "", result.Err
+	}
+	return result.Text, nil
+}
+
+// translateWithModel translates text with the Converse API
+func translateWithModel(ctx context.Context, opts AgentOptions, instructions, text string) (string, error) {
+	client, err := NewAWSHelper(AgentOptions{Region: opts.Region, UseFIPS: opts.UseFIPS, UseDualStack: opts.UseDualStack, Verbose: opts.Verbose}).CreateBedrockRuntimeClient(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := client.Converse(ctx, &bedrockruntime.ConverseInput{
+		ModelId: aws.String(opts.TranslateModel),
+		System:  []types.SystemContentBlock{&types.SystemContentBlockMemberText{Value: instructions}},
+		Messages: []types.Message{{
+			Role:    types.ConversationRoleUser,
+			Content: []types.ContentBlock{&types.ContentBlockMemberText{Value: text}},
+		}},
+	})
+	if err != nil {
+		return "", HandleAWSError(fmt.Errorf("failed to invoke model '%s': %w", opts.TranslateModel, err))
+	}
+
+	var translation strings.Builder
+	if message, ok := output.Output.(*types.ConverseOutputMemberMessage); ok {
+		for _, block := range message.Value.Content {
+			if t, ok := block.(*types.ContentBlockMemberText); ok {
+				translation.WriteString(t.Value)
+			}
+		}
+	}
+	return translation.String(), nil
+}
+
+// translating reports whether the answer of the last response is to be translated
+func (rf *ResponseFormatter) translating() bool {
+	return rf.Options.TranslateTo != ""
+}
+
+// replacingWithTranslation reports whether the translation is shown instead of the answer
+func (rf *ResponseFormatter) replacingWithTranslation() bool {
+	return rf.translating() && rf.Options.TranslateReplace
+}
+
+// translateResult translates the answer of the last response once and keeps the translation.
+// Partial and empty answers are not translated.
+func (rf *ResponseFormatter) translateResult() string {
+	result := &rf.lastResult
+	if !rf.translating() || result.Interrupted || strings.TrimSpace(result.Text) == "" || result.Translation != "" {
+		return result.Translation
+	}
+
+	ctx := rf.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	logVerbose(rf.Options, "Translating the answer to %s with %s", rf.Options.TranslateTo, translator(rf.Options))
+	rf.Progress.Start(PhaseTranslating)
+	translation, err := translateText(ctx, rf.Options, result.Text)
+	rf.Progress.Stop()
+	if err != nil {
+		rf.Options.Warnings.Warn(WarningTranslation, fmt.Sprintf("failed to translate the answer to %s", rf.Options.TranslateTo), err)
+		return ""
+	}
+	result.Translation = strings.TrimSpace(translation)
+	return result.Translation
+}
+
+// writeTextTranslation writes the translation after the answer, or the translation in place
+// of the answer that was not written; without a translation that is the answer itself
+func (rf *ResponseFormatter) writeTextTranslation(withHeader bool) {
+	translation := rf.translateResult()
+	if rf.replacingWithTranslation() {
+		text := rf.lastResult.Text
+		if translation != "" {
+			text = translation
+		}
+		if text != "" {
+			rf.writeAnswerText(text)
+		}
+		if translation != "" && withHeader {
+			fmt.Fprintf(rf.Notices, "%s\n", rf.noticeColor.Notice(fmt.Sprintf("[Translated to %s by %s]", rf.Options.TranslateTo, translator(rf.Options))))
+		}
+		return
+	}
+	if translation == "" {
+		return
+	}
+
+	if withHeader {
+		fmt.Fprintf(rf.Notices, "\n%s\n", rf.noticeColor.style(ansiBold, fmt.Sprintf("Translation (%s):", rf.Options.TranslateTo)))
+	} else {
+		fmt.Fprintln(rf.Writer)
+	}
+	rf.writeAnswerText(translation)
+}
+
+// writeAnswerText writes text styled like a streamed answer, ending with a line break
+func (rf *ResponseFormatter) writeAnswerText(text string) {
+	var out io.Writer = rf.Writer
+	var markdown *markdownWriter
+	if rf.color.enabled {
+		markdown = newMarkdownWriter(rf.Writer, rf.color)
+		out = markdown
+	}
+	fmt.Fprintln(out, strings.TrimRight(text, "\n"))
+	if markdown != nil {
+		markdown.Flush()
+	}
+}
+
+// addTranslation adds the translation to a JSON response document; with --translate-replace
+// it becomes the content and the answer is kept as originalContent
+func (rf *ResponseFormatter) addTranslation(response map[string]interface{}) {
+	translation := rf.translateResult()
+	if translation == "" {
+		return
+	}
+	response["translation"] = map[string]interface{}{
+		"language":   rf.Options.TranslateTo,
+		"content":    translation,
+		"translator": translator(rf.Options),
+	}
+	if rf.replacingWithTranslation() {
+		response["originalContent"] = response["content"]
+		response["content"] = translation
+	}
+}
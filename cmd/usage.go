@@ -0,0 +1,94 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file contains token usage and cost estimation utilities for the AWS Bedrock
+Intelligent Agents CLI. Token counts are collected from the model invocation
+metadata included in agent trace events.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/types"
+)
+
+// TokenUsage accumulates model token counts reported in trace events
+type TokenUsage struct {
+	InputTokens  int64
+	OutputTokens int64
+}
+
+// Add adds other to the usage totals
+func (u *TokenUsage) Add(other TokenUsage) {
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+}
+
+// IsZero reports whether no tokens were recorded
+func (u TokenUsage) IsZero() bool {
+	return u.InputTokens == 0 && u.OutputTokens == 0
+}
+
+// Total returns the sum of input and output tokens
+func (u TokenUsage) Total() int64 {
+	return u.InputTokens + u.OutputTokens
+}
+
+// TokenPricing holds the price in USD per 1,000 input and output tokens
+type TokenPricing struct {
+	InputPer1K  float64
+	OutputPer1K float64
+}
+
+// IsSet reports whether any price has been configured
+func (p TokenPricing) IsSet() bool {
+	return p.InputPer1K > 0 || p.OutputPer1K > 0
+}
+
+// EstimateCost returns the estimated cost in USD for the given usage
+func (p TokenPricing) EstimateCost(u TokenUsage) float64 {
+	return float64(u.InputTokens)/1000*p.InputPer1K + float64(u.OutputTokens)/1000*p.OutputPer1K
+}
+
+// formatCost formats a USD amount with enough precision for single requests
+func formatCost(cost float64) string {
+	return fmt.Sprintf("$%.4f", cost)
+}
+
+// traceUsage extracts the token usage reported by a single trace event
+func traceUsage(part types.TracePart) TokenUsage {
+	var metadata *types.Metadata
+
+	switch trace := part.Trace.(type) {
+	case *types.TraceMemberOrchestrationTrace:
+		if out, ok := trace.Value.(*types.OrchestrationTraceMemberModelInvocationOutput); ok {
+			metadata = out.Value.Metadata
+		}
+	case *types.TraceMemberPreProcessingTrace:
+		if out, ok := trace.Value.(*types.PreProcessingTraceMemberModelInvocationOutput); ok {
+			metadata = out.Value.Metadata
+		}
+	case *types.TraceMemberPostProcessingTrace:
+		if out, ok := trace.Value.(*types.PostProcessingTraceMemberModelInvocationOutput); ok {
+			metadata = out.Value.Metadata
+		}
+	case *types.TraceMemberRoutingClassifierTrace:
+		if out, ok := trace.Value.(*types.RoutingClassifierTraceMemberModelInvocationOutput); ok {
+			metadata = out.Value.Metadata
+		}
+	}
+
+	if metadata == nil || metadata.Usage == nil {
+		return TokenUsage{}
+	}
+
+	var usage TokenUsage
+	if metadata.Usage.InputTokens != nil {
+		usage.InputTokens = int64(*metadata.Usage.InputTokens)
+	}
+	if metadata.Usage.OutputTokens != nil {
+		usage.OutputTokens = int64(*metadata.Usage.OutputTokens)
+	}
+	return usage
+}
@@ -0,0 +1,141 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the usage budget guard of the AWS Bedrock Intelligent Agents CLI.
+Commands that send many requests, like chat and run, add up the token usage reported in
+trace events, and no new request is sent once --max-tokens-total tokens or an estimated
+--max-cost in USD have been used. The consumption is summarized when the command exits.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// UsageBudget tracks the token usage of every request of a run against its limits.
+// A nil budget does not limit.
+type UsageBudget struct {
+	maxTokens int64
+	maxCost   float64
+	pricing   TokenPricing
+
+	mu       sync.Mutex
+	usage    TokenUsage
+	requests int
+}
+
+// BudgetExceededError is returned instead of sending a request once the budget is used up
+type BudgetExceededError struct {
+	Limit string // tokens or cost
+	Used  string
+	Max   string
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("%s budget exceeded (%s of %s used), no further requests are sent", e.Limit, e.Used, e.Max)
+}
+
+// isBudgetExceeded reports whether err is caused by the usage budget
+func isBudgetExceeded(err error) bool {
+	var budgetErr *BudgetExceededError
+	return errors.As(err, &budgetErr)
+}
+
+// NewUsageBudget creates a budget of maxTokens tokens and maxCost USD, or returns nil when
+// neither is set. The cost limit needs the token prices to estimate the cost of a request.
+func NewUsageBudget(maxTokens int64, maxCost float64, pricing TokenPricing) (*UsageBudget, error) {
+	if maxTokens < 0 {
+		return nil, fmt.Errorf("--max-tokens-total must not be negative")
+	}
+	if maxCost < 0 {
+		return nil, fmt.Errorf("--max-cost must not be negative")
+	}
+	if maxCost > 0 && !pricing.IsSet() {
+		return nil, fmt.Errorf("--max-cost requires --input-token-price or --output-token-price (or the input_token_price and output_token_price settings)")
+	}
+	if maxTokens == 0 && maxCost == 0 {
+		return nil, nil
+	}
+	return &UsageBudget{maxTokens: maxTokens, maxCost: maxCost, pricing: pricing}, nil
+}
+
+// Record adds the token usage of a finished request
+func (b *UsageBudget) Record(usage TokenUsage) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.usage.Add(usage)
+	b.requests++
+}
+
+// Check returns a *BudgetExceededError when a limit has been reached
+func (b *UsageBudget) Check() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.maxTokens > 0 && b.usage.Total() >= b.maxTokens {
+		return &BudgetExceededError{Limit: "token", Used: formatCount(b.usage.Total()), Max: formatCount(b.maxTokens)}
+	}
+	if cost := b.pricing.EstimateCost(b.usage); b.maxCost > 0 && cost >= b.maxCost {
+		return &BudgetExceededError{Limit: "cost", Used: formatCost(cost), Max: formatCost(b.maxCost)}
+	}
+	return nil
+}
+
+// Summary describes the consumption against the limits, for the end of the run
+func (b *UsageBudget) Summary() string {
+	if b == nil {
+		return ""
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	summary := fmt.Sprintf("Usage: %d request(s), %s in / %s out tokens", b.requests,
+		formatCount(b.usage.InputTokens), formatCount(b.usage.OutputTokens))
+	if b.maxTokens > 0 {
+		summary += fmt.Sprintf(", %s of %s tokens", formatCount(b.usage.Total()), formatCount(b.maxTokens))
+	}
+	if b.pricing.IsSet() {
+		summary += ", estimated cost " + formatCost(b.pricing.EstimateCost(b.usage))
+		if b.maxCost > 0 {
+			summary += " of " + formatCost(b.maxCost)
+		}
+	}
+	return summary
+}
+
+// applyUsageBudgetConfig applies the max_tokens_total and max_cost settings to options not set via flags
+func applyUsageBudgetConfig(v *viper.Viper, options *AgentOptions) {
+	if v.InConfig("max_tokens_total") && options.MaxTokensTotal == 0 {
+		options.MaxTokensTotal = v.GetInt64("max_tokens_total")
+		logVerbose(*options, "Loaded token budget from config: %d", options.MaxTokensTotal)
+	}
+	if v.InConfig("max_cost") && options.MaxCost == 0 {
+		options.MaxCost = v.GetFloat64("max_cost")
+		logVerbose(*options, "Loaded cost budget from config: %s", formatCost(options.MaxCost))
+	}
+}
+
+// setupUsageBudget applies the budget settings and creates the budget shared by copies of the options.
+// Token usage is only reported in trace events, so they are enabled when a limit is set.
+func setupUsageBudget(v *viper.Viper, options *AgentOptions, pricing TokenPricing) error {
+	applyUsageBudgetConfig(v, options)
+	budget, err := NewUsageBudget(options.MaxTokensTotal, options.MaxCost, pricing)
+	if err != nil {
+		return err
+	}
+	options.UsageBudget = budget
+	if budget != nil {
+		options.EnableTrace = true
+	}
+	return nil
+}
@@ -5,6 +5,14 @@ This file implements helper utilities shared across the CLI commands.
 */
 package cmd
 
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
 // logVerbose logs a message if verbose mode is enabled
 // This function is now a wrapper around LogVerbose for backward compatibility
 func logVerbose(opts AgentOptions, format string, args ...interface{}) {
@@ -21,3 +29,32 @@ func logError(message string, err error) {
 var (
 	handleAWSError = HandleAWSError
 )
+
+// stdinReader is shared by all interactive prompts so buffered input is not lost between them
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// readLine writes a prompt to stderr and returns the trimmed line entered by the user
+func readLine(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// confirmAction asks the user a yes/no question and returns true only for an explicit yes
+func confirmAction(question string) (bool, error) {
+	answer, err := readLine(question + " [y/N]: ")
+	if err != nil {
+		return false, err
+	}
+
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
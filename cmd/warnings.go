@@ -0,0 +1,114 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements the warnings channel of the AWS Bedrock Intelligent Agents CLI.
+Non-fatal problems such as failed file saves, skipped citations, or config fallbacks are
+collected per invocation, logged to stderr, and included in the "warnings" array of JSON
+output. While a response is being written, stderr output is held back until it is
+complete so warnings never break up the answer in a terminal.
+*/
+package cmd
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Warning kinds reported in JSON output
+const (
+	WarningConfig       = "config"
+	WarningFileSave     = "file_save"
+	WarningHook         = "hook"
+	WarningCitation     = "citation"
+	WarningRecording    = "recording"
+	WarningSessionStore = "session_store"
+	WarningOutput       = "output"
+	WarningTelemetry    = "telemetry"
+	WarningNotify       = "notify"
+	WarningActionLogs   = "action_logs"
+	WarningClipboard    = "clipboard"
+	WarningTranslation  = "translation"
+	WarningTool         = "tool"
+)
+
+// Warning is a non-fatal problem that occurred while handling an invocation
+type Warning struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// WarningCollector records the warnings of an invocation. A nil collector only logs them.
+type WarningCollector struct {
+	mu       sync.Mutex
+	warnings []Warning // Not yet included in a JSON document
+	held     []Warning // Not yet logged because a response is being written
+	holding  bool
+}
+
+// NewWarningCollector creates an empty collector
+func NewWarningCollector() *WarningCollector {
+	return &WarningCollector{}
+}
+
+// Warn records a warning; err is appended to the message when not nil
+func (c *WarningCollector) Warn(kind, message string, err error) {
+	if err != nil {
+		message = fmt.Sprintf("%s: %v", message, err)
+	}
+	warning := Warning{Type: kind, Message: message}
+
+	if c == nil {
+		logWarning(warning)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.warnings = append(c.warnings, warning)
+	if c.holding {
+		c.held = append(c.held, warning)
+		return
+	}
+	logWarning(warning)
+}
+
+// Hold delays logging until Release, use it while the response is written
+func (c *WarningCollector) Hold() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.holding = true
+}
+
+// Release logs the warnings held back since Hold
+func (c *WarningCollector) Release() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, warning := range c.held {
+		logWarning(warning)
+	}
+	c.held = nil
+	c.holding = false
+}
+
+// Take returns the warnings recorded since the last call, for inclusion in a JSON document
+func (c *WarningCollector) Take() []Warning {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	warnings := c.warnings
+	c.warnings = nil
+	return warnings
+}
+
+// logWarning writes a warning to stderr
+func logWarning(warning Warning) {
+	LogWarn("Warning: %s", warning.Message)
+}
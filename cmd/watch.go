@@ -0,0 +1,152 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+This file implements watch mode for the 'invoke' command. With --watch the agent is
+invoked again whenever the --prompt-file or one of the local --upload-files changes,
+with a separator between runs, until the command is interrupted. Files are polled
+rather than subscribed to, so editors that save by replacing the file are noticed too.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// watchInterval is how often the watched files are checked for changes
+const watchInterval = 500 * time.Millisecond
+
+// fileStamp identifies a version of a watched file
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+	exists  bool
+}
+
+// runWatchLoop invokes the agent and repeats the invocation after every change of the watched files
+func runWatchLoop(ctx context.Context, opts AgentOptions) error {
+	files, err := watchedFiles(opts)
+	if err != nil {
+		return err
+	}
+
+	// Every run uses the same client and draws from the same rate limit
+	opts.Clients = NewRuntimeClients(nil)
+	if v, err := LoadConfigForCommand(opts.ConfigFile, "invoke", false); err == nil {
+		if err := setupRateLimiter(v, &opts); err != nil {
+			return err
+		}
+	}
+	for run := 1; ; run++ {
+		// Changes made while the agent is answering start the next run right away
+		stamps := stampFiles(files)
+
+		if err := runInvokeCommand(ctx, opts); err != nil && ctx.Err() == nil {
+			logError("Error invoking agent", err)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		fmt.Fprintf(os.Stderr, "\nWatching %s for changes (press Ctrl+C to stop)\n", describeWatchedFiles(files))
+		changed, ok := waitForChange(ctx, files, stamps)
+		if !ok {
+			return nil
+		}
+		fmt.Fprintf(os.Stderr, "\n%s Run %d, %s changed at %s %s\n\n",
+			strings.Repeat("=", 10), run+1, filepath.Base(changed), time.Now().Format("15:04:05"), strings.Repeat("=", 10))
+	}
+}
+
+// watchedFiles returns the prompt file, vars file, and local upload files that trigger a new run
+func watchedFiles(opts AgentOptions) ([]string, error) {
+	var files []string
+	if opts.PromptFile != "" {
+		files = append(files, opts.PromptFile)
+	}
+	if opts.VarsFile != "" {
+		files = append(files, opts.VarsFile)
+	}
+	for _, entry := range opts.UploadFiles {
+		switch {
+		case entry == StdinUpload:
+			return nil, fmt.Errorf("--watch cannot be combined with an upload read from stdin")
+		case isS3URI(entry) || isDataURI(entry):
+			continue
+		}
+		files = append(files, entry)
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("--watch requires --prompt-file or local --upload-files to watch")
+	}
+	return files, nil
+}
+
+// stampFiles records the current version of every watched file
+func stampFiles(files []string) []fileStamp {
+	stamps := make([]fileStamp, len(files))
+	for i, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			stamps[i] = fileStamp{modTime: info.ModTime(), size: info.Size(), exists: true}
+		}
+	}
+	return stamps
+}
+
+// waitForChange polls the files until one differs from its stamp and has stopped changing.
+// It returns the changed file, or false when the context is canceled first.
+func waitForChange(ctx context.Context, files []string, stamps []fileStamp) (string, bool) {
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	changed := ""
+	var pending []fileStamp
+	for {
+		select {
+		case <-ctx.Done():
+			return "", false
+		case <-ticker.C:
+		}
+
+		current := stampFiles(files)
+
+		// Wait for one quiet interval so a file that is still being written is read once complete
+		if pending != nil {
+			if equalStamps(current, pending) {
+				return changed, true
+			}
+			pending = current
+			continue
+		}
+
+		for i := range files {
+			if current[i] != stamps[i] {
+				changed, pending = files[i], current
+				break
+			}
+		}
+	}
+}
+
+// equalStamps reports whether two snapshots of the watched files are the same
+func equalStamps(a, b []fileStamp) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// describeWatchedFiles names the watched files for the status line
+func describeWatchedFiles(files []string) string {
+	if len(files) == 1 {
+		return files[0]
+	}
+	return fmt.Sprintf("%d files", len(files))
+}
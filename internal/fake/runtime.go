@@ -0,0 +1,211 @@
+/*
+Copyright © 2025 AWS-BIA Contributors
+
+Package fake implements a fake Bedrock Agent runtime for the AWS Bedrock Intelligent Agents
+CLI. AgentRuntime implements cmd.BedrockAgentRuntimeAPI by answering InvokeAgent calls with
+canned event streams, so streaming, formatting, and file saving can be exercised end to end
+without AWS credentials or network access. Inject it with cmd.NewRuntimeClients in
+AgentOptions.Clients. It lives outside the cmd package so the CLI binary does not ship it,
+and it does not import cmd, so the tests of cmd can use it.
+*/
+package fake
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/protocol/eventstream"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime"
+)
+
+// Event is one canned event-stream message, with its payload in the JSON shape of the API
+type Event struct {
+	Type      string          // Event type such as chunk, files, trace, or returnControl
+	Payload   json.RawMessage // Event payload
+	Exception bool            // Sent as an exception that ends the stream, Type is the exception type
+}
+
+// Recording is a raw HTTP response of InvokeAgent, in the JSON shape of a --record file
+type Recording struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	Stream     []byte      `json:"stream"` // Event stream response body
+}
+
+// Response is the canned answer to one InvokeAgent call
+type Response struct {
+	Events    []Event    // Sent on the event stream in order
+	Recording *Recording // Replayed instead of Events
+	Err       error      // Returned by InvokeAgent instead of a stream
+	SessionID string     // Defaults to the session ID of the input
+	MemoryID  string
+}
+
+// AgentRuntime answers InvokeAgent calls with canned responses and records the inputs.
+// Each call takes the next response, the last one answers every call after it. The events go
+// through the SDK's event-stream decoding, so they reach the stream processor like real ones.
+type AgentRuntime struct {
+	Region string // Reported by Options, defaults to us-east-1
+
+	mu        sync.Mutex
+	responses []Response
+	calls     int
+	inputs    []*bedrockagentruntime.InvokeAgentInput
+}
+
+// NewAgentRuntime creates a fake runtime that answers with the responses in order
+func NewAgentRuntime(responses ...Response) *AgentRuntime {
+	return &AgentRuntime{responses: responses}
+}
+
+// InvokeAgent records the input and returns the next canned response. The option functions
+// apply as with the real client, so --record, --cache, and --dump-stream capture the fake stream.
+func (f *AgentRuntime) InvokeAgent(ctx context.Context, params *bedrockagentruntime.InvokeAgentInput,
+	optFns ...func(*bedrockagentruntime.Options)) (*bedrockagentruntime.InvokeAgentOutput, error) {
+
+	f.mu.Lock()
+	f.inputs = append(f.inputs, params)
+	var response Response
+	if len(f.responses) > 0 {
+		response = f.responses[min(f.calls, len(f.responses)-1)]
+	}
+	f.calls++
+	f.mu.Unlock()
+
+	if response.Err != nil {
+		return nil, response.Err
+	}
+
+	recording := response.Recording
+	if recording == nil {
+		stream, err := encodeEvents(response.Events)
+		if err != nil {
+			return nil, err
+		}
+		sessionID := response.SessionID
+		if sessionID == "" {
+			sessionID = aws.ToString(params.SessionId)
+		}
+		header := http.Header{}
+		header.Set("Content-Type", "application/vnd.amazon.eventstream")
+		header.Set("X-Amzn-Bedrock-Agent-Content-Type", "application/json")
+		header.Set("X-Amz-Bedrock-Agent-Session-Id", sessionID)
+		if response.MemoryID != "" {
+			header.Set("X-Amz-Bedrock-Agent-Memory-Id", response.MemoryID)
+		}
+		recording = &Recording{StatusCode: http.StatusOK, Header: header, Stream: stream}
+	}
+
+	return f.client(recording).InvokeAgent(ctx, params, optFns...)
+}
+
+// Options returns the options of the fake client, with static credentials so credential checks succeed
+func (f *AgentRuntime) Options() bedrockagentruntime.Options {
+	return f.client(&Recording{}).Options()
+}
+
+// client creates an SDK client whose HTTP responses come from the recording
+func (f *AgentRuntime) client(recording *Recording) *bedrockagentruntime.Client {
+	region := f.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	return bedrockagentruntime.New(bedrockagentruntime.Options{
+		Region:      region,
+		Credentials: credentials.NewStaticCredentialsProvider("FAKE", "FAKE", ""),
+		Retryer:     aws.NopRetryer{},
+		HTTPClient:  &replayHTTPClient{recording: recording},
+	})
+}
+
+// Inputs returns the inputs of every InvokeAgent call so far
+func (f *AgentRuntime) Inputs() []*bedrockagentruntime.InvokeAgentInput {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*bedrockagentruntime.InvokeAgentInput(nil), f.inputs...)
+}
+
+// replayHTTPClient answers every request with the canned response
+type replayHTTPClient struct {
+	recording *Recording
+}
+
+// Do returns the canned response without making a network call
+func (c *replayHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		Status:        http.StatusText(c.recording.StatusCode),
+		StatusCode:    c.recording.StatusCode,
+		Header:        c.recording.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(c.recording.Stream)),
+		ContentLength: int64(len(c.recording.Stream)),
+		Request:       req,
+	}, nil
+}
+
+// Chunk returns a chunk event carrying text
+func Chunk(text string) Event {
+	payload, _ := json.Marshal(map[string]interface{}{"bytes": []byte(text)})
+	return Event{Type: "chunk", Payload: payload}
+}
+
+// File returns a files event carrying one generated file
+func File(name, mediaType string, data []byte) Event {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"files": []map[string]interface{}{{"name": name, "type": mediaType, "bytes": data}},
+	})
+	return Event{Type: "files", Payload: payload}
+}
+
+// ReturnControl returns a returnControl event asking the caller to run one function
+func ReturnControl(invocationID, actionGroup, function string, parameters map[string]string) Event {
+	params := make([]map[string]string, 0, len(parameters))
+	for name, value := range parameters {
+		params = append(params, map[string]string{"name": name, "type": "string", "value": value})
+	}
+	payload, _ := json.Marshal(map[string]interface{}{
+		"invocationId": invocationID,
+		"invocationInputs": []map[string]interface{}{{
+			"functionInvocationInput": map[string]interface{}{
+				"actionGroup": actionGroup,
+				"function":    function,
+				"parameters":  params,
+			},
+		}},
+	})
+	return Event{Type: "returnControl", Payload: payload}
+}
+
+// Exception returns an exception such as throttlingException that ends the stream with an error
+func Exception(exceptionType, message string) Event {
+	payload, _ := json.Marshal(map[string]string{"message": message})
+	return Event{Type: exceptionType, Payload: payload, Exception: true}
+}
+
+// encodeEvents encodes the events as an AWS event-stream response body
+func encodeEvents(events []Event) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := eventstream.NewEncoder()
+	for _, event := range events {
+		var headers eventstream.Headers
+		if event.Exception {
+			headers.Set(":message-type", eventstream.StringValue("exception"))
+			headers.Set(":exception-type", eventstream.StringValue(event.Type))
+		} else {
+			headers.Set(":message-type", eventstream.StringValue("event"))
+			headers.Set(":event-type", eventstream.StringValue(event.Type))
+		}
+		headers.Set(":content-type", eventstream.StringValue("application/json"))
+
+		if err := encoder.Encode(&buf, eventstream.Message{Headers: headers, Payload: event.Payload}); err != nil {
+			return nil, fmt.Errorf("failed to encode fake %s event: %w", event.Type, err)
+		}
+	}
+	return buf.Bytes(), nil
+}